@@ -0,0 +1,183 @@
+// admin manages API clients:
+//
+//	admin [config flags] create <name> [role|scope...]  create a client and print its API key
+//	admin [config flags] revoke <name>                  revoke the active client with the name
+//	admin [config flags] list                           list all clients
+//
+// Roles are analyst (read only), editor (read and write) and admin
+// (everything), see auth.Roles. A client is an analyst by default.
+//
+// The storage is configured like the server: with the config file
+// (CONFIG_FILE or -config), environment variables and flags given before
+// the command. Clients are stored by the postgres and sqlite storages,
+// the memory storage can not be managed.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Hofsiedge/person-api/internal/auth"
+	"github.com/Hofsiedge/person-api/internal/config"
+	"github.com/Hofsiedge/person-api/internal/domain"
+	"github.com/Hofsiedge/person-api/internal/repo"
+	"github.com/Hofsiedge/person-api/internal/repo/postgres"
+	"github.com/Hofsiedge/person-api/internal/repo/sqlite"
+)
+
+const usage = `usage:
+  admin [config flags] create <name> [role|scope...]  create a client and print its API key
+  admin [config flags] revoke <name>                  revoke the active client with the name
+  admin [config flags] list                           list all clients
+
+roles: analyst (default), editor, admin`
+
+var (
+	errUsage         = errors.New(usage)
+	errMemoryStorage = errors.New("clients of the memory storage are not stored, use the postgres or sqlite storage")
+)
+
+// scopes expands roles to their scopes
+func scopes(grants []string) []string {
+	if len(grants) == 0 {
+		grants = []string{"analyst"}
+	}
+
+	result := make([]string, 0, len(grants))
+
+	for _, grant := range grants {
+		if roleScopes, found := auth.Roles[grant]; found {
+			result = append(result, roleScopes...)
+		} else {
+			result = append(result, grant)
+		}
+	}
+
+	slices.Sort(result)
+
+	return slices.Compact(result)
+}
+
+func create(ctx context.Context, clients repo.APIClientRepo, name string, grants []string) error {
+	key, err := auth.GenerateKey()
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	hash, err := auth.HashSecret(key.Secret)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	//nolint:exhaustruct
+	if _, err = clients.CreateClient(ctx, domain.APIClient{
+		Name:       name,
+		KeyID:      key.KeyID,
+		SecretHash: hash,
+		Scopes:     scopes(grants),
+	}); err != nil {
+		return fmt.Errorf("could not create a client: %w", err)
+	}
+
+	fmt.Printf("API key (%s header): %s\n", auth.APIKeyHeader, key)
+	fmt.Printf("Basic credentials: %s:%s\n", name, key.Secret)
+	fmt.Println("The key is not stored and can not be shown again.")
+
+	return nil
+}
+
+func list(ctx context.Context, clients repo.APIClientRepo) error {
+	result, err := clients.ListClients(ctx)
+	if err != nil {
+		return fmt.Errorf("could not list clients: %w", err)
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0) //nolint:gomnd
+	fmt.Fprintln(writer, "NAME\tKEY ID\tSCOPES\tCREATED\tREVOKED")
+
+	for _, client := range result {
+		revoked := "-"
+		if client.RevokedAt != nil {
+			revoked = client.RevokedAt.Format(time.RFC3339)
+		}
+
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", client.Name, client.KeyID,
+			strings.Join(client.Scopes, " "), client.CreatedAt.Format(time.RFC3339), revoked)
+	}
+
+	return writer.Flush() //nolint:wrapcheck
+}
+
+func run(ctx context.Context, clients repo.APIClientRepo, args []string) error {
+	switch {
+	case len(args) >= 2 && args[0] == "create":
+		return create(ctx, clients, args[1], args[2:])
+	case len(args) == 2 && args[0] == "revoke":
+		if err := clients.RevokeClient(ctx, args[1]); err != nil {
+			return fmt.Errorf("could not revoke a client: %w", err)
+		}
+
+		return nil
+	case len(args) == 1 && args[0] == "list":
+		return list(ctx, clients)
+	}
+
+	return errUsage
+}
+
+// openClients opens the client repo of the configured storage. Only the
+// section of the storage is validated.
+func openClients(cfg config.Config) (repo.APIClientRepo, func(), error) {
+	switch cfg.Storage.Backend {
+	case config.StorageMemory:
+		return nil, nil, errMemoryStorage
+	case config.StorageSQLite:
+		if cfg.Storage.SQLiteFile == "" {
+			return nil, nil, fmt.Errorf("%w: SQLITE_FILE is required", config.ErrInvalid)
+		}
+
+		people, err := sqlite.New(cfg.Storage.SQLiteFile)
+		if err != nil {
+			return nil, nil, err //nolint:wrapcheck
+		}
+
+		return people.APIClients(), func() { _ = people.Close() }, nil
+	}
+
+	if err := cfg.Postgres.Validate(); err != nil {
+		return nil, nil, err //nolint:wrapcheck
+	}
+
+	people, err := postgres.New(cfg.Postgres)
+	if err != nil {
+		return nil, nil, err //nolint:wrapcheck
+	}
+
+	return people.APIClients(), people.Close, nil
+}
+
+func main() {
+	// only the storage is used, other sections may be invalid
+	cfg, args, err := config.LoadCommand(os.Args[1:])
+	if err != nil && !errors.Is(err, config.ErrInvalid) {
+		log.Fatal(err)
+	}
+
+	clients, closeStorage, err := openClients(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer closeStorage()
+
+	if err = run(context.Background(), clients, args); err != nil {
+		closeStorage()
+		log.Fatal(err)
+	}
+}
@@ -1,23 +1,42 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"log/slog"
 	"net/http"
 	"net/http/httputil"
 	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	"github.com/Hofsiedge/person-api/internal/api"
+	"github.com/Hofsiedge/person-api/internal/auth"
 	"github.com/Hofsiedge/person-api/internal/completer"
 	"github.com/Hofsiedge/person-api/internal/config"
-	"github.com/Hofsiedge/person-api/internal/repo/postgres"
+	"github.com/Hofsiedge/person-api/internal/domain"
+	"github.com/Hofsiedge/person-api/internal/health"
+	"github.com/Hofsiedge/person-api/internal/listener"
+	"github.com/Hofsiedge/person-api/internal/metrics"
+	"github.com/Hofsiedge/person-api/internal/purger"
+	"github.com/Hofsiedge/person-api/internal/repo"
+	"github.com/Hofsiedge/person-api/internal/repo/memory"
+	"github.com/Hofsiedge/person-api/internal/reqctx"
+	"github.com/Hofsiedge/person-api/internal/tracing"
 	"github.com/Hofsiedge/person-api/internal/utils"
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/gorilla/mux"
 	middleware "github.com/oapi-codegen/nethttp-middleware"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
+// how long the number of people is counted for a metrics scrape
+const peopleCountTimeout = 5 * time.Second
+
 type loggingTransport struct {
 	logger *slog.Logger
 }
@@ -33,7 +52,7 @@ func (s *loggingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
 		respBytes, _ = httputil.DumpResponse(resp, true)
 	}
 
-	s.logger.Debug("completer client was used",
+	s.logger.DebugContext(r.Context(), "completer client was used",
 		slog.String("request", string(bytes)),
 		slog.String("response", string(respBytes)),
 	)
@@ -41,57 +60,301 @@ func (s *loggingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
 	return resp, err //nolint:wrapcheck
 }
 
-func makeCompleter(logger *slog.Logger) *completer.Completer {
-	completerCfg, err := config.Read[config.CompleterConfig]()
-	if err != nil {
-		log.Fatal(err)
-	}
-
+func makeCompleter(completerCfg config.CompleterConfig, logger *slog.Logger) *completer.Completer {
 	//nolint:exhaustruct
 	completerHTTPClient := http.Client{
-		Transport: &loggingTransport{
+		// propagates the trace context to the fillers
+		Transport: otelhttp.NewTransport(&loggingTransport{
 			logger: logger,
-		},
+		}),
 		Timeout: completer.Timeout,
 	}
 
 	return completer.New(completerCfg, &completerHTTPClient)
 }
 
+// makeMetrics returns metrics of the service with the pool (if there is
+// one), people and filler metrics registered
+func makeMetrics(store *storage, comp *completer.Completer) *metrics.Metrics {
+	serviceMetrics := metrics.New()
+	serviceMetrics.MustRegister(
+		metrics.PeopleCollector(store.count, peopleCountTimeout),
+		metrics.FillerQuotaCollector(comp.RequestsLeft),
+	)
+
+	if store.stat != nil {
+		serviceMetrics.MustRegister(metrics.PoolCollector(store.stat))
+	}
+
+	comp.SetObserver(serviceMetrics)
+
+	return serviceMetrics
+}
+
+// makeHealthChecks returns the readiness checks: the ones of the storage
+// (database connectivity, schema version, custom types) and (optionally)
+// filler providers
+func makeHealthChecks(
+	store *storage, healthCfg config.HealthConfig, completerCfg config.CompleterConfig,
+) []health.Check {
+	checks := store.checks
+
+	if !healthCfg.CheckProviders {
+		return checks
+	}
+
+	client := &http.Client{Timeout: healthCfg.Timeout} //nolint:exhaustruct
+
+	return append(checks,
+		health.Check{Name: completer.Agify, Check: health.HTTPCheck(client, completerCfg.AgifyURL)},
+		health.Check{Name: completer.Genderize, Check: health.HTTPCheck(client, completerCfg.GenderizeURL)},
+		health.Check{Name: completer.Nationalize, Check: health.HTTPCheck(client, completerCfg.NationalizeURL)},
+	)
+}
+
+// makeAdminServer returns the admin server serving /metrics, /healthz
+// and /readyz
+func makeAdminServer(
+	serviceMetrics *metrics.Metrics, checks []health.Check, cfg config.Config,
+) *http.Server {
+	adminRouter := mux.NewRouter()
+	adminRouter.Handle("/metrics", serviceMetrics.Handler()).Methods(http.MethodGet)
+	adminRouter.Handle("/healthz", health.LivenessHandler()).Methods(http.MethodGet)
+	adminRouter.Handle("/readyz", health.ReadinessHandler(checks, cfg.Health.Timeout)).Methods(http.MethodGet)
+
+	// the admin listener is internal: plain HTTP/1.1 with the timeouts
+	// of the API listener
+	serverCfg := cfg.Server
+	serverCfg.H2C = false
+
+	return listener.NewServer(cfg.Admin.Addr, serverCfg, adminRouter)
+}
+
+// makeAPIServer returns the API server of handler, serving HTTPS if TLS
+// is configured
+func makeAPIServer(
+	serverCfg config.ServerConfig, tlsCfg config.TLSConfig, handler http.Handler, background *workers, logger *slog.Logger,
+) *http.Server {
+	tlsConfig, certificate, err := listener.TLSConfig(tlsCfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	server := listener.NewServer(serverCfg.Addr, serverCfg, handler)
+	server.TLSConfig = tlsConfig
+
+	if certificate != nil {
+		background.Go("tls certificate", func(ctx context.Context) {
+			certificate.Watch(ctx, tlsCfg.ReloadInterval, logger)
+		})
+	}
+
+	return server
+}
+
+// makeJWTAuthenticator returns nil if JWTs are not configured
+func makeJWTAuthenticator(jwtCfg config.JWTConfig, background *workers, logger *slog.Logger) *auth.JWTAuthenticator {
+	var (
+		keys *auth.KeySet
+		err  error
+	)
+
+	switch {
+	case jwtCfg.JWKSFile != "":
+		keys, err = auth.NewJWKSKeySet(jwtCfg.JWKSFile)
+	case jwtCfg.PEMDir != "":
+		keys, err = auth.NewPEMKeySet(jwtCfg.PEMDir)
+	default:
+		return nil
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	background.Go("jwt keys", func(ctx context.Context) {
+		keys.Watch(ctx, jwtCfg.ReloadInterval, logger)
+	})
+
+	return auth.NewJWTAuthenticator(keys, auth.JWTOptions{
+		Issuer:   jwtCfg.Issuer,
+		Audience: jwtCfg.Audience,
+		Leeway:   jwtCfg.Leeway,
+	})
+}
+
+// makeAuthMiddlewares returns authentication and authorization
+// middlewares (the last one is the outermost)
+func makeAuthMiddlewares(
+	spec *openapi3.T, clients repo.APIClientRepo, cfg config.Config, background *workers, logger *slog.Logger,
+) []api.StrictMiddlewareFunc {
+	authCfg := cfg.Auth
+	if authCfg.Disabled {
+		logger.Warn("authentication is disabled")
+
+		return []api.StrictMiddlewareFunc{}
+	}
+
+	authenticator := auth.Chain{auth.NewClientAuthenticator(clients, authCfg.CacheTTL)}
+	if jwtAuthenticator := makeJWTAuthenticator(cfg.JWT, background, logger); jwtAuthenticator != nil {
+		authenticator = append(authenticator, jwtAuthenticator)
+	}
+
+	policy, err := api.PolicyFromSpec(spec)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if authCfg.PolicyFile != "" {
+		override, err := api.LoadPolicy(authCfg.PolicyFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		policy = policy.Override(override)
+	}
+
+	if err = policy.Validate(spec); err != nil {
+		log.Fatal(err)
+	}
+
+	return []api.StrictMiddlewareFunc{
+		api.AuthorizationMiddleware(policy, logger),
+		api.AuthMiddleware(authenticator, logger),
+	}
+}
+
+// rateLimitOptions returns the options of the rate limiter: the costs
+// of the spec overridden by the config
+func rateLimitOptions(specCosts api.RateLimitCosts, rateLimitCfg config.RateLimitConfig) (api.RateLimitOptions, error) {
+	options := api.RateLimitOptions{
+		Costs:         specCosts.Override(rateLimitCfg.Costs),
+		Bucket:        domain.TokenBucket{Capacity: rateLimitCfg.Burst, Rate: rateLimitCfg.Rate},
+		AddressBucket: domain.TokenBucket{Capacity: rateLimitCfg.AddressBurst, Rate: rateLimitCfg.AddressRate},
+	}
+
+	return options, options.Validate() //nolint:wrapcheck
+}
+
+// makeRateLimiter returns nil if rate limiting is disabled
+func makeRateLimiter(
+	specCosts api.RateLimitCosts, shared repo.RateLimitRepo, rateLimitCfg config.RateLimitConfig, logger *slog.Logger,
+) *api.RateLimiter {
+	if rateLimitCfg.Disabled {
+		logger.Warn("rate limiting is disabled")
+
+		return nil
+	}
+
+	options, err := rateLimitOptions(specCosts, rateLimitCfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var buckets repo.RateLimitRepo = memory.NewRateLimits()
+	if rateLimitCfg.Shared {
+		buckets = shared
+	}
+
+	return api.NewRateLimiter(buckets, options, logger)
+}
+
+// printConfig prints the effective config with secrets redacted
+func printConfig(args []string) {
+	cfg, err := config.Load(args)
+	if err != nil && !errors.Is(err, config.ErrInvalid) {
+		log.Fatal(err)
+	}
+
+	data, yamlErr := cfg.Redacted().YAML()
+	if yamlErr != nil {
+		log.Fatal(yamlErr)
+	}
+
+	fmt.Print(string(data))
+
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
 //nolint:funlen
 func main() {
-	serverCfg, err := config.Read[config.ServerConfig]()
+	if len(os.Args) > 2 && os.Args[1] == "config" && os.Args[2] == "print" {
+		printConfig(os.Args[3:])
+
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		migrateCommand(os.Args[2:])
+
+		return
+	}
+
+	// stop on the first signal, the second one kills the process
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	cfg, err := config.Load(os.Args[1:])
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	// logger
-	level := serverCfg.LogLevel
-	if serverCfg.Debug {
-		level = slog.LevelDebug
-	}
+	var level slog.LevelVar
 
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+	level.Set(logLevel(cfg.Server))
+
+	// records logged with a request context carry the request ID
+	logger := slog.New(reqctx.NewLogHandler(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
 		AddSource:   false,
-		Level:       level,
+		Level:       &level,
 		ReplaceAttr: nil,
-	}))
+	})))
 
-	// postgres repo
-	pgCfg, err := config.Read[config.PostgresConfig]()
+	// tracing
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	people, err := postgres.New(pgCfg)
+	background := newWorkers(logger)
+
+	// repos
+	store, err := openStorage(cfg, background, logger)
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	// purging deleted people
+	background.Go("purger", purger.New(store.people, cfg.Retention, logger).Run)
+
 	// external API client
-	comp := makeCompleter(logger)
+	comp := makeCompleter(cfg.Completer, logger)
+
+	serviceMetrics := makeMetrics(store, comp)
 
-	server, err := api.New(people, comp, logger)
+	checks := makeHealthChecks(store, cfg.Health, cfg.Completer)
+	if report := health.Run(context.Background(), checks, cfg.Health.Timeout); report.Status != health.StatusOK {
+		logger.Warn("service is not ready", slog.Any("checks", report.Checks))
+	}
+
+	// readiness fails as soon as shutdown starts
+	var draining atomic.Bool
+
+	checks = append(checks, health.Check{Name: "shutdown", Check: func(context.Context) error {
+		if draining.Load() {
+			return errNotReady
+		}
+
+		return nil
+	}})
+
+	adminServer := makeAdminServer(serviceMetrics, checks, cfg)
+	serve(adminServer, "admin server", logger)
+
+	server, err := api.New(store.people, store.audit, comp, logger)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -105,31 +368,95 @@ func main() {
 
 	//nolint:exhaustruct
 	spec.Servers = openapi3.Servers{&openapi3.Server{URL: "/api/v0"}}
-	//nolint:exhaustruct
-	oapiValidator := middleware.OapiRequestValidatorWithOptions(spec, &middleware.Options{
-		SilenceServersWarning: true,
-	})
+	oapiValidator := middleware.OapiRequestValidatorWithOptions(spec, api.ValidatorOptions())
+
+	operationNames, err := api.OperationNames(spec)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	baseRouter := mux.NewRouter()
 	baseRouter.Use(utils.HTTPLoggerMiddleware(logger))
+	baseRouter.NotFoundHandler = api.NotFoundHandler()
+	baseRouter.MethodNotAllowedHandler = api.MethodNotAllowedHandler()
 
 	apiRouter := baseRouter.PathPrefix("/api/v0/").Subrouter()
+	apiRouter.Use(otelhttp.NewMiddleware(tracing.ServiceName,
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			if name := operationNames(r); name != "" {
+				return name
+			}
+
+			return r.Method
+		}),
+	))
+	apiRouter.Use(serviceMetrics.HTTPMiddleware(operationNames))
+	apiRouter.Use(api.ClientIPMiddleware)
+
+	if store.readYourWrites > 0 {
+		apiRouter.Use(api.ReadYourWritesMiddleware(store.readYourWrites))
+	}
+
+	specCosts, err := api.RateLimitCostsFromSpec(spec)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// every request takes a token of the (larger) address bucket before it
+	// is validated and authenticated, operations are limited by the bucket
+	// of the client (or of the address for anonymous requests)
+	rateLimiter := makeRateLimiter(specCosts, store.sharedRateLimits, cfg.RateLimit, logger)
+	if rateLimiter != nil {
+		apiRouter.Use(rateLimiter.Middleware(operationNames))
+	}
+
+	apiRouter.Use(api.MergePatchAliasMiddleware)
 	apiRouter.Use(oapiValidator)
 
-	api.HandlerFromMux(
-		api.NewStrictHandler(server, []api.StrictMiddlewareFunc{}),
-		apiRouter,
-	)
+	idempotency := api.NewIdempotency(store.idempotency, api.IdempotencyOptions{
+		TTL:  cfg.Idempotency.TTL,
+		Wait: cfg.Idempotency.Wait,
+	}, logger)
+	apiRouter.Use(idempotency.Middleware)
 
-	//nolint:exhaustruct
-	httpServer := &http.Server{
-		Addr:         "0.0.0.0:80",
-		ReadTimeout:  serverCfg.ReadTimout,
-		WriteTimeout: serverCfg.WriteTimout,
-		Handler:      baseRouter,
+	strictMiddlewares := makeAuthMiddlewares(spec, store.clients, cfg, background, logger)
+	if rateLimiter != nil {
+		strictMiddlewares = append([]api.StrictMiddlewareFunc{rateLimiter.StrictMiddleware}, strictMiddlewares...)
 	}
 
-	logger.Info("started server")
+	// idempotency keys are reserved for authenticated and authorized clients
+	strictMiddlewares = append([]api.StrictMiddlewareFunc{idempotency.StrictMiddleware}, strictMiddlewares...)
+
+	//nolint:exhaustruct
+	api.HandlerWithOptions(
+		api.NewStrictHandlerWithOptions(server, strictMiddlewares, api.StrictHandlerOptions()),
+		api.GorillaServerOptions{
+			BaseRouter:       apiRouter,
+			ErrorHandlerFunc: api.ParamErrorHandler,
+		},
+	)
+
+	// wraps the router to identify unmatched requests too
+	httpServer := makeAPIServer(cfg.Server, cfg.TLS, api.RequestIDMiddleware(baseRouter), background, logger)
 
-	log.Fatal(httpServer.ListenAndServe())
+	serve(httpServer, "server", logger)
+
+	background.Go("config reloader", (&reloader{
+		cfg:         cfg,
+		args:        os.Args[1:],
+		level:       &level,
+		rateLimiter: rateLimiter,
+		specCosts:   specCosts,
+		completer:   comp,
+		logger:      logger,
+	}).Run)
+
+	<-ctx.Done()
+	stop()
+
+	shutdown(cfg.Server.ShutdownDelay, cfg.Server.ShutdownTimeout, &draining,
+		httpServer, adminServer, background, logger,
+		store.cleanup,
+		cleanupStep{name: "flushed traces", run: shutdownTracing},
+	)
 }
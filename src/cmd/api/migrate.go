@@ -0,0 +1,128 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"strconv"
+
+	"github.com/Hofsiedge/person-api/internal/config"
+	"github.com/Hofsiedge/person-api/internal/repo/postgres"
+)
+
+const migrateUsage = `usage:
+  api migrate [config flags] up          apply all new migrations
+  api migrate [config flags] down [N]    revert the last N migrations (1 by default)
+  api migrate [config flags] status      print the applied and the expected version
+  api migrate [config flags] force <V>   set the version after fixing a failed migration
+
+The database is configured like the server: with the config file
+(CONFIG_FILE or -config), environment variables and flags given before
+the action.`
+
+var errMigrateUsage = errors.New(migrateUsage)
+
+// printStatus prints the version of the schema, fails if the server can
+// not use it
+func printStatus(migrator *postgres.Migrator) error {
+	status, err := migrator.Status()
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	fmt.Printf("version: %d\ndirty: %t\nexpected: %d\n", status.Version, status.Dirty, status.Expected)
+
+	return status.Check() //nolint:wrapcheck
+}
+
+// migrateAction parses the arguments of `api migrate`
+func migrateAction(args []string) (func(*postgres.Migrator) error, error) {
+	var (
+		number int
+		err    error
+	)
+
+	if len(args) == 2 { //nolint:gomnd
+		if number, err = strconv.Atoi(args[1]); err != nil {
+			return nil, errMigrateUsage
+		}
+	}
+
+	switch {
+	case len(args) == 1 && args[0] == "up":
+		return (*postgres.Migrator).Up, nil
+	case len(args) == 1 && args[0] == "down":
+		return func(migrator *postgres.Migrator) error { return migrator.Down(1) }, nil
+	case len(args) == 2 && args[0] == "down":
+		return func(migrator *postgres.Migrator) error { return migrator.Down(number) }, nil
+	case len(args) == 2 && args[0] == "force":
+		return func(migrator *postgres.Migrator) error { return migrator.Force(number) }, nil
+	case len(args) == 1 && args[0] == "status":
+		return printStatus, nil
+	}
+
+	return nil, errMigrateUsage
+}
+
+// migrateCommand runs `api migrate ...`
+func migrateCommand(args []string) {
+	// only the database is used, other sections may be invalid
+	cfg, actionArgs, err := config.LoadCommand(args)
+	if err != nil && !errors.Is(err, config.ErrInvalid) {
+		log.Fatal(err)
+	}
+
+	action, err := migrateAction(actionArgs)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err = cfg.Postgres.Validate(); err != nil {
+		log.Fatal(err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+	migrator, err := postgres.NewMigrator(cfg.Postgres, logger)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer migrator.Close()
+
+	if err = action(migrator); err != nil {
+		migrator.Close()
+		log.Fatal(err)
+	}
+}
+
+// migrateSchema applies the migrations if pgCfg.Migrate is set and checks
+// that the schema is the one the repo expects. The server does not start
+// with a schema it can not use.
+func migrateSchema(pgCfg config.PostgresConfig, logger *slog.Logger) error {
+	migrator, err := postgres.NewMigrator(pgCfg, logger)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	defer migrator.Close()
+
+	if pgCfg.Migrate {
+		if err = migrator.Up(); err != nil {
+			return err //nolint:wrapcheck
+		}
+	}
+
+	status, err := migrator.Status()
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	if err = status.Check(); err != nil {
+		return fmt.Errorf("%w (apply the migrations with `api migrate up` or DB_MIGRATE=true)", err)
+	}
+
+	logger.Info("checked the schema", slog.Int64("version", status.Version), slog.Bool("migrated", pgCfg.Migrate))
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"reflect"
+	"syscall"
+
+	"github.com/Hofsiedge/person-api/internal/api"
+	"github.com/Hofsiedge/person-api/internal/completer"
+	"github.com/Hofsiedge/person-api/internal/config"
+)
+
+// logLevel is the level of the logger, DEBUG overrides LOG_LEVEL
+func logLevel(serverCfg config.ServerConfig) slog.Level {
+	if serverCfg.Debug {
+		return slog.LevelDebug
+	}
+
+	return serverCfg.LogLevel
+}
+
+// reloader reloads the config on SIGHUP and applies the fields that can
+// be changed without a restart (see config.Config.WithReloadable)
+type reloader struct {
+	level       *slog.LevelVar
+	rateLimiter *api.RateLimiter // nil if rate limiting is disabled
+	specCosts   api.RateLimitCosts
+	completer   *completer.Completer
+	logger      *slog.Logger
+	args        []string
+	cfg         config.Config
+}
+
+// Run reloads the config on every SIGHUP until ctx is done
+func (r *reloader) Run(ctx context.Context) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	defer signal.Stop(signals)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signals:
+			r.reload()
+		}
+	}
+}
+
+// reload applies the reloadable fields of a valid config. The current
+// config is kept if the new one is invalid.
+func (r *reloader) reload() {
+	next, err := config.Load(r.args)
+	if err != nil {
+		r.logger.Error("could not reload the config", slog.String("error", err.Error()))
+
+		return
+	}
+
+	var options api.RateLimitOptions
+	if r.rateLimiter != nil {
+		if options, err = rateLimitOptions(r.specCosts, next.RateLimit); err != nil {
+			r.logger.Error("could not reload the config", slog.String("error", err.Error()))
+
+			return
+		}
+	}
+
+	if !reflect.DeepEqual(r.cfg.WithReloadable(next), next) {
+		r.logger.Warn("config fields other than the log level, rate limits and the filler token " +
+			"were changed, they are applied on restart")
+	}
+
+	r.level.Set(logLevel(next.Server))
+
+	if r.rateLimiter != nil {
+		r.rateLimiter.SetOptions(options)
+	}
+
+	r.completer.SetToken(next.Completer.CompleterToken)
+
+	r.cfg = r.cfg.WithReloadable(next)
+	r.logger.Info("reloaded the config",
+		slog.String("log_level", logLevel(next.Server).String()),
+		slog.Float64("rate_limit_burst", next.RateLimit.Burst),
+		slog.Float64("rate_limit_rate", next.RateLimit.Rate))
+}
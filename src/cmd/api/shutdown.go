@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Hofsiedge/person-api/internal/listener"
+)
+
+var errNotReady = errors.New("shutting down")
+
+// how long each cleanup step may take: they run after the shutdown timeout
+// may have been spent draining requests
+const cleanupTimeout = 2 * time.Second
+
+// workers runs background workers until they are stopped
+type workers struct {
+	ctx    context.Context //nolint:containedctx
+	cancel context.CancelFunc
+	logger *slog.Logger
+	wg     sync.WaitGroup
+}
+
+func newWorkers(logger *slog.Logger) *workers {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &workers{
+		ctx:    ctx,
+		cancel: cancel,
+		logger: logger,
+		wg:     sync.WaitGroup{},
+	}
+}
+
+// Go runs a worker. run must return soon after ctx is done leaving
+// unfinished work for the next start (e.g. by rolling back a transaction).
+func (w *workers) Go(name string, run func(ctx context.Context)) {
+	w.wg.Add(1)
+
+	go func() {
+		defer w.wg.Done()
+
+		run(w.ctx)
+		w.logger.Info("stopped a background worker", slog.String("worker", name))
+	}()
+}
+
+// Stop cancels the workers and waits for them until ctx is done
+func (w *workers) Stop(ctx context.Context) error {
+	w.cancel()
+
+	done := make(chan struct{})
+
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("background workers were not stopped: %w", ctx.Err())
+	}
+}
+
+// cleanupStep is a named step of shutdown. name is logged when run is done.
+type cleanupStep struct {
+	run  func(ctx context.Context) error
+	name string
+}
+
+// serve starts the server in the background. Failing to serve is fatal.
+func serve(server *http.Server, name string, logger *slog.Logger) {
+	go func() {
+		logger.Info("started "+name, slog.String("addr", server.Addr), slog.Bool("tls", server.TLSConfig != nil))
+
+		if err := listener.ListenAndServe(server); !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+}
+
+// shutdown gracefully stops the service:
+//  1. /readyz fails so that no new requests are routed to the instance,
+//     the API server keeps serving for delay while load balancers notice;
+//  2. the API server stops accepting connections and drains in-flight
+//     requests (the steps up to 4 take up to timeout);
+//  3. background workers are canceled and waited for;
+//  4. the admin server is stopped;
+//  5. cleanup steps are run in order (closing the pool, flushing
+//     telemetry, ...), each within cleanupTimeout of its own, so that slow
+//     draining does not leave them no time.
+func shutdown(
+	delay, timeout time.Duration, draining *atomic.Bool, apiServer, adminServer *http.Server,
+	background *workers, logger *slog.Logger, cleanup ...cleanupStep,
+) {
+	start := time.Now()
+	step := func(message string, err error) {
+		if err != nil {
+			logger.Error("shutdown step failed", slog.String("step", message), slog.String("error", err.Error()))
+
+			return
+		}
+
+		logger.Info(message, slog.Duration("elapsed", time.Since(start)))
+	}
+
+	logger.Info("shutting down", slog.Duration("delay", delay), slog.Duration("timeout", timeout))
+	draining.Store(true)
+	time.Sleep(delay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	step("drained in-flight requests", apiServer.Shutdown(ctx))
+	step("stopped background workers", background.Stop(ctx))
+	step("stopped admin server", adminServer.Shutdown(ctx))
+
+	for _, cleanupStep := range cleanup {
+		func() {
+			ctx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+			defer cancel()
+
+			step(cleanupStep.name, cleanupStep.run(ctx))
+		}()
+	}
+
+	logger.Info("shut down", slog.Duration("elapsed", time.Since(start)))
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Hofsiedge/person-api/internal/health"
+)
+
+// newServer serves handler on a free local port
+func newServer(t *testing.T, handler http.Handler) (*http.Server, string) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := &http.Server{Handler: handler, ReadHeaderTimeout: time.Second} //nolint:exhaustruct
+
+	go func() {
+		if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+			t.Error(err)
+		}
+	}()
+
+	return server, "http://" + listener.Addr().String()
+}
+
+func get(url string) (int, error) {
+	// every request opens a connection
+	client := http.Client{Transport: &http.Transport{DisableKeepAlives: true}, Timeout: time.Second} //nolint:exhaustruct
+
+	response, err := client.Get(url) //nolint:noctx
+	if err != nil {
+		return 0, err //nolint:wrapcheck
+	}
+	defer response.Body.Close()
+
+	return response.StatusCode, nil
+}
+
+// readiness fails while the API server still accepts connections
+func TestShutdownDelay(t *testing.T) {
+	t.Parallel()
+
+	var draining atomic.Bool
+
+	apiServer, apiURL := newServer(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	adminServer, adminURL := newServer(t, health.ReadinessHandler([]health.Check{{
+		Name: "shutdown",
+		Check: func(context.Context) error {
+			if draining.Load() {
+				return errNotReady
+			}
+
+			return nil
+		},
+	}}, time.Second))
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		shutdown(time.Second, time.Second, &draining, apiServer, adminServer, newWorkers(logger), logger)
+	}()
+
+	for !draining.Load() {
+		time.Sleep(time.Millisecond)
+	}
+
+	if status, err := get(adminURL + "/readyz"); err != nil || status != http.StatusServiceUnavailable {
+		t.Errorf("readiness did not fail on shutdown: %d (%v)", status, err)
+	}
+
+	if status, err := get(apiURL); err != nil || status != http.StatusOK {
+		t.Errorf("API server did not accept connections during the delay: %d (%v)", status, err)
+	}
+
+	<-done
+
+	if _, err := get(apiURL); err == nil {
+		t.Error("API server accepted connections after shutdown")
+	}
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Hofsiedge/person-api/internal/config"
+	"github.com/Hofsiedge/person-api/internal/health"
+	"github.com/Hofsiedge/person-api/internal/repo"
+	"github.com/Hofsiedge/person-api/internal/repo/memory"
+	"github.com/Hofsiedge/person-api/internal/repo/postgres"
+	"github.com/Hofsiedge/person-api/internal/repo/sqlite"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// storage is the backend of the repos (STORAGE)
+type storage struct {
+	people      repo.PersonRepo
+	audit       repo.AuditRepo
+	clients     repo.APIClientRepo
+	idempotency repo.IdempotencyRepo
+	// buckets shared between replicas, nil if the backend can not share them
+	sharedRateLimits repo.RateLimitRepo
+	// count returns the number of active and deleted people
+	count func(ctx context.Context) (int, int, error)
+	// stat returns statistics of the connection pool, nil if there is none
+	stat func() *pgxpool.Stat
+	// readiness checks of the backend
+	checks []health.Check
+	// how long reads of a client go to the primary after its writes,
+	// 0 if there are no read replicas
+	readYourWrites time.Duration
+	// cleanup releases the backend on shutdown
+	cleanup cleanupStep
+}
+
+// openStorage opens the backend of cfg. Background work of the backend
+// (saving snapshots) is run by background.
+func openStorage(cfg config.Config, background *workers, logger *slog.Logger) (*storage, error) {
+	switch cfg.Storage.Backend {
+	case config.StorageMemory:
+		return openMemory(cfg.Storage, background, logger)
+	case config.StorageSQLite:
+		return openSQLite(cfg.Storage, logger)
+	}
+
+	return openPostgres(cfg.Postgres, background, logger)
+}
+
+// openPostgres migrates the schema (types of the schema are registered
+// for connections of the pool, so it is migrated first) and connects.
+// Replicas are checked every DB_REPLICA_CHECK_INTERVAL, so a replica may
+// lag up to DB_REPLICA_MAX_LAG + DB_REPLICA_CHECK_INTERVAL before it is
+// found lagging: clients read their writes from the primary for that long.
+func openPostgres(pgCfg config.PostgresConfig, background *workers, logger *slog.Logger) (*storage, error) {
+	if err := migrateSchema(pgCfg, logger); err != nil {
+		return nil, err
+	}
+
+	people, err := postgres.New(pgCfg)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	var readYourWrites time.Duration
+
+	if len(pgCfg.Replicas) > 0 {
+		readYourWrites = pgCfg.ReplicaMaxLag + pgCfg.ReplicaCheckInterval
+
+		checkReplicas := func(ctx context.Context) {
+			for _, status := range people.CheckReplicas(ctx) {
+				switch {
+				case !status.Changed:
+				case status.Healthy:
+					logger.Info("reading from the replica", slog.String("replica", status.Name),
+						slog.Duration("lag", status.Lag))
+				default:
+					logger.Warn("stopped reading from the replica", slog.String("replica", status.Name),
+						slog.Duration("lag", status.Lag), slog.Any("error", status.Err))
+				}
+			}
+		}
+
+		checkReplicas(context.Background())
+
+		background.Go("replica checker", func(ctx context.Context) {
+			ticker := time.NewTicker(pgCfg.ReplicaCheckInterval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+
+				checkReplicas(ctx)
+			}
+		})
+	}
+
+	return &storage{
+		people:           people,
+		audit:            people.AuditLog(),
+		clients:          people.APIClients(),
+		idempotency:      people.IdempotencyKeys(),
+		sharedRateLimits: people.RateLimits(),
+		count:            people.Count,
+		stat:             people.Stat,
+		checks: []health.Check{
+			{Name: "postgres", Check: people.Ping},
+			{Name: "migrations", Check: people.CheckSchema},
+			{Name: "types", Check: people.CheckTypes},
+		},
+		readYourWrites: readYourWrites,
+		cleanup: cleanupStep{name: "closed database connections", run: func(context.Context) error {
+			people.Close()
+
+			return nil
+		}},
+	}, nil
+}
+
+// openMemory loads the snapshot if MEMORY_SNAPSHOT_FILE is set and saves
+// it every MEMORY_SNAPSHOT_INTERVAL and on shutdown. API clients are kept
+// in memory too, so the admin CLI can not issue keys: use JWTs or
+// disable authentication.
+func openMemory(storageCfg config.StorageConfig, background *workers, logger *slog.Logger) (*storage, error) {
+	people := memory.NewPeople()
+
+	logger.Warn("people are stored in memory, run a single instance")
+
+	save := func(ctx context.Context) error { return nil }
+
+	if path := storageCfg.SnapshotFile; path != "" {
+		if err := people.LoadFile(context.Background(), path); err != nil {
+			return nil, fmt.Errorf("could not start the memory storage: %w", err)
+		}
+
+		save = func(ctx context.Context) error { return people.SaveFile(ctx, path) }
+
+		background.Go("memory snapshot", func(ctx context.Context) {
+			ticker := time.NewTicker(storageCfg.SnapshotInterval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+
+				if err := save(ctx); err != nil {
+					logger.Error("could not save the memory snapshot", slog.String("error", err.Error()))
+				}
+			}
+		})
+	}
+
+	return &storage{
+		people:           people,
+		audit:            people.AuditLog(),
+		clients:          memory.NewAPIClients(),
+		idempotency:      memory.NewIdempotencyKeys(),
+		sharedRateLimits: nil,
+		count:            people.Count,
+		stat:             nil,
+		checks:           []health.Check{},
+		readYourWrites:   0,
+		cleanup:          cleanupStep{name: "saved the memory snapshot", run: save},
+	}, nil
+}
+
+// openSQLite opens (and migrates) the database file. API clients and
+// idempotency keys are stored in it too, rate limit buckets are kept
+// in memory.
+func openSQLite(storageCfg config.StorageConfig, logger *slog.Logger) (*storage, error) {
+	people, err := sqlite.New(storageCfg.SQLiteFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not start the sqlite storage: %w", err)
+	}
+
+	logger.Info("people are stored in sqlite, run a single instance",
+		slog.String("file", storageCfg.SQLiteFile))
+
+	return &storage{
+		people:           people,
+		audit:            people.AuditLog(),
+		clients:          people.APIClients(),
+		idempotency:      people.IdempotencyKeys(),
+		sharedRateLimits: nil,
+		count:            people.Count,
+		stat:             nil,
+		checks:           []health.Check{{Name: "sqlite", Check: people.Ping}},
+		readYourWrites:   0,
+		cleanup: cleanupStep{name: "closed the sqlite database", run: func(context.Context) error {
+			return people.Close()
+		}},
+	}, nil
+}
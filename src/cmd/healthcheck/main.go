@@ -0,0 +1,54 @@
+// healthcheck probes the admin listener of the server running in the same
+// container (the image has no curl or wget):
+//
+//	healthcheck [config flags] [path]  request path (/readyz by default) and
+//	                                   exit with 1 unless the response is 200 OK
+//
+// The admin listener is configured like the server: with the config file
+// (CONFIG_FILE or -config), environment variables and flags.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/Hofsiedge/person-api/internal/config"
+)
+
+const timeout = 5 * time.Second
+
+func main() {
+	// only the admin listener is used, other sections may be invalid
+	cfg, args, err := config.LoadCommand(os.Args[1:])
+	if err != nil && !errors.Is(err, config.ErrInvalid) {
+		log.Fatal(err)
+	}
+
+	_, port, err := net.SplitHostPort(cfg.Admin.Addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	path := "/readyz"
+	if len(args) > 0 {
+		path = args[0]
+	}
+
+	client := http.Client{Timeout: timeout} //nolint:exhaustruct
+
+	response, err := client.Get(fmt.Sprintf("http://%s%s", net.JoinHostPort("127.0.0.1", port), path))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		response.Body.Close()
+		log.Fatalf("%s: %s", path, response.Status)
+	}
+}
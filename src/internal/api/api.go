@@ -4,12 +4,10 @@ import (
 	"context"
 	"errors"
 	"log/slog"
-	"net/http"
 	"time"
 
 	"github.com/Hofsiedge/person-api/internal/completer"
 	"github.com/Hofsiedge/person-api/internal/domain"
-	"github.com/Hofsiedge/person-api/internal/filler"
 	"github.com/Hofsiedge/person-api/internal/repo"
 )
 
@@ -20,6 +18,7 @@ import (
 // ensure that Server implements StrictServerInterface
 var _ StrictServerInterface = &Server{
 	People:    nil,
+	Audit:     nil,
 	Completer: nil,
 	Logger:    nil,
 }
@@ -29,40 +28,41 @@ var ErrInit = errors.New("unexpected nil in argument list")
 // implements StrictServerInterface.
 type Server struct {
 	People    repo.PersonRepo
+	Audit     repo.AuditRepo
 	Completer Completer
 	Logger    *slog.Logger
 }
 
 type Completer interface {
-	Complete(name string) (completer.CompletionData, error)
+	Complete(ctx context.Context, name string) (completer.CompletionData, error)
 	UnlockingTime() (time.Time, error)
 }
 
-func New(repo repo.PersonRepo, completer Completer, logger *slog.Logger) (*Server, error) {
-	if repo == nil || logger == nil {
+func New(people repo.PersonRepo, audit repo.AuditRepo, completer Completer, logger *slog.Logger) (*Server, error) {
+	if people == nil || audit == nil || logger == nil {
 		return nil, ErrInit
 	}
 
-	return &Server{repo, completer, logger}, nil
+	return &Server{people, audit, completer, logger}, nil
 }
 
 // PersonGet implements StrictServerInterface.
 func (s *Server) PersonGet( //nolint:ireturn
 	ctx context.Context, request PersonGetRequestObject,
 ) (PersonGetResponseObject, error) {
-	person, err := s.People.GetByID(ctx, request.PersonID)
+	var (
+		person domain.Person
+		err    error
+	)
+
+	if request.Params.AsOf != nil {
+		person, err = s.People.GetAsOf(ctx, request.PersonID, *request.Params.AsOf)
+	} else {
+		person, err = s.People.GetByID(ctx, request.PersonID)
+	}
+
 	if err != nil {
-		switch {
-		case errors.Is(err, repo.ErrNotFound):
-			return PersonGet404Response{}, nil
-		case errors.Is(err, repo.ErrUnexpected):
-			fallthrough
-		default:
-			s.Logger.Log(ctx, slog.LevelError, "unexpected error getting a person",
-				slog.String("message", err.Error()))
-
-			return PersonGet5XXResponse{http.StatusInternalServerError}, nil
-		}
+		return s.problem(ctx, "error getting a person", err), nil
 	}
 
 	s.Logger.Log(ctx, slog.LevelDebug, "found a person by id",
@@ -83,34 +83,26 @@ func (s *Server) PersonGet( //nolint:ireturn
 func (s *Server) PersonList( //nolint:ireturn
 	ctx context.Context, request PersonListRequestObject,
 ) (PersonListResponseObject, error) {
+	withTotal := valueOr(request.Params.Total, true)
+
 	page, err := s.People.List(ctx, domain.PersonFilter{
-		Name:        request.Params.Name,
-		Surname:     request.Params.Surname,
-		Patronymic:  request.Params.Patronymic,
-		Nationality: (*domain.Nationality)(request.Params.Nationality),
-		Sex:         (*domain.Sex)(request.Params.Sex),
-		AgeMin:      request.Params.AgeMin,
-		AgeMax:      request.Params.AgeMax,
-		Threshold:   request.Params.Threshold,
+		Name:           request.Params.Name,
+		Surname:        request.Params.Surname,
+		Patronymic:     request.Params.Patronymic,
+		Nationality:    (*domain.Nationality)(request.Params.Nationality),
+		Sex:            (*domain.Sex)(request.Params.Sex),
+		AgeMin:         request.Params.AgeMin,
+		AgeMax:         request.Params.AgeMax,
+		Threshold:      request.Params.Threshold,
+		Match:          domain.Match(valueOr(request.Params.Match, Trigram)),
+		IncludeDeleted: request.Params.IncludeDeleted != nil && *request.Params.IncludeDeleted,
 	}, domain.PaginationFilter{
-		Offset: *request.Params.Offset,
-		Limit:  *request.Params.Limit,
+		Offset:       *request.Params.Offset,
+		Limit:        *request.Params.Limit,
+		WithoutTotal: !withTotal,
 	})
 	if err != nil {
-		s.Logger.Log(ctx, slog.LevelDebug, "error searching people",
-			slog.String("message", err.Error()))
-
-		switch {
-		case errors.Is(err, repo.ErrArgument):
-			return PersonList400Response{}, nil
-		case errors.Is(err, repo.ErrUnexpected):
-			fallthrough
-		default:
-			s.Logger.Log(ctx, slog.LevelError, "unexpected error searching people",
-				slog.String("message", err.Error()))
-
-			return PersonList5XXResponse{http.StatusInternalServerError}, nil
-		}
+		return s.problem(ctx, "error searching people", err), nil
 	}
 
 	people := make([]PersonFullWithID, len(page.Items))
@@ -133,11 +125,16 @@ func (s *Server) PersonList( //nolint:ireturn
 		slog.Int("length", len(page.Items)),
 	)
 
+	var total *int
+	if withTotal {
+		total = &page.TotalItems
+	}
+
 	return PersonList200JSONResponse{
 		Pagination: PaginationOffsetLimit{
 			CurrentLimit:  page.CurrentLimit,
 			CurrentOffset: page.CurrentOffset,
-			TotalItems:    page.TotalItems,
+			TotalItems:    total,
 		},
 		People: people,
 	}, nil
@@ -147,70 +144,46 @@ func (s *Server) PersonList( //nolint:ireturn
 func (s *Server) PersonPatch( //nolint:ireturn
 	ctx context.Context, request PersonPatchRequestObject,
 ) (PersonPatchResponseObject, error) {
-	err := s.People.PartialUpdate(ctx, request.PersonID, domain.PersonPartial{
-		Name:        request.Body.Name,
-		Surname:     request.Body.Surname,
-		Patronymic:  request.Body.Patronymic,
-		Nationality: request.Body.Nationality,
-		Sex:         (*domain.Sex)(request.Body.Sex),
-		Age:         request.Body.Age,
-	})
+	var (
+		operations []domain.PatchOperation
+		err        error
+	)
+
+	switch {
+	case request.ApplicationMergePatchPlusJSONBody != nil:
+		operations = mergePatchOperations(*request.ApplicationMergePatchPlusJSONBody)
+	case request.ApplicationJSONPatchPlusJSONBody != nil:
+		operations, err = jsonPatchOperations(*request.ApplicationJSONPatchPlusJSONBody)
+	default:
+		return problemResponse{retryAfter: nil, challenge: nil, Problem: ProblemInvalidBody.New("no patch document")}, nil
+	}
+
+	if err == nil {
+		operations, err = s.enrichRemovals(ctx, request.PersonID, operations)
+	}
+
+	if err == nil {
+		err = s.People.Patch(ctx, request.PersonID, operations)
+	}
+
 	if err != nil {
-		s.Logger.Log(ctx, slog.LevelDebug, "error patching a person",
-			slog.String("message", err.Error()))
-
-		switch {
-		case errors.Is(err, repo.ErrArgument):
-			return PersonPatch400Response{}, nil
-		case errors.Is(err, repo.ErrNotFound):
-			return PersonPatch404Response{}, nil
-		case errors.Is(err, repo.ErrUnexpected):
-			fallthrough
-		default:
-			s.Logger.Log(ctx, slog.LevelError, "unexpected error patching a person",
-				slog.String("message", err.Error()))
-
-			return PersonPatch5XXResponse{http.StatusInternalServerError}, nil
-		}
+		return s.problem(ctx, "error patching a person", err), nil
 	}
 
 	s.Logger.Log(ctx, slog.LevelDebug, "patched a person",
-		slog.String("uuid", request.PersonID.String()))
+		slog.String("uuid", request.PersonID.String()),
+		slog.Int("operations", len(operations)))
 
 	return PersonPatch200Response{}, nil
 }
 
 // PersonPost implements StrictServerInterface.
-func (s *Server) PersonPost( //nolint:ireturn,cyclop,funlen
+func (s *Server) PersonPost( //nolint:ireturn
 	ctx context.Context, request PersonPostRequestObject,
 ) (PersonPostResponseObject, error) {
-	compData, err := s.Completer.Complete(request.Body.Name)
+	compData, err := s.Completer.Complete(ctx, request.Body.Name)
 	if err != nil {
-		s.Logger.Log(ctx, slog.LevelInfo, "completer error",
-			slog.String("message", err.Error()))
-
-		switch {
-		case errors.Is(err, filler.ErrUser):
-			return PersonPost422Response{}, nil
-
-		case errors.Is(err, filler.ErrEnvironment), errors.Is(err, filler.ErrAPI):
-			return PersonPost5XXResponse{http.StatusInternalServerError}, nil
-
-		case errors.Is(err, filler.ErrLimitReached):
-			unlockingTime, err := s.Completer.UnlockingTime()
-			if err != nil {
-				return PersonPost5XXResponse{http.StatusInternalServerError}, nil //nolint:nilerr
-			}
-
-			return PersonPost503Response{
-				Headers: PersonPost503ResponseHeaders{
-					RetryAfter: int(time.Until(unlockingTime).Seconds()),
-				},
-			}, nil
-
-		default:
-			return PersonPost5XXResponse{http.StatusInternalServerError}, nil
-		}
+		return s.problem(ctx, "completer error", err), nil
 	}
 
 	s.Logger.Log(ctx, slog.LevelDebug, "completer result",
@@ -232,20 +205,7 @@ func (s *Server) PersonPost( //nolint:ireturn,cyclop,funlen
 
 	personID, err := s.People.Create(ctx, person)
 	if err != nil {
-		s.Logger.Log(ctx, slog.LevelDebug, "error creating a person",
-			slog.String("message", err.Error()))
-
-		switch {
-		case errors.Is(err, repo.ErrArgument):
-			return PersonPost400Response{}, nil
-		case errors.Is(err, repo.ErrUnexpected):
-			fallthrough
-		default:
-			s.Logger.Log(ctx, slog.LevelError, "unexpected error",
-				slog.String("message", err.Error()))
-
-			return PersonPost5XXResponse{http.StatusInternalServerError}, nil
-		}
+		return s.problem(ctx, "error creating a person", err), nil
 	}
 
 	s.Logger.Log(ctx, slog.LevelDebug, "created a person",
@@ -258,6 +218,63 @@ func (s *Server) PersonPost( //nolint:ireturn,cyclop,funlen
 	return response, nil
 }
 
+// PersonBatchPost implements StrictServerInterface.
+//
+// People are enriched before the transaction: it may be retried, and
+// enrichment spends the quota of the fillers.
+func (s *Server) PersonBatchPost( //nolint:ireturn
+	ctx context.Context, request PersonBatchPostRequestObject,
+) (PersonBatchPostResponseObject, error) {
+	completions := make(map[string]completer.CompletionData)
+	people := make([]domain.Person, len(request.Body.People))
+
+	for i, data := range request.Body.People {
+		compData, found := completions[data.Name]
+		if !found {
+			var err error
+			if compData, err = s.Completer.Complete(ctx, data.Name); err != nil {
+				return s.problem(ctx, "completer error", err), nil
+			}
+
+			completions[data.Name] = compData
+		}
+
+		people[i] = domain.Person{
+			Name:        data.Name,
+			Surname:     data.Surname,
+			Patronymic:  data.Patronymic,
+			Nationality: compData.Nationality,
+			Sex:         compData.Sex,
+			Age:         compData.Age,
+			ID:          [16]byte{},
+		}
+	}
+
+	var personIDs []UUID
+
+	err := s.People.WithinTx(ctx, func(ctx context.Context) error {
+		personIDs = make([]UUID, len(people))
+
+		for i, person := range people {
+			personID, err := s.People.Create(ctx, person)
+			if err != nil {
+				return err //nolint:wrapcheck
+			}
+
+			personIDs[i] = personID
+		}
+
+		return nil
+	})
+	if err != nil {
+		return s.problem(ctx, "error creating people", err), nil
+	}
+
+	s.Logger.Log(ctx, slog.LevelDebug, "created people", slog.Int("count", len(personIDs)))
+
+	return PersonBatchPost201JSONResponse{Uuids: personIDs}, nil
+}
+
 // PersonPut implements StrictServerInterface.
 func (s *Server) PersonPut( //nolint:ireturn
 	ctx context.Context, request PersonPutRequestObject,
@@ -272,22 +289,7 @@ func (s *Server) PersonPut( //nolint:ireturn
 		ID:          [16]byte{},
 	})
 	if err != nil {
-		s.Logger.Log(ctx, slog.LevelDebug, "error replacing a person",
-			slog.String("message", err.Error()))
-
-		switch {
-		case errors.Is(err, repo.ErrArgument):
-			return PersonPut400Response{}, nil
-		case errors.Is(err, repo.ErrNotFound):
-			return PersonPut404Response{}, nil
-		case errors.Is(err, repo.ErrUnexpected):
-			fallthrough
-		default:
-			s.Logger.Log(ctx, slog.LevelError, "unexpected error",
-				slog.String("error", err.Error()))
-
-			return PersonPut5XXResponse{http.StatusInternalServerError}, nil
-		}
+		return s.problem(ctx, "error replacing a person", err), nil
 	}
 
 	s.Logger.Log(ctx, slog.LevelDebug, "replaced a person",
@@ -302,19 +304,7 @@ func (s *Server) PersonDelete( //nolint:ireturn
 ) (PersonDeleteResponseObject, error) {
 	err := s.People.Delete(ctx, request.PersonID)
 	if err != nil {
-		switch {
-		case errors.Is(err, repo.ErrArgument):
-			return PersonDelete400Response{}, nil
-		case errors.Is(err, repo.ErrNotFound):
-			return PersonDelete404Response{}, nil
-		case errors.Is(err, repo.ErrUnexpected):
-			fallthrough
-		default:
-			s.Logger.Log(ctx, slog.LevelError, "unexpected error",
-				slog.String("message", err.Error()))
-
-			return PersonDelete5XXResponse{http.StatusInternalServerError}, nil
-		}
+		return s.problem(ctx, "error deleting a person", err), nil
 	}
 
 	s.Logger.Log(ctx, slog.LevelDebug, "deleted a person",
@@ -322,3 +312,158 @@ func (s *Server) PersonDelete( //nolint:ireturn
 
 	return PersonDelete200Response{}, nil
 }
+
+// PersonRestore implements StrictServerInterface.
+func (s *Server) PersonRestore( //nolint:ireturn
+	ctx context.Context, request PersonRestoreRequestObject,
+) (PersonRestoreResponseObject, error) {
+	err := s.People.Restore(ctx, request.PersonID)
+	if err != nil {
+		return s.problem(ctx, "error restoring a person", err), nil
+	}
+
+	s.Logger.Log(ctx, slog.LevelDebug, "restored a person",
+		slog.String("uuid", request.PersonID.String()))
+
+	return PersonRestore200Response{}, nil
+}
+
+// PersonHistory implements StrictServerInterface.
+func (s *Server) PersonHistory( //nolint:ireturn
+	ctx context.Context, request PersonHistoryRequestObject,
+) (PersonHistoryResponseObject, error) {
+	page, err := s.People.History(ctx, request.PersonID, domain.PaginationFilter{
+		Offset:       valueOr(request.Params.Offset, 0),
+		Limit:        valueOr(request.Params.Limit, defaultLimit),
+		WithoutTotal: false,
+	})
+	if err != nil {
+		return s.problem(ctx, "error getting the history of a person", err), nil
+	}
+
+	changes := make([]PersonChange, len(page.Items))
+	for i, change := range page.Items {
+		changes[i] = PersonChange{
+			Actor:     nonEmpty(change.Actor),
+			ChangedAt: change.ChangedAt,
+			Id:        change.ID,
+			New:       personWithID(change.New),
+			Old:       personWithID(change.Old),
+			Operation: PersonChangeOperation(change.Operation),
+			RequestId: nonEmpty(change.RequestID),
+		}
+	}
+
+	s.Logger.Log(ctx, slog.LevelDebug, "got the history of a person",
+		slog.String("uuid", request.PersonID.String()),
+		slog.Int("total", page.TotalItems),
+		slog.Int("length", len(page.Items)),
+	)
+
+	return PersonHistory200JSONResponse{
+		Changes: changes,
+		Pagination: PaginationOffsetLimit{
+			CurrentLimit:  page.CurrentLimit,
+			CurrentOffset: page.CurrentOffset,
+			TotalItems:    &page.TotalItems,
+		},
+	}, nil
+}
+
+// AuditList implements StrictServerInterface.
+func (s *Server) AuditList( //nolint:ireturn
+	ctx context.Context, request AuditListRequestObject,
+) (AuditListResponseObject, error) {
+	page, err := s.Audit.Audit(ctx, domain.AuditFilter{
+		Actor:    request.Params.Actor,
+		PersonID: request.Params.PersonId,
+		From:     request.Params.From,
+		To:       request.Params.To,
+	}, domain.PaginationFilter{
+		Offset:       valueOr(request.Params.Offset, 0),
+		Limit:        valueOr(request.Params.Limit, defaultLimit),
+		WithoutTotal: false,
+	})
+	if err != nil {
+		return s.problem(ctx, "error listing audit entries", err), nil
+	}
+
+	entries := make([]AuditEntry, len(page.Items))
+	for i, entry := range page.Items {
+		diff := make(map[string]FieldChange, len(entry.Diff))
+		for name, change := range entry.Diff {
+			diff[name] = FieldChange{New: nonNil(change.New), Old: nonNil(change.Old)}
+		}
+
+		entries[i] = AuditEntry{
+			Actor:      nonEmpty(entry.Actor),
+			ClientIp:   nonEmpty(entry.ClientIP),
+			Diff:       diff,
+			Id:         entry.ID,
+			OccurredAt: entry.OccurredAt,
+			Operation:  AuditEntryOperation(entry.Operation),
+			PersonId:   entry.PersonID,
+			RequestId:  nonEmpty(entry.RequestID),
+		}
+	}
+
+	s.Logger.Log(ctx, slog.LevelDebug, "listed audit entries",
+		slog.Int("total", page.TotalItems),
+		slog.Int("length", len(page.Items)),
+	)
+
+	return AuditList200JSONResponse{
+		Entries: entries,
+		Pagination: PaginationOffsetLimit{
+			CurrentLimit:  page.CurrentLimit,
+			CurrentOffset: page.CurrentOffset,
+			TotalItems:    &page.TotalItems,
+		},
+	}, nil
+}
+
+// default page size (see openapi.yaml)
+const defaultLimit = 20
+
+// valueOr returns the value of an optional parameter or its default
+func valueOr[T any](value *T, defaultValue T) T {
+	if value == nil {
+		return defaultValue
+	}
+
+	return *value
+}
+
+// nonEmpty returns nil for an empty string
+func nonEmpty(value string) *string {
+	if value == "" {
+		return nil
+	}
+
+	return &value
+}
+
+// nonNil returns a pointer to a JSON value or nil if it is null
+func nonNil(value any) *any {
+	if value == nil {
+		return nil
+	}
+
+	return &value
+}
+
+func personWithID(person *domain.Person) *PersonFullWithID {
+	if person == nil {
+		return nil
+	}
+
+	return &PersonFullWithID{
+		Age:         person.Age,
+		Id:          person.ID,
+		Name:        person.Name,
+		Nationality: string(person.Nationality),
+		Patronymic:  person.Patronymic,
+		Sex:         Sex(person.Sex),
+		Surname:     person.Surname,
+	}
+}
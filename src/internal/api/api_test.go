@@ -11,19 +11,24 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
 	"reflect"
 	"regexp"
 	"slices"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/Hofsiedge/person-api/internal/api"
+	"github.com/Hofsiedge/person-api/internal/auth"
 	"github.com/Hofsiedge/person-api/internal/completer"
 	"github.com/Hofsiedge/person-api/internal/domain"
+	"github.com/Hofsiedge/person-api/internal/filler"
 	"github.com/Hofsiedge/person-api/internal/repo"
-	"github.com/Hofsiedge/person-api/internal/repo/mock"
+	"github.com/Hofsiedge/person-api/internal/repo/memory"
+	"github.com/Hofsiedge/person-api/internal/reqctx"
 	"github.com/Hofsiedge/person-api/internal/utils"
 	"github.com/google/uuid"
 	middleware "github.com/oapi-codegen/nethttp-middleware"
@@ -31,7 +36,7 @@ import (
 
 type MockCompleter struct{}
 
-func (mc MockCompleter) Complete(name string) (completer.CompletionData, error) {
+func (mc MockCompleter) Complete(_ context.Context, name string) (completer.CompletionData, error) {
 	return completer.CompletionData{
 		Sex:         domain.Female,
 		Nationality: domain.Nationality("RU"),
@@ -56,7 +61,7 @@ func subtests(t *testing.T, testCases []testCase) {
 		test := tCase
 		t.Run(test.name, func(t *testing.T) {
 			t.Parallel()
-			people := mock.New()
+			people := memory.NewPeople()
 			request, check := test.init(t, people) //nolint:bodyclose
 			result := serve(t, request, people)
 			defer result.Body.Close()
@@ -99,6 +104,36 @@ func checkNoBody(t *testing.T, response *http.Response) {
 	t.Errorf("unexpected response body: %v", data)
 }
 
+func checkProblem(t *testing.T, response *http.Response, problemType api.ProblemType) api.Problem {
+	t.Helper()
+
+	if contentType := response.Header.Get("Content-Type"); contentType != api.ProblemContentType {
+		t.Errorf("unexpected problem content type: %q", contentType)
+	}
+
+	problem := unmarshalJSONBody[api.Problem](t, response)
+	if problem.Type != problemType.URI() || problem.Status != problemType.Status ||
+		problem.Title != problemType.Title {
+		t.Errorf("problem mismatch: expected %v, got %v", problemType, problem)
+	}
+
+	return problem
+}
+
+func checkInvalidParam(t *testing.T, problem api.Problem, in api.ProblemInvalidParamIn, name string) {
+	t.Helper()
+
+	if problem.InvalidParams != nil {
+		for _, param := range *problem.InvalidParams {
+			if param.In == in && param.Name == name {
+				return
+			}
+		}
+	}
+
+	t.Errorf("no invalid param %s %q in problem %v", in, name, problem)
+}
+
 func unmarshalJSONBody[T any](t *testing.T, response *http.Response) T {
 	t.Helper()
 
@@ -138,17 +173,52 @@ func checkBody[T any](t *testing.T, response *http.Response, expected T) {
 	}
 }
 
+func personWithID(person domain.Person) *api.PersonFullWithID {
+	return &api.PersonFullWithID{
+		Age:         person.Age,
+		Id:          person.ID,
+		Name:        person.Name,
+		Nationality: string(person.Nationality),
+		Patronymic:  person.Patronymic,
+		Sex:         api.Sex(person.Sex),
+		Surname:     person.Surname,
+	}
+}
+
 // initialize a server and run request against it
 func serve(t *testing.T, request *http.Request, people repo.PersonRepo) *http.Response {
 	t.Helper()
 
+	recorder := httptest.NewRecorder()
+	newHandler(t, people).ServeHTTP(recorder, request)
+
+	return recorder.Result()
+}
+
+// initialize a server. middlewares are run after validation
+func newHandler(t *testing.T, people repo.PersonRepo, middlewares ...api.MiddlewareFunc) http.Handler {
+	t.Helper()
+
+	return newStrictHandler(t, people, []api.StrictMiddlewareFunc{}, middlewares...)
+}
+
+func newStrictHandler(
+	t *testing.T, people repo.PersonRepo, strictMiddlewares []api.StrictMiddlewareFunc, middlewares ...api.MiddlewareFunc,
+) http.Handler {
+	t.Helper()
+
 	logger := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{
 		AddSource:   false,
 		Level:       nil,
 		ReplaceAttr: nil,
 	}))
 
-	server, err := api.New(people, MockCompleter{}, logger)
+	var audit repo.AuditRepo = memory.NewPeople().AuditLog()
+	if mockPeople, ok := people.(*memory.People); ok {
+		audit = mockPeople.AuditLog()
+	}
+
+	server, err := api.New(people, audit, MockCompleter{}, logger)
 	if err != nil {
 		t.Fatalf("error creating a server: %v", err)
 	}
@@ -159,19 +229,16 @@ func serve(t *testing.T, request *http.Request, people repo.PersonRepo) *http.Re
 	}
 	// do not validate server names
 	spec.Servers = nil
-	oapiValidator := middleware.OapiRequestValidator(spec)
+	oapiValidator := middleware.OapiRequestValidatorWithOptions(spec, api.ValidatorOptions())
 
-	handler := api.HandlerWithOptions(
-		api.NewStrictHandler(server, []api.StrictMiddlewareFunc{}),
+	return api.HandlerWithOptions(
+		api.NewStrictHandlerWithOptions(server, strictMiddlewares, api.StrictHandlerOptions()),
 		api.GorillaServerOptions{ //nolint:exhaustruct
-			Middlewares: []api.MiddlewareFunc{oapiValidator},
+			// the last middleware is the outermost
+			Middlewares:      append(middlewares, oapiValidator, api.MergePatchAliasMiddleware),
+			ErrorHandlerFunc: api.ParamErrorHandler,
 		},
 	)
-	recorder := httptest.NewRecorder()
-	handler.ServeHTTP(recorder, request)
-	result := recorder.Result()
-
-	return result
 }
 
 func TestGet(t *testing.T) {
@@ -188,7 +255,7 @@ func TestGet(t *testing.T) {
 				request := makeGetRequest(uuid.New())
 
 				return request, func(response *http.Response) {
-					checkNoBody(t, response)
+					checkProblem(t, response, api.ProblemNotFound)
 				}
 			},
 			status: http.StatusNotFound,
@@ -199,8 +266,8 @@ func TestGet(t *testing.T) {
 				request := makeGetRequest("124390845")
 
 				return request, func(response *http.Response) {
-					pattern := regexp.MustCompile("Invalid format for parameter personID")
-					checkStringBody(t, response, pattern)
+					problem := checkProblem(t, response, api.ProblemValidation)
+					checkInvalidParam(t, problem, "path", "personID")
 				}
 			},
 			status: http.StatusBadRequest,
@@ -236,6 +303,132 @@ func TestGet(t *testing.T) {
 	subtests(t, testCases)
 }
 
+func TestGetAsOf(t *testing.T) {
+	t.Parallel()
+
+	makeGetRequest := func(id uuid.UUID, asOf time.Time) *http.Request {
+		query := url.Values{"as_of": []string{asOf.Format(time.RFC3339Nano)}}
+
+		return httptest.NewRequest(http.MethodGet, fmt.Sprintf("/person/%s?%s", id, query.Encode()), nil)
+	}
+
+	testCases := []testCase{
+		{
+			name: "did not exist",
+			init: func(t *testing.T, people repo.PersonRepo) (*http.Request, func(response *http.Response)) { //nolint:thelper
+				before := time.Now()
+				personID, err := people.Create(context.Background(), utils.MakePerson())
+				if err != nil {
+					t.Fatalf("error initializing repo: %v", err)
+				}
+
+				return makeGetRequest(personID, before.Add(-time.Second)), func(response *http.Response) {
+					checkProblem(t, response, api.ProblemNotFound)
+				}
+			},
+			status: http.StatusNotFound,
+		},
+		{
+			name: "before update",
+			init: func(t *testing.T, people repo.PersonRepo) (*http.Request, func(response *http.Response)) { //nolint:thelper
+				person := utils.MakePerson()
+				personID, err := people.Create(context.Background(), person)
+				if err != nil {
+					t.Fatalf("error initializing repo: %v", err)
+				}
+				person.ID = personID
+				created := time.Now()
+
+				if err = people.FullUpdate(context.Background(), personID, utils.MakePerson()); err != nil {
+					t.Fatalf("error initializing repo: %v", err)
+				}
+
+				return makeGetRequest(personID, created), func(response *http.Response) {
+					checkBody(t, response, api.PersonGet200JSONResponse(*personWithID(person)))
+				}
+			},
+			status: http.StatusOK,
+		},
+		{
+			name: "invalid moment",
+			init: func(t *testing.T, _ repo.PersonRepo) (*http.Request, func(response *http.Response)) { //nolint:thelper
+				request := httptest.NewRequest(http.MethodGet,
+					fmt.Sprintf("/person/%s?as_of=yesterday", uuid.New()), nil)
+
+				return request, func(response *http.Response) {
+					problem := checkProblem(t, response, api.ProblemValidation)
+					checkInvalidParam(t, problem, "query", "as_of")
+				}
+			},
+			status: http.StatusBadRequest,
+		},
+	}
+
+	subtests(t, testCases)
+}
+
+func TestHistory(t *testing.T) {
+	t.Parallel()
+
+	makeHistoryRequest := func(id any, offset, limit int) *http.Request {
+		return httptest.NewRequest(http.MethodGet,
+			fmt.Sprintf("/person/%s/history?offset=%d&limit=%d", id, offset, limit), nil)
+	}
+
+	testCases := []testCase{
+		{
+			name: "not found",
+			init: func(t *testing.T, _ repo.PersonRepo) (*http.Request, func(response *http.Response)) { //nolint:thelper
+				return makeHistoryRequest(uuid.New(), 0, 10), func(response *http.Response) {
+					checkProblem(t, response, api.ProblemNotFound)
+				}
+			},
+			status: http.StatusNotFound,
+		},
+		{
+			name: "deleted person",
+			init: func(t *testing.T, people repo.PersonRepo) (*http.Request, func(response *http.Response)) { //nolint:thelper
+				person := utils.MakePerson()
+				personID, err := people.Create(context.Background(), person)
+				if err != nil {
+					t.Fatalf("error initializing repo: %v", err)
+				}
+				person.ID = personID
+
+				replacement := utils.MakePerson()
+				replacement.ID = personID
+				if err = people.FullUpdate(context.Background(), personID, replacement); err != nil {
+					t.Fatalf("error initializing repo: %v", err)
+				}
+				if err = people.Delete(context.Background(), personID); err != nil {
+					t.Fatalf("error initializing repo: %v", err)
+				}
+
+				return makeHistoryRequest(personID, 1, 1), func(response *http.Response) {
+					page := unmarshalJSONBody[api.PersonHistory200JSONResponse](t, response)
+					if pagination := page.Pagination; pagination.CurrentLimit != 1 || pagination.CurrentOffset != 1 ||
+						pagination.TotalItems == nil || *pagination.TotalItems != 3 {
+						t.Errorf("pagination mismatch: %+v", pagination)
+					}
+					if len(page.Changes) != 1 {
+						t.Fatalf("unexpected number of changes: %v", page.Changes)
+					}
+
+					change := page.Changes[0]
+					if change.Operation != api.PersonChangeOperationUpdate ||
+						!reflect.DeepEqual(change.Old, personWithID(person)) ||
+						!reflect.DeepEqual(change.New, personWithID(replacement)) {
+						t.Errorf("unexpected change: %+v", change)
+					}
+				}
+			},
+			status: http.StatusOK,
+		},
+	}
+
+	subtests(t, testCases)
+}
+
 func TestDelete(t *testing.T) {
 	t.Parallel()
 
@@ -247,7 +440,9 @@ func TestDelete(t *testing.T) {
 		{
 			name: "not found",
 			init: func(t *testing.T, people repo.PersonRepo) (*http.Request, func(response *http.Response)) { //nolint:thelper
-				return makeDeleteRequest(uuid.New()), nil
+				return makeDeleteRequest(uuid.New()), func(response *http.Response) {
+					checkProblem(t, response, api.ProblemNotFound)
+				}
 			},
 			status: http.StatusNotFound,
 		},
@@ -287,6 +482,67 @@ func TestDelete(t *testing.T) {
 	subtests(t, testCases)
 }
 
+func TestRestore(t *testing.T) {
+	t.Parallel()
+
+	makeRestoreRequest := func(id any) *http.Request {
+		return httptest.NewRequest(http.MethodPost, fmt.Sprintf("/person/%s/restore", id), nil)
+	}
+
+	testCases := []testCase{
+		{
+			name: "not found",
+			init: func(t *testing.T, people repo.PersonRepo) (*http.Request, func(response *http.Response)) { //nolint:thelper
+				return makeRestoreRequest(uuid.New()), func(response *http.Response) {
+					checkProblem(t, response, api.ProblemNotFound)
+				}
+			},
+			status: http.StatusNotFound,
+		},
+		{
+			name: "not deleted",
+			init: func(t *testing.T, people repo.PersonRepo) (*http.Request, func(response *http.Response)) { //nolint:thelper
+				personID, err := people.Create(context.Background(), utils.MakePerson())
+				if err != nil {
+					t.Fatalf("error initializing repo: %v", err)
+				}
+
+				return makeRestoreRequest(personID), func(response *http.Response) {
+					checkProblem(t, response, api.ProblemConflict)
+				}
+			},
+			status: http.StatusConflict,
+		},
+		{
+			name: "valid",
+			init: func(t *testing.T, people repo.PersonRepo) (*http.Request, func(response *http.Response)) { //nolint:thelper
+				person := utils.MakePerson()
+				personID, err := people.Create(context.Background(), person)
+				if err != nil {
+					t.Fatalf("error initializing repo: %v", err)
+				}
+				if err = people.Delete(context.Background(), personID); err != nil {
+					t.Fatalf("error initializing repo: %v", err)
+				}
+
+				return makeRestoreRequest(personID), func(response *http.Response) {
+					checkNoBody(t, response)
+					person.ID = personID
+					restored, err := people.GetByID(context.Background(), personID)
+					if err != nil {
+						t.Errorf("restored person is not in the repo: %v", err)
+					} else if restored != person {
+						t.Errorf("restored person mismatch: expected %v, got %v", person, restored)
+					}
+				}
+			},
+			status: http.StatusOK,
+		},
+	}
+
+	subtests(t, testCases)
+}
+
 //nolint:funlen
 func TestPut(t *testing.T) {
 	t.Parallel()
@@ -334,7 +590,7 @@ func TestPut(t *testing.T) {
 				body := makeBody()
 
 				return makePutRequest(uuid.New(), &body), func(response *http.Response) {
-					checkNoBody(t, response)
+					checkProblem(t, response, api.ProblemNotFound)
 				}
 			},
 			status: http.StatusNotFound,
@@ -457,7 +713,102 @@ func TestPost(t *testing.T) {
 				request.Header.Set("Content-Type", "application/json")
 
 				return request, func(response *http.Response) {
-					checkStringBody(t, response, regexp.MustCompile(`property "patronymic" is missing`))
+					problem := checkProblem(t, response, api.ProblemValidation)
+					checkInvalidParam(t, problem, "body", "/patronymic")
+				}
+			},
+			status: http.StatusBadRequest,
+		},
+	}
+
+	subtests(t, testCases)
+}
+
+// failingPeople fails to create the person with the name
+type failingPeople struct {
+	*memory.People
+	name string
+}
+
+func (p failingPeople) Create(ctx context.Context, obj domain.Person) (uuid.UUID, error) {
+	if obj.Name == p.name {
+		return uuid.UUID{}, repo.ErrUnexpected
+	}
+
+	return p.People.Create(ctx, obj) //nolint:wrapcheck
+}
+
+func TestBatchPost(t *testing.T) {
+	t.Parallel()
+
+	makeBatchRequest := func(people ...domain.Person) *http.Request {
+		body := api.PersonBatchPostJSONRequestBody{People: make([]api.PersonPostData, len(people))}
+		for i, person := range people {
+			body.People[i] = api.PersonPostData{
+				Name:       person.Name,
+				Patronymic: person.Patronymic,
+				Surname:    person.Surname,
+			}
+		}
+
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("could not marshal post body: %v", err)
+		}
+
+		request := httptest.NewRequest(http.MethodPost, "/person/batch", bytes.NewReader(data))
+		request.Header.Set("Content-Type", "application/json")
+
+		return request
+	}
+
+	testCases := []testCase{
+		{
+			name: "valid",
+			init: func(t *testing.T, people repo.PersonRepo) (*http.Request, func(response *http.Response)) { //nolint:thelper
+				batch := []domain.Person{utils.MakePerson(), utils.MakePerson(), utils.MakePerson()}
+
+				return makeBatchRequest(batch...), func(response *http.Response) {
+					created := unmarshalJSONBody[api.PersonBatchPost201JSONResponse](t, response)
+					if len(created.Uuids) != len(batch) {
+						t.Fatalf("unexpected number of IDs: expected %d, got %d", len(batch), len(created.Uuids))
+					}
+
+					for i, personID := range created.Uuids {
+						person, err := people.GetByID(context.Background(), personID)
+						if err != nil {
+							t.Fatalf("Person was not saved after response")
+						}
+
+						if person.Surname != batch[i].Surname {
+							t.Errorf("IDs are not in the order of the request: expected %s, got %s",
+								batch[i].Surname, person.Surname)
+						}
+					}
+				}
+			},
+			status: http.StatusCreated,
+		},
+		{
+			name: "empty",
+			init: func(t *testing.T, _ repo.PersonRepo) (*http.Request, func(response *http.Response)) { //nolint:thelper
+				return makeBatchRequest(), func(response *http.Response) {
+					problem := checkProblem(t, response, api.ProblemValidation)
+					checkInvalidParam(t, problem, "body", "/people")
+				}
+			},
+			status: http.StatusBadRequest,
+		},
+		{
+			name: "too many",
+			init: func(t *testing.T, _ repo.PersonRepo) (*http.Request, func(response *http.Response)) { //nolint:thelper
+				batch := make([]domain.Person, 11)
+				for i := range batch {
+					batch[i] = utils.MakePerson()
+				}
+
+				return makeBatchRequest(batch...), func(response *http.Response) {
+					checkProblem(t, response, api.ProblemValidation)
 				}
 			},
 			status: http.StatusBadRequest,
@@ -465,13 +816,34 @@ func TestPost(t *testing.T) {
 	}
 
 	subtests(t, testCases)
+
+	t.Run("all or nothing", func(t *testing.T) {
+		t.Parallel()
+
+		batch := []domain.Person{utils.MakePerson(), utils.MakePerson(), utils.MakePerson()}
+		people := failingPeople{People: memory.NewPeople(), name: batch[2].Name}
+
+		recorder := httptest.NewRecorder()
+		newHandler(t, people).ServeHTTP(recorder, makeBatchRequest(batch...))
+
+		response := recorder.Result()
+		defer response.Body.Close()
+
+		if response.StatusCode != http.StatusInternalServerError {
+			t.Errorf("unexpected status code: %d", response.StatusCode)
+		}
+
+		if active, _, err := people.Count(context.Background()); err != nil || active != 0 {
+			t.Errorf("%d people were created by a failed batch", active)
+		}
+	})
 }
 
 //nolint:funlen
 func TestPatch(t *testing.T) {
 	t.Parallel()
 
-	makePatchRequest := func(personID any, body any) *http.Request {
+	makeRequest := func(personID any, body any, contentType string) *http.Request {
 		var reader io.Reader
 
 		if body != nil {
@@ -488,21 +860,56 @@ func TestPatch(t *testing.T) {
 			fmt.Sprintf("/person/%s", personID),
 			reader,
 		)
-		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("Content-Type", contentType)
 
 		return request
 	}
 
+	makePatchRequest := func(personID any, body map[string]any) *http.Request {
+		return makeRequest(personID, body, "application/merge-patch+json")
+	}
+
+	makeJSONPatchRequest := func(personID any, body []map[string]any) *http.Request {
+		return makeRequest(personID, body, "application/json-patch+json")
+	}
+
+	createPerson := func(t *testing.T, people repo.PersonRepo) domain.Person {
+		t.Helper()
+
+		person := utils.MakePerson()
+
+		personID, err := people.Create(context.Background(), person)
+		if err != nil {
+			t.Fatalf("error initializing repo: %v", err)
+		}
+
+		person.ID = personID
+
+		return person
+	}
+
+	checkPerson := func(t *testing.T, people repo.PersonRepo, expected domain.Person) {
+		t.Helper()
+
+		personAfter, err := people.GetByID(context.Background(), expected.ID)
+		if err != nil {
+			t.Fatalf("Person was deleted after response")
+		}
+
+		if !reflect.DeepEqual(expected, personAfter) {
+			t.Errorf("unexpected Person after patch: expected %v, got %v", expected, personAfter)
+		}
+	}
+
 	testCases := []testCase{
 		{
 			name: "not found",
 			init: func(t *testing.T, _ repo.PersonRepo) (*http.Request, func(response *http.Response)) { //nolint:thelper
 				newAge := 60
 
-				return makePatchRequest(uuid.New(), api.PersonPatchJSONRequestBody{ //nolint:exhaustruct
-						Age: &newAge,
-					}), func(response *http.Response) {
-						checkNoBody(t, response)
+				return makePatchRequest(uuid.New(), map[string]any{"age": newAge}),
+					func(response *http.Response) {
+						checkProblem(t, response, api.ProblemNotFound)
 					}
 			},
 			status: http.StatusNotFound,
@@ -512,9 +919,8 @@ func TestPatch(t *testing.T) {
 			init: func(t *testing.T, _ repo.PersonRepo) (*http.Request, func(response *http.Response)) { //nolint:thelper
 				newAge := 60
 
-				return makePatchRequest("quux", api.PersonPatchJSONRequestBody{ //nolint:exhaustruct
-						Age: &newAge,
-					}), func(response *http.Response) {
+				return makePatchRequest("quux", map[string]any{"age": newAge}),
+					func(response *http.Response) {
 						checkStringBody(t, response, regexp.MustCompile(`Invalid format for parameter personID`))
 					}
 			},
@@ -529,9 +935,7 @@ func TestPatch(t *testing.T) {
 					t.Fatalf("error initializing repo: %v", err)
 				}
 				newAge := person.Age / 2
-				request := makePatchRequest(personID, api.PersonPatchJSONRequestBody{ //nolint:exhaustruct
-					Age: &newAge,
-				})
+				request := makePatchRequest(personID, map[string]any{"age": newAge})
 
 				return request, func(response *http.Response) {
 					checkNoBody(t, response)
@@ -562,15 +966,197 @@ func TestPatch(t *testing.T) {
 			},
 			status: http.StatusOK,
 		},
-	}
+		{
+			name: "merge patch null resets fields",
+			init: func(t *testing.T, people repo.PersonRepo) (*http.Request, func(response *http.Response)) { //nolint:thelper
+				person := createPerson(t, people)
+				request := makePatchRequest(person.ID, map[string]any{
+					"patronymic":  nil,
+					"age":         nil,
+					"nationality": nil,
+				})
 
-	subtests(t, testCases)
-}
+				// values from MockCompleter
+				person.Patronymic = ""
+				person.Age = 50
+				person.Nationality = "RU"
 
-func makeListRequest(personFilter domain.PersonFilter, paginationFilter *domain.PaginationFilter) *http.Request {
-	values := url.Values{}
-	// personFilter values
-	if personFilter.Sex != nil {
+				return request, func(response *http.Response) {
+					checkNoBody(t, response)
+					checkPerson(t, people, person)
+				}
+			},
+			status: http.StatusOK,
+		},
+		{
+			name: "merge patch null name",
+			init: func(t *testing.T, people repo.PersonRepo) (*http.Request, func(response *http.Response)) { //nolint:thelper
+				person := createPerson(t, people)
+
+				return makePatchRequest(person.ID, map[string]any{"name": nil}),
+					func(response *http.Response) {
+						checkPerson(t, people, person)
+					}
+			},
+			status: http.StatusBadRequest,
+		},
+		{
+			name: "merge patch without fields",
+			init: func(t *testing.T, people repo.PersonRepo) (*http.Request, func(response *http.Response)) { //nolint:thelper
+				person := createPerson(t, people)
+
+				return makePatchRequest(person.ID, map[string]any{}), nil
+			},
+			status: http.StatusBadRequest,
+		},
+		{
+			name: "application/json is merge patch",
+			init: func(t *testing.T, people repo.PersonRepo) (*http.Request, func(response *http.Response)) { //nolint:thelper
+				person := createPerson(t, people)
+				request := makeRequest(person.ID, map[string]any{"age": 46, "patronymic": nil},
+					"application/json; charset=utf-8")
+
+				person.Age = 46
+				person.Patronymic = ""
+
+				return request, func(response *http.Response) {
+					checkNoBody(t, response)
+					checkPerson(t, people, person)
+				}
+			},
+			status: http.StatusOK,
+		},
+		{
+			name: "json patch",
+			init: func(t *testing.T, people repo.PersonRepo) (*http.Request, func(response *http.Response)) { //nolint:thelper
+				person := createPerson(t, people)
+				request := makeJSONPatchRequest(person.ID, []map[string]any{
+					{"op": "test", "path": "/name", "value": person.Name},
+					{"op": "replace", "path": "/age", "value": 46},
+					{"op": "replace", "path": "/sex", "value": "male"},
+					{"op": "remove", "path": "/patronymic"},
+				})
+
+				person.Age = 46
+				person.Sex = domain.Male
+				person.Patronymic = ""
+
+				return request, func(response *http.Response) {
+					checkNoBody(t, response)
+					checkPerson(t, people, person)
+				}
+			},
+			status: http.StatusOK,
+		},
+		{
+			name: "json patch failed test",
+			init: func(t *testing.T, people repo.PersonRepo) (*http.Request, func(response *http.Response)) { //nolint:thelper
+				person := createPerson(t, people)
+				request := makeJSONPatchRequest(person.ID, []map[string]any{
+					{"op": "replace", "path": "/age", "value": person.Age + 1},
+					{"op": "test", "path": "/surname", "value": person.Surname + "x"},
+				})
+
+				return request, func(response *http.Response) {
+					checkProblem(t, response, api.ProblemPatchTestFailed)
+					checkPerson(t, people, person)
+				}
+			},
+			status: http.StatusConflict,
+		},
+		{
+			name: "json patch invalid value",
+			init: func(t *testing.T, people repo.PersonRepo) (*http.Request, func(response *http.Response)) { //nolint:thelper
+				person := createPerson(t, people)
+				request := makeJSONPatchRequest(person.ID, []map[string]any{
+					{"op": "replace", "path": "/age", "value": "old"},
+				})
+
+				return request, func(response *http.Response) {
+					checkPerson(t, people, person)
+				}
+			},
+			status: http.StatusBadRequest,
+		},
+		{
+			name: "json patch remove surname",
+			init: func(t *testing.T, people repo.PersonRepo) (*http.Request, func(response *http.Response)) { //nolint:thelper
+				person := createPerson(t, people)
+				request := makeJSONPatchRequest(person.ID, []map[string]any{
+					{"op": "remove", "path": "/surname"},
+				})
+
+				return request, func(response *http.Response) {
+					checkPerson(t, people, person)
+				}
+			},
+			status: http.StatusBadRequest,
+		},
+	}
+
+	subtests(t, testCases)
+}
+
+func TestProblemTypeOf(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		err      error
+		expected api.ProblemType
+	}{
+		{fmt.Errorf("%w: %w", repo.ErrNotFound, errors.New("no rows")), api.ProblemNotFound},
+		{repo.ErrArgument, api.ProblemInvalidArgument},
+		{fmt.Errorf("%w: %w", repo.ErrConflict, domain.ErrPatchTestFailed), api.ProblemPatchTestFailed},
+		{fmt.Errorf("%w: %w", repo.ErrArgument, domain.ErrPatchInvalid), api.ProblemInvalidPatch},
+		{repo.ErrUnexpected, api.ProblemInternal},
+		{fmt.Errorf("%w {age: %w} {sex: %w}",
+			completer.ErrCompleterError, filler.ErrLimitReached, filler.ErrInvalidName), api.ProblemInvalidName},
+		{fmt.Errorf("%w {age: %w}", completer.ErrCompleterError, filler.ErrTimeout), api.ProblemEnrichmentTimeout},
+		{fmt.Errorf("%w {age: %w}", completer.ErrCompleterError, filler.ErrInvalidStatus), api.ProblemEnrichmentFailed},
+		{fmt.Errorf("%w {age: %w}", completer.ErrCompleterError, filler.ErrInvalidAPIToken), api.ProblemInternal},
+		{errors.New("unknown"), api.ProblemInternal},
+	}
+
+	for _, testCase := range testCases {
+		if problemType := api.ProblemTypeOf(testCase.err); problemType != testCase.expected {
+			t.Errorf("problem type mismatch for %q: expected %v, got %v",
+				testCase.err, testCase.expected, problemType)
+		}
+	}
+}
+
+func TestProblemOf(t *testing.T) {
+	t.Parallel()
+
+	// a repo error wrapping a Postgres error
+	err := fmt.Errorf("%w: %w", repo.ErrArgument,
+		errors.New(`ERROR: new row for relation "people" violates check constraint "people_age_check" (SQLSTATE 23514)`))
+
+	problem := api.ProblemOf(err)
+	if problem.Type != api.ProblemInvalidArgument.URI() {
+		t.Fatalf("unexpected problem type: %s", problem.Type)
+	}
+
+	if problem.Detail == nil {
+		t.Fatal("client errors must have a detail")
+	}
+
+	for _, internal := range []string{"SQLSTATE", "people_age_check", "repo error"} {
+		if strings.Contains(*problem.Detail, internal) {
+			t.Errorf("detail %q exposes %q", *problem.Detail, internal)
+		}
+	}
+
+	err = fmt.Errorf("%w: %w", repo.ErrUnexpected, errors.New(`ERROR: function people.foo() does not exist (SQLSTATE 42883)`))
+	if problem := api.ProblemOf(err); problem.Detail != nil {
+		t.Errorf("server errors must have no detail, got %q", *problem.Detail)
+	}
+}
+
+func makeListRequest(personFilter domain.PersonFilter, paginationFilter *domain.PaginationFilter) *http.Request {
+	values := url.Values{}
+	// personFilter values
+	if personFilter.Sex != nil {
 		values.Add("sex", string(*personFilter.Sex))
 	}
 
@@ -597,6 +1183,14 @@ func makeListRequest(personFilter domain.PersonFilter, paginationFilter *domain.
 	if personFilter.Surname != nil {
 		values.Add("surname", *personFilter.Surname)
 	}
+
+	if personFilter.IncludeDeleted {
+		values.Add("include_deleted", "true")
+	}
+
+	if personFilter.Match != "" {
+		values.Add("match", string(personFilter.Match))
+	}
 	// paginationFilter values
 	if paginationFilter != nil {
 		values.Add("offset", strconv.Itoa(paginationFilter.Offset))
@@ -669,7 +1263,7 @@ func TestList(t *testing.T) {
 						Pagination: api.PaginationOffsetLimit{
 							CurrentLimit:  expected.CurrentLimit,
 							CurrentOffset: expected.CurrentOffset,
-							TotalItems:    expected.TotalItems,
+							TotalItems:    &expected.TotalItems,
 						},
 						People: records,
 					}
@@ -678,7 +1272,1209 @@ func TestList(t *testing.T) {
 			},
 			status: http.StatusOK,
 		},
+		{
+			name: "deleted people",
+			init: func(t *testing.T, people repo.PersonRepo) (*http.Request, func(response *http.Response)) { //nolint:thelper
+				fillDB(people)
+				page, err := people.List(context.Background(),
+					domain.PersonFilter{}, //nolint:exhaustruct
+					domain.PaginationFilter{Limit: 1, Offset: 0})
+				if err != nil {
+					t.Fatalf("error initializing repo: %v", err)
+				}
+				deletedID := page.Items[0].ID
+				if err = people.Delete(context.Background(), deletedID); err != nil {
+					t.Fatalf("error initializing repo: %v", err)
+				}
+
+				pagination := domain.PaginationFilter{Limit: 1000, Offset: 0}
+
+				return makeListRequest(domain.PersonFilter{}, &pagination), //nolint:exhaustruct
+					func(response *http.Response) {
+						result := unmarshalJSONBody[api.PersonList200JSONResponse](t, response)
+						for _, person := range result.People {
+							if person.Id == deletedID {
+								t.Errorf("deleted person is listed")
+							}
+						}
+					}
+			},
+			status: http.StatusOK,
+		},
+		{
+			name: "include deleted people",
+			init: func(t *testing.T, people repo.PersonRepo) (*http.Request, func(response *http.Response)) { //nolint:thelper
+				person := utils.MakePerson()
+				personID, err := people.Create(context.Background(), person)
+				if err != nil {
+					t.Fatalf("error initializing repo: %v", err)
+				}
+				if err = people.Delete(context.Background(), personID); err != nil {
+					t.Fatalf("error initializing repo: %v", err)
+				}
+
+				filter := domain.PersonFilter{IncludeDeleted: true} //nolint:exhaustruct
+				pagination := domain.PaginationFilter{Limit: 10, Offset: 0}
+
+				return makeListRequest(filter, &pagination), func(response *http.Response) {
+					result := unmarshalJSONBody[api.PersonList200JSONResponse](t, response)
+					if len(result.People) != 1 || result.People[0].Id != personID {
+						t.Errorf("deleted person is not listed: %v", result.People)
+					}
+				}
+			},
+			status: http.StatusOK,
+		},
+		{
+			name: "phonetic match",
+			init: func(t *testing.T, people repo.PersonRepo) (*http.Request, func(response *http.Response)) { //nolint:thelper
+				person := utils.MakePerson()
+				person.Surname = "Шмидт"
+				personID, err := people.Create(context.Background(), person)
+				if err != nil {
+					t.Fatalf("error initializing repo: %v", err)
+				}
+
+				surname := "Schmidt"
+				filter := domain.PersonFilter{Surname: &surname, Match: domain.MatchPhonetic} //nolint:exhaustruct
+
+				pagination := domain.PaginationFilter{Limit: 10, Offset: 0}
+
+				return makeListRequest(filter, &pagination), func(response *http.Response) {
+					result := unmarshalJSONBody[api.PersonList200JSONResponse](t, response)
+					if len(result.People) != 1 || result.People[0].Id != personID {
+						t.Errorf("the person is not matched: %v", result.People)
+					}
+				}
+			},
+			status: http.StatusOK,
+		},
+		{
+			name: "invalid match",
+			init: func(t *testing.T, _ repo.PersonRepo) (*http.Request, func(response *http.Response)) { //nolint:thelper
+				request := httptest.NewRequest(http.MethodGet, "/person?match=soundex", nil)
+
+				return request, func(response *http.Response) {
+					problem := checkProblem(t, response, api.ProblemValidation)
+					checkInvalidParam(t, problem, "query", "match")
+				}
+			},
+			status: http.StatusBadRequest,
+		},
+		{
+			name: "invalid query parameter",
+			init: func(t *testing.T, _ repo.PersonRepo) (*http.Request, func(response *http.Response)) { //nolint:thelper
+				request := httptest.NewRequest(http.MethodGet, "/person?limit=-1&age_max=200", nil)
+
+				return request, func(response *http.Response) {
+					problem := checkProblem(t, response, api.ProblemValidation)
+					checkInvalidParam(t, problem, "query", "limit")
+					checkInvalidParam(t, problem, "query", "age_max")
+				}
+			},
+			status: http.StatusBadRequest,
+		},
 	}
 
 	subtests(t, testCases)
 }
+
+// tokenAuthenticator authenticates "Bearer <subject>.<token>" requests:
+// tokens of a subject may change (e.g. be refreshed)
+type tokenAuthenticator struct{}
+
+func (tokenAuthenticator) Authenticate(ctx context.Context, r *http.Request) (auth.Principal, error) {
+	token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !found {
+		return auth.Principal{}, auth.ErrNoCredentials
+	}
+
+	subject, _, _ := strings.Cut(token, ".")
+
+	return auth.Principal{Kind: auth.KindJWT, Subject: subject, Scopes: nil}, nil
+}
+
+func (tokenAuthenticator) Challenge() string {
+	return auth.BearerChallenge
+}
+
+// countingKeys counts reservations of idempotency keys
+type countingKeys struct {
+	*memory.IdempotencyKeys
+	reserved atomic.Int32
+}
+
+func (k *countingKeys) Reserve(
+	ctx context.Context, key string, fingerprint []byte, ttl time.Duration,
+) (*domain.StoredResponse, error) {
+	k.reserved.Add(1)
+
+	return k.IdempotencyKeys.Reserve(ctx, key, fingerprint, ttl) //nolint:wrapcheck
+}
+
+//nolint:funlen,cyclop,maintidx
+func TestIdempotency(t *testing.T) {
+	t.Parallel()
+
+	var (
+		// the next request fails with 500
+		fail bool
+		// the next request waits until the channel is closed
+		block   chan struct{}
+		started = make(chan struct{})
+		people  = memory.NewPeople()
+		keys    = &countingKeys{IdempotencyKeys: memory.NewIdempotencyKeys(), reserved: atomic.Int32{}}
+		logger  = slog.New(slog.NewTextHandler(io.Discard, nil))
+		control = func(f api.StrictHandlerFunc, _ string) api.StrictHandlerFunc {
+			return func(ctx context.Context, w http.ResponseWriter, r *http.Request, request any) (any, error) {
+				switch {
+				case fail:
+					fail = false
+					w.WriteHeader(http.StatusInternalServerError)
+
+					return nil, nil
+				case block != nil:
+					wait := block
+					block = nil
+					started <- struct{}{}
+					<-wait
+				}
+
+				return f(ctx, w, r, request)
+			}
+		}
+	)
+
+	idempotency := api.NewIdempotency(keys, api.IdempotencyOptions{
+		TTL:  time.Hour,
+		Wait: 0,
+	}, logger)
+
+	handler := newStrictHandler(t, people, []api.StrictMiddlewareFunc{
+		control,
+		idempotency.StrictMiddleware,
+		api.AuthMiddleware(tokenAuthenticator{}, logger),
+	}, idempotency.Middleware)
+
+	post := func(token, key string, person domain.Person) *http.Response {
+		data, err := json.Marshal(api.PersonPostJSONRequestBody{
+			Name:       person.Name,
+			Patronymic: person.Patronymic,
+			Surname:    person.Surname,
+		})
+		if err != nil {
+			t.Errorf("could not marshal post body: %v", err)
+		}
+
+		request := httptest.NewRequest(http.MethodPost, "/person", bytes.NewReader(data))
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set(api.IdempotencyKeyHeader, key)
+
+		if token != "" {
+			request.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+
+		return recorder.Result()
+	}
+
+	person := utils.MakePerson()
+
+	first := post("alice.1", "key", person)
+	defer first.Body.Close()
+
+	if first.StatusCode != http.StatusCreated {
+		t.Fatalf("unexpected status code: %d", first.StatusCode)
+	}
+
+	created := unmarshalJSONBody[api.PersonPost201JSONResponse](t, first)
+
+	t.Run("replay", func(t *testing.T) {
+		response := post("alice.1", "key", person)
+		defer response.Body.Close()
+
+		if response.StatusCode != http.StatusCreated ||
+			response.Header.Get(api.IdempotentReplayedHeader) != "true" {
+			t.Errorf("response was not replayed: %d %v", response.StatusCode, response.Header)
+		}
+
+		if replayed := unmarshalJSONBody[api.PersonPost201JSONResponse](t, response); replayed != created {
+			t.Errorf("replayed body mismatch: expected %v, got %v", created, replayed)
+		}
+
+		if active, _, err := people.Count(context.Background()); err != nil || active != 1 {
+			t.Errorf("the person was created %d times", active)
+		}
+	})
+
+	t.Run("replay with refreshed credentials", func(t *testing.T) {
+		response := post("alice.2", "key", person)
+		defer response.Body.Close()
+
+		if response.StatusCode != http.StatusCreated ||
+			response.Header.Get(api.IdempotentReplayedHeader) != "true" {
+			t.Errorf("response was not replayed: %d %v", response.StatusCode, response.Header)
+		}
+	})
+
+	t.Run("key reused", func(t *testing.T) {
+		response := post("alice.1", "key", utils.MakePerson())
+		defer response.Body.Close()
+
+		if response.StatusCode != http.StatusUnprocessableEntity {
+			t.Errorf("unexpected status code: %d", response.StatusCode)
+		}
+
+		checkProblem(t, response, api.ProblemIdempotencyKeyReused)
+	})
+
+	t.Run("keys of another client", func(t *testing.T) {
+		response := post("bob.1", "key", utils.MakePerson())
+		defer response.Body.Close()
+
+		if response.StatusCode != http.StatusCreated || response.Header.Get(api.IdempotentReplayedHeader) != "" {
+			t.Errorf("the key of another client was used: %d %v", response.StatusCode, response.Header)
+		}
+	})
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		reserved := keys.reserved.Load()
+
+		response := post("", "unauthenticated", utils.MakePerson())
+		response.Body.Close()
+
+		if response.StatusCode != http.StatusUnauthorized {
+			t.Errorf("unexpected status code: %d", response.StatusCode)
+		}
+
+		if keys.reserved.Load() != reserved {
+			t.Error("an unauthenticated request reserved a key")
+		}
+	})
+
+	t.Run("in progress", func(t *testing.T) {
+		another := utils.MakePerson()
+		block = make(chan struct{})
+		wait := block
+		done := make(chan *http.Response)
+
+		go func() {
+			done <- post("alice.1", "in progress", another)
+		}()
+
+		<-started
+
+		response := post("alice.1", "in progress", another)
+		defer response.Body.Close()
+
+		if response.StatusCode != http.StatusConflict {
+			t.Errorf("unexpected status code: %d", response.StatusCode)
+		}
+
+		checkProblem(t, response, api.ProblemRequestInProgress)
+
+		close(wait)
+
+		if blocked := <-done; blocked.StatusCode != http.StatusCreated {
+			t.Errorf("unexpected status code of the first request: %d", blocked.StatusCode)
+		}
+	})
+
+	t.Run("server errors are not stored", func(t *testing.T) {
+		another := utils.MakePerson()
+		fail = true
+
+		response := post("alice.1", "failed", another)
+		response.Body.Close()
+
+		if response.StatusCode != http.StatusInternalServerError {
+			t.Errorf("unexpected status code: %d", response.StatusCode)
+		}
+
+		retry := post("alice.1", "failed", another)
+		retry.Body.Close()
+
+		if retry.StatusCode != http.StatusCreated || retry.Header.Get(api.IdempotentReplayedHeader) != "" {
+			t.Errorf("failed request was not retried: %d %v", retry.StatusCode, retry.Header)
+		}
+	})
+
+	t.Run("batch", func(t *testing.T) {
+		data, err := json.Marshal(api.PersonBatchPostJSONRequestBody{People: []api.PersonPostData{
+			{Name: "Quux", Patronymic: "Buzz", Surname: "Foo"},
+			{Name: "Quux", Patronymic: "Buzz", Surname: "Bar"},
+		}})
+		if err != nil {
+			t.Fatalf("could not marshal post body: %v", err)
+		}
+
+		postBatch := func() *http.Response {
+			request := httptest.NewRequest(http.MethodPost, "/person/batch", bytes.NewReader(data))
+			request.Header.Set("Content-Type", "application/json")
+			request.Header.Set(api.IdempotencyKeyHeader, "batch")
+			request.Header.Set("Authorization", "Bearer alice.1")
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+
+			return recorder.Result()
+		}
+
+		before, _, _ := people.Count(context.Background())
+
+		first := postBatch()
+		defer first.Body.Close()
+
+		retry := postBatch()
+		defer retry.Body.Close()
+
+		if first.StatusCode != http.StatusCreated || retry.Header.Get(api.IdempotentReplayedHeader) != "true" {
+			t.Errorf("batch was not replayed: %d %v", first.StatusCode, retry.Header)
+		}
+
+		if after, _, err := people.Count(context.Background()); err != nil || after != before+2 {
+			t.Errorf("unexpected number of created people: %d", after-before)
+		}
+	})
+}
+
+//nolint:funlen
+func TestAuth(t *testing.T) {
+	t.Parallel()
+
+	var (
+		people  = memory.NewPeople()
+		clients = memory.NewAPIClients()
+		logger  = slog.New(slog.NewTextHandler(io.Discard, nil))
+	)
+
+	key, err := auth.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hash, err := auth.HashSecret(key.Secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//nolint:exhaustruct
+	if _, err = clients.CreateClient(context.Background(), domain.APIClient{
+		Name: "client", KeyID: key.KeyID, SecretHash: hash,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	person := utils.MakePerson()
+	person.ID, _ = people.Create(context.Background(), person)
+
+	handler := newStrictHandler(t, people, []api.StrictMiddlewareFunc{
+		api.AuthMiddleware(auth.NewClientAuthenticator(clients, 0), logger),
+	})
+
+	testCases := []struct {
+		setCredentials func(r *http.Request)
+		name           string
+		status         int
+	}{
+		{func(r *http.Request) {}, "no credentials", http.StatusUnauthorized},
+		{func(r *http.Request) { r.Header.Set(auth.APIKeyHeader, key.String()) }, "api key", http.StatusOK},
+		{
+			func(r *http.Request) { r.Header.Set(auth.APIKeyHeader, key.KeyID+".wrong") },
+			"wrong api key secret", http.StatusUnauthorized,
+		},
+		{
+			func(r *http.Request) { r.Header.Set(auth.APIKeyHeader, "unknown."+key.Secret) },
+			"unknown api key", http.StatusUnauthorized,
+		},
+		{func(r *http.Request) { r.SetBasicAuth("client", key.Secret) }, "basic", http.StatusOK},
+		{func(r *http.Request) { r.SetBasicAuth("client", "wrong") }, "wrong basic secret", http.StatusUnauthorized},
+		{func(r *http.Request) { r.SetBasicAuth("unknown", key.Secret) }, "unknown client", http.StatusUnauthorized},
+	}
+
+	for _, tCase := range testCases {
+		test := tCase
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			request := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/person/%s", person.ID), nil)
+			test.setCredentials(request)
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+			response := recorder.Result()
+
+			defer response.Body.Close()
+
+			if response.StatusCode != test.status {
+				t.Fatalf("unexpected status code: expected %d, got %d", test.status, response.StatusCode)
+			}
+
+			if test.status == http.StatusUnauthorized {
+				checkProblem(t, response, api.ProblemUnauthorized)
+
+				if response.Header.Get("WWW-Authenticate") != auth.BasicChallenge {
+					t.Errorf("unexpected challenge: %q", response.Header.Get("WWW-Authenticate"))
+				}
+			}
+		})
+	}
+
+	t.Run("actor", func(t *testing.T) {
+		t.Parallel()
+
+		another := utils.MakePerson()
+		another.ID, _ = people.Create(context.Background(), another)
+
+		request := httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/person/%s", another.ID), nil)
+		request.Header.Set(auth.APIKeyHeader, key.String())
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+
+		changes, err := people.History(context.Background(), another.ID, domain.PaginationFilter{Offset: 0, Limit: 1})
+		if recorder.Code != http.StatusOK || err != nil || changes.Items[0].Actor != "client:client" {
+			t.Errorf("the client was not recorded as the actor: %d %v %v", recorder.Code, changes, err)
+		}
+	})
+
+	t.Run("revoked", func(t *testing.T) {
+		t.Parallel()
+
+		another, err := auth.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		hash, err := auth.HashSecret(another.Secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		//nolint:exhaustruct
+		if _, err = clients.CreateClient(context.Background(), domain.APIClient{
+			Name: "revoked", KeyID: another.KeyID, SecretHash: hash,
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		if err = clients.RevokeClient(context.Background(), "revoked"); err != nil {
+			t.Fatal(err)
+		}
+
+		request := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/person/%s", person.ID), nil)
+		request.Header.Set(auth.APIKeyHeader, another.String())
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+
+		if recorder.Code != http.StatusUnauthorized {
+			t.Errorf("unexpected status code: %d", recorder.Code)
+		}
+	})
+}
+
+// scopesAuthenticator authenticates "Bearer <scope> <scope>..." requests
+type scopesAuthenticator struct{}
+
+func (scopesAuthenticator) Authenticate(ctx context.Context, r *http.Request) (auth.Principal, error) {
+	scopes, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !found {
+		return auth.Principal{}, auth.ErrNoCredentials
+	}
+
+	return auth.Principal{Kind: auth.KindJWT, Subject: "user", Scopes: strings.Fields(scopes)}, nil
+}
+
+func (scopesAuthenticator) Challenge() string {
+	return auth.BearerChallenge
+}
+
+// subjectAuthenticator takes the subject and the scopes from
+// "Authorization: Bearer <subject> <scopes>"
+type subjectAuthenticator struct{}
+
+func (subjectAuthenticator) Authenticate(ctx context.Context, r *http.Request) (auth.Principal, error) {
+	fields := strings.Fields(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if len(fields) == 0 {
+		return auth.Principal{}, auth.ErrNoCredentials
+	}
+
+	return auth.Principal{Kind: auth.KindJWT, Subject: fields[0], Scopes: fields[1:]}, nil
+}
+
+func (subjectAuthenticator) Challenge() string {
+	return auth.BearerChallenge
+}
+
+func TestPolicy(t *testing.T) {
+	t.Parallel()
+
+	spec, err := api.GetSwagger()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	policy, err := api.PolicyFromSpec(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = policy.Validate(spec); err != nil {
+		t.Errorf("x-required-scopes must be declared for every operation: %v", err)
+	}
+
+	path := t.TempDir() + "/policy.yaml"
+	if err = os.WriteFile(path, []byte("operations:\n  personDelete: [people:admin]\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	override, err := api.LoadPolicy(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if scopes := policy.Override(override)["PersonDelete"]; !reflect.DeepEqual(scopes, []string{"people:admin"}) {
+		t.Errorf("policy was not overridden: %v", scopes)
+	}
+
+	if err = policy.Override(override).Validate(spec); err != nil {
+		t.Errorf("overridden policy is invalid: %v", err)
+	}
+
+	if err = policy.Override(api.Policy{"unknown": nil}).Validate(spec); !errors.Is(err, api.ErrPolicy) {
+		t.Errorf("unknown operation was not reported: %v", err)
+	}
+
+	if err = policy.Override(api.Policy{"personList.sex": {"people:admin"}}).Validate(spec); err != nil {
+		t.Errorf("parameter rule is invalid: %v", err)
+	}
+
+	if err = policy.Override(api.Policy{"personList.unknown": nil}).Validate(spec); !errors.Is(err, api.ErrPolicy) {
+		t.Errorf("unknown parameter was not reported: %v", err)
+	}
+}
+
+func TestParameterAuthorization(t *testing.T) {
+	t.Parallel()
+
+	spec, err := api.GetSwagger()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	policy, err := api.PolicyFromSpec(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	policy = policy.Override(api.Policy{"personList.nationality": {"people:admin"}})
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	handler := newStrictHandler(t, memory.NewPeople(), []api.StrictMiddlewareFunc{
+		api.AuthorizationMiddleware(policy, logger),
+		api.AuthMiddleware(scopesAuthenticator{}, logger),
+	})
+
+	testCases := []struct {
+		name   string
+		query  string
+		scopes string
+		status int
+	}{
+		{"parameter not set", "", "people:read", http.StatusOK},
+		{"parameter set", "&nationality=RU", "people:read", http.StatusForbidden},
+		{"parameter set with scopes", "&nationality=RU", "people:read people:admin", http.StatusOK},
+	}
+
+	for _, tCase := range testCases {
+		test := tCase
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			request := httptest.NewRequest(http.MethodGet, "/person?offset=0&limit=20"+test.query, nil)
+			request.Header.Set("Authorization", "Bearer "+test.scopes)
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+			response := recorder.Result()
+
+			defer response.Body.Close()
+
+			if response.StatusCode != test.status {
+				t.Fatalf("unexpected status code: expected %d, got %d", test.status, response.StatusCode)
+			}
+
+			if test.status == http.StatusForbidden {
+				checkProblem(t, response, api.ProblemForbidden)
+			}
+		})
+	}
+}
+
+func TestAuthorization(t *testing.T) {
+	t.Parallel()
+
+	spec, err := api.GetSwagger()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	policy, err := api.PolicyFromSpec(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	people := memory.NewPeople()
+
+	person := utils.MakePerson()
+	person.ID, _ = people.Create(context.Background(), person)
+
+	handler := newStrictHandler(t, people, []api.StrictMiddlewareFunc{
+		api.AuthorizationMiddleware(policy, logger),
+		api.AuthMiddleware(scopesAuthenticator{}, logger),
+	})
+
+	testCases := []struct {
+		name   string
+		method string
+		scopes string
+		status int
+	}{
+		{"analyst reads", http.MethodGet, "people:read", http.StatusOK},
+		{"analyst can not patch", http.MethodPatch, "people:read", http.StatusForbidden},
+		{"editor patches", http.MethodPatch, "people:read people:write", http.StatusOK},
+		{"editor can not delete", http.MethodDelete, "people:read people:write", http.StatusForbidden},
+		{"no scopes", http.MethodGet, "", http.StatusForbidden},
+	}
+
+	for _, tCase := range testCases {
+		test := tCase
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var body io.Reader
+			if test.method == http.MethodPatch {
+				body = strings.NewReader(`{"age": 30}`)
+			}
+
+			request := httptest.NewRequest(test.method, fmt.Sprintf("/person/%s", person.ID), body)
+			request.Header.Set("Authorization", "Bearer "+test.scopes)
+
+			if body != nil {
+				request.Header.Set("Content-Type", "application/merge-patch+json")
+			}
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+			response := recorder.Result()
+
+			defer response.Body.Close()
+
+			if response.StatusCode != test.status {
+				t.Fatalf("unexpected status code: expected %d, got %d", test.status, response.StatusCode)
+			}
+
+			if test.status == http.StatusForbidden {
+				checkProblem(t, response, api.ProblemForbidden)
+			}
+		})
+	}
+}
+
+func TestIncludeDeletedRequiresAdmin(t *testing.T) {
+	t.Parallel()
+
+	spec, err := api.GetSwagger()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	policy, err := api.PolicyFromSpec(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	people := memory.NewPeople()
+
+	person := utils.MakePerson()
+	person.ID, _ = people.Create(context.Background(), person)
+
+	if err = people.Delete(context.Background(), person.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	handler := newStrictHandler(t, people, []api.StrictMiddlewareFunc{
+		api.AuthorizationMiddleware(policy, logger),
+		api.AuthMiddleware(scopesAuthenticator{}, logger),
+	})
+
+	testCases := []struct {
+		name   string
+		query  string
+		scopes string
+		status int
+		total  int
+	}{
+		{"reader", "", "people:read", http.StatusOK, 0},
+		{"reader without deleted", "&include_deleted=false", "people:read", http.StatusOK, 0},
+		{"reader with deleted", "&include_deleted=true", "people:read", http.StatusForbidden, 0},
+		{"admin with deleted", "&include_deleted=true", "people:read people:admin", http.StatusOK, 1},
+		// total_items is omitted
+		{"without total", "&total=false", "people:read", http.StatusOK, -1},
+	}
+
+	for _, tCase := range testCases {
+		test := tCase
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			request := httptest.NewRequest(http.MethodGet, "/person?offset=0&limit=20"+test.query, nil)
+			request.Header.Set("Authorization", "Bearer "+test.scopes)
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+			response := recorder.Result()
+
+			defer response.Body.Close()
+
+			if response.StatusCode != test.status {
+				t.Fatalf("unexpected status code: expected %d, got %d", test.status, response.StatusCode)
+			}
+
+			if test.status == http.StatusForbidden {
+				checkProblem(t, response, api.ProblemForbidden)
+
+				return
+			}
+
+			var page api.PersonPage
+			if err := json.NewDecoder(response.Body).Decode(&page); err != nil {
+				t.Fatal(err)
+			}
+
+			switch total := page.Pagination.TotalItems; {
+			case test.total < 0 && total != nil:
+				t.Errorf("unexpected number of people: expected none, got %d", *total)
+			case test.total >= 0 && (total == nil || *total != test.total):
+				t.Errorf("unexpected number of people: expected %d, got %v", test.total, total)
+			}
+		})
+	}
+}
+
+//nolint:funlen
+func TestAudit(t *testing.T) {
+	t.Parallel()
+
+	spec, err := api.GetSwagger()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	policy, err := api.PolicyFromSpec(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	people := memory.NewPeople()
+
+	handler := newStrictHandler(t, people, []api.StrictMiddlewareFunc{
+		api.AuthorizationMiddleware(policy, logger),
+		api.AuthMiddleware(scopesAuthenticator{}, logger),
+	}, api.ClientIPMiddleware)
+
+	do := func(request *http.Request, scopes string) *http.Response {
+		request.Header.Set("Authorization", "Bearer "+scopes)
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+
+		return recorder.Result()
+	}
+
+	person := utils.MakePerson()
+	person.ID, _ = people.Create(context.Background(), person)
+
+	request := httptest.NewRequest(http.MethodPatch, fmt.Sprintf("/person/%s", person.ID),
+		strings.NewReader(fmt.Sprintf(`{"age": %d}`, person.Age+1)))
+	request.Header.Set("Content-Type", "application/merge-patch+json")
+
+	response := do(request, "people:write")
+	response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("could not patch a person: %d", response.StatusCode)
+	}
+
+	response = do(httptest.NewRequest(http.MethodGet, "/audit?person_id="+person.ID.String(), nil), "people:admin")
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status code: %d", response.StatusCode)
+	}
+
+	page := unmarshalJSONBody[api.AuditPage](t, response)
+	if len(page.Entries) != 2 || page.Pagination.TotalItems == nil || *page.Pagination.TotalItems != 2 {
+		t.Fatalf("expected create and update entries, got %v", page)
+	}
+
+	update := page.Entries[0]
+	if update.Operation != api.AuditEntryOperationUpdate ||
+		update.Actor == nil || *update.Actor != "jwt:user" ||
+		update.ClientIp == nil || *update.ClientIp != "192.0.2.1" {
+		t.Errorf("unexpected update entry: %v", update)
+	}
+
+	age, found := update.Diff["age"]
+	if len(update.Diff) != 1 || !found || age.Old == nil || age.New == nil ||
+		*age.Old != float64(person.Age) || *age.New != float64(person.Age+1) {
+		t.Errorf("unexpected diff: %v", update.Diff)
+	}
+
+	if page.Entries[1].Operation != api.AuditEntryOperationCreate || page.Entries[1].Actor != nil {
+		t.Errorf("unexpected create entry: %v", page.Entries[1])
+	}
+
+	response = do(httptest.NewRequest(http.MethodGet, "/audit?actor=nobody", nil), "people:admin")
+	defer response.Body.Close()
+
+	if page = unmarshalJSONBody[api.AuditPage](t, response); page.Pagination.TotalItems == nil ||
+		*page.Pagination.TotalItems != 0 {
+		t.Errorf("actor filter was not applied: %v", page)
+	}
+
+	response = do(httptest.NewRequest(http.MethodGet, "/audit", nil), "people:read")
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusForbidden {
+		t.Errorf("audit is available without people:admin: %d", response.StatusCode)
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	t.Parallel()
+
+	people := memory.NewPeople()
+	handler := newStrictHandler(t, people, nil, api.RequestIDMiddleware)
+
+	person := utils.MakePerson()
+	person.ID, _ = people.Create(context.Background(), person)
+
+	do := func(requestID string) *http.Response {
+		request := httptest.NewRequest(http.MethodPatch, fmt.Sprintf("/person/%s", person.ID),
+			strings.NewReader(fmt.Sprintf(`{"age": %d}`, person.Age+1)))
+		request.Header.Set("Content-Type", "application/merge-patch+json")
+
+		if requestID != "" {
+			request.Header.Set(reqctx.RequestIDHeader, requestID)
+		}
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+
+		return recorder.Result()
+	}
+
+	response := do("client-request-1")
+	response.Body.Close()
+
+	if requestID := response.Header.Get(reqctx.RequestIDHeader); requestID != "client-request-1" {
+		t.Errorf("request ID was not returned: %q", requestID)
+	}
+
+	for _, invalid := range []string{"", "with space", strings.Repeat("a", 129)} {
+		response = do(invalid)
+		response.Body.Close()
+
+		if _, err := uuid.Parse(response.Header.Get(reqctx.RequestIDHeader)); err != nil {
+			t.Errorf("request ID %q was not replaced: %v", invalid, err)
+		}
+	}
+
+	// the first change creates the person, the second one is the newest
+	changes, err := people.History(context.Background(), person.ID, domain.PaginationFilter{Offset: 0, Limit: 10})
+	if err != nil || len(changes.Items) != 2 || changes.Items[0].RequestID != "client-request-1" {
+		t.Errorf("request ID was not recorded: %+v %v", changes, err)
+	}
+}
+
+func TestReadYourWrites(t *testing.T) {
+	t.Parallel()
+
+	var primary bool
+
+	handler := api.ReadYourWritesMiddleware(2500 * time.Millisecond)(
+		http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			primary = reqctx.PrimaryReads(r.Context())
+		}))
+
+	testCases := []struct {
+		prepare  func(request *http.Request)
+		name     string
+		method   string
+		expected bool
+	}{
+		{name: "read", method: http.MethodGet, prepare: func(*http.Request) {}, expected: false},
+		{name: "header", method: http.MethodGet, expected: true, prepare: func(request *http.Request) {
+			request.Header.Set(api.ReadYourWritesHeader, "true")
+		}},
+		{name: "false header", method: http.MethodGet, expected: false, prepare: func(request *http.Request) {
+			request.Header.Set(api.ReadYourWritesHeader, "false")
+		}},
+		{name: "cookie", method: http.MethodGet, expected: true, prepare: func(request *http.Request) {
+			request.AddCookie(&http.Cookie{Name: api.ReadYourWritesCookie, Value: "true"}) //nolint:exhaustruct
+		}},
+		{name: "write", method: http.MethodPatch, prepare: func(*http.Request) {}, expected: true},
+	}
+
+	for _, test := range testCases {
+		request := httptest.NewRequest(test.method, "/person", nil)
+		test.prepare(request)
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+
+		if primary != test.expected {
+			t.Errorf("%s: expected primary reads %v, got %v", test.name, test.expected, primary)
+		}
+
+		cookies := recorder.Result().Cookies()
+		if written := test.method != http.MethodGet; written != (len(cookies) == 1) ||
+			written && (cookies[0].Name != api.ReadYourWritesCookie || cookies[0].MaxAge != 3) {
+			t.Errorf("%s: unexpected cookies %v", test.name, cookies)
+		}
+	}
+}
+
+//nolint:funlen
+func TestRateLimit(t *testing.T) {
+	t.Parallel()
+
+	spec, err := api.GetSwagger()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	costs, err := api.RateLimitCostsFromSpec(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if costs["PersonPost"] <= 1 {
+		t.Errorf("personPost must cost more than other operations: %v", costs)
+	}
+
+	options := api.RateLimitOptions{
+		Costs:         api.RateLimitCosts{"personPost": 3},
+		Bucket:        domain.TokenBucket{Capacity: 3, Rate: 0.01},
+		AddressBucket: domain.TokenBucket{Capacity: 5, Rate: 0.01},
+	}
+	if err = options.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := options
+	invalid.Costs = api.RateLimitCosts{"personPost": 4}
+
+	if err = invalid.Validate(); !errors.Is(err, api.ErrRateLimit) {
+		t.Errorf("cost exceeding the capacity was not reported: %v", err)
+	}
+
+	invalid = options
+	invalid.AddressBucket.Rate = 0
+
+	if err = invalid.Validate(); !errors.Is(err, api.ErrRateLimit) {
+		t.Errorf("address bucket without a rate was not reported: %v", err)
+	}
+
+	// spec servers are not used in tests
+	spec.Servers = nil
+
+	operationNames, err := api.OperationNames(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	// the address limit is taken before validation
+	newLimitedHandler := func(limiter *api.RateLimiter) http.Handler {
+		return api.ClientIPMiddleware(limiter.Middleware(operationNames)(
+			newStrictHandler(t, memory.NewPeople(), []api.StrictMiddlewareFunc{
+				limiter.StrictMiddleware,
+				api.AuthMiddleware(subjectAuthenticator{}, logger),
+			}),
+		))
+	}
+
+	handler := newLimitedHandler(api.NewRateLimiter(memory.NewRateLimits(), options, logger))
+
+	do := func(request *http.Request, remoteAddr, scopes string) *http.Response {
+		request.RemoteAddr = remoteAddr
+		if scopes != "" {
+			request.Header.Set("Authorization", "Bearer "+scopes)
+		}
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+
+		return recorder.Result()
+	}
+
+	post := func() *http.Request {
+		person := utils.MakePerson()
+
+		data, err := json.Marshal(api.PersonPostJSONRequestBody{
+			Name:       person.Name,
+			Patronymic: person.Patronymic,
+			Surname:    person.Surname,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		request := httptest.NewRequest(http.MethodPost, "/person", bytes.NewReader(data))
+		request.Header.Set("Content-Type", "application/json")
+
+		return request
+	}
+
+	response := do(httptest.NewRequest(http.MethodGet, "/person?offset=0&limit=10", nil), "192.0.2.1:1234", "user people:read")
+	response.Body.Close()
+
+	if response.StatusCode != http.StatusOK ||
+		response.Header.Get(api.RateLimitLimitHeader) != "3" ||
+		response.Header.Get(api.RateLimitRemainingHeader) != "2" ||
+		response.Header.Get(api.RateLimitResetHeader) != "100" {
+		t.Errorf("unexpected response: %d %v", response.StatusCode, response.Header)
+	}
+
+	// the bucket of an authenticated client is keyed by the actor, not by the address
+	response = do(post(), "192.0.2.2:1234", "user people:read")
+	defer response.Body.Close()
+
+	checkProblem(t, response, api.ProblemRateLimited)
+
+	if retryAfter := response.Header.Get("Retry-After"); retryAfter != "100" {
+		t.Errorf("unexpected Retry-After: %q", retryAfter)
+	}
+
+	t.Run("shared address", func(t *testing.T) {
+		// clients behind one address have their own buckets
+		for _, subject := range []string{"first", "second"} {
+			response := do(httptest.NewRequest(http.MethodGet, "/person?offset=0&limit=10", nil),
+				"192.0.2.5:1234", subject+" people:read")
+			response.Body.Close()
+
+			if response.StatusCode != http.StatusOK || response.Header.Get(api.RateLimitRemainingHeader) != "2" {
+				t.Errorf("request of %s was limited by the address: %d %v", subject, response.StatusCode, response.Header)
+			}
+		}
+	})
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		// guessing credentials is limited by the address bucket
+		for i := 0; i < 5; i++ {
+			response := do(httptest.NewRequest(http.MethodGet, "/person?offset=0&limit=10", nil), "192.0.2.3:1234", "")
+			response.Body.Close()
+
+			if response.StatusCode != http.StatusUnauthorized ||
+				response.Header.Get(api.RateLimitRemainingHeader) != fmt.Sprint(4-i) {
+				t.Errorf("unauthenticated request was not counted: %d %v", response.StatusCode, response.Header)
+			}
+		}
+
+		response := do(httptest.NewRequest(http.MethodGet, "/person?offset=0&limit=10", nil), "192.0.2.3:1234", "")
+		defer response.Body.Close()
+
+		checkProblem(t, response, api.ProblemRateLimited)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		// a token of the address bucket is taken before validation
+		response := do(httptest.NewRequest(http.MethodPost, "/person", strings.NewReader("{}")), "192.0.2.4:1234", "")
+		response.Body.Close()
+
+		if response.StatusCode != http.StatusBadRequest || response.Header.Get(api.RateLimitRemainingHeader) != "4" {
+			t.Errorf("invalid request was not counted: %d %v", response.StatusCode, response.Header)
+		}
+	})
+
+	t.Run("anonymous", func(t *testing.T) {
+		// without authentication requests are limited by the address
+		limiter := api.NewRateLimiter(memory.NewRateLimits(), options, logger)
+		anonymous := api.ClientIPMiddleware(limiter.Middleware(operationNames)(
+			newStrictHandler(t, memory.NewPeople(), []api.StrictMiddlewareFunc{limiter.StrictMiddleware}),
+		))
+
+		for i := 0; i < 4; i++ {
+			request := httptest.NewRequest(http.MethodGet, "/person?offset=0&limit=10", nil)
+			request.RemoteAddr = "192.0.2.6:1234"
+
+			recorder := httptest.NewRecorder()
+			anonymous.ServeHTTP(recorder, request)
+
+			response := recorder.Result()
+			response.Body.Close()
+
+			switch {
+			case i < 3 && response.Header.Get(api.RateLimitRemainingHeader) != fmt.Sprint(2-i):
+				t.Errorf("anonymous request was not counted: %d %v", response.StatusCode, response.Header)
+			case i == 3 && response.StatusCode != http.StatusTooManyRequests:
+				t.Errorf("anonymous request was not limited: %d", response.StatusCode)
+			}
+		}
+	})
+
+	// options are replaced on config reload
+	limiter := api.NewRateLimiter(memory.NewRateLimits(), options, logger)
+	handler = newLimitedHandler(limiter)
+
+	reloaded := options
+	reloaded.Bucket.Capacity = 5
+	limiter.SetOptions(reloaded)
+
+	response = do(httptest.NewRequest(http.MethodGet, "/person?offset=0&limit=10", nil), "192.0.2.1:1234", "user people:read")
+	response.Body.Close()
+
+	if response.Header.Get(api.RateLimitLimitHeader) != "5" {
+		t.Errorf("reloaded options were not applied: %v", response.Header)
+	}
+}
+
+func TestOperationNames(t *testing.T) {
+	t.Parallel()
+
+	spec, err := api.GetSwagger()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// paths are matched without the server URL
+	spec.Servers = nil
+
+	operationNames, err := api.OperationNames(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		method, path, expected string
+	}{
+		{http.MethodGet, "/person", "PersonList"},
+		{http.MethodPost, "/person", "PersonPost"},
+		{http.MethodGet, "/person/" + uuid.NewString() + "/history", "PersonHistory"},
+		{http.MethodGet, "/unknown", ""},
+	}
+
+	for _, test := range testCases {
+		if name := operationNames(httptest.NewRequest(test.method, test.path, nil)); name != test.expected {
+			t.Errorf("%s %s: expected %q, got %q", test.method, test.path, test.expected, name)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/Hofsiedge/person-api/internal/auth"
+	"github.com/Hofsiedge/person-api/internal/reqctx"
+)
+
+// secured reports whether the operation has security requirements.
+// The generated wrappers put the scopes of its security schemes
+// into the request context.
+func secured(ctx context.Context) bool {
+	return ctx.Value(ApiKeyScopes) != nil || ctx.Value(BasicAuthScopes) != nil ||
+		ctx.Value(BearerAuthScopes) != nil
+}
+
+// AuthMiddleware authenticates requests to secured operations and
+// records the principal as the actor of the request along with its scopes.
+// Unauthenticated requests get a 401 problem with a WWW-Authenticate
+// challenge.
+func AuthMiddleware(authenticator auth.Authenticator, logger *slog.Logger) StrictMiddlewareFunc {
+	challenge := authenticator.Challenge()
+
+	return func(f StrictHandlerFunc, operationID string) StrictHandlerFunc {
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request, request any) (any, error) {
+			if !secured(ctx) {
+				return f(ctx, w, r, request)
+			}
+
+			principal, err := authenticator.Authenticate(ctx, r)
+			if err != nil {
+				if errors.Is(err, auth.ErrAuth) {
+					logger.DebugContext(ctx, "authentication failed",
+						slog.String("operation", operationID),
+						slog.String("message", err.Error()))
+
+					return problemResponse{
+						retryAfter: nil,
+						challenge:  &challenge,
+						Problem:    ProblemUnauthorized.New(err.Error()),
+					}, nil
+				}
+
+				problemType := ProblemTypeOf(err)
+				logger.ErrorContext(ctx, "could not authenticate a request",
+					slog.String("operation", operationID),
+					slog.String("message", err.Error()))
+
+				return problemResponse{retryAfter: nil, challenge: nil, Problem: problemType.New("")}, nil
+			}
+
+			ctx = reqctx.WithScopes(reqctx.WithActor(ctx, principal.Actor()), principal.Scopes)
+
+			return f(ctx, w, r.WithContext(ctx), request)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/Hofsiedge/person-api/internal/reqctx"
+	"github.com/getkin/kin-openapi/openapi3"
+	"gopkg.in/yaml.v3"
+)
+
+// RequiredScopesExtension lists the scopes an operation requires
+const RequiredScopesExtension = "x-required-scopes"
+
+var ErrPolicy = errors.New("invalid authorization policy")
+
+// Policy maps operation names to the scopes required to call
+// the operation. All of them are required.
+//
+// "<operation>.<parameter>" keys are parameter rules: the scopes are
+// required in addition when the query parameter of the operation is set
+// (to a value other than "" or "false").
+type Policy map[string][]string
+
+// parameterSeparator separates the operation and the parameter of
+// a parameter rule
+const parameterSeparator = "."
+
+// PolicyFromSpec reads x-required-scopes of the operations and their
+// parameters
+func PolicyFromSpec(spec *openapi3.T) (Policy, error) {
+	policy := make(Policy)
+
+	for _, path := range spec.Paths {
+		for _, operation := range path.Operations() {
+			name := operationName(operation.OperationID)
+
+			scopes, found, err := requiredScopes(operation.Extensions, operation.OperationID)
+			if err != nil {
+				return nil, err
+			}
+
+			if found {
+				policy[name] = scopes
+			}
+
+			for _, parameterRef := range operation.Parameters {
+				parameter := parameterRef.Value
+				key := operation.OperationID + parameterSeparator + parameter.Name
+
+				scopes, found, err := requiredScopes(parameter.Extensions, key)
+				if err != nil {
+					return nil, err
+				}
+
+				if found {
+					policy[name+parameterSeparator+parameter.Name] = scopes
+				}
+			}
+		}
+	}
+
+	return policy, nil
+}
+
+// requiredScopes reads x-required-scopes of extensions of owner
+func requiredScopes(extensions map[string]any, owner string) ([]string, bool, error) {
+	value, found := extensions[RequiredScopesExtension]
+	if !found {
+		return nil, false, nil
+	}
+
+	items, ok := value.([]any)
+	if !ok {
+		return nil, false, fmt.Errorf("%w: %s of %s is not a list", ErrPolicy, RequiredScopesExtension, owner)
+	}
+
+	scopes := make([]string, len(items))
+
+	for i, item := range items {
+		if scopes[i], ok = item.(string); !ok {
+			return nil, false, fmt.Errorf("%w: %s of %s is not a list of strings",
+				ErrPolicy, RequiredScopesExtension, owner)
+		}
+	}
+
+	return scopes, true, nil
+}
+
+// LoadPolicy reads a policy from a YAML file:
+//
+//	operations:
+//	  personDelete: [people:delete, people:admin]
+//	  personList.include_deleted: [people:admin]
+func LoadPolicy(path string) (Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read a policy: %w", err)
+	}
+
+	var file struct {
+		Operations Policy `yaml:"operations"`
+	}
+
+	if err = yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrPolicy, path, err)
+	}
+
+	return Policy{}.Override(file.Operations), nil
+}
+
+// Override returns the policy with the operations of other replaced
+func (p Policy) Override(other Policy) Policy {
+	result := make(Policy, len(p)+len(other))
+	for operationID, scopes := range p {
+		result[operationName(operationID)] = scopes
+	}
+
+	for operationID, scopes := range other {
+		result[operationName(operationID)] = scopes
+	}
+
+	return result
+}
+
+// Validate checks that the policy covers exactly the operations of spec,
+// so new operations can not be left unprotected by accident, and that
+// parameter rules refer to query parameters of the operations
+func (p Policy) Validate(spec *openapi3.T) error {
+	operations := make(map[string]bool)
+	parameters := make(map[string]bool)
+
+	for _, path := range spec.Paths {
+		for _, operation := range path.Operations() {
+			name := operationName(operation.OperationID)
+			operations[name] = true
+
+			for _, parameterRef := range operation.Parameters {
+				if parameterRef.Value.In == openapi3.ParameterInQuery {
+					parameters[name+parameterSeparator+parameterRef.Value.Name] = true
+				}
+			}
+		}
+	}
+
+	var problems []string
+
+	for operationID := range operations {
+		if _, found := p[operationID]; !found {
+			problems = append(problems, "no scopes for "+operationID)
+		}
+	}
+
+	for key := range p {
+		switch {
+		case strings.Contains(key, parameterSeparator):
+			if !parameters[key] {
+				problems = append(problems, "unknown query parameter "+key)
+			}
+		case !operations[key]:
+			problems = append(problems, "unknown operation "+key)
+		}
+	}
+
+	if len(problems) > 0 {
+		slices.Sort(problems)
+
+		return fmt.Errorf("%w: %s", ErrPolicy, strings.Join(problems, ", "))
+	}
+
+	return nil
+}
+
+// parameterRules returns the parameter rules of the operation: parameter
+// names to the scopes required when the parameter is set
+func (p Policy) parameterRules(operationID string) map[string][]string {
+	rules := make(map[string][]string)
+
+	for key, scopes := range p {
+		if parameter, found := strings.CutPrefix(key, operationID+parameterSeparator); found {
+			rules[parameter] = scopes
+		}
+	}
+
+	return rules
+}
+
+// requiredScopes returns the scopes required to call the operation with
+// the query: the ones of the operation and of the parameters set in query
+func (p Policy) requiredScopes(operationID string, rules map[string][]string, query url.Values) []string {
+	required := slices.Clone(p[operationID])
+
+	for parameter, scopes := range rules {
+		if parameterSet(query, parameter) {
+			required = append(required, scopes...)
+		}
+	}
+
+	slices.Sort(required)
+
+	return slices.Compact(required)
+}
+
+// parameterSet reports whether the query parameter has a value other
+// than "" or "false"
+func parameterSet(query url.Values, parameter string) bool {
+	for _, value := range query[parameter] {
+		if value != "" && value != "false" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// missingScopes returns the required scopes that were not granted
+func missingScopes(required, granted []string) []string {
+	var missing []string
+
+	for _, scope := range required {
+		if !slices.Contains(granted, scope) {
+			missing = append(missing, scope)
+		}
+	}
+
+	return missing
+}
+
+// AuthorizationMiddleware checks that the actor was granted the scopes
+// required by the operation and the parameters of the request
+// (reqctx.Scopes) and responds with a 403 problem otherwise. It must be
+// inside AuthMiddleware (before it in the middleware list). Operations
+// without security requirements are not checked.
+func AuthorizationMiddleware(policy Policy, logger *slog.Logger) StrictMiddlewareFunc {
+	return func(f StrictHandlerFunc, operationID string) StrictHandlerFunc {
+		rules := policy.parameterRules(operationID)
+
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request, request any) (any, error) {
+			if !secured(ctx) {
+				return f(ctx, w, r, request)
+			}
+
+			required := policy.requiredScopes(operationID, rules, r.URL.Query())
+			if missing := missingScopes(required, reqctx.Scopes(ctx)); len(missing) > 0 {
+				logger.DebugContext(ctx, "authorization failed",
+					slog.String("operation", operationID),
+					slog.String("actor", reqctx.Actor(ctx)),
+					slog.Any("missing", missing))
+
+				return problemResponse{
+					retryAfter: nil,
+					challenge:  nil,
+					Problem:    ProblemForbidden.New("missing scopes: " + strings.Join(missing, " ")),
+				}, nil
+			}
+
+			return f(ctx, w, r, request)
+		}
+	}
+}
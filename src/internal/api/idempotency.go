@@ -0,0 +1,235 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Hofsiedge/person-api/internal/domain"
+	"github.com/Hofsiedge/person-api/internal/repo"
+	"github.com/Hofsiedge/person-api/internal/reqctx"
+)
+
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// set on replayed responses
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	// how often a repeated request checks if the first one has completed
+	idempotencyPollInterval = 100 * time.Millisecond
+)
+
+type IdempotencyOptions struct {
+	// how long responses are replayed
+	TTL time.Duration
+	// how long a repeated request waits for the first one to complete
+	// before ProblemRequestInProgress is returned
+	Wait time.Duration
+}
+
+// responseRecorder saves the response while writing it
+type responseRecorder struct {
+	http.ResponseWriter
+	body   bytes.Buffer
+	status int
+}
+
+func (r *responseRecorder) WriteHeader(statusCode int) {
+	if r.status == 0 {
+		r.status = statusCode
+	}
+
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+
+	r.body.Write(data)
+
+	return r.ResponseWriter.Write(data) //nolint:wrapcheck
+}
+
+// fingerprint identifies the request: repeated requests must have
+// the same method, URI and body. Credentials are not a part of it (clients
+// may refresh them between retries): keys are namespaced by the actor.
+func fingerprint(r *http.Request, body []byte) []byte {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	hash.Write(body)
+
+	return hash.Sum(nil)
+}
+
+// actorKey namespaces the key by the actor, so that clients can not
+// replay or block requests of each other. Header values can not contain
+// line breaks, so the result is unambiguous.
+func actorKey(actor, key string) string {
+	return actor + "\n" + key
+}
+
+// reserve reserves the key waiting for a request in progress
+// up to options.Wait
+func reserve(
+	ctx context.Context, keys repo.IdempotencyRepo, key string, fingerprint []byte, options IdempotencyOptions,
+) (*domain.StoredResponse, error) {
+	deadline := time.Now().Add(options.Wait)
+
+	for {
+		response, err := keys.Reserve(ctx, key, fingerprint, options.TTL)
+		if !errors.Is(err, repo.ErrInProgress) || time.Now().Add(idempotencyPollInterval).After(deadline) {
+			return response, err //nolint:wrapcheck
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, err //nolint:wrapcheck
+		case <-time.After(idempotencyPollInterval):
+		}
+	}
+}
+
+// idempotentRequest is a request with the Idempotency-Key header passed
+// from Idempotency.Middleware to Idempotency.StrictMiddleware
+type idempotentRequest struct {
+	recorder    *responseRecorder
+	key         string
+	fingerprint []byte
+	// the key namespaced by the actor, set once it is reserved
+	reserved string
+}
+
+type idempotentRequestKey struct{}
+
+// Idempotency makes POST requests with the Idempotency-Key header
+// idempotent: the response of the first request is stored and replayed for
+// repeated requests with the same key within IdempotencyOptions.TTL.
+//
+// Keys are reserved once the client is authenticated and authorized
+// (StrictMiddleware), so that failed requests do not store anything and
+// clients have separate namespaces of keys. Responses are recorded by
+// Middleware.
+//
+// A key reused for a different request results in ProblemIdempotencyKeyReused.
+// A repeated request waits for the first one to complete up to
+// IdempotencyOptions.Wait and results in ProblemRequestInProgress after that.
+// Server errors are not stored, so such requests can be retried with
+// the same key.
+type Idempotency struct {
+	keys    repo.IdempotencyRepo
+	logger  *slog.Logger
+	options IdempotencyOptions
+}
+
+func NewIdempotency(keys repo.IdempotencyRepo, options IdempotencyOptions, logger *slog.Logger) *Idempotency {
+	return &Idempotency{keys: keys, logger: logger, options: options}
+}
+
+// Middleware records the responses of requests with the Idempotency-Key
+// header and stores them if StrictMiddleware has reserved the key
+func (i *Idempotency) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if r.Method != http.MethodPost || key == "" {
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeRequestProblem(w, r, ProblemInvalidBody.New(err.Error()))
+
+			return
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		request := &idempotentRequest{
+			recorder:    &responseRecorder{ResponseWriter: w, body: bytes.Buffer{}, status: 0},
+			key:         key,
+			fingerprint: fingerprint(r, body),
+			reserved:    "",
+		}
+
+		next.ServeHTTP(request.recorder, r.WithContext(context.WithValue(r.Context(), idempotentRequestKey{}, request)))
+
+		if request.reserved != "" {
+			// the response is saved even if the client is gone
+			i.save(context.WithoutCancel(r.Context()), request)
+		}
+	})
+}
+
+// save stores the recorded response or releases the key if the request
+// failed, so that it can be retried
+func (i *Idempotency) save(ctx context.Context, request *idempotentRequest) {
+	recorder := request.recorder
+	if recorder.status == 0 {
+		recorder.status = http.StatusOK
+	}
+
+	if recorder.status < http.StatusInternalServerError {
+		err := i.keys.Save(ctx, request.reserved, domain.StoredResponse{
+			ContentType: recorder.Header().Get("Content-Type"),
+			Body:        recorder.body.Bytes(),
+			Status:      recorder.status,
+		})
+		if err == nil {
+			return
+		}
+
+		i.logger.ErrorContext(ctx, "could not save an idempotent response", slog.String("message", err.Error()))
+	}
+
+	if err := i.keys.Release(ctx, request.reserved); err != nil {
+		i.logger.ErrorContext(ctx, "could not release an idempotency key", slog.String("message", err.Error()))
+	}
+}
+
+// StrictMiddleware reserves the key of the actor (reqctx.Actor) or replays
+// the stored response. It must be inside AuthMiddleware and
+// AuthorizationMiddleware (before them in the middleware list).
+func (i *Idempotency) StrictMiddleware(f StrictHandlerFunc, _ string) StrictHandlerFunc {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request, request any) (any, error) {
+		idempotent, found := ctx.Value(idempotentRequestKey{}).(*idempotentRequest)
+		if !found {
+			return f(ctx, w, r, request)
+		}
+
+		key := actorKey(reqctx.Actor(ctx), idempotent.key)
+
+		stored, err := reserve(ctx, i.keys, key, idempotent.fingerprint, i.options)
+		if err != nil {
+			problem := ProblemOf(err)
+			i.logger.Log(ctx, slog.LevelDebug, "could not reserve an idempotency key",
+				slog.String("message", err.Error()),
+				slog.String("problem", problem.Type))
+
+			return problemResponse{retryAfter: nil, challenge: nil, Problem: problem}, nil
+		}
+
+		if stored != nil {
+			if stored.ContentType != "" {
+				w.Header().Set("Content-Type", stored.ContentType)
+			}
+
+			w.Header().Set(IdempotentReplayedHeader, "true")
+			w.WriteHeader(stored.Status)
+			_, _ = w.Write(stored.Body)
+
+			// the response is written
+			return nil, nil
+		}
+
+		idempotent.reserved = key
+
+		return f(ctx, w, r, request)
+	}
+}
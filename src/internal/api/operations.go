@@ -0,0 +1,40 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+)
+
+// operationName returns the Go style name of the operation ("PersonGet" for
+// personGet). Strict handlers and the embedded spec use such names, while
+// configuration files may use operationId as in openapi.yaml.
+func operationName(operationID string) string {
+	if operationID == "" {
+		return ""
+	}
+
+	return strings.ToUpper(operationID[:1]) + operationID[1:]
+}
+
+// OperationNames returns a function naming the operation of a request
+// ("PersonGet") or returning an empty string if the request matches no
+// operation of the spec. Paths are matched against the spec servers.
+func OperationNames(spec *openapi3.T) (func(r *http.Request) string, error) {
+	router, err := gorillamux.NewRouter(spec)
+	if err != nil {
+		return nil, fmt.Errorf("could not make a router of the spec: %w", err)
+	}
+
+	return func(r *http.Request) string {
+		route, _, err := router.FindRoute(r)
+		if err != nil {
+			return ""
+		}
+
+		return operationName(route.Operation.OperationID)
+	}, nil
+}
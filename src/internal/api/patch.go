@@ -0,0 +1,242 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/Hofsiedge/person-api/internal/completer"
+	"github.com/Hofsiedge/person-api/internal/domain"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/google/uuid"
+)
+
+//nolint:gochecknoinits
+func init() {
+	// the OpenAPI validator knows application/json-patch+json but not
+	// application/merge-patch+json
+	openapi3filter.RegisterBodyDecoder("application/merge-patch+json",
+		openapi3filter.RegisteredBodyDecoder("application/json"))
+}
+
+// MergePatchAliasMiddleware treats PATCH requests with application/json
+// bodies as JSON Merge Patch documents: clients sent application/json
+// before JSON Patch was supported. Generated handlers match content types
+// by prefix (application/json matches application/json-patch+json), so
+// the alias is not a separate content type of the spec. Must be outside
+// the OpenAPI validator.
+func MergePatchAliasMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch {
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err == nil && mediaType == "application/json" {
+			r.Header.Set("Content-Type", mime.FormatMediaType("application/merge-patch+json", params))
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Nullable is a JSON value that tells an absent field from an explicit null.
+// Used in JSON Merge Patch documents (see PersonMergePatch).
+type Nullable[T any] struct {
+	Value T
+	// the field was present in the document (Value or null)
+	Set bool
+	// the field was explicitly set to null
+	Null bool
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It is only called for present
+// fields, so absent fields keep Set == false.
+func (n *Nullable[T]) UnmarshalJSON(data []byte) error {
+	n.Set = true
+
+	if bytes.Equal(data, []byte("null")) {
+		n.Null = true
+
+		return nil
+	}
+
+	return json.Unmarshal(data, &n.Value) //nolint:wrapcheck
+}
+
+// operation converts a merge patch field to a patch operation.
+// Returns false if the field is absent.
+func (n Nullable[T]) operation(field domain.PersonField, convert func(T) any) (domain.PatchOperation, bool) {
+	switch {
+	case !n.Set:
+		return domain.PatchOperation{}, false //nolint:exhaustruct
+	case n.Null:
+		return domain.PatchOperation{Value: nil, Op: domain.PatchRemove, Field: field}, true
+	default:
+		return domain.PatchOperation{Value: convert(n.Value), Op: domain.PatchReplace, Field: field}, true
+	}
+}
+
+func identity[T any](value T) any {
+	return value
+}
+
+// mergePatchOperations converts a JSON Merge Patch (RFC 7396) document
+// to patch operations
+func mergePatchOperations(patch PersonMergePatch) []domain.PatchOperation {
+	operations := make([]domain.PatchOperation, 0)
+
+	add := func(operation domain.PatchOperation, present bool) {
+		if present {
+			operations = append(operations, operation)
+		}
+	}
+
+	add(patch.Name.operation(domain.FieldName, identity[string]))
+	add(patch.Surname.operation(domain.FieldSurname, identity[string]))
+	add(patch.Patronymic.operation(domain.FieldPatronymic, identity[string]))
+	add(patch.Age.operation(domain.FieldAge, identity[int]))
+	add(patch.Sex.operation(domain.FieldSex, func(sex Sex) any {
+		return domain.Sex(sex)
+	}))
+	add(patch.Nationality.operation(domain.FieldNationality, func(code string) any {
+		return domain.Nationality(code)
+	}))
+
+	return operations
+}
+
+// jsonPatchOperations converts a JSON Patch (RFC 6902) document to patch operations.
+// JSON values are converted to the field types expected by domain.PatchOperation.
+func jsonPatchOperations(patch PersonJSONPatch) ([]domain.PatchOperation, error) {
+	operations := make([]domain.PatchOperation, len(patch))
+
+	for i, jsonOperation := range patch {
+		operation := domain.PatchOperation{
+			Value: nil,
+			Op:    domain.PatchOp(jsonOperation.Op),
+			Field: domain.PersonField(strings.TrimPrefix(string(jsonOperation.Path), "/")),
+		}
+
+		if jsonOperation.Value != nil {
+			value, err := patchValue(operation.Field, *jsonOperation.Value)
+			if err != nil {
+				return nil, fmt.Errorf("operation #%d: %w", i, err)
+			}
+
+			operation.Value = value
+		}
+
+		operations[i] = operation
+	}
+
+	return operations, nil
+}
+
+// patchValue converts a decoded JSON value to the type of the field
+func patchValue(field domain.PersonField, value any) (any, error) {
+	err := fmt.Errorf("%w: invalid value %v for %q", domain.ErrPatchInvalid, value, field)
+
+	switch field {
+	case domain.FieldAge:
+		number, ok := value.(float64)
+		if !ok || number != math.Trunc(number) {
+			return nil, err
+		}
+
+		return int(number), nil
+	case domain.FieldSex:
+		text, ok := value.(string)
+		if !ok {
+			return nil, err
+		}
+
+		return domain.Sex(text), nil
+	case domain.FieldNationality:
+		text, ok := value.(string)
+		if !ok {
+			return nil, err
+		}
+
+		return domain.Nationality(text), nil
+	case domain.FieldName, domain.FieldSurname, domain.FieldPatronymic:
+		text, ok := value.(string)
+		if !ok {
+			return nil, err
+		}
+
+		return text, nil
+	}
+
+	return value, nil
+}
+
+// enrichRemovals replaces removals of enrichable fields with enriched values.
+//
+// The name used for enrichment is the one in effect at the position of the
+// removal: either replaced by a preceding operation or the current one.
+func (s *Server) enrichRemovals(
+	ctx context.Context, personID uuid.UUID, operations []domain.PatchOperation,
+) ([]domain.PatchOperation, error) {
+	var (
+		name        *string
+		completions = make(map[string]completer.CompletionData)
+	)
+
+	result := make([]domain.PatchOperation, len(operations))
+
+	for i, operation := range operations {
+		result[i] = operation
+
+		if operation.Op == domain.PatchReplace && operation.Field == domain.FieldName {
+			if value, ok := operation.Value.(string); ok {
+				name = &value
+			}
+
+			continue
+		}
+
+		if operation.Op != domain.PatchRemove || !operation.Field.Enrichable() {
+			continue
+		}
+
+		if name == nil {
+			person, err := s.People.GetByID(ctx, personID)
+			if err != nil {
+				return nil, err //nolint:wrapcheck
+			}
+
+			name = &person.Name
+		}
+
+		data, found := completions[*name]
+		if !found {
+			var err error
+			if data, err = s.Completer.Complete(ctx, *name); err != nil {
+				return nil, err //nolint:wrapcheck
+			}
+
+			completions[*name] = data
+		}
+
+		result[i].Op = domain.PatchReplace
+
+		switch operation.Field { //nolint:exhaustive
+		case domain.FieldAge:
+			result[i].Value = data.Age
+		case domain.FieldSex:
+			result[i].Value = data.Sex
+		case domain.FieldNationality:
+			result[i].Value = data.Nationality
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,471 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Hofsiedge/person-api/internal/completer"
+	"github.com/Hofsiedge/person-api/internal/domain"
+	"github.com/Hofsiedge/person-api/internal/filler"
+	"github.com/Hofsiedge/person-api/internal/repo"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/gorilla/mux"
+	middleware "github.com/oapi-codegen/nethttp-middleware"
+)
+
+const (
+	ProblemContentType = "application/problem+json"
+	// prefix of all problem type URIs. Type URIs are stable and must not be
+	// changed: clients rely on them to tell errors apart
+	ProblemTypePrefix = "urn:person-api:problem:"
+)
+
+// ProblemType is a kind of error described by a Problem
+type ProblemType struct {
+	Name   string
+	Title  string
+	Status int
+}
+
+// URI returns the stable type URI of the ProblemType
+func (t ProblemType) URI() string {
+	return ProblemTypePrefix + t.Name
+}
+
+// New makes a Problem of the type. detail is omitted if empty.
+func (t ProblemType) New(detail string) Problem {
+	problem := Problem{
+		Detail:        nil,
+		Instance:      nil,
+		InvalidParams: nil,
+		Status:        t.Status,
+		Title:         t.Title,
+		Type:          t.URI(),
+	}
+
+	if detail != "" {
+		problem.Detail = &detail
+	}
+
+	return problem
+}
+
+//nolint:gochecknoglobals
+var (
+	ProblemValidation       = ProblemType{"validation", "Request validation failed", http.StatusBadRequest}
+	ProblemInvalidBody      = ProblemType{"invalid-body", "Request body could not be decoded", http.StatusBadRequest}
+	ProblemInvalidArgument  = ProblemType{"invalid-argument", "Invalid Person data", http.StatusBadRequest}
+	ProblemInvalidPatch     = ProblemType{"invalid-patch", "Invalid patch document", http.StatusBadRequest}
+	ProblemUnauthorized     = ProblemType{"unauthorized", "Authentication required", http.StatusUnauthorized}
+	ProblemForbidden        = ProblemType{"forbidden", "Insufficient scope", http.StatusForbidden}
+	ProblemNotFound         = ProblemType{"not-found", "Person not found", http.StatusNotFound}
+	ProblemRouteNotFound    = ProblemType{"route-not-found", "Route not found", http.StatusNotFound}
+	ProblemMethodNotAllowed = ProblemType{
+		"method-not-allowed", "Method not allowed", http.StatusMethodNotAllowed,
+	}
+	ProblemConflict        = ProblemType{"conflict", "Conflict with the current state", http.StatusConflict}
+	ProblemPatchTestFailed = ProblemType{
+		"patch-test-failed", "Patch test operation failed", http.StatusConflict,
+	}
+	ProblemRequestInProgress = ProblemType{
+		"request-in-progress", "Request with the idempotency key is in progress", http.StatusConflict,
+	}
+	ProblemIdempotencyKeyReused = ProblemType{
+		"idempotency-key-reused", "Idempotency key was used for another request", http.StatusUnprocessableEntity,
+	}
+	ProblemInvalidName = ProblemType{
+		"invalid-name", "Person name seems to be invalid", http.StatusUnprocessableEntity,
+	}
+	ProblemUnknownName = ProblemType{
+		"unknown-name", "Enrichment services do not know the name", http.StatusUnprocessableEntity,
+	}
+	ProblemRateLimited = ProblemType{
+		"rate-limited", "Rate limit exceeded", http.StatusTooManyRequests,
+	}
+	ProblemEnrichmentLimit = ProblemType{
+		"enrichment-limit-reached", "Enrichment request limit reached", http.StatusServiceUnavailable,
+	}
+	ProblemStorageUnavailable = ProblemType{
+		"storage-unavailable", "Storage is unavailable", http.StatusServiceUnavailable,
+	}
+	ProblemEnrichmentFailed = ProblemType{
+		"enrichment-failed", "Enrichment services failed", http.StatusBadGateway,
+	}
+	ProblemEnrichmentUnavailable = ProblemType{
+		"enrichment-unavailable", "Enrichment services are unavailable", http.StatusBadGateway,
+	}
+	ProblemEnrichmentTimeout = ProblemType{
+		"enrichment-timeout", "Enrichment services timed out", http.StatusGatewayTimeout,
+	}
+	ProblemInternal = ProblemType{"internal", "Internal server error", http.StatusInternalServerError}
+)
+
+// sentinel errors to problem types, checked in order (specific errors first).
+// detail is the one sent to clients: error messages may contain internal
+// information (SQL statements, function names, SQLSTATE codes), so they
+// are only logged.
+//
+//nolint:gochecknoglobals
+var problemTypes = []struct {
+	err     error
+	problem ProblemType
+	detail  string
+}{
+	{repo.ErrNotFound, ProblemNotFound, "the Person does not exist"},
+	{repo.ErrInProgress, ProblemRequestInProgress, "the first request with the idempotency key has not completed yet"},
+	{repo.ErrKeyReused, ProblemIdempotencyKeyReused, "the idempotency key was used for a request with another body"},
+	{domain.ErrPatchTestFailed, ProblemPatchTestFailed, "a test operation does not match the Person"},
+	{repo.ErrConflict, ProblemConflict, "the Person was changed concurrently or can not be changed this way"},
+	{domain.ErrPatch, ProblemInvalidPatch, "the patch document has an invalid operation or value"},
+	{repo.ErrArgument, ProblemInvalidArgument, "the Person data violates a constraint"},
+	{repo.ErrConnect, ProblemStorageUnavailable, ""},
+	// user errors take priority if several fillers failed
+	{filler.ErrInvalidName, ProblemInvalidName, "enrichment services rejected the name"},
+	{filler.ErrNotFound, ProblemUnknownName, "enrichment services have no data for the name"},
+	{filler.ErrUser, ProblemInvalidName, "enrichment services rejected the name"},
+	{filler.ErrLimitReached, ProblemEnrichmentLimit, ""},
+	{filler.ErrTimeout, ProblemEnrichmentTimeout, ""},
+	{filler.ErrNetworkError, ProblemEnrichmentUnavailable, ""},
+	{filler.ErrEnvironment, ProblemInternal, ""},
+	{completer.ErrInvalidConfig, ProblemInternal, ""},
+	{completer.ErrWrongUsage, ProblemInternal, ""},
+	{filler.ErrFiller, ProblemEnrichmentFailed, ""},
+	{completer.ErrCompleterError, ProblemEnrichmentFailed, ""},
+}
+
+// problemOf returns the ProblemType and the detail for an error.
+// Unknown errors are ProblemInternal.
+func problemOf(err error) (ProblemType, string) {
+	for _, mapping := range problemTypes {
+		if errors.Is(err, mapping.err) {
+			return mapping.problem, mapping.detail
+		}
+	}
+
+	return ProblemInternal, ""
+}
+
+// ProblemTypeOf returns the ProblemType for an error. Unknown errors are ProblemInternal.
+func ProblemTypeOf(err error) ProblemType {
+	problemType, _ := problemOf(err)
+
+	return problemType
+}
+
+// ProblemOf returns the Problem for an error with a fixed detail.
+// Server errors have no detail.
+func ProblemOf(err error) Problem {
+	problemType, detail := problemOf(err)
+	if problemType.Status >= http.StatusInternalServerError {
+		detail = ""
+	}
+
+	return problemType.New(detail)
+}
+
+// problemResponse is a Problem response of any operation
+type problemResponse struct {
+	// seconds, sent in Retry-After header if not nil
+	retryAfter *int
+	// sent in WWW-Authenticate header if not nil
+	challenge *string
+	Problem
+}
+
+func (r problemResponse) write(w http.ResponseWriter) error {
+	if r.retryAfter != nil {
+		w.Header().Set("Retry-After", fmt.Sprint(*r.retryAfter))
+	}
+
+	if r.challenge != nil {
+		w.Header().Set("WWW-Authenticate", *r.challenge)
+	}
+
+	return writeProblem(w, r.Problem)
+}
+
+func (r problemResponse) VisitPersonListResponse(w http.ResponseWriter) error {
+	return r.write(w)
+}
+
+func (r problemResponse) VisitPersonGetResponse(w http.ResponseWriter) error {
+	return r.write(w)
+}
+
+func (r problemResponse) VisitPersonPostResponse(w http.ResponseWriter) error {
+	return r.write(w)
+}
+
+func (r problemResponse) VisitPersonBatchPostResponse(w http.ResponseWriter) error {
+	return r.write(w)
+}
+
+func (r problemResponse) VisitPersonPutResponse(w http.ResponseWriter) error {
+	return r.write(w)
+}
+
+func (r problemResponse) VisitPersonPatchResponse(w http.ResponseWriter) error {
+	return r.write(w)
+}
+
+func (r problemResponse) VisitPersonDeleteResponse(w http.ResponseWriter) error {
+	return r.write(w)
+}
+
+func (r problemResponse) VisitPersonRestoreResponse(w http.ResponseWriter) error {
+	return r.write(w)
+}
+
+func (r problemResponse) VisitPersonHistoryResponse(w http.ResponseWriter) error {
+	return r.write(w)
+}
+
+func (r problemResponse) VisitAuditListResponse(w http.ResponseWriter) error {
+	return r.write(w)
+}
+
+func writeProblem(w http.ResponseWriter, problem Problem) error {
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(problem.Status)
+
+	return json.NewEncoder(w).Encode(problem) //nolint:wrapcheck
+}
+
+// problem converts an error to a problem response and logs it.
+// The error is only logged, clients get the detail of ProblemOf.
+func (s *Server) problem(ctx context.Context, message string, err error) problemResponse {
+	problemType := ProblemTypeOf(err)
+
+	level := slog.LevelDebug
+	if problemType.Status >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+
+	s.Logger.Log(ctx, level, message,
+		slog.String("message", err.Error()),
+		slog.String("problem", problemType.URI()))
+
+	response := problemResponse{retryAfter: nil, challenge: nil, Problem: ProblemOf(err)}
+
+	if problemType == ProblemEnrichmentLimit {
+		unlockingTime, unlockErr := s.Completer.UnlockingTime()
+		if unlockErr != nil {
+			return problemResponse{retryAfter: nil, challenge: nil, Problem: ProblemInternal.New("")}
+		}
+
+		retryAfter := max(0, int(time.Until(unlockingTime).Seconds()))
+		response.retryAfter = &retryAfter
+	}
+
+	return response
+}
+
+func writeRequestProblem(w http.ResponseWriter, r *http.Request, problem Problem) {
+	instance := r.URL.EscapedPath()
+	problem.Instance = &instance
+
+	_ = writeProblem(w, problem)
+}
+
+// StrictHandlerOptions returns options for NewStrictHandlerWithOptions that
+// render body decoding and response errors as problems
+func StrictHandlerOptions() StrictHTTPServerOptions {
+	return StrictHTTPServerOptions{
+		RequestErrorHandlerFunc: func(w http.ResponseWriter, r *http.Request, err error) {
+			writeRequestProblem(w, r, ProblemInvalidBody.New(err.Error()))
+		},
+		ResponseErrorHandlerFunc: func(w http.ResponseWriter, r *http.Request, err error) {
+			writeRequestProblem(w, r, ProblemInternal.New(""))
+		},
+	}
+}
+
+// ParamErrorHandler renders parameter binding errors as problems.
+// Used as GorillaServerOptions.ErrorHandlerFunc.
+func ParamErrorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	var (
+		name          string
+		invalidFormat *InvalidParamFormatError
+		required      *RequiredParamError
+		unmarshaling  *UnmarshalingParamError
+		tooMany       *TooManyValuesForParamError
+	)
+
+	switch {
+	case errors.As(err, &invalidFormat):
+		name = invalidFormat.ParamName
+	case errors.As(err, &required):
+		name = required.ParamName
+	case errors.As(err, &unmarshaling):
+		name = unmarshaling.ParamName
+	case errors.As(err, &tooMany):
+		name = tooMany.ParamName
+	default:
+		writeRequestProblem(w, r, ProblemValidation.New(err.Error()))
+
+		return
+	}
+
+	location := ProblemInvalidParamIn("query")
+	if _, found := mux.Vars(r)[name]; found {
+		location = "path"
+	}
+
+	problem := ProblemValidation.New(err.Error())
+	problem.InvalidParams = &[]ProblemInvalidParam{{In: location, Name: name, Reason: err.Error()}}
+
+	writeRequestProblem(w, r, problem)
+}
+
+// NotFoundHandler renders unknown routes as problems
+func NotFoundHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeRequestProblem(w, r, ProblemRouteNotFound.New(""))
+	})
+}
+
+// MethodNotAllowedHandler renders unsupported methods as problems
+func MethodNotAllowedHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeRequestProblem(w, r, ProblemMethodNotAllowed.New(""))
+	})
+}
+
+// validationError carries a Problem from MultiErrorHandler to ErrorHandler
+// of the validator middleware - the latter only receives the error message,
+// so the Problem is encoded as JSON.
+type validationError struct {
+	problem Problem
+}
+
+func (e validationError) Error() string {
+	data, err := json.Marshal(e.problem)
+	if err != nil {
+		return e.problem.Title
+	}
+
+	return string(data)
+}
+
+// ValidatorOptions returns options for the OpenAPI validator middleware
+// (nethttp-middleware) that render validation errors as problems with
+// the paths of invalid fields
+func ValidatorOptions() *middleware.Options {
+	//nolint:exhaustruct
+	return &middleware.Options{
+		Options: openapi3filter.Options{
+			MultiError: true,
+			// credentials are checked by AuthMiddleware
+			AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+		},
+		ErrorHandler:          validatorErrorHandler,
+		MultiErrorHandler:     validatorMultiErrorHandler,
+		SilenceServersWarning: true,
+	}
+}
+
+func validatorErrorHandler(w http.ResponseWriter, message string, statusCode int) {
+	var problem Problem
+
+	if err := json.Unmarshal([]byte(message), &problem); err != nil || problem.Type == "" {
+		problemType := ProblemValidation
+
+		switch statusCode {
+		case http.StatusNotFound:
+			problemType = ProblemRouteNotFound
+		case http.StatusUnauthorized:
+			problemType = ProblemUnauthorized
+		case http.StatusInternalServerError:
+			problemType = ProblemInternal
+		}
+
+		problem = problemType.New(message)
+	}
+
+	_ = writeProblem(w, problem)
+}
+
+func validatorMultiErrorHandler(errs openapi3.MultiError) (int, error) {
+	problemType := ProblemValidation
+	invalidParams := make([]ProblemInvalidParam, 0, len(errs))
+
+	for _, err := range errs {
+		var securityErr *openapi3filter.SecurityRequirementsError
+		if errors.As(err, &securityErr) {
+			problemType = ProblemUnauthorized
+
+			continue
+		}
+
+		invalidParams = append(invalidParams, requestInvalidParams(err)...)
+	}
+
+	problem := problemType.New(strings.Split(errs.Error(), "\n")[0])
+	if len(invalidParams) > 0 {
+		problem.InvalidParams = &invalidParams
+	}
+
+	return problemType.Status, validationError{problem}
+}
+
+// requestInvalidParams converts a validation error to invalid params
+func requestInvalidParams(err error) []ProblemInvalidParam {
+	var requestErr *openapi3filter.RequestError
+	if !errors.As(err, &requestErr) {
+		return []ProblemInvalidParam{{In: "body", Name: "", Reason: err.Error()}}
+	}
+
+	if requestErr.Parameter != nil {
+		reason := requestErr.Reason
+		if requestErr.Err != nil {
+			reason = schemaReason(requestErr.Err)
+		}
+
+		return []ProblemInvalidParam{{
+			In:     ProblemInvalidParamIn(requestErr.Parameter.In),
+			Name:   requestErr.Parameter.Name,
+			Reason: reason,
+		}}
+	}
+
+	// request body
+	var nested openapi3.MultiError
+	if !errors.As(requestErr.Err, &nested) {
+		nested = openapi3.MultiError{requestErr.Err}
+	}
+
+	params := make([]ProblemInvalidParam, 0, len(nested))
+
+	for _, err := range nested {
+		param := ProblemInvalidParam{In: "body", Name: "", Reason: schemaReason(err)}
+
+		var schemaErr *openapi3.SchemaError
+		if errors.As(err, &schemaErr) && len(schemaErr.JSONPointer()) > 0 {
+			param.Name = "/" + strings.Join(schemaErr.JSONPointer(), "/")
+		}
+
+		params = append(params, param)
+	}
+
+	return params
+}
+
+func schemaReason(err error) string {
+	var schemaErr *openapi3.SchemaError
+	if errors.As(err, &schemaErr) {
+		return schemaErr.Reason
+	}
+
+	if err == nil {
+		return ""
+	}
+
+	return err.Error()
+}
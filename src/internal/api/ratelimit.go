@@ -0,0 +1,226 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/Hofsiedge/person-api/internal/domain"
+	"github.com/Hofsiedge/person-api/internal/repo"
+	"github.com/Hofsiedge/person-api/internal/reqctx"
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+const (
+	// RateLimitCostExtension is the number of tokens an operation takes
+	// from the bucket of the client (1 if not set)
+	RateLimitCostExtension = "x-rate-limit-cost"
+	// bucket capacity
+	RateLimitLimitHeader = "RateLimit-Limit"
+	// tokens left in the bucket
+	RateLimitRemainingHeader = "RateLimit-Remaining"
+	// seconds until the bucket is full
+	RateLimitResetHeader = "RateLimit-Reset"
+)
+
+var ErrRateLimit = errors.New("invalid rate limit")
+
+// RateLimitCosts maps operation names to the number of tokens
+// a request takes
+type RateLimitCosts map[string]float64
+
+// RateLimitCostsFromSpec reads x-rate-limit-cost of the operations
+func RateLimitCostsFromSpec(spec *openapi3.T) (RateLimitCosts, error) {
+	costs := make(RateLimitCosts)
+
+	for _, path := range spec.Paths {
+		for _, operation := range path.Operations() {
+			value, found := operation.Extensions[RateLimitCostExtension]
+			if !found {
+				continue
+			}
+
+			cost, ok := value.(float64)
+			if !ok {
+				return nil, fmt.Errorf("%w: %s of %s is not a number", ErrRateLimit, RateLimitCostExtension, operation.OperationID)
+			}
+
+			costs[operationName(operation.OperationID)] = cost
+		}
+	}
+
+	return costs, nil
+}
+
+// Override returns the costs with the operations of other replaced
+func (c RateLimitCosts) Override(other RateLimitCosts) RateLimitCosts {
+	result := make(RateLimitCosts, len(c)+len(other))
+	for operationID, cost := range c {
+		result[operationName(operationID)] = cost
+	}
+
+	for operationID, cost := range other {
+		result[operationName(operationID)] = cost
+	}
+
+	return result
+}
+
+type RateLimitOptions struct {
+	// costs of operations, 1 by default
+	Costs RateLimitCosts
+	// bucket of a client (or of an address for anonymous requests)
+	Bucket domain.TokenBucket
+	// bucket of an address taking a token for every request before it is
+	// authenticated, no limit if the capacity is 0
+	AddressBucket domain.TokenBucket
+}
+
+// Validate checks that the buckets are valid and every request can be
+// allowed: costs are not negative and do not exceed the capacity
+func (o RateLimitOptions) Validate() error {
+	if o.Bucket.Capacity <= 0 || o.Bucket.Rate <= 0 {
+		return fmt.Errorf("%w: capacity %v and rate %v must be positive", ErrRateLimit, o.Bucket.Capacity, o.Bucket.Rate)
+	}
+
+	if o.AddressBucket.Capacity != 0 && (o.AddressBucket.Capacity < 1 || o.AddressBucket.Rate <= 0) {
+		return fmt.Errorf("%w: address capacity %v must be 0 or at least 1 with a positive rate %v",
+			ErrRateLimit, o.AddressBucket.Capacity, o.AddressBucket.Rate)
+	}
+
+	for operationID, cost := range o.Costs {
+		if cost < 0 || cost > o.Bucket.Capacity {
+			return fmt.Errorf("%w: cost %v of %s is not in [0, %v]", ErrRateLimit, cost, operationID, o.Bucket.Capacity)
+		}
+	}
+
+	return nil
+}
+
+// RateLimiter limits the rate of requests of every client with a token
+// bucket. Its options can be replaced while it is serving.
+type RateLimiter struct {
+	buckets repo.RateLimitRepo
+	logger  *slog.Logger
+	options atomic.Pointer[RateLimitOptions]
+}
+
+// NewRateLimiter returns a RateLimiter with valid options
+// (see RateLimitOptions.Validate)
+func NewRateLimiter(buckets repo.RateLimitRepo, options RateLimitOptions, logger *slog.Logger) *RateLimiter {
+	limiter := &RateLimiter{
+		buckets: buckets,
+		logger:  logger,
+		options: atomic.Pointer[RateLimitOptions]{},
+	}
+	limiter.SetOptions(options)
+
+	return limiter
+}
+
+// SetOptions replaces the options. Existing buckets are kept, so clients
+// keep their tokens (up to the new capacity).
+func (l *RateLimiter) SetOptions(options RateLimitOptions) {
+	options.Costs = RateLimitCosts{}.Override(options.Costs)
+	l.options.Store(&options)
+}
+
+// limit takes cost tokens from the bucket of the key and sets RateLimit-*
+// headers. Returns a 429 problem if the limit is exceeded. Requests are
+// allowed if the buckets are unavailable.
+func (l *RateLimiter) limit(
+	ctx context.Context, w http.ResponseWriter, key, operationID string, cost float64, bucket domain.TokenBucket,
+) *problemResponse {
+	result, err := l.buckets.Take(ctx, key, cost, bucket)
+	if err != nil {
+		l.logger.ErrorContext(ctx, "could not check the rate limit",
+			slog.String("operation", operationID),
+			slog.String("message", err.Error()))
+
+		return nil
+	}
+
+	reset := bucket.Until(result.Tokens, bucket.Capacity)
+	w.Header().Set(RateLimitLimitHeader, fmt.Sprint(math.Floor(bucket.Capacity)))
+	w.Header().Set(RateLimitRemainingHeader, fmt.Sprint(math.Floor(result.Tokens)))
+	w.Header().Set(RateLimitResetHeader, fmt.Sprint(math.Ceil(reset.Seconds())))
+
+	if result.Allowed {
+		return nil
+	}
+
+	l.logger.DebugContext(ctx, "rate limit exceeded",
+		slog.String("operation", operationID),
+		slog.String("key", key))
+
+	retryAfter := int(math.Ceil(bucket.Until(result.Tokens, cost).Seconds()))
+
+	return &problemResponse{
+		retryAfter: &retryAfter,
+		challenge:  nil,
+		Problem:    ProblemRateLimited.New(fmt.Sprintf("the operation costs %v tokens", cost)),
+	}
+}
+
+// Middleware takes a token of the address bucket (see ClientIPMiddleware)
+// for every request before it is validated and authenticated, so that
+// floods of invalid requests and attempts to guess credentials are limited.
+// The address bucket is larger than the bucket of a client, so that clients
+// behind one NAT do not share their limits. operationNames names
+// the operation of a request (see OperationNames) for logs.
+// Requests exceeding the limit get a 429 problem with Retry-After.
+func (l *RateLimiter) Middleware(operationNames func(r *http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			bucket := l.options.Load().AddressBucket
+			if bucket.Capacity == 0 {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			problem := l.limit(r.Context(), w, "address:"+reqctx.ClientIP(r.Context()), operationNames(r), 1, bucket)
+			if problem == nil {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			instance := r.URL.EscapedPath()
+			problem.Instance = &instance
+			_ = problem.write(w)
+		})
+	}
+}
+
+// StrictMiddleware takes the cost of the operation from the bucket of the
+// authenticated client (by the actor, so that a client has the same limit
+// from any address) or of the address for anonymous requests. It must be
+// inside AuthMiddleware (before it in the middleware list). Responses carry
+// RateLimit-* headers of the bucket, requests exceeding the limit get a 429
+// problem with Retry-After.
+func (l *RateLimiter) StrictMiddleware(f StrictHandlerFunc, operationID string) StrictHandlerFunc {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request, request any) (any, error) {
+		options := l.options.Load()
+
+		cost, found := options.Costs[operationID]
+		if !found {
+			cost = 1
+		}
+
+		key := "ip:" + reqctx.ClientIP(ctx)
+		if actor := reqctx.Actor(ctx); actor != "" {
+			key = "actor:" + actor
+		}
+
+		if problem := l.limit(ctx, w, key, operationID, cost, options.Bucket); problem != nil {
+			return *problem, nil
+		}
+
+		return f(ctx, w, r, request)
+	}
+}
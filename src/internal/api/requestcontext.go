@@ -0,0 +1,123 @@
+package api
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Hofsiedge/person-api/internal/reqctx"
+	"github.com/google/uuid"
+)
+
+// ClientIPMiddleware records the IP address of the client in the request
+// context (reqctx.ClientIP). The address is taken from the connection:
+// forwarding headers can be forged and are not trusted.
+func ClientIPMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+
+		if net.ParseIP(host) != nil {
+			r = r.WithContext(reqctx.WithClientIP(r.Context(), host))
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// maximum length of an accepted X-Request-ID
+const maxRequestIDLength = 128
+
+// validRequestID reports whether the request ID of a client can be used:
+// it must be short and consist of printable ASCII characters, so that it
+// can not forge log records or headers
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+
+	for _, char := range requestID {
+		if char < '!' || char > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// RequestIDMiddleware records the request ID in the request context
+// (reqctx.RequestID) and returns it in the X-Request-ID response header.
+// A valid X-Request-ID of the request is used, otherwise a new one
+// is generated.
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(reqctx.RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+
+		w.Header().Set(reqctx.RequestIDHeader, requestID)
+		next.ServeHTTP(w, r.WithContext(reqctx.WithRequestID(r.Context(), requestID)))
+	})
+}
+
+const (
+	// ReadYourWritesHeader makes the reads of a request go to the primary
+	// database ("true")
+	ReadYourWritesHeader = "X-Read-Your-Writes"
+	// ReadYourWritesCookie is set by writes: the reads of the client go to
+	// the primary database while it is set
+	ReadYourWritesCookie = "read_your_writes"
+)
+
+// writeMethods are the methods of the operations changing people
+//
+//nolint:gochecknoglobals
+var writeMethods = map[string]bool{
+	http.MethodPost:   true,
+	http.MethodPut:    true,
+	http.MethodPatch:  true,
+	http.MethodDelete: true,
+}
+
+// ReadYourWritesMiddleware routes the reads of a client that has to see
+// its own writes to the primary database (reqctx.WithPrimaryReads) rather
+// than to a possibly lagging replica: reads of requests with
+// X-Read-Your-Writes: true and of requests with the read_your_writes cookie.
+// Writes set the cookie for window (the greatest lag of the replicas that
+// are read from), clients that do not keep cookies send the header.
+func ReadYourWritesMiddleware(window time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			primary, _ := strconv.ParseBool(r.Header.Get(ReadYourWritesHeader))
+
+			if _, err := r.Cookie(ReadYourWritesCookie); err == nil {
+				primary = true
+			}
+
+			if writeMethods[r.Method] {
+				primary = true
+
+				//nolint:exhaustruct
+				http.SetCookie(w, &http.Cookie{
+					Name:     ReadYourWritesCookie,
+					Value:    "true",
+					Path:     "/",
+					MaxAge:   int(math.Ceil(window.Seconds())),
+					HttpOnly: true,
+					SameSite: http.SameSiteStrictMode,
+				})
+			}
+
+			if primary {
+				r = r.WithContext(reqctx.WithPrimaryReads(r.Context()))
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
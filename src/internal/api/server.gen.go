@@ -8,6 +8,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/oapi-codegen/runtime"
@@ -16,24 +17,36 @@ import (
 
 // ServerInterface represents all server handlers.
 type ServerInterface interface {
+	// List audit entries
+	// (GET /audit)
+	AuditList(w http.ResponseWriter, r *http.Request, params AuditListParams)
 	// List Person records
 	// (GET /person)
 	PersonList(w http.ResponseWriter, r *http.Request, params PersonListParams)
 	// Create a Person
 	// (POST /person)
-	PersonPost(w http.ResponseWriter, r *http.Request)
+	PersonPost(w http.ResponseWriter, r *http.Request, params PersonPostParams)
+	// Create several people
+	// (POST /person/batch)
+	PersonBatchPost(w http.ResponseWriter, r *http.Request, params PersonBatchPostParams)
 	// Delete a Person by id
 	// (DELETE /person/{personID})
 	PersonDelete(w http.ResponseWriter, r *http.Request, personID PersonID)
 	// Get a Person by id
 	// (GET /person/{personID})
-	PersonGet(w http.ResponseWriter, r *http.Request, personID PersonID)
-	// Update a part of Person (via JSON Merge Patch)
+	PersonGet(w http.ResponseWriter, r *http.Request, personID PersonID, params PersonGetParams)
+	// Update a part of Person (via JSON Merge Patch or JSON Patch)
 	// (PATCH /person/{personID})
 	PersonPatch(w http.ResponseWriter, r *http.Request, personID PersonID)
 	// Replace a Person
 	// (PUT /person/{personID})
 	PersonPut(w http.ResponseWriter, r *http.Request, personID PersonID)
+	// Get the change history of a Person
+	// (GET /person/{personID}/history)
+	PersonHistory(w http.ResponseWriter, r *http.Request, personID PersonID, params PersonHistoryParams)
+	// Restore a deleted Person
+	// (POST /person/{personID}/restore)
+	PersonRestore(w http.ResponseWriter, r *http.Request, personID PersonID, params PersonRestoreParams)
 }
 
 // ServerInterfaceWrapper converts contexts to parameters.
@@ -45,12 +58,92 @@ type ServerInterfaceWrapper struct {
 
 type MiddlewareFunc func(http.Handler) http.Handler
 
+// AuditList operation middleware
+func (siw *ServerInterfaceWrapper) AuditList(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	ctx = context.WithValue(ctx, ApiKeyScopes, []string{})
+
+	ctx = context.WithValue(ctx, BasicAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params AuditListParams
+
+	// ------------- Optional query parameter "actor" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "actor", r.URL.Query(), &params.Actor)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "actor", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "person_id" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "person_id", r.URL.Query(), &params.PersonId)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "person_id", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "from" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "from", r.URL.Query(), &params.From)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "from", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "to" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "to", r.URL.Query(), &params.To)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "to", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "offset" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "offset", r.URL.Query(), &params.Offset)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "offset", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", r.URL.Query(), &params.Limit)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "limit", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.AuditList(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
 // PersonList operation middleware
 func (siw *ServerInterfaceWrapper) PersonList(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	var err error
 
+	ctx = context.WithValue(ctx, ApiKeyScopes, []string{})
+
+	ctx = context.WithValue(ctx, BasicAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
 	// Parameter object where we will unmarshal all parameters from the context
 	var params PersonListParams
 
@@ -118,6 +211,14 @@ func (siw *ServerInterfaceWrapper) PersonList(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	// ------------- Optional query parameter "match" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "match", r.URL.Query(), &params.Match)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "match", Err: err})
+		return
+	}
+
 	// ------------- Optional query parameter "offset" -------------
 
 	err = runtime.BindQueryParameter("form", true, false, "offset", r.URL.Query(), &params.Offset)
@@ -134,6 +235,22 @@ func (siw *ServerInterfaceWrapper) PersonList(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	// ------------- Optional query parameter "include_deleted" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "include_deleted", r.URL.Query(), &params.IncludeDeleted)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "include_deleted", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "total" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "total", r.URL.Query(), &params.Total)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "total", Err: err})
+		return
+	}
+
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		siw.Handler.PersonList(w, r, params)
 	}))
@@ -149,8 +266,87 @@ func (siw *ServerInterfaceWrapper) PersonList(w http.ResponseWriter, r *http.Req
 func (siw *ServerInterfaceWrapper) PersonPost(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
+	var err error
+
+	ctx = context.WithValue(ctx, ApiKeyScopes, []string{})
+
+	ctx = context.WithValue(ctx, BasicAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params PersonPostParams
+
+	headers := r.Header
+
+	// ------------- Optional header parameter "Idempotency-Key" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("Idempotency-Key")]; found {
+		var IdempotencyKey IdempotencyKey
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "Idempotency-Key", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithLocation("simple", false, "Idempotency-Key", runtime.ParamLocationHeader, valueList[0], &IdempotencyKey)
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "Idempotency-Key", Err: err})
+			return
+		}
+
+		params.IdempotencyKey = &IdempotencyKey
+
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PersonPost(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// PersonBatchPost operation middleware
+func (siw *ServerInterfaceWrapper) PersonBatchPost(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	ctx = context.WithValue(ctx, ApiKeyScopes, []string{})
+
+	ctx = context.WithValue(ctx, BasicAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params PersonBatchPostParams
+
+	headers := r.Header
+
+	// ------------- Optional header parameter "Idempotency-Key" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("Idempotency-Key")]; found {
+		var IdempotencyKey IdempotencyKey
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "Idempotency-Key", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithLocation("simple", false, "Idempotency-Key", runtime.ParamLocationHeader, valueList[0], &IdempotencyKey)
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "Idempotency-Key", Err: err})
+			return
+		}
+
+		params.IdempotencyKey = &IdempotencyKey
+
+	}
+
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.PersonPost(w, r)
+		siw.Handler.PersonBatchPost(w, r, params)
 	}))
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -175,6 +371,12 @@ func (siw *ServerInterfaceWrapper) PersonDelete(w http.ResponseWriter, r *http.R
 		return
 	}
 
+	ctx = context.WithValue(ctx, ApiKeyScopes, []string{})
+
+	ctx = context.WithValue(ctx, BasicAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		siw.Handler.PersonDelete(w, r, personID)
 	}))
@@ -201,8 +403,25 @@ func (siw *ServerInterfaceWrapper) PersonGet(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	ctx = context.WithValue(ctx, ApiKeyScopes, []string{})
+
+	ctx = context.WithValue(ctx, BasicAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params PersonGetParams
+
+	// ------------- Optional query parameter "as_of" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "as_of", r.URL.Query(), &params.AsOf)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "as_of", Err: err})
+		return
+	}
+
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.PersonGet(w, r, personID)
+		siw.Handler.PersonGet(w, r, personID, params)
 	}))
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -227,6 +446,12 @@ func (siw *ServerInterfaceWrapper) PersonPatch(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	ctx = context.WithValue(ctx, ApiKeyScopes, []string{})
+
+	ctx = context.WithValue(ctx, BasicAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		siw.Handler.PersonPatch(w, r, personID)
 	}))
@@ -253,6 +478,12 @@ func (siw *ServerInterfaceWrapper) PersonPut(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	ctx = context.WithValue(ctx, ApiKeyScopes, []string{})
+
+	ctx = context.WithValue(ctx, BasicAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		siw.Handler.PersonPut(w, r, personID)
 	}))
@@ -264,6 +495,113 @@ func (siw *ServerInterfaceWrapper) PersonPut(w http.ResponseWriter, r *http.Requ
 	handler.ServeHTTP(w, r.WithContext(ctx))
 }
 
+// PersonHistory operation middleware
+func (siw *ServerInterfaceWrapper) PersonHistory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "personID" -------------
+	var personID PersonID
+
+	err = runtime.BindStyledParameter("simple", false, "personID", mux.Vars(r)["personID"], &personID)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "personID", Err: err})
+		return
+	}
+
+	ctx = context.WithValue(ctx, ApiKeyScopes, []string{})
+
+	ctx = context.WithValue(ctx, BasicAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params PersonHistoryParams
+
+	// ------------- Optional query parameter "offset" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "offset", r.URL.Query(), &params.Offset)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "offset", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", r.URL.Query(), &params.Limit)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "limit", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PersonHistory(w, r, personID, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// PersonRestore operation middleware
+func (siw *ServerInterfaceWrapper) PersonRestore(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "personID" -------------
+	var personID PersonID
+
+	err = runtime.BindStyledParameter("simple", false, "personID", mux.Vars(r)["personID"], &personID)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "personID", Err: err})
+		return
+	}
+
+	ctx = context.WithValue(ctx, ApiKeyScopes, []string{})
+
+	ctx = context.WithValue(ctx, BasicAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params PersonRestoreParams
+
+	headers := r.Header
+
+	// ------------- Optional header parameter "Idempotency-Key" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("Idempotency-Key")]; found {
+		var IdempotencyKey IdempotencyKey
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "Idempotency-Key", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithLocation("simple", false, "Idempotency-Key", runtime.ParamLocationHeader, valueList[0], &IdempotencyKey)
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "Idempotency-Key", Err: err})
+			return
+		}
+
+		params.IdempotencyKey = &IdempotencyKey
+
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PersonRestore(w, r, personID, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
 type UnescapedCookieParamError struct {
 	ParamName string
 	Err       error
@@ -377,10 +715,14 @@ func HandlerWithOptions(si ServerInterface, options GorillaServerOptions) http.H
 		ErrorHandlerFunc:   options.ErrorHandlerFunc,
 	}
 
+	r.HandleFunc(options.BaseURL+"/audit", wrapper.AuditList).Methods("GET")
+
 	r.HandleFunc(options.BaseURL+"/person", wrapper.PersonList).Methods("GET")
 
 	r.HandleFunc(options.BaseURL+"/person", wrapper.PersonPost).Methods("POST")
 
+	r.HandleFunc(options.BaseURL+"/person/batch", wrapper.PersonBatchPost).Methods("POST")
+
 	r.HandleFunc(options.BaseURL+"/person/{personID}", wrapper.PersonDelete).Methods("DELETE")
 
 	r.HandleFunc(options.BaseURL+"/person/{personID}", wrapper.PersonGet).Methods("GET")
@@ -389,103 +731,420 @@ func HandlerWithOptions(si ServerInterface, options GorillaServerOptions) http.H
 
 	r.HandleFunc(options.BaseURL+"/person/{personID}", wrapper.PersonPut).Methods("PUT")
 
+	r.HandleFunc(options.BaseURL+"/person/{personID}/history", wrapper.PersonHistory).Methods("GET")
+
+	r.HandleFunc(options.BaseURL+"/person/{personID}/restore", wrapper.PersonRestore).Methods("POST")
+
 	return r
 }
 
-type N404NotFoundResponse struct {
-}
+type N400BadRequestApplicationProblemPlusJSONResponse Problem
 
-type N5XXInternalServerErrorResponse struct {
+type N401UnauthorizedResponseHeaders struct {
+	WWWAuthenticate string
 }
+type N401UnauthorizedApplicationProblemPlusJSONResponse struct {
+	Body Problem
 
-type PersonListRequestObject struct {
-	Params PersonListParams
+	Headers N401UnauthorizedResponseHeaders
 }
 
-type PersonListResponseObject interface {
-	VisitPersonListResponse(w http.ResponseWriter) error
-}
+type N403ForbiddenApplicationProblemPlusJSONResponse Problem
 
-type PersonList200JSONResponse PersonPage
+type N404NotFoundApplicationProblemPlusJSONResponse Problem
 
-func (response PersonList200JSONResponse) VisitPersonListResponse(w http.ResponseWriter) error {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(200)
+type N409ConflictApplicationProblemPlusJSONResponse Problem
 
-	return json.NewEncoder(w).Encode(response)
-}
+type N422InvalidNameApplicationProblemPlusJSONResponse Problem
 
-type PersonList400Response struct {
+type N429TooManyRequestsResponseHeaders struct {
+	RetryAfter int
 }
+type N429TooManyRequestsApplicationProblemPlusJSONResponse struct {
+	Body Problem
 
-func (response PersonList400Response) VisitPersonListResponse(w http.ResponseWriter) error {
-	w.WriteHeader(400)
-	return nil
+	Headers N429TooManyRequestsResponseHeaders
 }
 
-type PersonList5XXResponse struct {
-	StatusCode int
+type N503UnavailableResponseHeaders struct {
+	RetryAfter int
 }
+type N503UnavailableApplicationProblemPlusJSONResponse struct {
+	Body Problem
 
-func (response PersonList5XXResponse) VisitPersonListResponse(w http.ResponseWriter) error {
-	w.WriteHeader(response.StatusCode)
-	return nil
+	Headers N503UnavailableResponseHeaders
 }
 
-type PersonPostRequestObject struct {
-	Body *PersonPostJSONRequestBody
+type N5XXInternalServerErrorApplicationProblemPlusJSONResponse Problem
+
+type AuditListRequestObject struct {
+	Params AuditListParams
 }
 
-type PersonPostResponseObject interface {
-	VisitPersonPostResponse(w http.ResponseWriter) error
+type AuditListResponseObject interface {
+	VisitAuditListResponse(w http.ResponseWriter) error
 }
 
-type PersonPost201JSONResponse PostCreatedResponse
+type AuditList200JSONResponse AuditPage
 
-func (response PersonPost201JSONResponse) VisitPersonPostResponse(w http.ResponseWriter) error {
+func (response AuditList200JSONResponse) VisitAuditListResponse(w http.ResponseWriter) error {
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(201)
+	w.WriteHeader(200)
 
 	return json.NewEncoder(w).Encode(response)
 }
 
-type PersonPost400Response struct {
+type AuditList400ApplicationProblemPlusJSONResponse struct {
+	N400BadRequestApplicationProblemPlusJSONResponse
 }
 
-func (response PersonPost400Response) VisitPersonPostResponse(w http.ResponseWriter) error {
+func (response AuditList400ApplicationProblemPlusJSONResponse) VisitAuditListResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
 	w.WriteHeader(400)
-	return nil
+
+	return json.NewEncoder(w).Encode(response)
 }
 
-type PersonPost422Response struct {
+type AuditList401ApplicationProblemPlusJSONResponse struct {
+	N401UnauthorizedApplicationProblemPlusJSONResponse
 }
 
-func (response PersonPost422Response) VisitPersonPostResponse(w http.ResponseWriter) error {
-	w.WriteHeader(422)
-	return nil
+func (response AuditList401ApplicationProblemPlusJSONResponse) VisitAuditListResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("WWW-Authenticate", fmt.Sprint(response.Headers.WWWAuthenticate))
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response.Body)
 }
 
-type PersonPost503ResponseHeaders struct {
-	RetryAfter int
+type AuditList403ApplicationProblemPlusJSONResponse struct {
+	N403ForbiddenApplicationProblemPlusJSONResponse
+}
+
+func (response AuditList403ApplicationProblemPlusJSONResponse) VisitAuditListResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
 }
 
-type PersonPost503Response struct {
-	Headers PersonPost503ResponseHeaders
+type AuditList429ApplicationProblemPlusJSONResponse struct {
+	N429TooManyRequestsApplicationProblemPlusJSONResponse
 }
 
-func (response PersonPost503Response) VisitPersonPostResponse(w http.ResponseWriter) error {
+func (response AuditList429ApplicationProblemPlusJSONResponse) VisitAuditListResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("Retry-After", fmt.Sprint(response.Headers.RetryAfter))
+	w.WriteHeader(429)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type AuditList5XXApplicationProblemPlusJSONResponse struct {
+	Body       Problem
+	StatusCode int
+}
+
+func (response AuditList5XXApplicationProblemPlusJSONResponse) VisitAuditListResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type PersonListRequestObject struct {
+	Params PersonListParams
+}
+
+type PersonListResponseObject interface {
+	VisitPersonListResponse(w http.ResponseWriter) error
+}
+
+type PersonList200JSONResponse PersonPage
+
+func (response PersonList200JSONResponse) VisitPersonListResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PersonList400ApplicationProblemPlusJSONResponse struct {
+	N400BadRequestApplicationProblemPlusJSONResponse
+}
+
+func (response PersonList400ApplicationProblemPlusJSONResponse) VisitPersonListResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PersonList401ApplicationProblemPlusJSONResponse struct {
+	N401UnauthorizedApplicationProblemPlusJSONResponse
+}
+
+func (response PersonList401ApplicationProblemPlusJSONResponse) VisitPersonListResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("WWW-Authenticate", fmt.Sprint(response.Headers.WWWAuthenticate))
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type PersonList403ApplicationProblemPlusJSONResponse struct {
+	N403ForbiddenApplicationProblemPlusJSONResponse
+}
+
+func (response PersonList403ApplicationProblemPlusJSONResponse) VisitPersonListResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PersonList429ApplicationProblemPlusJSONResponse struct {
+	N429TooManyRequestsApplicationProblemPlusJSONResponse
+}
+
+func (response PersonList429ApplicationProblemPlusJSONResponse) VisitPersonListResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("Retry-After", fmt.Sprint(response.Headers.RetryAfter))
+	w.WriteHeader(429)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type PersonList5XXApplicationProblemPlusJSONResponse struct {
+	Body       Problem
+	StatusCode int
+}
+
+func (response PersonList5XXApplicationProblemPlusJSONResponse) VisitPersonListResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type PersonPostRequestObject struct {
+	Params PersonPostParams
+	Body   *PersonPostJSONRequestBody
+}
+
+type PersonPostResponseObject interface {
+	VisitPersonPostResponse(w http.ResponseWriter) error
+}
+
+type PersonPost201JSONResponse PostCreatedResponse
+
+func (response PersonPost201JSONResponse) VisitPersonPostResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(201)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PersonPost400ApplicationProblemPlusJSONResponse struct {
+	N400BadRequestApplicationProblemPlusJSONResponse
+}
+
+func (response PersonPost400ApplicationProblemPlusJSONResponse) VisitPersonPostResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PersonPost401ApplicationProblemPlusJSONResponse struct {
+	N401UnauthorizedApplicationProblemPlusJSONResponse
+}
+
+func (response PersonPost401ApplicationProblemPlusJSONResponse) VisitPersonPostResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("WWW-Authenticate", fmt.Sprint(response.Headers.WWWAuthenticate))
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type PersonPost403ApplicationProblemPlusJSONResponse struct {
+	N403ForbiddenApplicationProblemPlusJSONResponse
+}
+
+func (response PersonPost403ApplicationProblemPlusJSONResponse) VisitPersonPostResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PersonPost409ApplicationProblemPlusJSONResponse struct {
+	N409ConflictApplicationProblemPlusJSONResponse
+}
+
+func (response PersonPost409ApplicationProblemPlusJSONResponse) VisitPersonPostResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(409)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PersonPost422ApplicationProblemPlusJSONResponse struct {
+	N422InvalidNameApplicationProblemPlusJSONResponse
+}
+
+func (response PersonPost422ApplicationProblemPlusJSONResponse) VisitPersonPostResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(422)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PersonPost429ApplicationProblemPlusJSONResponse struct {
+	N429TooManyRequestsApplicationProblemPlusJSONResponse
+}
+
+func (response PersonPost429ApplicationProblemPlusJSONResponse) VisitPersonPostResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("Retry-After", fmt.Sprint(response.Headers.RetryAfter))
+	w.WriteHeader(429)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type PersonPost503ApplicationProblemPlusJSONResponse struct {
+	N503UnavailableApplicationProblemPlusJSONResponse
+}
+
+func (response PersonPost503ApplicationProblemPlusJSONResponse) VisitPersonPostResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
 	w.Header().Set("Retry-After", fmt.Sprint(response.Headers.RetryAfter))
 	w.WriteHeader(503)
-	return nil
+
+	return json.NewEncoder(w).Encode(response.Body)
 }
 
-type PersonPost5XXResponse struct {
+type PersonPost5XXApplicationProblemPlusJSONResponse struct {
+	Body       Problem
 	StatusCode int
 }
 
-func (response PersonPost5XXResponse) VisitPersonPostResponse(w http.ResponseWriter) error {
+func (response PersonPost5XXApplicationProblemPlusJSONResponse) VisitPersonPostResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
 	w.WriteHeader(response.StatusCode)
-	return nil
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type PersonBatchPostRequestObject struct {
+	Params PersonBatchPostParams
+	Body   *PersonBatchPostJSONRequestBody
+}
+
+type PersonBatchPostResponseObject interface {
+	VisitPersonBatchPostResponse(w http.ResponseWriter) error
+}
+
+type PersonBatchPost201JSONResponse BatchCreatedResponse
+
+func (response PersonBatchPost201JSONResponse) VisitPersonBatchPostResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(201)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PersonBatchPost400ApplicationProblemPlusJSONResponse struct {
+	N400BadRequestApplicationProblemPlusJSONResponse
+}
+
+func (response PersonBatchPost400ApplicationProblemPlusJSONResponse) VisitPersonBatchPostResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PersonBatchPost401ApplicationProblemPlusJSONResponse struct {
+	N401UnauthorizedApplicationProblemPlusJSONResponse
+}
+
+func (response PersonBatchPost401ApplicationProblemPlusJSONResponse) VisitPersonBatchPostResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("WWW-Authenticate", fmt.Sprint(response.Headers.WWWAuthenticate))
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type PersonBatchPost403ApplicationProblemPlusJSONResponse struct {
+	N403ForbiddenApplicationProblemPlusJSONResponse
+}
+
+func (response PersonBatchPost403ApplicationProblemPlusJSONResponse) VisitPersonBatchPostResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PersonBatchPost409ApplicationProblemPlusJSONResponse struct {
+	N409ConflictApplicationProblemPlusJSONResponse
+}
+
+func (response PersonBatchPost409ApplicationProblemPlusJSONResponse) VisitPersonBatchPostResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(409)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PersonBatchPost422ApplicationProblemPlusJSONResponse struct {
+	N422InvalidNameApplicationProblemPlusJSONResponse
+}
+
+func (response PersonBatchPost422ApplicationProblemPlusJSONResponse) VisitPersonBatchPostResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(422)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PersonBatchPost429ApplicationProblemPlusJSONResponse struct {
+	N429TooManyRequestsApplicationProblemPlusJSONResponse
+}
+
+func (response PersonBatchPost429ApplicationProblemPlusJSONResponse) VisitPersonBatchPostResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("Retry-After", fmt.Sprint(response.Headers.RetryAfter))
+	w.WriteHeader(429)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type PersonBatchPost503ApplicationProblemPlusJSONResponse struct {
+	N503UnavailableApplicationProblemPlusJSONResponse
+}
+
+func (response PersonBatchPost503ApplicationProblemPlusJSONResponse) VisitPersonBatchPostResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("Retry-After", fmt.Sprint(response.Headers.RetryAfter))
+	w.WriteHeader(503)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type PersonBatchPost5XXApplicationProblemPlusJSONResponse struct {
+	Body       Problem
+	StatusCode int
+}
+
+func (response PersonBatchPost5XXApplicationProblemPlusJSONResponse) VisitPersonBatchPostResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
 }
 
 type PersonDeleteRequestObject struct {
@@ -504,32 +1163,78 @@ func (response PersonDelete200Response) VisitPersonDeleteResponse(w http.Respons
 	return nil
 }
 
-type PersonDelete400Response struct {
+type PersonDelete400ApplicationProblemPlusJSONResponse struct {
+	N400BadRequestApplicationProblemPlusJSONResponse
 }
 
-func (response PersonDelete400Response) VisitPersonDeleteResponse(w http.ResponseWriter) error {
+func (response PersonDelete400ApplicationProblemPlusJSONResponse) VisitPersonDeleteResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
 	w.WriteHeader(400)
-	return nil
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PersonDelete401ApplicationProblemPlusJSONResponse struct {
+	N401UnauthorizedApplicationProblemPlusJSONResponse
+}
+
+func (response PersonDelete401ApplicationProblemPlusJSONResponse) VisitPersonDeleteResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("WWW-Authenticate", fmt.Sprint(response.Headers.WWWAuthenticate))
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type PersonDelete403ApplicationProblemPlusJSONResponse struct {
+	N403ForbiddenApplicationProblemPlusJSONResponse
+}
+
+func (response PersonDelete403ApplicationProblemPlusJSONResponse) VisitPersonDeleteResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
 }
 
-type PersonDelete404Response = N404NotFoundResponse
+type PersonDelete404ApplicationProblemPlusJSONResponse struct {
+	N404NotFoundApplicationProblemPlusJSONResponse
+}
 
-func (response PersonDelete404Response) VisitPersonDeleteResponse(w http.ResponseWriter) error {
+func (response PersonDelete404ApplicationProblemPlusJSONResponse) VisitPersonDeleteResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
 	w.WriteHeader(404)
-	return nil
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PersonDelete429ApplicationProblemPlusJSONResponse struct {
+	N429TooManyRequestsApplicationProblemPlusJSONResponse
+}
+
+func (response PersonDelete429ApplicationProblemPlusJSONResponse) VisitPersonDeleteResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("Retry-After", fmt.Sprint(response.Headers.RetryAfter))
+	w.WriteHeader(429)
+
+	return json.NewEncoder(w).Encode(response.Body)
 }
 
-type PersonDelete5XXResponse struct {
+type PersonDelete5XXApplicationProblemPlusJSONResponse struct {
+	Body       Problem
 	StatusCode int
 }
 
-func (response PersonDelete5XXResponse) VisitPersonDeleteResponse(w http.ResponseWriter) error {
+func (response PersonDelete5XXApplicationProblemPlusJSONResponse) VisitPersonDeleteResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
 	w.WriteHeader(response.StatusCode)
-	return nil
+
+	return json.NewEncoder(w).Encode(response.Body)
 }
 
 type PersonGetRequestObject struct {
 	PersonID PersonID `json:"personID"`
+	Params   PersonGetParams
 }
 
 type PersonGetResponseObject interface {
@@ -545,33 +1250,79 @@ func (response PersonGet200JSONResponse) VisitPersonGetResponse(w http.ResponseW
 	return json.NewEncoder(w).Encode(response)
 }
 
-type PersonGet400Response struct {
+type PersonGet400ApplicationProblemPlusJSONResponse struct {
+	N400BadRequestApplicationProblemPlusJSONResponse
 }
 
-func (response PersonGet400Response) VisitPersonGetResponse(w http.ResponseWriter) error {
+func (response PersonGet400ApplicationProblemPlusJSONResponse) VisitPersonGetResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
 	w.WriteHeader(400)
-	return nil
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PersonGet401ApplicationProblemPlusJSONResponse struct {
+	N401UnauthorizedApplicationProblemPlusJSONResponse
+}
+
+func (response PersonGet401ApplicationProblemPlusJSONResponse) VisitPersonGetResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("WWW-Authenticate", fmt.Sprint(response.Headers.WWWAuthenticate))
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type PersonGet403ApplicationProblemPlusJSONResponse struct {
+	N403ForbiddenApplicationProblemPlusJSONResponse
+}
+
+func (response PersonGet403ApplicationProblemPlusJSONResponse) VisitPersonGetResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
 }
 
-type PersonGet404Response = N404NotFoundResponse
+type PersonGet404ApplicationProblemPlusJSONResponse struct {
+	N404NotFoundApplicationProblemPlusJSONResponse
+}
 
-func (response PersonGet404Response) VisitPersonGetResponse(w http.ResponseWriter) error {
+func (response PersonGet404ApplicationProblemPlusJSONResponse) VisitPersonGetResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
 	w.WriteHeader(404)
-	return nil
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PersonGet429ApplicationProblemPlusJSONResponse struct {
+	N429TooManyRequestsApplicationProblemPlusJSONResponse
+}
+
+func (response PersonGet429ApplicationProblemPlusJSONResponse) VisitPersonGetResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("Retry-After", fmt.Sprint(response.Headers.RetryAfter))
+	w.WriteHeader(429)
+
+	return json.NewEncoder(w).Encode(response.Body)
 }
 
-type PersonGet5XXResponse struct {
+type PersonGet5XXApplicationProblemPlusJSONResponse struct {
+	Body       Problem
 	StatusCode int
 }
 
-func (response PersonGet5XXResponse) VisitPersonGetResponse(w http.ResponseWriter) error {
+func (response PersonGet5XXApplicationProblemPlusJSONResponse) VisitPersonGetResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
 	w.WriteHeader(response.StatusCode)
-	return nil
+
+	return json.NewEncoder(w).Encode(response.Body)
 }
 
 type PersonPatchRequestObject struct {
-	PersonID PersonID `json:"personID"`
-	Body     *PersonPatchJSONRequestBody
+	PersonID                          PersonID `json:"personID"`
+	ApplicationJSONPatchPlusJSONBody  *PersonPatchApplicationJSONPatchPlusJSONRequestBody
+	ApplicationMergePatchPlusJSONBody *PersonPatchApplicationMergePatchPlusJSONRequestBody
 }
 
 type PersonPatchResponseObject interface {
@@ -586,28 +1337,107 @@ func (response PersonPatch200Response) VisitPersonPatchResponse(w http.ResponseW
 	return nil
 }
 
-type PersonPatch400Response struct {
+type PersonPatch400ApplicationProblemPlusJSONResponse struct {
+	N400BadRequestApplicationProblemPlusJSONResponse
 }
 
-func (response PersonPatch400Response) VisitPersonPatchResponse(w http.ResponseWriter) error {
+func (response PersonPatch400ApplicationProblemPlusJSONResponse) VisitPersonPatchResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
 	w.WriteHeader(400)
-	return nil
+
+	return json.NewEncoder(w).Encode(response)
 }
 
-type PersonPatch404Response = N404NotFoundResponse
+type PersonPatch401ApplicationProblemPlusJSONResponse struct {
+	N401UnauthorizedApplicationProblemPlusJSONResponse
+}
 
-func (response PersonPatch404Response) VisitPersonPatchResponse(w http.ResponseWriter) error {
+func (response PersonPatch401ApplicationProblemPlusJSONResponse) VisitPersonPatchResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("WWW-Authenticate", fmt.Sprint(response.Headers.WWWAuthenticate))
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type PersonPatch403ApplicationProblemPlusJSONResponse struct {
+	N403ForbiddenApplicationProblemPlusJSONResponse
+}
+
+func (response PersonPatch403ApplicationProblemPlusJSONResponse) VisitPersonPatchResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PersonPatch404ApplicationProblemPlusJSONResponse struct {
+	N404NotFoundApplicationProblemPlusJSONResponse
+}
+
+func (response PersonPatch404ApplicationProblemPlusJSONResponse) VisitPersonPatchResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
 	w.WriteHeader(404)
-	return nil
+
+	return json.NewEncoder(w).Encode(response)
 }
 
-type PersonPatch5XXResponse struct {
+type PersonPatch409ApplicationProblemPlusJSONResponse struct {
+	N409ConflictApplicationProblemPlusJSONResponse
+}
+
+func (response PersonPatch409ApplicationProblemPlusJSONResponse) VisitPersonPatchResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(409)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PersonPatch422ApplicationProblemPlusJSONResponse struct {
+	N422InvalidNameApplicationProblemPlusJSONResponse
+}
+
+func (response PersonPatch422ApplicationProblemPlusJSONResponse) VisitPersonPatchResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(422)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PersonPatch429ApplicationProblemPlusJSONResponse struct {
+	N429TooManyRequestsApplicationProblemPlusJSONResponse
+}
+
+func (response PersonPatch429ApplicationProblemPlusJSONResponse) VisitPersonPatchResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("Retry-After", fmt.Sprint(response.Headers.RetryAfter))
+	w.WriteHeader(429)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type PersonPatch503ApplicationProblemPlusJSONResponse struct {
+	N503UnavailableApplicationProblemPlusJSONResponse
+}
+
+func (response PersonPatch503ApplicationProblemPlusJSONResponse) VisitPersonPatchResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("Retry-After", fmt.Sprint(response.Headers.RetryAfter))
+	w.WriteHeader(503)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type PersonPatch5XXApplicationProblemPlusJSONResponse struct {
+	Body       Problem
 	StatusCode int
 }
 
-func (response PersonPatch5XXResponse) VisitPersonPatchResponse(w http.ResponseWriter) error {
+func (response PersonPatch5XXApplicationProblemPlusJSONResponse) VisitPersonPatchResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
 	w.WriteHeader(response.StatusCode)
-	return nil
+
+	return json.NewEncoder(w).Encode(response.Body)
 }
 
 type PersonPutRequestObject struct {
@@ -627,50 +1457,291 @@ func (response PersonPut200Response) VisitPersonPutResponse(w http.ResponseWrite
 	return nil
 }
 
-type PersonPut400Response struct {
+type PersonPut400ApplicationProblemPlusJSONResponse struct {
+	N400BadRequestApplicationProblemPlusJSONResponse
 }
 
-func (response PersonPut400Response) VisitPersonPutResponse(w http.ResponseWriter) error {
+func (response PersonPut400ApplicationProblemPlusJSONResponse) VisitPersonPutResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
 	w.WriteHeader(400)
-	return nil
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PersonPut401ApplicationProblemPlusJSONResponse struct {
+	N401UnauthorizedApplicationProblemPlusJSONResponse
 }
 
-type PersonPut404Response = N404NotFoundResponse
+func (response PersonPut401ApplicationProblemPlusJSONResponse) VisitPersonPutResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("WWW-Authenticate", fmt.Sprint(response.Headers.WWWAuthenticate))
+	w.WriteHeader(401)
 
-func (response PersonPut404Response) VisitPersonPutResponse(w http.ResponseWriter) error {
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type PersonPut403ApplicationProblemPlusJSONResponse struct {
+	N403ForbiddenApplicationProblemPlusJSONResponse
+}
+
+func (response PersonPut403ApplicationProblemPlusJSONResponse) VisitPersonPutResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PersonPut404ApplicationProblemPlusJSONResponse struct {
+	N404NotFoundApplicationProblemPlusJSONResponse
+}
+
+func (response PersonPut404ApplicationProblemPlusJSONResponse) VisitPersonPutResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
 	w.WriteHeader(404)
-	return nil
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PersonPut429ApplicationProblemPlusJSONResponse struct {
+	N429TooManyRequestsApplicationProblemPlusJSONResponse
+}
+
+func (response PersonPut429ApplicationProblemPlusJSONResponse) VisitPersonPutResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("Retry-After", fmt.Sprint(response.Headers.RetryAfter))
+	w.WriteHeader(429)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type PersonPut5XXApplicationProblemPlusJSONResponse struct {
+	Body       Problem
+	StatusCode int
+}
+
+func (response PersonPut5XXApplicationProblemPlusJSONResponse) VisitPersonPutResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type PersonHistoryRequestObject struct {
+	PersonID PersonID `json:"personID"`
+	Params   PersonHistoryParams
+}
+
+type PersonHistoryResponseObject interface {
+	VisitPersonHistoryResponse(w http.ResponseWriter) error
 }
 
-type PersonPut5XXResponse struct {
+type PersonHistory200JSONResponse PersonHistoryPage
+
+func (response PersonHistory200JSONResponse) VisitPersonHistoryResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PersonHistory400ApplicationProblemPlusJSONResponse struct {
+	N400BadRequestApplicationProblemPlusJSONResponse
+}
+
+func (response PersonHistory400ApplicationProblemPlusJSONResponse) VisitPersonHistoryResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PersonHistory401ApplicationProblemPlusJSONResponse struct {
+	N401UnauthorizedApplicationProblemPlusJSONResponse
+}
+
+func (response PersonHistory401ApplicationProblemPlusJSONResponse) VisitPersonHistoryResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("WWW-Authenticate", fmt.Sprint(response.Headers.WWWAuthenticate))
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type PersonHistory403ApplicationProblemPlusJSONResponse struct {
+	N403ForbiddenApplicationProblemPlusJSONResponse
+}
+
+func (response PersonHistory403ApplicationProblemPlusJSONResponse) VisitPersonHistoryResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PersonHistory404ApplicationProblemPlusJSONResponse struct {
+	N404NotFoundApplicationProblemPlusJSONResponse
+}
+
+func (response PersonHistory404ApplicationProblemPlusJSONResponse) VisitPersonHistoryResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PersonHistory429ApplicationProblemPlusJSONResponse struct {
+	N429TooManyRequestsApplicationProblemPlusJSONResponse
+}
+
+func (response PersonHistory429ApplicationProblemPlusJSONResponse) VisitPersonHistoryResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("Retry-After", fmt.Sprint(response.Headers.RetryAfter))
+	w.WriteHeader(429)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type PersonHistory5XXApplicationProblemPlusJSONResponse struct {
+	Body       Problem
 	StatusCode int
 }
 
-func (response PersonPut5XXResponse) VisitPersonPutResponse(w http.ResponseWriter) error {
+func (response PersonHistory5XXApplicationProblemPlusJSONResponse) VisitPersonHistoryResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
 	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type PersonRestoreRequestObject struct {
+	PersonID PersonID `json:"personID"`
+	Params   PersonRestoreParams
+}
+
+type PersonRestoreResponseObject interface {
+	VisitPersonRestoreResponse(w http.ResponseWriter) error
+}
+
+type PersonRestore200Response struct {
+}
+
+func (response PersonRestore200Response) VisitPersonRestoreResponse(w http.ResponseWriter) error {
+	w.WriteHeader(200)
 	return nil
 }
 
+type PersonRestore400ApplicationProblemPlusJSONResponse struct {
+	N400BadRequestApplicationProblemPlusJSONResponse
+}
+
+func (response PersonRestore400ApplicationProblemPlusJSONResponse) VisitPersonRestoreResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PersonRestore401ApplicationProblemPlusJSONResponse struct {
+	N401UnauthorizedApplicationProblemPlusJSONResponse
+}
+
+func (response PersonRestore401ApplicationProblemPlusJSONResponse) VisitPersonRestoreResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("WWW-Authenticate", fmt.Sprint(response.Headers.WWWAuthenticate))
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type PersonRestore403ApplicationProblemPlusJSONResponse struct {
+	N403ForbiddenApplicationProblemPlusJSONResponse
+}
+
+func (response PersonRestore403ApplicationProblemPlusJSONResponse) VisitPersonRestoreResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PersonRestore404ApplicationProblemPlusJSONResponse struct {
+	N404NotFoundApplicationProblemPlusJSONResponse
+}
+
+func (response PersonRestore404ApplicationProblemPlusJSONResponse) VisitPersonRestoreResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PersonRestore409ApplicationProblemPlusJSONResponse struct {
+	N409ConflictApplicationProblemPlusJSONResponse
+}
+
+func (response PersonRestore409ApplicationProblemPlusJSONResponse) VisitPersonRestoreResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(409)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PersonRestore429ApplicationProblemPlusJSONResponse struct {
+	N429TooManyRequestsApplicationProblemPlusJSONResponse
+}
+
+func (response PersonRestore429ApplicationProblemPlusJSONResponse) VisitPersonRestoreResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("Retry-After", fmt.Sprint(response.Headers.RetryAfter))
+	w.WriteHeader(429)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type PersonRestore5XXApplicationProblemPlusJSONResponse struct {
+	Body       Problem
+	StatusCode int
+}
+
+func (response PersonRestore5XXApplicationProblemPlusJSONResponse) VisitPersonRestoreResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
 // StrictServerInterface represents all server handlers.
 type StrictServerInterface interface {
+	// List audit entries
+	// (GET /audit)
+	AuditList(ctx context.Context, request AuditListRequestObject) (AuditListResponseObject, error)
 	// List Person records
 	// (GET /person)
 	PersonList(ctx context.Context, request PersonListRequestObject) (PersonListResponseObject, error)
 	// Create a Person
 	// (POST /person)
 	PersonPost(ctx context.Context, request PersonPostRequestObject) (PersonPostResponseObject, error)
+	// Create several people
+	// (POST /person/batch)
+	PersonBatchPost(ctx context.Context, request PersonBatchPostRequestObject) (PersonBatchPostResponseObject, error)
 	// Delete a Person by id
 	// (DELETE /person/{personID})
 	PersonDelete(ctx context.Context, request PersonDeleteRequestObject) (PersonDeleteResponseObject, error)
 	// Get a Person by id
 	// (GET /person/{personID})
 	PersonGet(ctx context.Context, request PersonGetRequestObject) (PersonGetResponseObject, error)
-	// Update a part of Person (via JSON Merge Patch)
+	// Update a part of Person (via JSON Merge Patch or JSON Patch)
 	// (PATCH /person/{personID})
 	PersonPatch(ctx context.Context, request PersonPatchRequestObject) (PersonPatchResponseObject, error)
 	// Replace a Person
 	// (PUT /person/{personID})
 	PersonPut(ctx context.Context, request PersonPutRequestObject) (PersonPutResponseObject, error)
+	// Get the change history of a Person
+	// (GET /person/{personID}/history)
+	PersonHistory(ctx context.Context, request PersonHistoryRequestObject) (PersonHistoryResponseObject, error)
+	// Restore a deleted Person
+	// (POST /person/{personID}/restore)
+	PersonRestore(ctx context.Context, request PersonRestoreRequestObject) (PersonRestoreResponseObject, error)
 }
 
 type StrictHandlerFunc = strictnethttp.StrictHttpHandlerFunc
@@ -702,6 +1773,32 @@ type strictHandler struct {
 	options     StrictHTTPServerOptions
 }
 
+// AuditList operation middleware
+func (sh *strictHandler) AuditList(w http.ResponseWriter, r *http.Request, params AuditListParams) {
+	var request AuditListRequestObject
+
+	request.Params = params
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.AuditList(ctx, request.(AuditListRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "AuditList")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(AuditListResponseObject); ok {
+		if err := validResponse.VisitAuditListResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
 // PersonList operation middleware
 func (sh *strictHandler) PersonList(w http.ResponseWriter, r *http.Request, params PersonListParams) {
 	var request PersonListRequestObject
@@ -729,9 +1826,11 @@ func (sh *strictHandler) PersonList(w http.ResponseWriter, r *http.Request, para
 }
 
 // PersonPost operation middleware
-func (sh *strictHandler) PersonPost(w http.ResponseWriter, r *http.Request) {
+func (sh *strictHandler) PersonPost(w http.ResponseWriter, r *http.Request, params PersonPostParams) {
 	var request PersonPostRequestObject
 
+	request.Params = params
+
 	var body PersonPostJSONRequestBody
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode JSON body: %w", err))
@@ -759,6 +1858,39 @@ func (sh *strictHandler) PersonPost(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// PersonBatchPost operation middleware
+func (sh *strictHandler) PersonBatchPost(w http.ResponseWriter, r *http.Request, params PersonBatchPostParams) {
+	var request PersonBatchPostRequestObject
+
+	request.Params = params
+
+	var body PersonBatchPostJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode JSON body: %w", err))
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.PersonBatchPost(ctx, request.(PersonBatchPostRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "PersonBatchPost")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(PersonBatchPostResponseObject); ok {
+		if err := validResponse.VisitPersonBatchPostResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
 // PersonDelete operation middleware
 func (sh *strictHandler) PersonDelete(w http.ResponseWriter, r *http.Request, personID PersonID) {
 	var request PersonDeleteRequestObject
@@ -786,10 +1918,11 @@ func (sh *strictHandler) PersonDelete(w http.ResponseWriter, r *http.Request, pe
 }
 
 // PersonGet operation middleware
-func (sh *strictHandler) PersonGet(w http.ResponseWriter, r *http.Request, personID PersonID) {
+func (sh *strictHandler) PersonGet(w http.ResponseWriter, r *http.Request, personID PersonID, params PersonGetParams) {
 	var request PersonGetRequestObject
 
 	request.PersonID = personID
+	request.Params = params
 
 	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
 		return sh.ssi.PersonGet(ctx, request.(PersonGetRequestObject))
@@ -816,13 +1949,24 @@ func (sh *strictHandler) PersonPatch(w http.ResponseWriter, r *http.Request, per
 	var request PersonPatchRequestObject
 
 	request.PersonID = personID
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json-patch+json") {
 
-	var body PersonPatchJSONRequestBody
-	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode JSON body: %w", err))
-		return
+		var body PersonPatchApplicationJSONPatchPlusJSONRequestBody
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode JSON body: %w", err))
+			return
+		}
+		request.ApplicationJSONPatchPlusJSONBody = &body
+	}
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/merge-patch+json") {
+
+		var body PersonPatchApplicationMergePatchPlusJSONRequestBody
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode JSON body: %w", err))
+			return
+		}
+		request.ApplicationMergePatchPlusJSONBody = &body
 	}
-	request.Body = &body
 
 	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
 		return sh.ssi.PersonPatch(ctx, request.(PersonPatchRequestObject))
@@ -876,3 +2020,57 @@ func (sh *strictHandler) PersonPut(w http.ResponseWriter, r *http.Request, perso
 		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
 	}
 }
+
+// PersonHistory operation middleware
+func (sh *strictHandler) PersonHistory(w http.ResponseWriter, r *http.Request, personID PersonID, params PersonHistoryParams) {
+	var request PersonHistoryRequestObject
+
+	request.PersonID = personID
+	request.Params = params
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.PersonHistory(ctx, request.(PersonHistoryRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "PersonHistory")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(PersonHistoryResponseObject); ok {
+		if err := validResponse.VisitPersonHistoryResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// PersonRestore operation middleware
+func (sh *strictHandler) PersonRestore(w http.ResponseWriter, r *http.Request, personID PersonID, params PersonRestoreParams) {
+	var request PersonRestoreRequestObject
+
+	request.PersonID = personID
+	request.Params = params
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.PersonRestore(ctx, request.(PersonRestoreRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "PersonRestore")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(PersonRestoreResponseObject); ok {
+		if err := validResponse.VisitPersonRestoreResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
@@ -18,36 +18,88 @@ import (
 // Base64 encoded, gzipped, json marshaled Swagger object
 var swaggerSpec = []string{
 
-	"H4sIAAAAAAAC/9RYbW/jNhL+KwSvwKWAbNmOE9z6W7ppCxf7Emw2d8UFucVYGllsKVIlKTdGoP9+ICnL",
-	"UiTZzm5wh36KFb7MMzMP5+2JRjLLpUBhNF080RwUZGhQ+S9UWorltf0do44Uyw2Tgi7o8prIhJgUyY3b",
-	"QwPK7P9zMCkNqIAM7dfufEAV/lEwhTFdGFVgQHWUYgb24u8UJnRB/xbukYR+VYd3d8trWpalPa9zKTQ6",
-	"XPPJ/IM0P8lCxF1oHhD5k5nUAdQ5RixhGJPlNfkTNBHSkMSdLQN68euvS2FQCeC3qDaoflRKqh6Fq01E",
-	"u10E3TaLrMJqj1yt0f7BR8hyjnQxnwU0g0eWFRldTGcXAc2Y8F+TgJptbo3EhME1KgvmrSyEUdu3MsYu",
-	"gmqRRDJGstqS5e1Hcj69vBxNCfA8hdGMBnvR9NMddcLfoViblC5mTnjjKwdjVaIL+p/7q9G/H55m5Xe0",
-	"RqWNYmJtQd3AmgmwID4miUbzjmXMOHYomaMyzPskKpRCYb7w3fJhVXfbpbvz+H4jDfAvzGCmj20um2y7",
-	"fwasI7l99UN9nVz9hpFxBnCE+gE0drX2RG+4nF5nzCi2pS1zT3vsmoNRUmwzFrUv+CdoxnHDorTPG7pQ",
-	"XZl3OoXf5eaYzHJQuZ8Kzu2VwPnHhC7uD79Kf+YGlGHAaRk8tezt4O2BthQNKKzRxQfLJ+DMWENpfOwx",
-	"/EML3b+YSX0geglGp5cF2PYai08MPG0msfggzBtY9zAkr5/PMZn9D80yBaXz8xOt+X+a6pXR9n4HpWDb",
-	"0asBsRY2/BB2fn+hK9z76bqistmh8zaqlm3OHDnRjKOl59eRE7f42Pc+Gs6V2lyDga9Wu+eJtB7G7r0M",
-	"MExq81YhGIw/VZmwS7Wi+Epiu4N9Hr/1lkNhQ+09zYBb3Am6Hw/NdFMtdeKVk9cKVtH5eTKHaD6an1/A",
-	"aH6ZTEer2exidPHm4nI1jd5Es+iinbrOL1uBzX42k9dk9AZGycPTP8pR/Xt+wu9pX8IL6ONoLUfVP61h",
-	"xk6Fxv9HLMul8inQljsLumYmLVbjSGbhWso1x9BZtHSVCxOJ7GbzzynThGkCxKA2JFfS2pwkUpEfkwQj",
-	"wzb4Xq6YsypnEVYeryqr98vPNKCF4nRBU2NyvQhDmaPQslARjqVah9WhMGMmdCGAGWd/z0vgS5FIcnWz",
-	"pAHdoNIe1mQ8GU/sbnsZ5Iwu6Pl4Mj73bE0dzUJf1tmfa5+6LQnd61zGtYB3TBt3al9Q3veXan/XxGpF",
-	"ziLQOGJCo9DM6h8QzTLGQTGzJRpBRen3u0LzjwLVdl9p7pJOXVUeSYSDSKpn+E1g9qnv6/GAMrbCrnHt",
-	"Y8VJ0AKCWW62jk9CNk4PYW7Hohr2UaDvfSHmBNVgfZLvEwNr/JIxQU9tAFzs75Hqi+oXSYXHb5XaoGud",
-	"ishZtw53JfowU1ulz0l4WvnsEHnxcYiSeLr2VS7sRiyFOpU8dlbvMI6cTcYTYiSZjidDupvdFS0sMSZQ",
-	"cONq+X231NsriSJb+RK/iw6JX7XPRmEkVawtHP07ywfg1B3AAJaDPcYwAP0MgUJTKEHOgHPCEjJonV2D",
-	"0oNmdgzOw7MOeTaZuKZMCoPCxWjIc84iR77wtyp8n8aHRnXrElpb7yuSwxr3scomj7mX/ryD3gBnMXFa",
-	"k0Ze8F34EIparXCgVXc9eJFloLZ0QW3aqZDsfOBKaKkHM5WtraoJBWrzg4y3r226Xe1YtgsvowosO46b",
-	"vp70nqKxx4O7gQloEvndRBdRhFonBefbow6tzidSZeCqxvlsNjiWcclVI2buaayQMH+LY8HkvKdQQltt",
-	"gWJ8SwoBG+CwckVRihBXc6pPaNR2dJUYVG3rtK/6UMcHjZEUsSaFMIy7KVGGJpUxWWEkM9QENsB2gg4+",
-	"vPJV2eu9RaB+S2WwK7fCp900rfSacTQ4ROlrv9opv/og7reE9bxuKJ4MEsfDOZE4n59P5ZgfygHxhPI9",
-	"ij08P27X5izwVX3hbVj7gqy2xNP0QNH7M5rXN/orRqLmUKAbCD7X41w/Pd37yCv+F3bmz2h6PJmDidLB",
-	"tOAWv9GbL0kpVYvcnCRvgBdYj0nml1ap3azu+VpdR/wiU/Fsxragd7ftgcOCVoO3unWvJ4v0LQpw9vsA",
-	"GV6J+HY/c6yFtmV1zpbBC4uLapZ4SoI8HImKPH6NFPZ/Zeud04GArZIabSA52zAgv9x+/EDeo1ojcRT9",
-	"3jG5GC5vCvO/ZPFLw9Fr+FxhziH6izv9k1eimfzd7NLu7xuevJMR8GqAsx8ELcKQ24VUahNCzsLNhJYP",
-	"5X8DAAD//42eMJnoGwAA",
+	"H4sIAAAAAAAC/+w8aW8bSXZ/pdBZIBLSPERJ3jWDfND42NGsD0GS48Hajlnsfk3Wuruqt6paEmMICPIb",
+	"8mM2QH7E7D8K3qvqi2xSpGwLM4mBwZjqruPVq3cf/TmIVJYrCdKaYPw5yLnmGVjQ9JeIIcuVBRkt/gQL",
+	"fBKDibTIrVAyGAcv+ScwzM6BafhrAcayaoYd++cmV9IAUwn9nQhtbDX6Wtg5Pf4ECyYM05CnfAEx26M3",
+	"k9Nqtd65fzVmVhcwYXPgMeh9liiN04BbiMt1Tb2w4RmwqYoXfXYOhRFyVu1HY7hUdg6ahiCwRWoNE5Id",
+	"jUYh4ysrs4zHwK7nIoXGcZQEhN5YkaY4OddqpsGY1oLDx332J1gYxjUwE6kcYmYVrcILOwdpRURbRakA",
+	"ad/LIAwEItmdNAgDyTMIxsFpfSk9vJUwMNEcMo7Xk/GbFyBndh6MR8fHYZAJWf59EAZ2keMCxmohZ8Ht",
+	"bRjkoI2Sp09Xr/b0aXllZzSmhCbndl7DUs0PA8SQ0BAHY7ygJlC/05AE4+AfBjWpDdxbM3jz5vRpcIug",
+	"lJRChHc0HP7A43OHdHwQKYlkgD95nqeIKqHkINdqmkL2T39BCMeft9z0zM1y+y4dW17xVNT3XfMDU45K",
+	"gtswOBoevJF4a0qLf4f4IQF8KQxRsdJMeFgjDTGSD09NEHpyISy+ffu2d9KgrTYAy9RwSwc7fK70VMQx",
+	"yIc81eUcWMTTFDRLefTJyRRiEsNKwmLTBT1WOWgCg+1Nbnrl254bPdl393P0StnnqpAPejeOUxrCJ4dI",
+	"JAJidvqUXXPDpLIsIagIyMdPlExSEdmHRnVJ3ZHfvyEwo0JrkJYZyy0siQAEejTyLPKKJMCDIxcFDzMA",
+	"mUHpOYWSCxxwjy+VesnlwksO8+CIRaSlIhO2RJ2T5qge4CYCiCHus2dXoBe1aoy41gIMm5xzCy9wco/+",
+	"P2F70yL6BJZFPOeRsIv9sDnoHDIupJCzCduz6hNIw1JI7D7jMm6PM4CLGYiUjA0rpBUpAeeXF4YlRZru",
+	"e6Vq+ux1yWOGRcpYdsBoA1bIFPUa8h230KOT9nDEBBcx4NRWQwSdg9WL3kliQbeR3MbdqyKbgkacrQJZ",
+	"ESuXeN8arBYQB6TcRFZkwXhYqTYhLcxAe2l2PDx8I/kVFymfpg9KrJeoojXXIl2wogHC18ZNBnauYjaF",
+	"SGVgWHOju7Hz88+n0oKWPL0AfQX6mdZKP6yyddszQ/szIABwnJ+Ma5/M6Obghmc5XuLRKEQzx53tYHS8",
+	"+aRhcFLEwj6TVnfYrycsmnM5IznHvZRzJt50wbisVU2fTWKRJBOW8dz4STFLBKSx8Vac0EylMfGehGt2",
+	"xdMCDNvjU0P8X5q/kMYsFjGpArgRxrIpJEoDKnSOpEDj3A77xE25RjCscJYRj6zSqyd54sSMnXNvo9ar",
+	"sD2RsE9SXcv9YMUGDAMnoD6KvMMGPGM8jsmSbUuzzSsipgjUOBa4Ek/PWkfYRC3PEUFPCO7gtlpbTf8C",
+	"kcUHIt5kqgJdcxgkSmfcOjp4dBR0kYWKSNPFHznReTUjRrlmRQZdJ6voAaeARJp7F0QauMXhRR67HzGk",
+	"QD80GKs0/soLPYPgQ8eazoT+KOK7UONMZWdog7EfN6PCj9qdIm6bpvw7d5mE9zbOmshoHuJDx6URC55x",
+	"x8htcsYb8z+XWBPnMP86RJbC05C/heBYyO6kpQbn16TEteb0d85nQlaXuVGCVSNfJ4kBS1p1BVHlSVor",
+	"d2HjB26j+RMimvjc2wCriCkKEZuuC6550S3BclB5inYQPVU6dsqiQQTbIqyksDaqls7pAOs62BNVILKf",
+	"qBg6BJR7ySLlpOvpxWt2ePDoUe+A8TSf894oCGshH5y/CcKWN9vyZUeIZGtB48r/9u6k9+cPn0e3v+ti",
+	"2aZAWUGyhOtg/BkZO0Vmuu2SON23v7KUt5s/puXrzVqpHK5ozbvHW2V5+rG6xXUWgoZI6diwDGmsDHZ4",
+	"KgiZyoRFihEJE7ZySPxrjLpMaJt/SXhqYLJ/lxHRpos2AlZO2EUwTuH+wLvoX3r3oiaJp5mwWiyCO6Ia",
+	"RBpayUUmovYC/8qNSOFKRPMuQjGFXt3zjZnzT+oquDuSsvZwNpqfKWOfcstXT+l4F39txaBuzWq5W2KR",
+	"UzfzYEhAln/dwcV+4/W3UrPM3SZTn01UGpML0DZ3SoeYm0paUbiOtGIcsomE683TnCqlaaRC4z6bGMlz",
+	"M1fO58DxHb4qu56DE4hzgbstcJC2ED+ISUWDdjMtNmtzt+J2lo0TalvQ0fMiTd8KO3dC34nA3ad1mUTl",
+	"DQXhl1pHD2vsNC6uNHmq461nFUQI0VGavk6C8buteJhrDNgFt+HnFgQkgmph1BJmYcCJBpw24qmwFPyF",
+	"mw7YPrSg89e1I4x0LgSwzS7bWqpLuBXxRjB/dGzabSW6e+nQfE5KmTbv389abIm9B7AXyzPdaS86wH66",
+	"eP3qDJXJKhLwFaN3bO/8+RP26PFwtM9iFRUZCjDMkNSSuhHZ4RoY+fcQM25VJjACuyAPVkhnSI5RJHO5",
+	"8AjOWMJFiua4smReiMoZfi93Q3R1ngqg4HajAguDtRNX6EV1OLMTDZm6ggmqH7CmdsfHbFIz2aSKpUCW",
+	"20XIJnwGk5BNDNxMXGCtwX4TwmEi0tSFp0FqEc0J6xjSEBEYxmdcoIZEfvYreO6eUEhLKuvCWgidQ2Mp",
+	"Rt0zkpR5yiP8ZcHYbjeS23lTBA+crBh4MTJoC41BS6wMUIbgPw4uXL82gfxCKxtSgGMVzXQrMkaCmXiw",
+	"/akR9EkdUjFNe/9otMweKg/8odbzxEvQM9jEFDSgyRq/P3z8aC1rnDgjxEd18GYxnMoK6UkcL7FI018v",
+	"BZXx1yXzZqcAGp7QRUtdGm/JvgiDm95M9fzTV37wOyHth+a7nvkk8p7K3Tl7ucL52q15G97buF+zu3u7",
+	"GwA1N3xzV3UZpVu5rl/tqFt5Q2tu/WvDgnKmIaMyTpHqBOhHS+r4V/eC6wJudgLq/o7fV0LMevex2xz6",
+	"YlMkvJ/j2bb8N/uXNYjh3c5maQ3vaKBS5GDVQPU42xgadDbekhjYNKMZ3KopedOMC7jpigzUJm8zLHCv",
+	"Y3c4Di293tDnXUAoY7cKQ97L3KeJnTfu80ErYpfSTywGiyamV9d/GP5+v88muIiLE6AbP02BvTk/xVoj",
+	"aUWyqIp6hLM78DelkkI2scKmbqqwhs2LjMueBh7TIqbIMq4XHTrTQdEB402eckc1ZXY/cvkfYZgPj8uo",
+	"ikX4jFmnvy+N5TLqsKDOuJ13OrYRLwzESws3DbVcDK6GAxeLH0SHh8kRj456R4fHvHf0KDnoTUej497x",
+	"4+NH04PocTSKjrsBo3z6R6p86XC5zlcrY9AkoQKqMiGG0KKnADGjxUpBsJ2scWfzZQZnuE2XP2Yst0UH",
+	"fD9eXp4x95I0eMvMHB51BleRSNry/1VVqdGBIvegObzQcuzw3uO5GPvrGUtle+UqVeSm0OLOQAS9LQGr",
+	"zrqBnVrYWuFi0YrO+BKuvxagF1VGOggDvMJO36JUj8t06gnA1WQozZwj6lRb5Yoe7COD8AaBBNu4Fxq4",
+	"TzPXY6WLc2cFZU0ZxnqoOGF0fCdChSyL1qqlu7B5sauBsgI3CcMW1FsyYsOCPHzUMjsOH7VtxmHvMe8l",
+	"Hz7/4bZX/T7a4vfB3XYmSu0+HaFpu4gsV9rlPMjLDGbCzotpP1LZYKbULIUBTnQpfgNRoYVdXCA7e4Wc",
+	"i87y0ZOzU6rEfB+8L4bDwwh/i5h+Q989MhBpsO7R+4AJYwqI62olHmdCsicvTtcVS/7cOzk79WWSpQRx",
+	"0NyGwZQbEWGBXDdoPtlN1I0izsFS1lviUrRAvfLc2pzWBa5Blwu7v56X7P/T28tguRDip7eXzIiZLI92",
+	"fjE6fhSyZ/gPMtaz+OnFCdUkMKsLytUQJnQLGNpnGZpbEumJWj3iJeos0qmW5LlWyAXkEz9LEoisuIKX",
+	"aiqIzlMRgTcQPGZfnuI5Cp36rcx4MFA5SKMKHUFf6dnATxpkwg4actabPzw9lYliJ2d4eVegjQNr2B/2",
+	"hz6yLHkugnFw2B/2D304gAhqwDG1i79mYFdP9nauynxDWManwjKVEFb5hzqpUOZQ8ZYpcbBHkQEkLmGs",
+	"5lZp44oxqvDFaVwmqV8ICjI2K6ff7ZBLMCXplvLY49dlJVrFvZtTUN2x0Xipfndplzpxv2PF7sp2F5br",
+	"qurNigyY9uF3GaWFEVcQMlQJh4eHj/dbKmA0HB31hge94cHlcDim//68BtxEq6wF6TbJlVVYn9WGYhNS",
+	"uNkK0tE2kFr1FeDEkkK5kty1iqE3uWZjn28NW/VkCS9SS/GdzRnd9QCYJQg02EKvo6oyB9wBwuguGD4s",
+	"lYKPhsMNNWm71aLVhSgd1WgnLOc+r9msPXGlusN1S1ewDto1665KfJtZ7VJyV4S9zbxGpbarfd1i0mqB",
+	"rCsEvHvqmmpB0vjOjQrGAYrCJeyhHbFUo13nn8ckYoMPuIr3WxpCvS1rnRjbRti6kf9onObei7iBnpAG",
+	"pBGWmNuITKQcjRRmgOtovr+Gjsts3H1FcAWJ98S/CJg6J3h/eLyIruCqwwVbgRa6mDbZCFI1Zq+DuR2O",
+	"WNt6sALoSychaKMKWOcpdOrKGXzMhGztcWf4p2NXfrPzrvzmS3dtkGsVjWJ7q0Fn8mbXU2orJ7wVPK2Q",
+	"1ibihZs1u7o32+3mw2GrGkaDmWPlLGJ9heLY3rA/RH1z0B+uO7stl1iv9Kp0R6fycRquC7of1TVJEZcO",
+	"oqoqygNZLWaaZxNmIqVdC1xG/SnueeMcfTbJ50qCxcxQImRs/IIG4wIYuuKp+ARs76kqMCb1EiynCXTd",
+	"xhfzY3m+hRvbXgJ1ousAYEAtBddKV4aNwx9auRLMP7OpsnOG14IHcdOt5tKkwqKUdc1oL7gV2Fbzy3/9",
+	"8j+//O3v//n3//jlb7/8NyavfH7Gp5/oL5+7KrvsHKRiJpV/WF3L+3VmAuGz+9ICj8lGNrR+UiI0CIMS",
+	"MR1xi9+gOcX2OHYQJmy4/w0sqxVwTtEyj6EqsvKlUxVY6K1cA9KLsqWntADb6R2tgVe4LT76Lbohp3rD",
+	"CuCpUilwMmq2MR3CzvSdi5LSyHYxZCJSilnuTRollZP9PqNpOMpEXBrPUHnZEtCxgmsctXMu2+QeMgMW",
+	"75SOhT9SNItSjrloil4blnBjQa/BGQHWjalWFqxC1De1mRuJqI1Gc6NH7Lu1vL213Oa5O8xlzFwgyQe5",
+	"MmtNZMzrrJrIXfDWQwZLnd6OoujQP2BU+CsTU1XA2g7T+oTsEikffL3dOzJeG9o4G1WrpogiMAa1zeK3",
+	"QuLDbUi80YPquju3YYtWC+gXctM2R1vq4/uqTOjooaoCcgzY7m10tdWb+PJaCwstN3YwLcuSSlZdUlK0",
+	"rWEG9QxPqwCkZUpGMGYgSLugOVCW3qGx1aiilkpC2UrqKVYYXz+EBX301YHJ2euLS+ZhmnREMJeq1H/d",
+	"cqNdTP/AwqOzbadTetBNXkPjur6Lj//j4qPNxt1C5Hh3IfK5/L7GrZMgVDi/Ikuets33qk/aZzlct7Cg",
+	"Nm9vxNeNphqQM4SSaOoKFa8VEU/Lqv3d5EN5gGCdjbpW9ZZOyW+Sd462mVR/o+LXYpG6S65boaeYj73D",
+	"JvVUiVbphrjtH8F+Ae2s+Hh/BNts9uGmbGjj7nmmqIR2b3PC6fDg8mC0OY3DzUeV7J7J+fYOWbMWr/tb",
+	"FM2vkdRfIvFfyvjORN+MiZA2d+Kg2qsrbcZOt45efqH83dYy6xEo1ZcePOv4r1ZQUf/JDE6Tl9zVTfle",
+	"gHeu88I1KZQtCWV3gR/jCmduQz+07mwoR7t4ux999IhKFekLJq/axZpLe1a9EuU6zYj47Yf6oxI7tqfQ",
+	"5TbRk4GewQb8+O9VVO0RfObOgb3IvlFs+V0lbX5Sc7nU4DUO3ly06zrHge/6qoqQqgrm4AlIThCjbXUi",
+	"44vyTWPT9l4dcwnbZ9WGJzLuPBPWZrcBwye3u2K60cTRIcgmm1Dvo9EbiHfCEpWm6pqVzR80o2ySok/w",
+	"uEKXdNFfXciVnEYR5NZ5VFxitJ5T8HYzZGRH3eWcbDaAXMPk/x8D6LvDsY12eUNUwTjLW7lctnclOFtp",
+	"eqqKMvGv/WA7ByQM8mJ9dLGwD6WE7mMSbRcV2Mx4Xid9dz0e0mryZsVSIO5+zvLA9/yvLRBc0zbsklWY",
+	"5ymdT9QW3mdWEkyfXS5/UcC4gkHvfghptYqLCNY70r7R+Wt6Q9/LxL6Fh9XsSN+Y+Yq6qOm7vPj2Xlbj",
+	"Iw8lT6pkWwniva5uAVJ+mmJt/P7cDTCML2fPXdbcf1vn7qR5l4g4rz+McX8RcZ/A/U5K0ocXv5unG8zT",
+	"X4VipYtaIdRtKzMbLR1Eg2UzxzsqvWi0UPgHjd6Hdx+QrNzHHbsKNV+oiKe+AaBuJBgPBim+mCtjfU8b",
+	"OvD/OwDkpm/pqlwAAA==",
 }
 
 // GetSwagger returns the content of the embedded swagger specification file
@@ -4,26 +4,128 @@
 package api
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 )
 
+const (
+	ApiKeyScopes     = "apiKey.Scopes"
+	BasicAuthScopes  = "basicAuth.Scopes"
+	BearerAuthScopes = "bearerAuth.Scopes"
+)
+
+// Defines values for AuditEntryOperation.
+const (
+	AuditEntryOperationCreate  AuditEntryOperation = "create"
+	AuditEntryOperationDelete  AuditEntryOperation = "delete"
+	AuditEntryOperationPurge   AuditEntryOperation = "purge"
+	AuditEntryOperationRestore AuditEntryOperation = "restore"
+	AuditEntryOperationUpdate  AuditEntryOperation = "update"
+)
+
+// Defines values for PersonChangeOperation.
+const (
+	PersonChangeOperationCreate   PersonChangeOperation = "create"
+	PersonChangeOperationDelete   PersonChangeOperation = "delete"
+	PersonChangeOperationPurge    PersonChangeOperation = "purge"
+	PersonChangeOperationRestore  PersonChangeOperation = "restore"
+	PersonChangeOperationSnapshot PersonChangeOperation = "snapshot"
+	PersonChangeOperationUpdate   PersonChangeOperation = "update"
+)
+
+// Defines values for PersonJSONPatchOperationOp.
+const (
+	Remove  PersonJSONPatchOperationOp = "remove"
+	Replace PersonJSONPatchOperationOp = "replace"
+	Test    PersonJSONPatchOperationOp = "test"
+)
+
+// Defines values for PersonJSONPatchOperationPath.
+const (
+	PersonJSONPatchOperationPathAge         PersonJSONPatchOperationPath = "/age"
+	PersonJSONPatchOperationPathName        PersonJSONPatchOperationPath = "/name"
+	PersonJSONPatchOperationPathNationality PersonJSONPatchOperationPath = "/nationality"
+	PersonJSONPatchOperationPathPatronymic  PersonJSONPatchOperationPath = "/patronymic"
+	PersonJSONPatchOperationPathSex         PersonJSONPatchOperationPath = "/sex"
+	PersonJSONPatchOperationPathSurname     PersonJSONPatchOperationPath = "/surname"
+)
+
+// Defines values for ProblemInvalidParamIn.
+const (
+	Body   ProblemInvalidParamIn = "body"
+	Header ProblemInvalidParamIn = "header"
+	Path   ProblemInvalidParamIn = "path"
+	Query  ProblemInvalidParamIn = "query"
+)
+
 // Defines values for Sex.
 const (
 	Female Sex = "female"
 	Male   Sex = "male"
 )
 
+// Defines values for PersonListParamsMatch.
+const (
+	Fulltext PersonListParamsMatch = "fulltext"
+	Phonetic PersonListParamsMatch = "phonetic"
+	Trigram  PersonListParamsMatch = "trigram"
+)
+
 // Age defines model for Age.
 type Age = int
 
+// AuditEntry A change of a Person made by an operation. `diff` maps changed fields to their old and new values (absent if the field did not exist before or after the change)
+type AuditEntry struct {
+	// Actor Client that made the change (if known)
+	Actor *string `json:"actor,omitempty"`
+
+	// ClientIp IP address of the client (if known)
+	ClientIp *string                `json:"client_ip,omitempty"`
+	Diff     map[string]FieldChange `json:"diff"`
+
+	// Id ID of the entry
+	Id         int64               `json:"id"`
+	OccurredAt time.Time           `json:"occurred_at"`
+	Operation  AuditEntryOperation `json:"operation"`
+	PersonId   UUID                `json:"person_id"`
+
+	// RequestId ID of the request that made the change (if known)
+	RequestId *string `json:"request_id,omitempty"`
+}
+
+// AuditEntryOperation defines model for AuditEntry.Operation.
+type AuditEntryOperation string
+
+// AuditPage defines model for AuditPage.
+type AuditPage struct {
+	// Entries Audit entries, newest first
+	Entries    []AuditEntry          `json:"entries"`
+	Pagination PaginationOffsetLimit `json:"pagination"`
+}
+
+// BatchCreatedResponse defines model for BatchCreatedResponse.
+type BatchCreatedResponse struct {
+	// Uuids IDs of the created people in the order of the request
+	Uuids []UUID `json:"uuids"`
+}
+
 // CountryCode Country code by ISO 3166-1 alpha-2
 type CountryCode = string
 
+// FieldChange defines model for FieldChange.
+type FieldChange struct {
+	New *interface{} `json:"new,omitempty"`
+	Old *interface{} `json:"old,omitempty"`
+}
+
 // PaginationOffsetLimit defines model for PaginationOffsetLimit.
 type PaginationOffsetLimit struct {
 	CurrentLimit  int `json:"current_limit"`
 	CurrentOffset int `json:"current_offset"`
-	TotalItems    int `json:"total_items"`
+
+	// TotalItems Number of records matching the request, omitted if it was not requested (`total=false`)
+	TotalItems *int `json:"total_items,omitempty"`
 }
 
 // PersonBase defines model for PersonBase.
@@ -33,6 +135,30 @@ type PersonBase struct {
 	Surname    *string `json:"surname,omitempty"`
 }
 
+// PersonBatchPostData defines model for PersonBatchPostData.
+type PersonBatchPostData struct {
+	People []PersonPostData `json:"people"`
+}
+
+// PersonChange A change of a Person. `old` is absent if the Person was created or restored, `new` is absent if the Person was deleted or purged. `snapshot` is the state of the Person when the history started
+type PersonChange struct {
+	// Actor Client that made the change (if known)
+	Actor     *string   `json:"actor,omitempty"`
+	ChangedAt time.Time `json:"changed_at"`
+
+	// Id ID of the change
+	Id        int64                 `json:"id"`
+	New       *PersonFullWithID     `json:"new,omitempty"`
+	Old       *PersonFullWithID     `json:"old,omitempty"`
+	Operation PersonChangeOperation `json:"operation"`
+
+	// RequestId ID of the request that made the change (if known)
+	RequestId *string `json:"request_id,omitempty"`
+}
+
+// PersonChangeOperation defines model for PersonChange.Operation.
+type PersonChangeOperation string
+
 // PersonFull defines model for PersonFull.
 type PersonFull struct {
 	Age  Age    `json:"age"`
@@ -58,6 +184,44 @@ type PersonFullWithID struct {
 	Surname     string      `json:"surname"`
 }
 
+// PersonHistoryPage defines model for PersonHistoryPage.
+type PersonHistoryPage struct {
+	// Changes Changes of the Person, newest first
+	Changes    []PersonChange        `json:"changes"`
+	Pagination PaginationOffsetLimit `json:"pagination"`
+}
+
+// PersonJSONPatch JSON Patch (RFC 6902) document for a Person. Operations are applied atomically and in order: if any of them fails, nothing is changed
+type PersonJSONPatch = []PersonJSONPatchOperation
+
+// PersonJSONPatchOperation defines model for PersonJSONPatchOperation.
+type PersonJSONPatchOperation struct {
+	// Op `remove` resets the field: `patronymic` becomes empty, `age`, `sex` and `nationality` are filled by enrichment services again. `name` and `surname` can not be removed
+	Op   PersonJSONPatchOperationOp   `json:"op"`
+	Path PersonJSONPatchOperationPath `json:"path"`
+
+	// Value Operand of `replace` and `test` operations
+	Value *interface{} `json:"value,omitempty"`
+}
+
+// PersonJSONPatchOperationOp `remove` resets the field: `patronymic` becomes empty, `age`, `sex` and `nationality` are filled by enrichment services again. `name` and `surname` can not be removed
+type PersonJSONPatchOperationOp string
+
+// PersonJSONPatchOperationPath defines model for PersonJSONPatchOperation.Path.
+type PersonJSONPatchOperationPath string
+
+// PersonMergePatch JSON Merge Patch (RFC 7396) document for a Person. Absent fields are left unchanged. `null` resets the field: `patronymic` becomes empty, `age`, `sex` and `nationality` are filled by enrichment services again. `name` and `surname` can not be reset
+type PersonMergePatch struct {
+	Age  Nullable[int]    `json:"age"`
+	Name Nullable[string] `json:"name,omitempty"`
+
+	// Nationality Country code by ISO 3166-1 alpha-2
+	Nationality Nullable[string] `json:"nationality"`
+	Patronymic  Nullable[string] `json:"patronymic"`
+	Sex         Nullable[Sex]    `json:"sex"`
+	Surname     Nullable[string] `json:"surname,omitempty"`
+}
+
 // PersonPage defines model for PersonPage.
 type PersonPage struct {
 	Pagination PaginationOffsetLimit `json:"pagination"`
@@ -88,15 +252,95 @@ type PostCreatedResponse struct {
 	Uuid UUID `json:"uuid"`
 }
 
+// Problem Error details (RFC 7807). `type` is a stable URI identifying the kind of the error, `title` is its human-readable summary
+type Problem struct {
+	// Detail Explanation specific to this occurrence of the problem
+	Detail *string `json:"detail,omitempty"`
+
+	// Instance Path of the request that caused the problem
+	Instance *string `json:"instance,omitempty"`
+
+	// InvalidParams Request parameters and body fields that failed validation
+	InvalidParams *[]ProblemInvalidParam `json:"invalid_params,omitempty"`
+
+	// Status HTTP status code
+	Status int    `json:"status"`
+	Title  string `json:"title"`
+	Type   string `json:"type"`
+}
+
+// ProblemInvalidParam defines model for ProblemInvalidParam.
+type ProblemInvalidParam struct {
+	In ProblemInvalidParamIn `json:"in"`
+
+	// Name Parameter name or JSON Pointer (RFC 6901) to a body field
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// ProblemInvalidParamIn defines model for ProblemInvalidParam.In.
+type ProblemInvalidParamIn string
+
 // Sex defines model for Sex.
 type Sex string
 
 // UUID defines model for UUID.
 type UUID = uuid.UUID
 
+// IdempotencyKey defines model for idempotencyKey.
+type IdempotencyKey = string
+
 // PersonID defines model for personID.
 type PersonID = UUID
 
+// N400BadRequest Error details (RFC 7807). `type` is a stable URI identifying the kind of the error, `title` is its human-readable summary
+type N400BadRequest = Problem
+
+// N401Unauthorized Error details (RFC 7807). `type` is a stable URI identifying the kind of the error, `title` is its human-readable summary
+type N401Unauthorized = Problem
+
+// N403Forbidden Error details (RFC 7807). `type` is a stable URI identifying the kind of the error, `title` is its human-readable summary
+type N403Forbidden = Problem
+
+// N404NotFound Error details (RFC 7807). `type` is a stable URI identifying the kind of the error, `title` is its human-readable summary
+type N404NotFound = Problem
+
+// N409Conflict Error details (RFC 7807). `type` is a stable URI identifying the kind of the error, `title` is its human-readable summary
+type N409Conflict = Problem
+
+// N422InvalidName Error details (RFC 7807). `type` is a stable URI identifying the kind of the error, `title` is its human-readable summary
+type N422InvalidName = Problem
+
+// N429TooManyRequests Error details (RFC 7807). `type` is a stable URI identifying the kind of the error, `title` is its human-readable summary
+type N429TooManyRequests = Problem
+
+// N503Unavailable Error details (RFC 7807). `type` is a stable URI identifying the kind of the error, `title` is its human-readable summary
+type N503Unavailable = Problem
+
+// N5XXInternalServerError Error details (RFC 7807). `type` is a stable URI identifying the kind of the error, `title` is its human-readable summary
+type N5XXInternalServerError = Problem
+
+// AuditListParams defines parameters for AuditList.
+type AuditListParams struct {
+	// Actor Client that made the changes
+	Actor *string `form:"actor,omitempty" json:"actor,omitempty"`
+
+	// PersonId Changed Person
+	PersonId *UUID `form:"person_id,omitempty" json:"person_id,omitempty"`
+
+	// From Start of the time range (inclusive, RFC 3339)
+	From *time.Time `form:"from,omitempty" json:"from,omitempty"`
+
+	// To End of the time range (exclusive, RFC 3339)
+	To *time.Time `form:"to,omitempty" json:"to,omitempty"`
+
+	// Offset The number of records to skip
+	Offset *int `form:"offset,omitempty" json:"offset,omitempty"`
+
+	// Limit The numbers of records to return
+	Limit *int `form:"limit,omitempty" json:"limit,omitempty"`
+}
+
 // PersonListParams defines parameters for PersonList.
 type PersonListParams struct {
 	// Name Person's name (case-insensitive, similarity search)
@@ -123,18 +367,69 @@ type PersonListParams struct {
 	// Threshold Threshold for similarity search (0.0 to 1.0)
 	Threshold *float32 `form:"threshold,omitempty" json:"threshold,omitempty"`
 
+	// Match How names are matched. `trigram` scores them by trigram similarity. `phonetic` finds names sounding alike (Double Metaphone codes) and `fulltext` finds names containing every word of the searched ones; both compare names transliterated to Latin (`Дмитрий`, `Dmitriy` and `Dmitry` are the same) and ignore the threshold
+	Match *PersonListParamsMatch `form:"match,omitempty" json:"match,omitempty"`
+
 	// Offset The number of records to skip
 	Offset *int `form:"offset,omitempty" json:"offset,omitempty"`
 
 	// Limit The numbers of records to return (all if 0)
 	Limit *int `form:"limit,omitempty" json:"limit,omitempty"`
+
+	// IncludeDeleted Include deleted Person records that were not purged yet (for administrators)
+	IncludeDeleted *bool `form:"include_deleted,omitempty" json:"include_deleted,omitempty"`
+
+	// Total Count the people matching the filters (`total_items`). Counting scans every person matching the filters other than the threshold, set to false to list large tables faster
+	Total *bool `form:"total,omitempty" json:"total,omitempty"`
+}
+
+// PersonListParamsMatch defines parameters for PersonList.
+type PersonListParamsMatch string
+
+// PersonPostParams defines parameters for PersonPost.
+type PersonPostParams struct {
+	// IdempotencyKey Makes the request idempotent: the response of the first request with the key is replayed (with `Idempotent-Replayed: true` header) for repeated requests with the same body. Reusing the key with another body results in 422, a repeated request made while the first one is still in progress results in 409. Keys are scoped to the authenticated client
+	IdempotencyKey *IdempotencyKey `json:"Idempotency-Key,omitempty"`
+}
+
+// PersonBatchPostParams defines parameters for PersonBatchPost.
+type PersonBatchPostParams struct {
+	// IdempotencyKey Makes the request idempotent: the response of the first request with the key is replayed (with `Idempotent-Replayed: true` header) for repeated requests with the same body. Reusing the key with another body results in 422, a repeated request made while the first one is still in progress results in 409. Keys are scoped to the authenticated client
+	IdempotencyKey *IdempotencyKey `json:"Idempotency-Key,omitempty"`
+}
+
+// PersonGetParams defines parameters for PersonGet.
+type PersonGetParams struct {
+	// AsOf Get the Person as it was at the moment (RFC 3339)
+	AsOf *time.Time `form:"as_of,omitempty" json:"as_of,omitempty"`
+}
+
+// PersonHistoryParams defines parameters for PersonHistory.
+type PersonHistoryParams struct {
+	// Offset The number of records to skip
+	Offset *int `form:"offset,omitempty" json:"offset,omitempty"`
+
+	// Limit The numbers of records to return
+	Limit *int `form:"limit,omitempty" json:"limit,omitempty"`
+}
+
+// PersonRestoreParams defines parameters for PersonRestore.
+type PersonRestoreParams struct {
+	// IdempotencyKey Makes the request idempotent: the response of the first request with the key is replayed (with `Idempotent-Replayed: true` header) for repeated requests with the same body. Reusing the key with another body results in 422, a repeated request made while the first one is still in progress results in 409. Keys are scoped to the authenticated client
+	IdempotencyKey *IdempotencyKey `json:"Idempotency-Key,omitempty"`
 }
 
 // PersonPostJSONRequestBody defines body for PersonPost for application/json ContentType.
 type PersonPostJSONRequestBody = PersonPostData
 
-// PersonPatchJSONRequestBody defines body for PersonPatch for application/json ContentType.
-type PersonPatchJSONRequestBody = PersonPartial
+// PersonBatchPostJSONRequestBody defines body for PersonBatchPost for application/json ContentType.
+type PersonBatchPostJSONRequestBody = PersonBatchPostData
+
+// PersonPatchApplicationJSONPatchPlusJSONRequestBody defines body for PersonPatch for application/json-patch+json ContentType.
+type PersonPatchApplicationJSONPatchPlusJSONRequestBody = PersonJSONPatch
+
+// PersonPatchApplicationMergePatchPlusJSONRequestBody defines body for PersonPatch for application/merge-patch+json ContentType.
+type PersonPatchApplicationMergePatchPlusJSONRequestBody = PersonMergePatch
 
 // PersonPutJSONRequestBody defines body for PersonPut for application/json ContentType.
 type PersonPutJSONRequestBody = PersonFull
@@ -0,0 +1,259 @@
+// Package auth authenticates requests by API keys (X-API-Key header),
+// HTTP Basic credentials of API clients and JWT bearer tokens.
+//
+// An API key is "<key id>.<secret>": the key id is stored as is and used
+// to find the client, the secret is only stored as a bcrypt hash.
+// Basic credentials are the client name and the same secret.
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Hofsiedge/person-api/internal/domain"
+	"github.com/Hofsiedge/person-api/internal/repo"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	APIKeyHeader = "X-API-Key"
+	// sent with 401 responses if API clients are accepted
+	BasicChallenge = `Basic realm="person-api", charset="UTF-8"`
+
+	keyIDBytes  = 8
+	secretBytes = 32
+)
+
+// sentinel errors.
+var (
+	ErrAuth               = errors.New("authentication error")
+	ErrNoCredentials      = fmt.Errorf("%w: no credentials", ErrAuth)
+	ErrInvalidCredentials = fmt.Errorf("%w: invalid credentials", ErrAuth)
+)
+
+// Roles are named sets of scopes granted to API clients
+//
+//nolint:gochecknoglobals
+var Roles = map[string][]string{
+	"analyst": {"people:read"},
+	"editor":  {"people:read", "people:write"},
+	"admin":   {"people:read", "people:write", "people:delete", "people:admin"},
+}
+
+// compared with when a client is not found so that unknown clients
+// take as long to reject as wrong secrets
+//
+//nolint:gochecknoglobals
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy"), bcrypt.DefaultCost)
+
+// Key is a generated API key
+type Key struct {
+	KeyID  string
+	Secret string
+}
+
+// String returns the key as sent in X-API-Key header
+func (k Key) String() string {
+	return k.KeyID + "." + k.Secret
+}
+
+// GenerateKey generates a random API key
+func GenerateKey() (Key, error) {
+	keyID := make([]byte, keyIDBytes)
+	secret := make([]byte, secretBytes)
+
+	if _, err := rand.Read(keyID); err != nil {
+		return Key{}, fmt.Errorf("could not generate a key: %w", err)
+	}
+
+	if _, err := rand.Read(secret); err != nil {
+		return Key{}, fmt.Errorf("could not generate a key: %w", err)
+	}
+
+	return Key{
+		KeyID:  hex.EncodeToString(keyID),
+		Secret: base64.RawURLEncoding.EncodeToString(secret),
+	}, nil
+}
+
+// HashSecret returns a bcrypt hash of the secret
+func HashSecret(secret string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("could not hash a secret: %w", err)
+	}
+
+	return string(hash), nil
+}
+
+// kinds of principals
+const (
+	KindClient = "client"
+	KindJWT    = "jwt"
+)
+
+// Principal is an authenticated API client or token subject
+type Principal struct {
+	// KindClient or KindJWT
+	Kind    string
+	Subject string
+	Scopes  []string
+}
+
+// Actor identifies the principal in the audit log, rate limits and
+// idempotency keys: "<kind>:<subject>", so that a token subject can not
+// impersonate an API client with the same name.
+func (p Principal) Actor() string {
+	return p.Kind + ":" + p.Subject
+}
+
+type Authenticator interface {
+	// Authenticate returns the principal authenticated by the request.
+	// Returns ErrNoCredentials if the request has no credentials of
+	// the supported kind and ErrInvalidCredentials if they are wrong.
+	Authenticate(ctx context.Context, r *http.Request) (Principal, error)
+	// Challenge returns the WWW-Authenticate challenge
+	Challenge() string
+}
+
+// Chain tries authenticators in order until one finds credentials
+type Chain []Authenticator
+
+// Authenticate implements Authenticator.
+func (c Chain) Authenticate(ctx context.Context, r *http.Request) (Principal, error) {
+	for _, authenticator := range c {
+		principal, err := authenticator.Authenticate(ctx, r)
+		if !errors.Is(err, ErrNoCredentials) {
+			return principal, err
+		}
+	}
+
+	return Principal{}, ErrNoCredentials
+}
+
+// Challenge implements Authenticator.
+func (c Chain) Challenge() string {
+	challenges := make([]string, len(c))
+	for i, authenticator := range c {
+		challenges[i] = authenticator.Challenge()
+	}
+
+	return strings.Join(challenges, ", ")
+}
+
+type cacheEntry struct {
+	expiresAt time.Time
+	client    domain.APIClient
+}
+
+// ClientAuthenticator authenticates API clients of a repo.APIClientRepo.
+//
+// bcrypt is slow by design, so successful authentications are cached
+// for cacheTTL: a revoked key keeps working for at most that long.
+type ClientAuthenticator struct {
+	clients  repo.APIClientRepo
+	cache    map[[sha256.Size]byte]cacheEntry
+	cacheTTL time.Duration
+	mutex    sync.Mutex
+}
+
+func NewClientAuthenticator(clients repo.APIClientRepo, cacheTTL time.Duration) *ClientAuthenticator {
+	return &ClientAuthenticator{
+		clients:  clients,
+		cache:    make(map[[sha256.Size]byte]cacheEntry),
+		cacheTTL: cacheTTL,
+		mutex:    sync.Mutex{},
+	}
+}
+
+// Authenticate implements Authenticator.
+func (a *ClientAuthenticator) Authenticate(ctx context.Context, r *http.Request) (Principal, error) {
+	client, err := a.client(ctx, r)
+	if err != nil {
+		return Principal{}, err
+	}
+
+	return Principal{Kind: KindClient, Subject: client.Name, Scopes: client.Scopes}, nil
+}
+
+// Challenge implements Authenticator.
+func (a *ClientAuthenticator) Challenge() string {
+	return BasicChallenge
+}
+
+// client returns the client authenticated by the request
+func (a *ClientAuthenticator) client(ctx context.Context, r *http.Request) (domain.APIClient, error) {
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		keyID, secret, _ := strings.Cut(key, ".")
+
+		return a.verify("key:"+key, secret, func() (domain.APIClient, error) {
+			return a.clients.ClientByKeyID(ctx, keyID)
+		})
+	}
+
+	if name, secret, found := r.BasicAuth(); found {
+		return a.verify("basic:"+name+":"+secret, secret, func() (domain.APIClient, error) {
+			return a.clients.ClientByName(ctx, name)
+		})
+	}
+
+	return domain.APIClient{}, ErrNoCredentials
+}
+
+func (a *ClientAuthenticator) verify(
+	credentials, secret string, find func() (domain.APIClient, error),
+) (domain.APIClient, error) {
+	cacheKey := sha256.Sum256([]byte(credentials))
+	if client, found := a.cached(cacheKey); found {
+		return client, nil
+	}
+
+	client, err := find()
+	if err != nil && !errors.Is(err, repo.ErrNotFound) {
+		return domain.APIClient{}, fmt.Errorf("could not find a client: %w", err)
+	}
+
+	hash := []byte(client.SecretHash)
+	if err != nil {
+		hash = dummyHash
+	}
+
+	if bcrypt.CompareHashAndPassword(hash, []byte(secret)) != nil || err != nil {
+		return domain.APIClient{}, ErrInvalidCredentials
+	}
+
+	if a.cacheTTL > 0 {
+		a.mutex.Lock()
+		a.cache[cacheKey] = cacheEntry{expiresAt: time.Now().Add(a.cacheTTL), client: client}
+		a.mutex.Unlock()
+	}
+
+	return client, nil
+}
+
+func (a *ClientAuthenticator) cached(key [sha256.Size]byte) (domain.APIClient, bool) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	entry, found := a.cache[key]
+	if !found {
+		return domain.APIClient{}, false
+	}
+
+	if time.Now().After(entry.expiresAt) {
+		delete(a.cache, key)
+
+		return domain.APIClient{}, false
+	}
+
+	return entry.client, true
+}
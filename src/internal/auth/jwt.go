@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// BearerChallenge is sent with 401 responses if JWTs are accepted
+const BearerChallenge = `Bearer realm="person-api"`
+
+// JWTOptions are requirements for accepted tokens
+type JWTOptions struct {
+	// required iss claim, not checked if empty
+	Issuer string
+	// required aud claim, not checked if empty
+	Audience string
+	// allowed clock skew for exp and nbf
+	Leeway time.Duration
+}
+
+// claims are the registered claims with scopes either in "scope"
+// (a space separated string, RFC 8693) or in "scp" (an array)
+type claims struct {
+	jwt.RegisteredClaims
+	Scope string   `json:"scope"`
+	Scp   []string `json:"scp"`
+}
+
+func (c claims) scopes() []string {
+	if c.Scp != nil {
+		return c.Scp
+	}
+
+	return strings.Fields(c.Scope)
+}
+
+// JWTAuthenticator authenticates requests by signed bearer tokens
+// (RS256, ES256 or EdDSA) verified with the keys of a KeySet.
+// exp is required, nbf is checked if present.
+type JWTAuthenticator struct {
+	keys   *KeySet
+	parser *jwt.Parser
+}
+
+func NewJWTAuthenticator(keys *KeySet, options JWTOptions) *JWTAuthenticator {
+	parserOptions := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"RS256", "ES256", "EdDSA"}),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(options.Leeway),
+	}
+
+	if options.Issuer != "" {
+		parserOptions = append(parserOptions, jwt.WithIssuer(options.Issuer))
+	}
+
+	if options.Audience != "" {
+		parserOptions = append(parserOptions, jwt.WithAudience(options.Audience))
+	}
+
+	return &JWTAuthenticator{
+		keys:   keys,
+		parser: jwt.NewParser(parserOptions...),
+	}
+}
+
+// Authenticate implements Authenticator.
+func (a *JWTAuthenticator) Authenticate(ctx context.Context, r *http.Request) (Principal, error) {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return Principal{}, ErrNoCredentials
+	}
+
+	var tokenClaims claims
+
+	_, err := a.parser.ParseWithClaims(strings.TrimSpace(token), &tokenClaims, a.keyfunc)
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: %w", ErrInvalidCredentials, err)
+	}
+
+	if tokenClaims.Subject == "" {
+		return Principal{}, fmt.Errorf("%w: token has no subject", ErrInvalidCredentials)
+	}
+
+	return Principal{Kind: KindJWT, Subject: tokenClaims.Subject, Scopes: tokenClaims.scopes()}, nil
+}
+
+// Challenge implements Authenticator.
+func (a *JWTAuthenticator) Challenge() string {
+	return BearerChallenge
+}
+
+func (a *JWTAuthenticator) keyfunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	keys := a.keys.Keys(kid)
+	if len(keys) == 0 {
+		return nil, errors.New("unknown key")
+	}
+
+	keySet := jwt.VerificationKeySet{Keys: make([]jwt.VerificationKey, len(keys))}
+	for i, key := range keys {
+		keySet.Keys[i] = key
+	}
+
+	return keySet, nil
+}
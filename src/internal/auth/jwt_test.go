@@ -0,0 +1,263 @@
+package auth_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Hofsiedge/person-api/internal/auth"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	issuer   = "https://gateway.example"
+	audience = "person-api"
+)
+
+type signingKey struct {
+	private crypto.Signer
+	method  jwt.SigningMethod
+	kid     string
+}
+
+func generateKeys(t *testing.T) []signingKey {
+	t.Helper()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048) //nolint:gomnd
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return []signingKey{
+		{rsaKey, jwt.SigningMethodRS256, "rsa"},
+		{ecKey, jwt.SigningMethodES256, "ec"},
+		{edKey, jwt.SigningMethodEdDSA, "ed"},
+	}
+}
+
+func encode(value *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(value.Bytes())
+}
+
+func writeJWKS(t *testing.T, path string, keys []signingKey) {
+	t.Helper()
+
+	jwks := make([]map[string]string, 0, len(keys))
+
+	for _, key := range keys {
+		switch public := key.private.Public().(type) {
+		case *rsa.PublicKey:
+			jwks = append(jwks, map[string]string{
+				"kty": "RSA", "kid": key.kid, "n": encode(public.N), "e": encode(big.NewInt(int64(public.E))),
+			})
+		case *ecdsa.PublicKey:
+			jwks = append(jwks, map[string]string{
+				"kty": "EC", "kid": key.kid, "crv": "P-256", "x": encode(public.X), "y": encode(public.Y),
+			})
+		case ed25519.PublicKey:
+			jwks = append(jwks, map[string]string{
+				"kty": "OKP", "kid": key.kid, "crv": "Ed25519", "x": base64.RawURLEncoding.EncodeToString(public),
+			})
+		}
+	}
+
+	data, err := json.Marshal(map[string]any{"keys": jwks})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = os.WriteFile(path, data, 0o600); err != nil { //nolint:gomnd
+		t.Fatal(err)
+	}
+}
+
+func sign(t *testing.T, key signingKey, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.kid
+
+	signed, err := token.SignedString(key.private)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signed
+}
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub":   "user",
+		"iss":   issuer,
+		"aud":   audience,
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"scope": "people:read people:write",
+	}
+}
+
+func authenticate(authenticator auth.Authenticator, token string) (auth.Principal, error) {
+	request := httptest.NewRequest(http.MethodGet, "/person", nil)
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	return authenticator.Authenticate(context.Background(), request) //nolint:wrapcheck
+}
+
+//nolint:funlen
+func TestJWTAuthenticator(t *testing.T) {
+	t.Parallel()
+
+	keys := generateKeys(t)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, path, keys)
+
+	keySet, err := auth.NewJWKSKeySet(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authenticator := auth.NewJWTAuthenticator(keySet, auth.JWTOptions{
+		Issuer:   issuer,
+		Audience: audience,
+		Leeway:   0,
+	})
+
+	for _, key := range keys {
+		principal, err := authenticate(authenticator, sign(t, key, validClaims()))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", key.method.Alg(), err)
+		}
+
+		expected := auth.Principal{Kind: auth.KindJWT, Subject: "user", Scopes: []string{"people:read", "people:write"}}
+		if !reflect.DeepEqual(principal, expected) {
+			t.Errorf("%s: principal mismatch: expected %v, got %v", key.method.Alg(), expected, principal)
+		}
+	}
+
+	claims := func(key string, value any) jwt.MapClaims {
+		result := validClaims()
+		if value == nil {
+			delete(result, key)
+		} else {
+			result[key] = value
+		}
+
+		return result
+	}
+
+	otherKey := generateKeys(t)[0]
+	otherKey.kid = "rsa"
+
+	testCases := []struct {
+		error error
+		name  string
+		token string
+	}{
+		{auth.ErrNoCredentials, "no token", ""},
+		{auth.ErrInvalidCredentials, "malformed", "token"},
+		{auth.ErrInvalidCredentials, "expired", sign(t, keys[0], claims("exp", time.Now().Add(-time.Hour).Unix()))},
+		{auth.ErrInvalidCredentials, "no exp", sign(t, keys[0], claims("exp", nil))},
+		{auth.ErrInvalidCredentials, "not yet valid", sign(t, keys[0], claims("nbf", time.Now().Add(time.Hour).Unix()))},
+		{auth.ErrInvalidCredentials, "wrong issuer", sign(t, keys[0], claims("iss", "https://other.example"))},
+		{auth.ErrInvalidCredentials, "wrong audience", sign(t, keys[0], claims("aud", "other"))},
+		{auth.ErrInvalidCredentials, "no subject", sign(t, keys[0], claims("sub", nil))},
+		{auth.ErrInvalidCredentials, "wrong key", sign(t, otherKey, validClaims())},
+		{
+			auth.ErrInvalidCredentials, "unsupported method",
+			func() string {
+				token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, validClaims()).SignedString([]byte("secret"))
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				return token
+			}(),
+		},
+	}
+
+	for _, tCase := range testCases {
+		test := tCase
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := authenticate(authenticator, test.token); !errors.Is(err, test.error) {
+				t.Errorf("expected %v, got %v", test.error, err)
+			}
+		})
+	}
+}
+
+func TestKeySetReload(t *testing.T) {
+	t.Parallel()
+
+	keys := generateKeys(t)
+	dir := t.TempDir()
+
+	writePEM := func(key signingKey) {
+		data, err := x509.MarshalPKIXPublicKey(key.private.Public())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		block := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Headers: nil, Bytes: data})
+		if err = os.WriteFile(filepath.Join(dir, key.kid+".pem"), block, 0o600); err != nil { //nolint:gomnd
+			t.Fatal(err)
+		}
+	}
+
+	writePEM(keys[0])
+
+	keySet, err := auth.NewPEMKeySet(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authenticator := auth.NewJWTAuthenticator(keySet, auth.JWTOptions{Issuer: "", Audience: "", Leeway: 0})
+
+	token := sign(t, keys[1], validClaims())
+	if _, err = authenticate(authenticator, token); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Errorf("unknown key was accepted: %v", err)
+	}
+
+	writePEM(keys[1])
+
+	if reloaded, err := keySet.Reload(); err != nil || !reloaded {
+		t.Fatalf("keys were not reloaded: %v", err)
+	}
+
+	if _, err = authenticate(authenticator, token); err != nil {
+		t.Errorf("added key was not accepted: %v", err)
+	}
+
+	if reloaded, err := keySet.Reload(); err != nil || reloaded {
+		t.Errorf("unchanged keys were reloaded: %v", err)
+	}
+}
@@ -0,0 +1,317 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrInvalidKey = errors.New("invalid key")
+
+// KeySet is a set of public keys that verify JWT signatures, loaded from
+// a JWKS file or from a directory of PEM files (the key id of a PEM key is
+// its file name without the extension). Keys are reloaded by Watch when
+// the source changes.
+type KeySet struct {
+	keys    map[string]crypto.PublicKey
+	source  string
+	version string
+	mutex   sync.RWMutex
+	pemDir  bool
+}
+
+// NewJWKSKeySet loads keys from a JWKS file
+func NewJWKSKeySet(path string) (*KeySet, error) {
+	return newKeySet(path, false)
+}
+
+// NewPEMKeySet loads keys from *.pem files in the directory
+func NewPEMKeySet(dir string) (*KeySet, error) {
+	return newKeySet(dir, true)
+}
+
+func newKeySet(source string, pemDir bool) (*KeySet, error) {
+	keySet := &KeySet{
+		keys:    nil,
+		source:  source,
+		version: "",
+		mutex:   sync.RWMutex{},
+		pemDir:  pemDir,
+	}
+
+	if _, err := keySet.Reload(); err != nil {
+		return nil, err
+	}
+
+	return keySet, nil
+}
+
+// Keys returns the key with the id or all keys if kid is empty
+func (s *KeySet) Keys(kid string) []crypto.PublicKey {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if kid != "" {
+		if key, found := s.keys[kid]; found {
+			return []crypto.PublicKey{key}
+		}
+
+		return nil
+	}
+
+	keys := make([]crypto.PublicKey, 0, len(s.keys))
+	for _, key := range s.keys {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+// Reload reloads the keys if the source has changed since the last load.
+// Reports whether the keys were reloaded. Keys are kept on error.
+func (s *KeySet) Reload() (bool, error) {
+	version, err := s.sourceVersion()
+	if err != nil {
+		return false, err
+	}
+
+	s.mutex.RLock()
+	unchanged := version == s.version
+	s.mutex.RUnlock()
+
+	if unchanged {
+		return false, nil
+	}
+
+	var keys map[string]crypto.PublicKey
+	if s.pemDir {
+		keys, err = loadPEMDir(s.source)
+	} else {
+		keys, err = loadJWKS(s.source)
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	s.mutex.Lock()
+	s.keys, s.version = keys, version
+	s.mutex.Unlock()
+
+	return true, nil
+}
+
+// Watch reloads the keys every interval until ctx is done
+func (s *KeySet) Watch(ctx context.Context, interval time.Duration, logger *slog.Logger) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reloaded, err := s.Reload()
+			if err != nil {
+				logger.Error("could not reload JWT keys",
+					slog.String("source", s.source),
+					slog.String("error", err.Error()))
+			} else if reloaded {
+				logger.Info("reloaded JWT keys", slog.String("source", s.source))
+			}
+		}
+	}
+}
+
+// sourceVersion identifies the state of the source by names, sizes and
+// modification times of the files
+func (s *KeySet) sourceVersion() (string, error) {
+	paths := []string{s.source}
+
+	if s.pemDir {
+		var err error
+
+		paths, err = filepath.Glob(filepath.Join(s.source, "*.pem"))
+		if err != nil {
+			return "", fmt.Errorf("could not list %s: %w", s.source, err)
+		}
+	}
+
+	var version strings.Builder
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return "", fmt.Errorf("could not read keys: %w", err)
+		}
+
+		fmt.Fprintf(&version, "%s:%d:%d\n", path, info.Size(), info.ModTime().UnixNano())
+	}
+
+	return version.String(), nil
+}
+
+func loadPEMDir(dir string) (map[string]crypto.PublicKey, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, fmt.Errorf("could not list %s: %w", dir, err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(paths))
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("could not read keys: %w", err)
+		}
+
+		block, _ := pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("%w: %s is not a PEM file", ErrInvalidKey, path)
+		}
+
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrInvalidKey, path, err)
+		}
+
+		keys[strings.TrimSuffix(filepath.Base(path), ".pem")] = key
+	}
+
+	return keys, nil
+}
+
+// jwk is a JSON Web Key (RFC 7517) of RSA, EC or OKP (Ed25519) type
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func loadJWKS(path string) (map[string]crypto.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read keys: %w", err)
+	}
+
+	var jwks struct {
+		Keys []jwk `json:"keys"`
+	}
+
+	if err = json.Unmarshal(data, &jwks); err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrInvalidKey, path, err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(jwks.Keys))
+
+	for i, key := range jwks.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+
+		publicKey, err := key.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("%w: key %d (%q) of %s: %w", ErrInvalidKey, i, key.Kid, path, err)
+		}
+
+		kid := key.Kid
+		if kid == "" {
+			kid = fmt.Sprint(i)
+		}
+
+		keys[kid] = publicKey
+	}
+
+	return keys, nil
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		curves := map[string]elliptic.Curve{
+			"P-256": elliptic.P256(),
+			"P-384": elliptic.P384(),
+			"P-521": elliptic.P521(),
+		}
+
+		curve, found := curves[k.Crv]
+		if !found {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		if !curve.IsOnCurve(x, y) { //nolint:staticcheck
+			return nil, errors.New("point is not on the curve")
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+
+		return ed25519.PublicKey(x), nil
+	}
+
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(data) == 0 {
+		return nil, errors.New("invalid key parameter")
+	}
+
+	return new(big.Int).SetBytes(data), nil
+}
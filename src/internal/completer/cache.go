@@ -0,0 +1,81 @@
+package completer
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+type cacheEntry struct {
+	expiresAt time.Time
+	name      string
+	data      CompletionData
+}
+
+// cache is an LRU cache of completions by name. Entries expire after ttl.
+type cache struct {
+	entries map[string]*list.Element
+	// the most recently used entry is at the front
+	order *list.List
+	ttl   time.Duration
+	size  int
+	mutex sync.Mutex
+}
+
+func newCache(size int, ttl time.Duration) *cache {
+	return &cache{
+		entries: make(map[string]*list.Element, size),
+		order:   list.New(),
+		ttl:     ttl,
+		size:    size,
+		mutex:   sync.Mutex{},
+	}
+}
+
+func (c *cache) get(name string) (CompletionData, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, found := c.entries[name]
+	if !found {
+		return CompletionData{}, false
+	}
+
+	entry, _ := element.Value.(*cacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.order.Remove(element)
+		delete(c.entries, name)
+
+		return CompletionData{}, false
+	}
+
+	c.order.MoveToFront(element)
+
+	return entry.data, true
+}
+
+// put adds or replaces the entry evicting the least recently used one
+// if the cache is full
+func (c *cache) put(name string, data CompletionData) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry := &cacheEntry{expiresAt: time.Now().Add(c.ttl), name: name, data: data}
+
+	if element, found := c.entries[name]; found {
+		element.Value = entry
+		c.order.MoveToFront(element)
+
+		return
+	}
+
+	if c.order.Len() >= c.size {
+		oldest := c.order.Back()
+		evicted, _ := oldest.Value.(*cacheEntry)
+
+		c.order.Remove(oldest)
+		delete(c.entries, evicted.name)
+	}
+
+	c.entries[name] = c.order.PushFront(entry)
+}
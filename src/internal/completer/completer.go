@@ -1,6 +1,7 @@
 package completer
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -12,9 +13,23 @@ import (
 	"github.com/Hofsiedge/person-api/internal/filler/agify"
 	"github.com/Hofsiedge/person-api/internal/filler/genderize"
 	"github.com/Hofsiedge/person-api/internal/filler/nationalize"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
-const Timeout = time.Second * 10
+const (
+	Timeout    = time.Second * 10
+	tracerName = "github.com/Hofsiedge/person-api/internal/completer"
+)
+
+// names of the fillers (providers)
+const (
+	Agify       = "agify"
+	Genderize   = "genderize"
+	Nationalize = "nationalize"
+)
 
 var (
 	ErrCompleterError = errors.New("completer error")
@@ -25,12 +40,23 @@ var (
 	ErrWrongUsage = fmt.Errorf("%w: wrong usage", ErrCompleterError)
 )
 
+// Observer is notified about filler calls (e.g. to collect metrics)
+type Observer interface {
+	// FillerCalled is called after every call of a filler
+	FillerCalled(provider string, duration time.Duration, err error)
+	// CacheLookup is called on every lookup of a name in the cache
+	CacheLookup(hit bool)
+}
+
 // Completer is an abstraction over filler.Filler that uses multiple concurrently
 // fillers and combines their results
 type Completer struct {
 	client *http.Client
 	// time when the fillers request quota resets
-	unlockTime   *time.Time
+	unlockTime *time.Time
+	observer   Observer
+	// nil if completions are not cached
+	cache        *cache
 	genderizer   genderize.Genderizer
 	nationalizer nationalize.Nationalizer
 	agifier      agify.Agifier
@@ -50,20 +76,88 @@ func New(cfg config.CompleterConfig, client *http.Client) *Completer {
 		}
 	}
 
-	var token *string
-	if len(cfg.CompleterToken) > 0 {
-		token = &cfg.CompleterToken
+	token := optionalToken(cfg.CompleterToken)
+
+	var completions *cache
+	if cfg.CacheSize > 0 {
+		completions = newCache(cfg.CacheSize, cfg.CacheTTL)
 	}
 
 	return &Completer{
 		client:       client,
 		unlockTime:   nil,
+		observer:     nil,
+		cache:        completions,
 		genderizer:   genderize.New(cfg.GenderizeURL, token, client),
 		nationalizer: nationalize.New(cfg.NationalizeURL, token, client),
 		agifier:      agify.New(cfg.AgifyURL, token, client),
 	}
 }
 
+// optionalToken returns nil if no token is set
+func optionalToken(token string) *string {
+	if len(token) > 0 {
+		return &token
+	}
+
+	return nil
+}
+
+// SetToken replaces the API token of the fillers (empty to use none).
+// It is safe to call concurrently with Complete.
+func (c *Completer) SetToken(token string) {
+	c.genderizer.SetToken(optionalToken(token))
+	c.nationalizer.SetToken(optionalToken(token))
+	c.agifier.SetToken(optionalToken(token))
+}
+
+// SetObserver sets the observer of filler calls. It must not be called
+// concurrently with Complete.
+func (c *Completer) SetObserver(observer Observer) {
+	c.observer = observer
+}
+
+// call calls a filler in a span of its own and notifies the observer
+func (c *Completer) call(ctx context.Context, provider string, fill func(ctx context.Context) error) error {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "completer."+provider,
+		trace.WithAttributes(attribute.String("provider", provider)))
+	defer span.End()
+
+	start := time.Now()
+	err := fill(ctx)
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	if c.observer != nil {
+		c.observer.FillerCalled(provider, time.Since(start), err)
+	}
+
+	return err
+}
+
+// RequestsLeft returns the number of requests left until the rate limiter
+// reset by provider. Fillers that have not been used yet are omitted.
+func (c *Completer) RequestsLeft() map[string]int {
+	result := make(map[string]int, 3) //nolint:gomnd
+
+	if left, err := c.agifier.RequestsLeft(); err == nil {
+		result[Agify] = left
+	}
+
+	if left, err := c.genderizer.RequestsLeft(); err == nil {
+		result[Genderize] = left
+	}
+
+	if left, err := c.nationalizer.RequestsLeft(); err == nil {
+		result[Nationalize] = left
+	}
+
+	return result
+}
+
 func bToI(b bool) int {
 	if b {
 		return 1
@@ -72,7 +166,34 @@ func bToI(b bool) int {
 	return 0
 }
 
-func (c *Completer) Complete(name string) (CompletionData, error) {
+// Complete returns the cached completion of the name or calls the fillers
+// concurrently. The calls are canceled with ctx. Only successful
+// completions are cached.
+func (c *Completer) Complete(ctx context.Context, name string) (CompletionData, error) {
+	if c.cache == nil {
+		return c.complete(ctx, name)
+	}
+
+	data, hit := c.cache.get(name)
+
+	if c.observer != nil {
+		c.observer.CacheLookup(hit)
+	}
+
+	if hit {
+		return data, nil
+	}
+
+	data, err := c.complete(ctx, name)
+	if err == nil {
+		c.cache.put(name, data)
+	}
+
+	return data, err
+}
+
+// complete calls the fillers concurrently
+func (c *Completer) complete(ctx context.Context, name string) (CompletionData, error) {
 	var wg sync.WaitGroup //nolint:varnamelen
 
 	wg.Add(3) //nolint:gomnd
@@ -83,19 +204,31 @@ func (c *Completer) Complete(name string) (CompletionData, error) {
 	)
 
 	go func() {
-		data.Sex, sexErr = c.genderizer.Fill(name)
+		sexErr = c.call(ctx, Genderize, func(ctx context.Context) (err error) {
+			data.Sex, err = c.genderizer.Fill(ctx, name)
+
+			return err
+		})
 
 		wg.Done()
 	}()
 
 	go func() {
-		data.Nationality, nationalityErr = c.nationalizer.Fill(name)
+		nationalityErr = c.call(ctx, Nationalize, func(ctx context.Context) (err error) {
+			data.Nationality, err = c.nationalizer.Fill(ctx, name)
+
+			return err
+		})
 
 		wg.Done()
 	}()
 
 	go func() {
-		data.Age, ageErr = c.agifier.Fill(name)
+		ageErr = c.call(ctx, Agify, func(ctx context.Context) (err error) {
+			data.Age, err = c.agifier.Fill(ctx, name)
+
+			return err
+		})
 
 		wg.Done()
 	}()
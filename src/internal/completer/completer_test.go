@@ -0,0 +1,97 @@
+package completer_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Hofsiedge/person-api/internal/completer"
+	"github.com/Hofsiedge/person-api/internal/config"
+	"github.com/Hofsiedge/person-api/internal/domain"
+)
+
+type lookups struct {
+	hits, misses atomic.Int32
+}
+
+func (l *lookups) FillerCalled(string, time.Duration, error) {}
+
+func (l *lookups) CacheLookup(hit bool) {
+	if hit {
+		l.hits.Add(1)
+	} else {
+		l.misses.Add(1)
+	}
+}
+
+// newProviders serves the fillers counting the calls
+func newProviders(t *testing.T, calls *atomic.Int32) config.CompleterConfig {
+	t.Helper()
+
+	bodies := map[string]string{
+		"/agify":       `{"count":1,"name":"Ashley","age":30}`,
+		"/genderize":   `{"count":1,"name":"Ashley","gender":"female","probability":0.99}`,
+		"/nationalize": `{"count":1,"name":"Ashley","country":[{"country_id":"US","probability":0.5}]}`,
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("X-Rate-Limit-Limit", "1000")
+		w.Header().Set("X-Rate-Limit-Remaining", "100")
+		w.Header().Set("X-Rate-Limit-Reset", "1000")
+		_, _ = w.Write([]byte(bodies[r.URL.Path]))
+	}))
+	t.Cleanup(server.Close)
+
+	return config.CompleterConfig{
+		CompleterToken: "",
+		AgifyURL:       server.URL + "/agify",
+		GenderizeURL:   server.URL + "/genderize",
+		NationalizeURL: server.URL + "/nationalize",
+		CacheSize:      1,
+		CacheTTL:       time.Hour,
+	}
+}
+
+func TestCache(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+
+	cfg := newProviders(t, &calls)
+	comp := completer.New(cfg, nil)
+	observer := &lookups{}
+	comp.SetObserver(observer)
+
+	expected := completer.CompletionData{Sex: domain.Female, Nationality: "US", Age: 30}
+
+	for _, name := range []string{"Ashley", "Ashley", "Michael", "Ashley"} {
+		data, err := comp.Complete(context.Background(), name)
+		if err != nil || data != expected {
+			t.Fatalf("unexpected completion of %s: %v (%v)", name, data, err)
+		}
+	}
+
+	// Michael evicted Ashley
+	if calls.Load() != 3*3 || observer.hits.Load() != 1 || observer.misses.Load() != 3 {
+		t.Errorf("unexpected calls: %d, hits: %d, misses: %d",
+			calls.Load(), observer.hits.Load(), observer.misses.Load())
+	}
+
+	cfg.CacheSize = 0
+	calls.Store(0)
+
+	uncached := completer.New(cfg, nil)
+	for i := 0; i < 2; i++ {
+		if _, err := uncached.Complete(context.Background(), "Ashley"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if calls.Load() != 2*3 {
+		t.Errorf("completions were cached with COMPLETER_CACHE_SIZE=0: %d calls", calls.Load())
+	}
+}
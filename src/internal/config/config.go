@@ -9,22 +9,179 @@ import (
 )
 
 type PostgresConfig struct {
-	ConnString string `env:"DB_CONN" env-description:"Postgres connection string" env-required:"true"`
+	//nolint:tagalign
+	ConnString string `env:"DB_CONN" env-description:"Postgres connection string (required for the postgres storage)" yaml:"conn" toml:"conn" secret:"true"`
+	//nolint:tagalign
+	Migrate bool `env:"DB_MIGRATE" env-default:"false" env-description:"apply the embedded migrations on start" yaml:"migrate" toml:"migrate"`
+	//nolint:tagalign
+	MigrateLockTimeout time.Duration `env:"DB_MIGRATE_LOCK_TIMEOUT" env-default:"1m" env-description:"how long migrations wait for another instance applying them" yaml:"migrate_lock_timeout" toml:"migrate_lock_timeout"`
+	//nolint:tagalign
+	Replicas []string `env:"DB_REPLICAS" env-description:"connection strings of read replicas (comma separated), people are read from them" yaml:"replicas" toml:"replicas" secret:"true"`
+	//nolint:tagalign
+	ReplicaMaxLag time.Duration `env:"DB_REPLICA_MAX_LAG" env-default:"5s" env-description:"replicas lagging more are not read from; a client reads from the primary for this long (plus DB_REPLICA_CHECK_INTERVAL) after its writes" yaml:"replica_max_lag" toml:"replica_max_lag"`
+	//nolint:tagalign
+	ReplicaCheckInterval time.Duration `env:"DB_REPLICA_CHECK_INTERVAL" env-default:"5s" env-description:"how often the health and the lag of replicas are checked" yaml:"replica_check_interval" toml:"replica_check_interval"`
+}
+
+// Validate checks the fields used to connect to the primary. It is
+// used by commands that only need the database, Config.Validate checks
+// the whole config.
+func (c PostgresConfig) Validate() error {
+	switch {
+	case c.ConnString == "":
+		return fmt.Errorf("%w: DB_CONN is required", ErrInvalid)
+	case c.MigrateLockTimeout <= 0:
+		return fmt.Errorf("%w: DB_MIGRATE_LOCK_TIMEOUT must be positive", ErrInvalid)
+	}
+
+	return nil
+}
+
+// storage backends
+const (
+	StoragePostgres = "postgres"
+	StorageMemory   = "memory"
+	StorageSQLite   = "sqlite"
+)
+
+type StorageConfig struct {
+	//nolint:tagalign
+	Backend string `env:"STORAGE" env-default:"postgres" env-description:"postgres, sqlite (a single instance with a database file) or memory (a single instance keeping people in memory)" yaml:"backend" toml:"backend"`
+	//nolint:tagalign
+	SnapshotFile string `env:"MEMORY_SNAPSHOT_FILE" env-description:"file the memory storage is loaded from on start and saved to" yaml:"snapshot_file" toml:"snapshot_file"`
+	//nolint:tagalign
+	SnapshotInterval time.Duration `env:"MEMORY_SNAPSHOT_INTERVAL" env-default:"1m" env-description:"how often the memory storage is saved (and on shutdown)" yaml:"snapshot_interval" toml:"snapshot_interval"`
+	//nolint:tagalign
+	SQLiteFile string `env:"SQLITE_FILE" env-default:"people.db" env-description:"database file of the sqlite storage, created if it does not exist" yaml:"sqlite_file" toml:"sqlite_file"`
 }
 
 type CompleterConfig struct {
-	CompleterToken string `env:"COMPLETER_TOKEN" env-description:"API token for filler services"`
-	AgifyURL       string `env:"AGIFY_URL"       env-required:"true"`
-	GenderizeURL   string `env:"GENDERIZE_URL"   env-required:"true"`
-	NationalizeURL string `env:"NATIONALIZE_URL" env-required:"true"`
+	//nolint:tagalign
+	CompleterToken string `env:"COMPLETER_TOKEN" env-description:"API token for filler services" yaml:"token" toml:"token" secret:"true"`
+	//nolint:tagalign
+	AgifyURL string `env:"AGIFY_URL" env-required:"true" yaml:"agify_url" toml:"agify_url"`
+	//nolint:tagalign
+	GenderizeURL string `env:"GENDERIZE_URL" env-required:"true" yaml:"genderize_url" toml:"genderize_url"`
+	//nolint:tagalign
+	NationalizeURL string `env:"NATIONALIZE_URL" env-required:"true" yaml:"nationalize_url" toml:"nationalize_url"`
+	//nolint:tagalign
+	CacheSize int `env:"COMPLETER_CACHE_SIZE" env-default:"10000" env-description:"number of names whose completions are cached, 0 disables the cache" yaml:"cache_size" toml:"cache_size"`
+	//nolint:tagalign
+	CacheTTL time.Duration `env:"COMPLETER_CACHE_TTL" env-default:"24h" env-description:"how long completions are cached" yaml:"cache_ttl" toml:"cache_ttl"`
 }
 
 type ServerConfig struct {
-	Debug       bool          `env:"DEBUG"         env-default:"false"`
-	ReadTimout  time.Duration `env:"TIMEOUT_READ"  env-required:"true"`
-	WriteTimout time.Duration `env:"TIMEOUT_WRITE" env-required:"true"`
 	//nolint:tagalign
-	LogLevel slog.Level `env:"LOG_LEVEL" env-required:"true" env-description:"DEBUG/INFO/WARNING/ERROR"`
+	Debug bool `env:"DEBUG" env-default:"false" yaml:"debug" toml:"debug"`
+	//nolint:tagalign
+	ReadTimout time.Duration `env:"TIMEOUT_READ" env-required:"true" yaml:"read_timeout" toml:"read_timeout"`
+	//nolint:tagalign
+	WriteTimout time.Duration `env:"TIMEOUT_WRITE" env-required:"true" yaml:"write_timeout" toml:"write_timeout"`
+	//nolint:tagalign
+	Addr string `env:"LISTEN_ADDR" env-default:"0.0.0.0:80" env-description:"address of the API listener" yaml:"addr" toml:"addr"`
+	//nolint:tagalign
+	ReadHeaderTimeout time.Duration `env:"TIMEOUT_READ_HEADER" env-default:"10s" env-description:"how long request headers are read" yaml:"read_header_timeout" toml:"read_header_timeout"`
+	//nolint:tagalign
+	IdleTimeout time.Duration `env:"TIMEOUT_IDLE" env-default:"120s" env-description:"how long idle keep-alive connections are kept" yaml:"idle_timeout" toml:"idle_timeout"`
+	//nolint:tagalign
+	MaxHeaderBytes int `env:"MAX_HEADER_BYTES" env-default:"1048576" env-description:"maximum size of request headers" yaml:"max_header_bytes" toml:"max_header_bytes"`
+	//nolint:tagalign
+	H2C bool `env:"H2C" env-default:"false" env-description:"accept HTTP/2 without TLS (for internal meshes)" yaml:"h2c" toml:"h2c"`
+	//nolint:tagalign
+	LogLevel slog.Level `env:"LOG_LEVEL" env-default:"INFO" env-description:"DEBUG/INFO/WARN/ERROR" yaml:"log_level" toml:"log_level"`
+	//nolint:tagalign
+	ShutdownDelay time.Duration `env:"SHUTDOWN_DELAY" env-default:"5s" env-description:"how long /readyz fails before the API server stops accepting connections on shutdown, so that load balancers stop routing requests to the instance" yaml:"shutdown_delay" toml:"shutdown_delay"`
+	//nolint:tagalign
+	ShutdownTimeout time.Duration `env:"SHUTDOWN_TIMEOUT" env-default:"10s" env-description:"how long in-flight requests and background workers are waited for on shutdown" yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+}
+
+type RetentionConfig struct {
+	//nolint:tagalign
+	Retention time.Duration `env:"DELETED_RETENTION" env-default:"720h" env-description:"how long deleted people can be restored" yaml:"retention" toml:"retention"`
+	//nolint:tagalign
+	PurgeInterval time.Duration `env:"PURGE_INTERVAL" env-default:"1h" env-description:"how often deleted people are purged" yaml:"purge_interval" toml:"purge_interval"`
+}
+
+type IdempotencyConfig struct {
+	//nolint:tagalign
+	TTL time.Duration `env:"IDEMPOTENCY_TTL" env-default:"24h" env-description:"how long responses to requests with Idempotency-Key are replayed" yaml:"ttl" toml:"ttl"`
+	//nolint:tagalign
+	Wait time.Duration `env:"IDEMPOTENCY_WAIT" env-default:"5s" env-description:"how long a repeated request waits for the first one" yaml:"wait" toml:"wait"`
+}
+
+type AuthConfig struct {
+	//nolint:tagalign
+	Disabled bool `env:"AUTH_DISABLED" env-default:"false" env-description:"serve all operations without authentication" yaml:"disabled" toml:"disabled"`
+	//nolint:tagalign
+	CacheTTL time.Duration `env:"AUTH_CACHE_TTL" env-default:"1m" env-description:"how long verified credentials are cached (and revoked keys keep working)" yaml:"cache_ttl" toml:"cache_ttl"`
+	//nolint:tagalign
+	PolicyFile string `env:"AUTH_POLICY_FILE" env-description:"YAML file overriding x-required-scopes of operations" yaml:"policy_file" toml:"policy_file"`
+}
+
+type JWTConfig struct {
+	//nolint:tagalign
+	JWKSFile string `env:"JWT_JWKS_FILE" env-description:"JWKS file with keys of the token issuer (JWTs are not accepted if neither it nor JWT_PEM_DIR is set)" yaml:"jwks_file" toml:"jwks_file"`
+	//nolint:tagalign
+	PEMDir string `env:"JWT_PEM_DIR" env-description:"directory of <key id>.pem public keys of the token issuer" yaml:"pem_dir" toml:"pem_dir"`
+	//nolint:tagalign
+	Issuer string `env:"JWT_ISSUER" env-description:"required iss claim" yaml:"issuer" toml:"issuer"`
+	//nolint:tagalign
+	Audience string `env:"JWT_AUDIENCE" env-description:"required aud claim" yaml:"audience" toml:"audience"`
+	//nolint:tagalign
+	Leeway time.Duration `env:"JWT_LEEWAY" env-default:"30s" env-description:"allowed clock skew for exp and nbf" yaml:"leeway" toml:"leeway"`
+	//nolint:tagalign
+	ReloadInterval time.Duration `env:"JWT_RELOAD_INTERVAL" env-default:"10s" env-description:"how often the keys are checked for changes" yaml:"reload_interval" toml:"reload_interval"`
+}
+
+type RateLimitConfig struct {
+	//nolint:tagalign
+	Disabled bool `env:"RATE_LIMIT_DISABLED" env-default:"false" env-description:"serve requests without rate limiting" yaml:"disabled" toml:"disabled"`
+	//nolint:tagalign
+	Burst float64 `env:"RATE_LIMIT_BURST" env-default:"60" env-description:"token bucket capacity of a client" yaml:"burst" toml:"burst"`
+	//nolint:tagalign
+	Rate float64 `env:"RATE_LIMIT_RATE" env-default:"1" env-description:"tokens added to the bucket of a client per second" yaml:"rate" toml:"rate"`
+	//nolint:tagalign
+	AddressBurst float64 `env:"RATE_LIMIT_ADDRESS_BURST" env-default:"600" env-description:"token bucket capacity of an address, every request takes a token before it is authenticated (0 disables the limit)" yaml:"address_burst" toml:"address_burst"`
+	//nolint:tagalign
+	AddressRate float64 `env:"RATE_LIMIT_ADDRESS_RATE" env-default:"10" env-description:"tokens added to the bucket of an address per second" yaml:"address_rate" toml:"address_rate"`
+	//nolint:tagalign
+	Costs map[string]float64 `env:"RATE_LIMIT_COSTS" env-description:"tokens taken by operations overriding x-rate-limit-cost (personPost:10,personGet:1)" yaml:"costs" toml:"costs"`
+	//nolint:tagalign
+	Shared bool `env:"RATE_LIMIT_SHARED" env-default:"false" env-description:"store buckets in Postgres to share the limits between replicas" yaml:"shared" toml:"shared"`
+}
+
+type AdminConfig struct {
+	//nolint:tagalign
+	Addr string `env:"ADMIN_ADDR" env-default:"0.0.0.0:9090" env-description:"address of the admin listener serving /metrics, /healthz and /readyz" yaml:"addr" toml:"addr"`
+}
+
+type TLSConfig struct {
+	//nolint:tagalign
+	CertFile string `env:"TLS_CERT_FILE" env-description:"PEM certificate (chain) of the API listener (HTTPS is served if set)" yaml:"cert_file" toml:"cert_file"`
+	//nolint:tagalign
+	KeyFile string `env:"TLS_KEY_FILE" env-description:"PEM private key of the certificate" yaml:"key_file" toml:"key_file"`
+	//nolint:tagalign
+	ClientCAFile string `env:"TLS_CLIENT_CA_FILE" env-description:"PEM bundle of CAs verifying client certificates (mTLS)" yaml:"client_ca_file" toml:"client_ca_file"`
+	//nolint:tagalign
+	ClientCertOptional bool `env:"TLS_CLIENT_CERT_OPTIONAL" env-default:"false" env-description:"accept clients without a certificate (presented ones are still verified)" yaml:"client_cert_optional" toml:"client_cert_optional"`
+	//nolint:tagalign
+	ReloadInterval time.Duration `env:"TLS_RELOAD_INTERVAL" env-default:"10s" env-description:"how often the certificate files are checked for changes" yaml:"reload_interval" toml:"reload_interval"`
+}
+
+type HealthConfig struct {
+	//nolint:tagalign
+	Timeout time.Duration `env:"READY_TIMEOUT" env-default:"2s" env-description:"timeout of every readiness check" yaml:"timeout" toml:"timeout"`
+	//nolint:tagalign
+	CheckProviders bool `env:"READY_CHECK_PROVIDERS" env-default:"false" env-description:"check that the filler providers are reachable for readiness" yaml:"check_providers" toml:"check_providers"`
+}
+
+type TracingConfig struct {
+	//nolint:tagalign
+	Exporter string `env:"TRACING_EXPORTER" env-default:"none" env-description:"none, stdout or otlp (configured with OTEL_EXPORTER_OTLP_* variables)" yaml:"exporter" toml:"exporter"`
+	//nolint:tagalign
+	File string `env:"TRACING_FILE" env-description:"file the stdout exporter appends spans to (standard output if empty)" yaml:"file" toml:"file"`
+	//nolint:tagalign
+	SampleRatio float64 `env:"TRACING_SAMPLE_RATIO" env-default:"1" env-description:"share of traces started by the service that are sampled" yaml:"sample_ratio" toml:"sample_ratio"`
 }
 
 // read config from environment variables
@@ -37,14 +194,7 @@ func Read[T any]() (T, error) {
 	)
 
 	if err = cleanenv.ReadEnv(&cfg); err != nil {
-		helpHeader := "Expected config:"
-		help, descErr := cleanenv.GetDescription(&cfg, &helpHeader)
-
-		if descErr == nil {
-			err = fmt.Errorf("could not read config: %w.\n%s", err, help)
-		} else {
-			err = fmt.Errorf("could not read config: %w", err)
-		}
+		err = describe(err, &cfg)
 	}
 
 	return cfg, err
@@ -0,0 +1,467 @@
+package config
+
+import (
+	"encoding"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ilyakaznacheev/cleanenv"
+	"gopkg.in/yaml.v3"
+)
+
+// FileEnv names the config file if the -config flag is not set
+const FileEnv = "CONFIG_FILE"
+
+// redacted replaces secrets in Redacted
+const redacted = "REDACTED"
+
+var ErrInvalid = errors.New("invalid config")
+
+// Config is the root config of the server, see Load
+type Config struct {
+	Server      ServerConfig      `yaml:"server"      toml:"server"`
+	TLS         TLSConfig         `yaml:"tls"         toml:"tls"`
+	Admin       AdminConfig       `yaml:"admin"       toml:"admin"`
+	Health      HealthConfig      `yaml:"health"      toml:"health"`
+	Storage     StorageConfig     `yaml:"storage"     toml:"storage"`
+	Postgres    PostgresConfig    `yaml:"postgres"    toml:"postgres"`
+	Completer   CompleterConfig   `yaml:"completer"   toml:"completer"`
+	Retention   RetentionConfig   `yaml:"retention"   toml:"retention"`
+	Idempotency IdempotencyConfig `yaml:"idempotency" toml:"idempotency"`
+	Auth        AuthConfig        `yaml:"auth"        toml:"auth"`
+	JWT         JWTConfig         `yaml:"jwt"         toml:"jwt"`
+	RateLimit   RateLimitConfig   `yaml:"rate_limit"  toml:"rate_limit"`
+	Tracing     TracingConfig     `yaml:"tracing"     toml:"tracing"`
+}
+
+// visitFields calls visit for every field with an env tag
+func visitFields(value reflect.Value, visit func(field reflect.StructField, value reflect.Value)) {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+
+		if _, found := field.Tag.Lookup("env"); found {
+			visit(field, value.Field(i))
+		} else if field.Type.Kind() == reflect.Struct {
+			visitFields(value.Field(i), visit)
+		}
+	}
+}
+
+// Load reads the root config from a YAML or TOML file (by the extension;
+// the -config flag or CONFIG_FILE), overrides it with environment variables
+// and then with command line flags, and validates it. Every variable has
+// a flag: LOG_LEVEL is -log-level and so on.
+//
+// Empty variables are ignored, so that values of the file are not reset
+// by variables that are declared but not set (e.g. by compose).
+// Validation errors (and missing required values) wrap ErrInvalid,
+// the config is returned with them.
+func Load(args []string) (Config, error) {
+	cfg, rest, err := LoadCommand(args)
+	if err == nil && len(rest) > 0 {
+		err = fmt.Errorf("unexpected arguments: %v", rest)
+	}
+
+	return cfg, err
+}
+
+// LoadCommand is Load for commands with arguments of their own: flags are
+// parsed up to the first non-flag argument, the arguments from it on
+// are returned.
+func LoadCommand(args []string) (Config, []string, error) {
+	var cfg Config
+
+	flags := flag.NewFlagSet("config", flag.ContinueOnError)
+	path := flags.String("config", os.Getenv(FileEnv), "YAML or TOML config file")
+	overrides := make(map[string]string)
+
+	visitFields(reflect.ValueOf(&cfg).Elem(), func(field reflect.StructField, _ reflect.Value) {
+		env := field.Tag.Get("env")
+		flags.Func(strings.ToLower(strings.ReplaceAll(env, "_", "-")), field.Tag.Get("env-description"),
+			func(value string) error {
+				overrides[env] = value
+
+				return nil
+			})
+	})
+
+	if err := flags.Parse(args); err != nil {
+		return cfg, nil, fmt.Errorf("could not parse flags: %w", err)
+	}
+
+	// defaults are set first, so that the file keeps the values it sets
+	// (zeros too) and the keys it leaves out get the defaults
+	if err := setDefaults(&cfg); err != nil {
+		return cfg, nil, describe(err, &cfg)
+	}
+
+	if *path != "" {
+		if err := parseFile(*path, &cfg); err != nil {
+			return cfg, nil, describe(err, &cfg)
+		}
+	}
+
+	if err := override(&cfg, overrides); err != nil {
+		return cfg, flags.Args(), describe(err, &cfg)
+	}
+
+	return cfg, flags.Args(), cfg.Validate()
+}
+
+// parseFile reads a YAML, JSON or TOML file
+func parseFile(path string, cfg *Config) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("could not open the config file: %w", err)
+	}
+	defer file.Close()
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = cleanenv.ParseYAML(file, cfg)
+	case ".json":
+		err = cleanenv.ParseJSON(file, cfg)
+	case ".toml":
+		err = cleanenv.ParseTOML(file, cfg)
+	default:
+		return fmt.Errorf("unsupported config file format %q", ext)
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not parse the config file: %w", err)
+	}
+
+	return nil
+}
+
+// setDefaults sets the fields with env-default to the defaults
+func setDefaults(cfg *Config) error {
+	var errs []error
+
+	visitFields(reflect.ValueOf(cfg).Elem(), func(field reflect.StructField, value reflect.Value) {
+		if raw, found := field.Tag.Lookup("env-default"); found {
+			if err := parseValue(value, raw); err != nil {
+				errs = append(errs, fmt.Errorf("could not parse the default of %s: %w", field.Tag.Get("env"), err))
+			}
+		}
+	})
+
+	return errors.Join(errs...)
+}
+
+// override sets the fields from the flags or the non-empty variables,
+// in order of precedence. Required fields that are still zero are
+// reported. The process environment is only read.
+func override(cfg *Config, flags map[string]string) error {
+	var missing, errs []error
+
+	visitFields(reflect.ValueOf(cfg).Elem(), func(field reflect.StructField, value reflect.Value) {
+		env := field.Tag.Get("env")
+
+		raw, found := flags[env]
+		if !found {
+			raw = os.Getenv(env)
+			found = raw != ""
+		}
+
+		if !found {
+			if value.IsZero() && field.Tag.Get("env-required") == "true" {
+				missing = append(missing, fmt.Errorf("%w: %s is required", ErrInvalid, env))
+			}
+
+			return
+		}
+
+		if err := parseValue(value, raw); err != nil {
+			errs = append(errs, fmt.Errorf("could not parse %s: %w", env, err))
+		}
+	})
+
+	// values that can not be parsed are not ErrInvalid
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	return errors.Join(missing...)
+}
+
+// parseValue parses a variable like cleanenv does: lists are comma
+// separated, maps are comma separated key:value pairs
+func parseValue(value reflect.Value, raw string) error {
+	if unmarshaler, ok := value.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return unmarshaler.UnmarshalText([]byte(raw)) //nolint:wrapcheck
+	}
+
+	var err error
+
+	switch value.Kind() { //nolint:exhaustive
+	case reflect.String:
+		value.SetString(raw)
+	case reflect.Bool:
+		var parsed bool
+		if parsed, err = strconv.ParseBool(raw); err == nil {
+			value.SetBool(parsed)
+		}
+	case reflect.Int, reflect.Int64:
+		var parsed int64
+		if value.Type() == reflect.TypeOf(time.Duration(0)) {
+			var duration time.Duration
+			duration, err = time.ParseDuration(raw)
+			parsed = int64(duration)
+		} else {
+			parsed, err = strconv.ParseInt(raw, 0, value.Type().Bits())
+		}
+
+		if err == nil {
+			value.SetInt(parsed)
+		}
+	case reflect.Float64:
+		var parsed float64
+		if parsed, err = strconv.ParseFloat(raw, value.Type().Bits()); err == nil {
+			value.SetFloat(parsed)
+		}
+	case reflect.Slice:
+		items := strings.Split(raw, ",")
+		slice := reflect.MakeSlice(value.Type(), len(items), len(items))
+
+		for i, item := range items {
+			if err = parseValue(slice.Index(i), strings.TrimSpace(item)); err != nil {
+				return err
+			}
+		}
+
+		value.Set(slice)
+	case reflect.Map:
+		result := reflect.MakeMap(value.Type())
+
+		for _, pair := range strings.Split(raw, ",") {
+			key, item, found := strings.Cut(pair, ":")
+			if !found {
+				return fmt.Errorf("%q is not a key:value pair", pair)
+			}
+
+			keyValue := reflect.New(value.Type().Key()).Elem()
+			itemValue := reflect.New(value.Type().Elem()).Elem()
+
+			if err = parseValue(keyValue, strings.TrimSpace(key)); err != nil {
+				return err
+			}
+
+			if err = parseValue(itemValue, strings.TrimSpace(item)); err != nil {
+				return err
+			}
+
+			result.SetMapIndex(keyValue, itemValue)
+		}
+
+		value.Set(result)
+	default:
+		return fmt.Errorf("unsupported type %s", value.Type())
+	}
+
+	return err //nolint:wrapcheck
+}
+
+// describe adds the description of the expected config to err
+func describe(err error, cfg any) error {
+	helpHeader := "Expected config:"
+
+	help, descErr := cleanenv.GetDescription(cfg, &helpHeader)
+	if descErr != nil {
+		return fmt.Errorf("could not read config: %w", err)
+	}
+
+	return fmt.Errorf("could not read config: %w.\n%s", err, help)
+}
+
+// validateURL checks that value is an absolute HTTP(S) URL
+func validateURL(name, value string) error {
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrInvalid, name, err)
+	}
+
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("%w: %s: %q is not an HTTP(S) URL", ErrInvalid, name, value)
+	}
+
+	return nil
+}
+
+// Validate checks the config as a whole. All problems are reported.
+//
+//nolint:cyclop,funlen
+func (c Config) Validate() error {
+	var errs []error
+
+	check := func(valid bool, format string, args ...any) {
+		if !valid {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrInvalid, fmt.Sprintf(format, args...)))
+		}
+	}
+
+	positive := map[string]time.Duration{
+		"TIMEOUT_READ":              c.Server.ReadTimout,
+		"TIMEOUT_WRITE":             c.Server.WriteTimout,
+		"TIMEOUT_READ_HEADER":       c.Server.ReadHeaderTimeout,
+		"TIMEOUT_IDLE":              c.Server.IdleTimeout,
+		"SHUTDOWN_TIMEOUT":          c.Server.ShutdownTimeout,
+		"READY_TIMEOUT":             c.Health.Timeout,
+		"IDEMPOTENCY_TTL":           c.Idempotency.TTL,
+		"IDEMPOTENCY_WAIT":          c.Idempotency.Wait,
+		"DB_MIGRATE_LOCK_TIMEOUT":   c.Postgres.MigrateLockTimeout,
+		"DB_REPLICA_MAX_LAG":        c.Postgres.ReplicaMaxLag,
+		"DB_REPLICA_CHECK_INTERVAL": c.Postgres.ReplicaCheckInterval,
+		"MEMORY_SNAPSHOT_INTERVAL":  c.Storage.SnapshotInterval,
+	}
+	for name, duration := range positive {
+		check(duration > 0, "%s must be positive, got %v", name, duration)
+	}
+
+	notNegative := map[string]time.Duration{
+		"DELETED_RETENTION": c.Retention.Retention,
+		"AUTH_CACHE_TTL":    c.Auth.CacheTTL,
+		"JWT_LEEWAY":        c.JWT.Leeway,
+	}
+	for name, duration := range notNegative {
+		check(duration >= 0, "%s must not be negative, got %v", name, duration)
+	}
+
+	switch c.Server.LogLevel {
+	case slog.LevelDebug, slog.LevelInfo, slog.LevelWarn, slog.LevelError:
+	default:
+		check(false, "LOG_LEVEL must be DEBUG, INFO, WARN or ERROR, got %v", c.Server.LogLevel)
+	}
+
+	check(c.Server.MaxHeaderBytes > 0, "MAX_HEADER_BYTES must be positive")
+
+	switch c.Storage.Backend {
+	case StoragePostgres:
+		check(c.Postgres.ConnString != "", "DB_CONN is required for the postgres storage")
+	case StorageMemory, StorageSQLite:
+		check(c.Storage.Backend != StorageSQLite || c.Storage.SQLiteFile != "",
+			"SQLITE_FILE is required for the sqlite storage")
+		check(c.RateLimit.Disabled || !c.RateLimit.Shared, "RATE_LIMIT_SHARED requires the postgres storage")
+		check(len(c.Postgres.Replicas) == 0, "DB_REPLICAS requires the postgres storage")
+	default:
+		check(false, "STORAGE must be postgres, sqlite or memory, got %q", c.Storage.Backend)
+	}
+
+	for name, value := range map[string]string{
+		"AGIFY_URL":       c.Completer.AgifyURL,
+		"GENDERIZE_URL":   c.Completer.GenderizeURL,
+		"NATIONALIZE_URL": c.Completer.NationalizeURL,
+	} {
+		if err := validateURL(name, value); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	check(c.Completer.CacheSize >= 0, "COMPLETER_CACHE_SIZE must not be negative")
+	check(c.Server.ShutdownDelay >= 0, "SHUTDOWN_DELAY must not be negative")
+	check(c.Completer.CacheSize == 0 || c.Completer.CacheTTL > 0, "COMPLETER_CACHE_TTL must be positive")
+
+	check(c.JWT.JWKSFile == "" || c.JWT.PEMDir == "", "only one of JWT_JWKS_FILE and JWT_PEM_DIR can be set")
+
+	check((c.TLS.CertFile == "") == (c.TLS.KeyFile == ""), "both TLS_CERT_FILE and TLS_KEY_FILE must be set")
+	check(c.TLS.ClientCAFile == "" || c.TLS.CertFile != "", "TLS_CLIENT_CA_FILE requires TLS_CERT_FILE")
+	check(c.TLS.CertFile == "" || !c.Server.H2C, "H2C can not be used with TLS (HTTP/2 is negotiated over TLS)")
+
+	if !c.RateLimit.Disabled {
+		check(c.RateLimit.Burst > 0 && c.RateLimit.Rate > 0, "RATE_LIMIT_BURST and RATE_LIMIT_RATE must be positive")
+		check(c.RateLimit.AddressBurst == 0 || (c.RateLimit.AddressBurst >= 1 && c.RateLimit.AddressRate > 0),
+			"RATE_LIMIT_ADDRESS_BURST must be 0 or at least 1 with a positive RATE_LIMIT_ADDRESS_RATE")
+
+		for operation, cost := range c.RateLimit.Costs {
+			check(cost >= 0 && cost <= c.RateLimit.Burst,
+				"cost %v of %s is not in [0, RATE_LIMIT_BURST]", cost, operation)
+		}
+	}
+
+	switch c.Tracing.Exporter {
+	case "none", "stdout", "otlp":
+	default:
+		check(false, "TRACING_EXPORTER must be none, stdout or otlp, got %q", c.Tracing.Exporter)
+	}
+
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "TRACING_SAMPLE_RATIO must be in [0, 1]")
+
+	return errors.Join(errs...)
+}
+
+// WithReloadable returns the config with the fields that are reloaded
+// without a restart taken from other: the log level, rate limits and
+// the filler token
+func (c Config) WithReloadable(other Config) Config {
+	c.Server.Debug = other.Server.Debug
+	c.Server.LogLevel = other.Server.LogLevel
+	c.RateLimit.Burst = other.RateLimit.Burst
+	c.RateLimit.Rate = other.RateLimit.Rate
+	c.RateLimit.AddressBurst = other.RateLimit.AddressBurst
+	c.RateLimit.AddressRate = other.RateLimit.AddressRate
+	c.RateLimit.Costs = other.RateLimit.Costs
+	c.Completer.CompleterToken = other.Completer.CompleterToken
+
+	return c
+}
+
+// redact replaces a secret. Only the password of a URL is replaced.
+func redact(secret string) string {
+	if parsed, err := url.Parse(secret); err == nil && parsed.User != nil {
+		if _, found := parsed.User.Password(); found {
+			parsed.User = url.UserPassword(parsed.User.Username(), redacted)
+
+			return parsed.String()
+		}
+	}
+
+	return redacted
+}
+
+// Redacted returns the config with the values of fields tagged secret
+// (strings and lists of strings) replaced, see redact
+func (c Config) Redacted() Config {
+	value := reflect.ValueOf(&c).Elem()
+
+	visitFields(value, func(field reflect.StructField, value reflect.Value) {
+		if field.Tag.Get("secret") != "true" {
+			return
+		}
+
+		switch value.Kind() { //nolint:exhaustive
+		case reflect.String:
+			if value.String() != "" {
+				value.SetString(redact(value.String()))
+			}
+		case reflect.Slice:
+			// the slice is shared with the original config
+			secrets := make([]string, 0, value.Len())
+			for i := 0; i < value.Len(); i++ {
+				secrets = append(secrets, redact(value.Index(i).String()))
+			}
+
+			value.Set(reflect.ValueOf(secrets))
+		}
+	})
+
+	return c
+}
+
+// YAML returns the config in the format of a config file
+func (c Config) YAML() ([]byte, error) {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode config: %w", err)
+	}
+
+	return data, nil
+}
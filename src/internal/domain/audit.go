@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FieldChange is a change of a single field. Values are decoded JSON,
+// nil if the field did not exist before or after the change.
+type FieldChange struct {
+	Old any `json:"old"`
+	New any `json:"new"`
+}
+
+// AuditEntry records who changed a Person, when and how
+type AuditEntry struct {
+	OccurredAt time.Time
+	// changed fields by name
+	Diff      map[string]FieldChange
+	Operation ChangeOperation
+	// empty if unknown
+	Actor string
+	// empty if unknown
+	ClientIP string
+	// empty if unknown
+	RequestID string
+	ID        int64
+	PersonID  uuid.UUID
+}
+
+// AuditFilter selects audit entries. nil fields are not applied,
+// the time range is [From, To).
+type AuditFilter struct {
+	Actor    *string
+	PersonID *uuid.UUID
+	From     *time.Time
+	To       *time.Time
+}
+
+// fields of a person as in to_jsonb(people.people) without the id.
+// numbers are float64 like decoded JSON
+func fields(person *Person) map[string]any {
+	if person == nil {
+		return map[string]any{}
+	}
+
+	return map[string]any{
+		"name":        person.Name,
+		"surname":     person.Surname,
+		"patronymic":  person.Patronymic,
+		"age":         float64(person.Age),
+		"sex":         string(person.Sex),
+		"nationality": string(person.Nationality),
+	}
+}
+
+// Diff returns the fields changed between old and value (nil before
+// a creation and after a deletion) like utils.jsonb_diff does for
+// the audit log
+func Diff(old, value *Person) map[string]FieldChange {
+	oldFields, newFields := fields(old), fields(value)
+	result := make(map[string]FieldChange)
+
+	for name := range oldFields {
+		if _, found := newFields[name]; !found {
+			result[name] = FieldChange{Old: oldFields[name], New: nil}
+		}
+	}
+
+	for name, newValue := range newFields {
+		if oldValue, found := oldFields[name]; !found || oldValue != newValue {
+			result[name] = FieldChange{Old: oldFields[name], New: newValue}
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,20 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIClient is a client allowed to use the API. Only a hash of its secret
+// is stored.
+type APIClient struct {
+	CreatedAt  time.Time
+	RevokedAt  *time.Time
+	Name       string
+	KeyID      string
+	SecretHash string
+	// scopes required by operations (x-required-scopes in openapi.yaml)
+	Scopes []string
+	ID     uuid.UUID
+}
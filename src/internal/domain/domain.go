@@ -48,6 +48,23 @@ type PersonPartial struct {
 	Age         *int
 }
 
+// Match is the way the names of a PersonFilter are matched
+type Match string
+
+const (
+	// MatchTrigram scores names by trigram word similarity
+	MatchTrigram Match = "trigram"
+	// MatchPhonetic matches names sounding alike
+	MatchPhonetic Match = "phonetic"
+	// MatchFulltext matches names containing every searched word
+	MatchFulltext Match = "fulltext"
+)
+
+// Valid checks the match, empty is MatchTrigram
+func (m Match) Valid() bool {
+	return (m == "") || (m == MatchTrigram) || (m == MatchPhonetic) || (m == MatchFulltext)
+}
+
 type PersonFilter struct {
 	Name        *string
 	Surname     *string
@@ -57,11 +74,18 @@ type PersonFilter struct {
 	AgeMin      *int
 	AgeMax      *int
 	Threshold   *float32
+	// empty is MatchTrigram
+	Match Match
+	// include soft deleted people
+	IncludeDeleted bool
 }
 
 type PaginationFilter struct {
 	Offset int
 	Limit  int
+	// the total is not needed: repos counting it with a separate query
+	// (the postgres one) skip it and leave TotalItems 0
+	WithoutTotal bool
 }
 
 type Page[T any] struct {
@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ChangeOperation is a kind of change recorded in the history of a Person
+type ChangeOperation string
+
+const (
+	// the state of the Person when the history started
+	ChangeSnapshot ChangeOperation = "snapshot"
+	ChangeCreate   ChangeOperation = "create"
+	ChangeUpdate   ChangeOperation = "update"
+	ChangeDelete   ChangeOperation = "delete"
+	ChangeRestore  ChangeOperation = "restore"
+	ChangePurge    ChangeOperation = "purge"
+)
+
+// PersonChange is a change of a Person recorded in the history
+type PersonChange struct {
+	ChangedAt time.Time
+	// nil if the Person was created or restored
+	Old *Person
+	// nil if the Person was deleted or purged
+	New       *Person
+	Operation ChangeOperation
+	// empty if unknown
+	Actor string
+	// empty if unknown
+	RequestID string
+	ID        int64
+	PersonID  uuid.UUID
+}
@@ -0,0 +1,9 @@
+package domain
+
+// StoredResponse is a response replayed for repeated requests
+// with the same Idempotency-Key
+type StoredResponse struct {
+	ContentType string
+	Body        []byte
+	Status      int
+}
@@ -0,0 +1,178 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// sentinel errors.
+var (
+	ErrPatch           = errors.New("patch error")
+	ErrPatchInvalid    = fmt.Errorf("%w: invalid operation", ErrPatch)
+	ErrPatchTestFailed = fmt.Errorf("%w: test operation failed", ErrPatch)
+)
+
+// PersonField is a Person field that can be addressed by a patch operation.
+// Values match JSON field names (and DB column names).
+type PersonField string
+
+const (
+	FieldName        PersonField = "name"
+	FieldSurname     PersonField = "surname"
+	FieldPatronymic  PersonField = "patronymic"
+	FieldAge         PersonField = "age"
+	FieldSex         PersonField = "sex"
+	FieldNationality PersonField = "nationality"
+)
+
+func (f PersonField) Valid() bool {
+	switch f {
+	case FieldName, FieldSurname, FieldPatronymic, FieldAge, FieldSex, FieldNationality:
+		return true
+	}
+
+	return false
+}
+
+// Enrichable reports whether the field is filled by enrichment services
+func (f PersonField) Enrichable() bool {
+	return f == FieldAge || f == FieldSex || f == FieldNationality
+}
+
+// PatchOp is a JSON Patch (RFC 6902) operation supported for Person
+type PatchOp string
+
+const (
+	PatchTest    PatchOp = "test"
+	PatchReplace PatchOp = "replace"
+	PatchRemove  PatchOp = "remove"
+)
+
+// PatchOperation is a single operation of a patch.
+//
+// Value is used by PatchTest and PatchReplace and must be of the field type:
+// string for FieldName, FieldSurname and FieldPatronymic, int for FieldAge,
+// Sex for FieldSex and Nationality for FieldNationality.
+//
+// PatchRemove is only applicable to FieldPatronymic (it becomes empty).
+// Enrichable fields are reset by replacing them with enriched values before
+// the patch is applied.
+type PatchOperation struct {
+	Value any
+	Op    PatchOp
+	Field PersonField
+}
+
+func (o PatchOperation) Validate() error {
+	if !o.Field.Valid() {
+		return fmt.Errorf("%w: unknown field %q", ErrPatchInvalid, o.Field)
+	}
+
+	switch o.Op {
+	case PatchRemove:
+		if o.Field != FieldPatronymic {
+			return fmt.Errorf("%w: %q can not be removed", ErrPatchInvalid, o.Field)
+		}
+
+		return nil
+	case PatchTest, PatchReplace:
+	default:
+		return fmt.Errorf("%w: unknown operation %q", ErrPatchInvalid, o.Op)
+	}
+
+	var valid bool
+
+	switch o.Field {
+	case FieldName, FieldSurname:
+		value, ok := o.Value.(string)
+		valid = ok && (o.Op == PatchTest || value != "")
+	case FieldPatronymic:
+		_, valid = o.Value.(string)
+	case FieldAge:
+		_, valid = o.Value.(int)
+	case FieldSex:
+		value, ok := o.Value.(Sex)
+		valid = ok && value.Valid()
+	case FieldNationality:
+		value, ok := o.Value.(Nationality)
+		valid = ok && value.Valid()
+	}
+
+	if !valid {
+		return fmt.Errorf("%w: invalid value %v for %q", ErrPatchInvalid, o.Value, o.Field)
+	}
+
+	return nil
+}
+
+// field returns a pointer to the Person field
+func (p *Person) field(field PersonField) any {
+	switch field {
+	case FieldName:
+		return &p.Name
+	case FieldSurname:
+		return &p.Surname
+	case FieldPatronymic:
+		return &p.Patronymic
+	case FieldAge:
+		return &p.Age
+	case FieldSex:
+		return &p.Sex
+	case FieldNationality:
+		return &p.Nationality
+	}
+
+	return nil
+}
+
+// Apply applies the operations in order and returns the patched Person.
+// The receiver is not modified, so a failed patch leaves no partial changes.
+func (p Person) Apply(operations []PatchOperation) (Person, error) {
+	if len(operations) == 0 {
+		return p, fmt.Errorf("%w: nothing to update", ErrPatchInvalid)
+	}
+
+	patched := p
+
+	for _, operation := range operations {
+		if err := operation.Validate(); err != nil {
+			return p, err
+		}
+
+		var err error
+
+		switch field := patched.field(operation.Field).(type) {
+		case *string:
+			err = applyValue(field, operation)
+		case *int:
+			err = applyValue(field, operation)
+		case *Sex:
+			err = applyValue(field, operation)
+		case *Nationality:
+			err = applyValue(field, operation)
+		}
+
+		if err != nil {
+			return p, err
+		}
+	}
+
+	return patched, nil
+}
+
+func applyValue[T comparable](field *T, operation PatchOperation) error {
+	var zero T
+
+	switch operation.Op {
+	case PatchTest:
+		if *field != operation.Value.(T) { //nolint:forcetypeassert
+			return fmt.Errorf("%w: %q is not %v", ErrPatchTestFailed, operation.Field, operation.Value)
+		}
+	case PatchReplace:
+		*field = operation.Value.(T) //nolint:forcetypeassert
+	case PatchRemove:
+		*field = zero
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"math"
+	"time"
+)
+
+// TokenBucket is a rate limit: a bucket holds up to Capacity tokens and is
+// refilled with Rate tokens per second. Requests take tokens from the bucket
+// of their client and are rejected if there are not enough tokens.
+type TokenBucket struct {
+	Capacity float64
+	// tokens per second
+	Rate float64
+}
+
+// BucketState is the state of the bucket of a client.
+// The zero state is a full bucket.
+type BucketState struct {
+	UpdatedAt time.Time
+	Tokens    float64
+}
+
+// Take refills the bucket up to now and takes cost tokens from it if there
+// are enough. Reports whether the tokens were taken.
+func (b TokenBucket) Take(state BucketState, cost float64, now time.Time) (BucketState, bool) {
+	tokens := b.Capacity
+	if !state.UpdatedAt.IsZero() {
+		tokens = math.Min(b.Capacity, state.Tokens+b.Rate*now.Sub(state.UpdatedAt).Seconds())
+	}
+
+	allowed := tokens >= cost
+	if allowed {
+		tokens -= cost
+	}
+
+	return BucketState{UpdatedAt: now, Tokens: tokens}, allowed
+}
+
+// Full reports whether the bucket is full at the moment, so the state
+// can be forgotten
+func (b TokenBucket) Full(state BucketState, now time.Time) bool {
+	return b.Until(state.Tokens, b.Capacity) <= now.Sub(state.UpdatedAt)
+}
+
+// Until returns how long it takes to refill the bucket from tokens
+// to the target number of tokens
+func (b TokenBucket) Until(tokens, target float64) time.Duration {
+	if tokens >= target {
+		return 0
+	}
+
+	return time.Duration((target - tokens) / b.Rate * float64(time.Second))
+}
+
+// RateLimitResult is the result of taking tokens from a bucket
+type RateLimitResult struct {
+	// tokens left in the bucket
+	Tokens  float64
+	Allowed bool
+}
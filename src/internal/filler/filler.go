@@ -1,6 +1,7 @@
 package filler
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -10,6 +11,8 @@ import (
 	"strconv"
 	"sync"
 	"time"
+
+	"github.com/Hofsiedge/person-api/internal/reqctx"
 )
 
 const GetRequestTimeout = time.Second * 3
@@ -77,6 +80,14 @@ func New[T any, C Converter[T]](baseURL string, token *string, client *http.Clie
 	}
 }
 
+// SetToken replaces the API token, nil removes it
+func (f *Filler[_, _]) SetToken(token *string) {
+	f.Lock()
+	defer f.Unlock()
+
+	f.token = token
+}
+
 // RequestsLeft returns the number of requests left until the rate limiter reset
 func (f *Filler[_, _]) RequestsLeft() (int, error) {
 	f.RLock()
@@ -129,18 +140,29 @@ func parseHeader(response *http.Response, header string) (int, error) {
 
 // performRequest performs a GET request for the provided name
 // and updated Filler fields using response headers
-func (f *Filler[_, _]) performRequest(name string) (*http.Response, error) {
+func (f *Filler[_, _]) performRequest(ctx context.Context, name string) (*http.Response, error) {
 	values := url.Values{}
 
 	values.Add("name", name)
 
+	f.RLock()
 	if f.token != nil {
 		values.Add("apikey", *f.token)
 	}
+	f.RUnlock()
 
 	URL := fmt.Sprintf("%s?%s", f.baseURL, values.Encode())
 
-	response, err := f.Client.Get(URL) //nolint:noctx
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidURL, err)
+	}
+
+	if requestID := reqctx.RequestID(ctx); requestID != "" {
+		request.Header.Set(reqctx.RequestIDHeader, requestID)
+	}
+
+	response, err := f.Client.Do(request)
 	if err != nil {
 		var urlErr *url.Error
 		if errors.As(err, &urlErr) && urlErr.Timeout() {
@@ -183,7 +205,8 @@ func (f *Filler[_, _]) performRequest(name string) (*http.Response, error) {
 	return response, err
 }
 
-func (f *Filler[T, C]) Fill(name string) (T, error) { //nolint:cyclop,ireturn
+// Fill requests the value for the name. The request is canceled with ctx.
+func (f *Filler[T, C]) Fill(ctx context.Context, name string) (T, error) { //nolint:cyclop,ireturn
 	var (
 		validResponse C
 		result        T
@@ -195,7 +218,7 @@ func (f *Filler[T, C]) Fill(name string) (T, error) { //nolint:cyclop,ireturn
 	}
 	f.RUnlock()
 
-	response, err := f.performRequest(name)
+	response, err := f.performRequest(ctx, name)
 	if err != nil {
 		return result, err
 	}
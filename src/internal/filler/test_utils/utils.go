@@ -1,6 +1,7 @@
 package testutils
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"net/http/httptest"
@@ -132,7 +133,7 @@ func RunSubtests[T any, C filler.Converter[T]](t *testing.T, testCases []TestCas
 
 			fill := filler.New[T, C](server.URL, nil, server.Client())
 
-			result, err := fill.Fill(testCase.Name)
+			result, err := fill.Fill(context.Background(), testCase.Name)
 
 			checkFillerFields(t, testCase.Fields, &fill)
 
@@ -0,0 +1,140 @@
+// Package health serves liveness (/healthz) and readiness (/readyz) probes.
+// Readiness runs dependency checks concurrently and reports the status and
+// latency of every check.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// Check is a named dependency check. It must return when ctx is done.
+type Check struct {
+	Check func(ctx context.Context) error
+	Name  string
+}
+
+// CheckResult is the result of a Check
+type CheckResult struct {
+	Status string `json:"status"`
+	// empty if the check passed
+	Error     string  `json:"error,omitempty"`
+	LatencyMS float64 `json:"latency_ms"`
+}
+
+// Report is the result of all checks. Status is StatusOK if all checks
+// passed.
+type Report struct {
+	Checks map[string]CheckResult `json:"checks"`
+	Status string                 `json:"status"`
+}
+
+// Run runs the checks concurrently, each one with timeout
+func Run(ctx context.Context, checks []Check, timeout time.Duration) Report {
+	report := Report{
+		Checks: make(map[string]CheckResult, len(checks)),
+		Status: StatusOK,
+	}
+
+	var (
+		wg    sync.WaitGroup //nolint:varnamelen
+		mutex sync.Mutex
+	)
+
+	wg.Add(len(checks))
+
+	for _, check := range checks {
+		go func(check Check) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check.Check(ctx)
+			result := CheckResult{
+				Status:    StatusOK,
+				Error:     "",
+				LatencyMS: float64(time.Since(start).Microseconds()) / 1000, //nolint:gomnd
+			}
+
+			if err != nil {
+				result.Status = StatusFail
+				result.Error = err.Error()
+			}
+
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			report.Checks[check.Name] = result
+			if err != nil {
+				report.Status = StatusFail
+			}
+		}(check)
+	}
+
+	wg.Wait()
+
+	return report
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// LivenessHandler responds with 200 while the process is able to serve
+// requests. It does not check dependencies, so a failing database does not
+// get the process restarted.
+func LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": StatusOK})
+	})
+}
+
+// ReadinessHandler runs the checks and responds with the Report: 200 if all
+// checks passed, 503 otherwise
+func ReadinessHandler(checks []Check, timeout time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := Run(r.Context(), checks, timeout)
+
+		status := http.StatusOK
+		if report.Status != StatusOK {
+			status = http.StatusServiceUnavailable
+		}
+
+		writeJSON(w, status, report)
+	})
+}
+
+// HTTPCheck checks that the server of url is reachable: any response
+// to a HEAD request passes, so the check does not depend on the API
+// of the server and does not use its quota
+func HTTPCheck(client *http.Client, url string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		request, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+		if err != nil {
+			return fmt.Errorf("invalid URL: %w", err)
+		}
+
+		response, err := client.Do(request)
+		if err != nil {
+			return fmt.Errorf("unreachable: %w", err)
+		}
+
+		response.Body.Close()
+
+		return nil
+	}
+}
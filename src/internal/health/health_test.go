@@ -0,0 +1,83 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Hofsiedge/person-api/internal/health"
+)
+
+var errDown = errors.New("down")
+
+func readiness(t *testing.T, checks []health.Check) (int, health.Report) {
+	t.Helper()
+
+	recorder := httptest.NewRecorder()
+	health.ReadinessHandler(checks, time.Second).
+		ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	var report health.Report
+	if err := json.NewDecoder(recorder.Body).Decode(&report); err != nil {
+		t.Fatal(err)
+	}
+
+	return recorder.Code, report
+}
+
+func TestReadiness(t *testing.T) {
+	t.Parallel()
+
+	pass := health.Check{Name: "pass", Check: func(context.Context) error { return nil }}
+	fail := health.Check{Name: "fail", Check: func(context.Context) error { return errDown }}
+	slow := health.Check{Name: "slow", Check: func(ctx context.Context) error {
+		<-ctx.Done()
+
+		return ctx.Err() //nolint:wrapcheck
+	}}
+
+	status, report := readiness(t, []health.Check{pass})
+	if status != http.StatusOK || report.Status != health.StatusOK || report.Checks["pass"].Status != health.StatusOK {
+		t.Errorf("unexpected report: %d %+v", status, report)
+	}
+
+	status, report = readiness(t, []health.Check{pass, fail, slow})
+	if status != http.StatusServiceUnavailable || report.Status != health.StatusFail {
+		t.Errorf("unexpected report: %d %+v", status, report)
+	}
+
+	if result := report.Checks["fail"]; result.Status != health.StatusFail || result.Error != errDown.Error() {
+		t.Errorf("unexpected result of a failed check: %+v", result)
+	}
+
+	if result := report.Checks["slow"]; result.Status != health.StatusFail || result.LatencyMS < 1000 {
+		t.Errorf("slow check was not timed out: %+v", result)
+	}
+
+	if result := report.Checks["pass"]; result.Status != health.StatusOK {
+		t.Errorf("unexpected result of a passed check: %+v", result)
+	}
+}
+
+func TestHTTPCheck(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	client := server.Client()
+
+	if err := health.HTTPCheck(client, server.URL)(context.Background()); err != nil {
+		t.Errorf("reachable server failed the check: %v", err)
+	}
+
+	server.Close()
+
+	if err := health.HTTPCheck(client, server.URL)(context.Background()); err == nil {
+		t.Error("closed server passed the check")
+	}
+}
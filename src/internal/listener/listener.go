@@ -0,0 +1,205 @@
+// Package listener configures HTTP servers of the service: timeouts,
+// header limits, TLS with certificate reloading, client certificate
+// verification (mTLS) and HTTP/2 without TLS (h2c).
+package listener
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Hofsiedge/person-api/internal/config"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+var ErrTLS = errors.New("invalid TLS config")
+
+// NewServer returns a server of handler listening on addr with the timeouts
+// and the header limit of cfg. With cfg.H2C the server accepts HTTP/2
+// without TLS (prior knowledge or Upgrade: h2c) as well as HTTP/1.1.
+func NewServer(addr string, cfg config.ServerConfig, handler http.Handler) *http.Server {
+	if cfg.H2C {
+		//nolint:exhaustruct
+		handler = h2c.NewHandler(handler, &http2.Server{IdleTimeout: cfg.IdleTimeout})
+	}
+
+	//nolint:exhaustruct
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadTimeout:       cfg.ReadTimout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.WriteTimout,
+		IdleTimeout:       cfg.IdleTimeout,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+	}
+}
+
+// ListenAndServe serves HTTPS if the server has a TLS config (the
+// certificate is taken from it) and HTTP otherwise
+func ListenAndServe(server *http.Server) error {
+	if server.TLSConfig != nil {
+		return server.ListenAndServeTLS("", "") //nolint:wrapcheck
+	}
+
+	return server.ListenAndServe() //nolint:wrapcheck
+}
+
+// TLSConfig returns the TLS config of a server and its certificate
+// (to be watched for changes) or nils if TLS is not configured. If a client
+// CA bundle is set, client certificates are verified against it and
+// required unless cfg.ClientCertOptional is set.
+func TLSConfig(cfg config.TLSConfig) (*tls.Config, *Certificate, error) {
+	switch {
+	case cfg.CertFile == "" && cfg.KeyFile == "" && cfg.ClientCAFile == "":
+		return nil, nil, nil
+	case cfg.CertFile == "" || cfg.KeyFile == "":
+		return nil, nil, fmt.Errorf("%w: both TLS_CERT_FILE and TLS_KEY_FILE must be set", ErrTLS)
+	}
+
+	certificate, err := NewCertificate(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	//nolint:exhaustruct
+	tlsConfig := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: certificate.GetCertificate,
+	}
+
+	if cfg.ClientCAFile != "" {
+		data, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not read client CAs: %w", err)
+		}
+
+		tlsConfig.ClientCAs = x509.NewCertPool()
+		if !tlsConfig.ClientCAs.AppendCertsFromPEM(data) {
+			return nil, nil, fmt.Errorf("%w: no certificates in %s", ErrTLS, cfg.ClientCAFile)
+		}
+
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		if cfg.ClientCertOptional {
+			tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+		}
+	}
+
+	return tlsConfig, certificate, nil
+}
+
+// Certificate is a server certificate loaded from a certificate and
+// a key file. It is reloaded by Watch when the files change, so renewed
+// certificates are served without a restart.
+type Certificate struct {
+	certificate *tls.Certificate
+	certFile    string
+	keyFile     string
+	version     string
+	mutex       sync.RWMutex
+}
+
+// NewCertificate loads a PEM encoded certificate (chain) and its key
+func NewCertificate(certFile, keyFile string) (*Certificate, error) {
+	certificate := &Certificate{
+		certificate: nil,
+		certFile:    certFile,
+		keyFile:     keyFile,
+		version:     "",
+		mutex:       sync.RWMutex{},
+	}
+
+	if _, err := certificate.Reload(); err != nil {
+		return nil, err
+	}
+
+	return certificate, nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate.
+func (c *Certificate) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return c.certificate, nil
+}
+
+// Reload reloads the certificate if the files have changed since the last
+// load. Reports whether the certificate was reloaded. The certificate is
+// kept on error (e.g. if only one of the files has been replaced yet).
+func (c *Certificate) Reload() (bool, error) {
+	version, err := c.filesVersion()
+	if err != nil {
+		return false, err
+	}
+
+	c.mutex.RLock()
+	unchanged := version == c.version
+	c.mutex.RUnlock()
+
+	if unchanged {
+		return false, nil
+	}
+
+	certificate, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("%w: %w", ErrTLS, err)
+	}
+
+	c.mutex.Lock()
+	c.certificate, c.version = &certificate, version
+	c.mutex.Unlock()
+
+	return true, nil
+}
+
+// Watch reloads the certificate every interval until ctx is done
+func (c *Certificate) Watch(ctx context.Context, interval time.Duration, logger *slog.Logger) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reloaded, err := c.Reload()
+			if err != nil {
+				logger.Error("could not reload the TLS certificate",
+					slog.String("cert_file", c.certFile),
+					slog.String("error", err.Error()))
+			} else if reloaded {
+				logger.Info("reloaded the TLS certificate", slog.String("cert_file", c.certFile))
+			}
+		}
+	}
+}
+
+// filesVersion identifies the state of the files by their sizes and
+// modification times
+func (c *Certificate) filesVersion() (string, error) {
+	var version string
+
+	for _, path := range []string{c.certFile, c.keyFile} {
+		info, err := os.Stat(path)
+		if err != nil {
+			return "", fmt.Errorf("could not read the certificate: %w", err)
+		}
+
+		version += fmt.Sprintf("%s:%d:%d\n", path, info.Size(), info.ModTime().UnixNano())
+	}
+
+	return version, nil
+}
@@ -0,0 +1,276 @@
+package listener_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Hofsiedge/person-api/internal/config"
+	"github.com/Hofsiedge/person-api/internal/listener"
+	"golang.org/x/net/http2"
+)
+
+type certificate struct {
+	template *x509.Certificate
+	key      crypto.Signer
+	der      []byte
+}
+
+// issue creates a certificate signed by parent (self-signed if parent is nil)
+func issue(t *testing.T, commonName string, parent *certificate) certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//nolint:exhaustruct
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+
+	signerTemplate, signerKey := template, crypto.Signer(key)
+	if parent == nil {
+		template.IsCA, template.BasicConstraintsValid = true, true
+	} else {
+		signerTemplate, signerKey = parent.template, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signerTemplate, key.Public(), signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return certificate{template: template, key: key, der: der}
+}
+
+func (c certificate) pem(t *testing.T) ([]byte, []byte) {
+	t.Helper()
+
+	key, err := x509.MarshalPKCS8PrivateKey(c.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Headers: nil, Bytes: c.der}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Headers: nil, Bytes: key})
+}
+
+func (c certificate) tls(t *testing.T) tls.Certificate {
+	t.Helper()
+
+	certPEM, keyPEM := c.pem(t)
+
+	result, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return result
+}
+
+// write writes the certificate and its key, the files get modTime
+func (c certificate) write(t *testing.T, certFile, keyFile string, modTime time.Time) {
+	t.Helper()
+
+	certPEM, keyPEM := c.pem(t)
+
+	for path, data := range map[string][]byte{certFile: certPEM, keyFile: keyPEM} {
+		if err := os.WriteFile(path, data, 0o600); err != nil { //nolint:gomnd
+			t.Fatal(err)
+		}
+
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// start serves a handler responding with the common name of the client
+// certificate
+func start(t *testing.T, serverCfg config.ServerConfig, tlsConfig *tls.Config) string {
+	t.Helper()
+
+	socket, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := listener.NewServer(socket.Addr().String(), serverCfg,
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+				fmt.Fprint(w, r.TLS.PeerCertificates[0].Subject.CommonName)
+			}
+
+			fmt.Fprint(w, r.Proto)
+		}))
+	server.TLSConfig = tlsConfig
+	server.ErrorLog = log.New(io.Discard, "", 0)
+
+	go func() {
+		if tlsConfig != nil {
+			_ = server.ServeTLS(socket, "", "")
+		} else {
+			_ = server.Serve(socket)
+		}
+	}()
+
+	t.Cleanup(func() { server.Close() })
+
+	return socket.Addr().String()
+}
+
+func get(client *http.Client, url string) (string, error) {
+	response, err := client.Get(url)
+	if err != nil {
+		return "", err //nolint:wrapcheck
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+
+	return string(body), err //nolint:wrapcheck
+}
+
+//nolint:exhaustruct
+var serverCfg = config.ServerConfig{
+	ReadTimout:        time.Second,
+	WriteTimout:       time.Second,
+	ReadHeaderTimeout: time.Second,
+	IdleTimeout:       time.Second,
+	MaxHeaderBytes:    http.DefaultMaxHeaderBytes,
+}
+
+func TestTLSConfig(t *testing.T) {
+	t.Parallel()
+
+	tlsConfig, certificate, err := listener.TLSConfig(config.TLSConfig{}) //nolint:exhaustruct
+	if tlsConfig != nil || certificate != nil || err != nil {
+		t.Errorf("TLS is enabled without certificates: %v", err)
+	}
+
+	//nolint:exhaustruct
+	if _, _, err := listener.TLSConfig(config.TLSConfig{CertFile: "cert.pem"}); !errors.Is(err, listener.ErrTLS) {
+		t.Errorf("TLS is enabled without a key: %v", err)
+	}
+}
+
+//nolint:funlen
+func TestMutualTLS(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	certFile, keyFile, caFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"), filepath.Join(dir, "ca.pem")
+
+	authority := issue(t, "ca", nil)
+	issue(t, "server", &authority).write(t, certFile, keyFile, time.Now())
+
+	caPEM, _ := authority.pem(t)
+	if err := os.WriteFile(caFile, caPEM, 0o600); err != nil { //nolint:gomnd
+		t.Fatal(err)
+	}
+
+	tlsConfig, certificate, err := listener.TLSConfig(config.TLSConfig{
+		CertFile:           certFile,
+		KeyFile:            keyFile,
+		ClientCAFile:       caFile,
+		ClientCertOptional: false,
+		ReloadInterval:     0,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	url := "https://" + start(t, serverCfg, tlsConfig)
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(caPEM)
+
+	client := func(certificates ...tls.Certificate) *http.Client {
+		//nolint:exhaustruct
+		return &http.Client{Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{RootCAs: roots, Certificates: certificates, MinVersion: tls.VersionTLS12},
+			ForceAttemptHTTP2: true,
+		}}
+	}
+
+	if _, err = get(client(), url); err == nil {
+		t.Error("client without a certificate was accepted")
+	}
+
+	if _, err = get(client(issue(t, "stranger", nil).tls(t)), url); err == nil {
+		t.Error("client with an unknown certificate was accepted")
+	}
+
+	body, err := get(client(issue(t, "client", &authority).tls(t)), url)
+	if err != nil || body != "clientHTTP/2.0" {
+		t.Errorf("client with a certificate was not accepted: %q, %v", body, err)
+	}
+
+	// renewal
+	issue(t, "renewed", &authority).write(t, certFile, keyFile, time.Now().Add(time.Minute))
+
+	if reloaded, err := certificate.Reload(); err != nil || !reloaded {
+		t.Fatalf("certificate was not reloaded: %v", err)
+	}
+
+	served, err := certificate.GetCertificate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if leaf, err := x509.ParseCertificate(served.Certificate[0]); err != nil || leaf.Subject.CommonName != "renewed" {
+		t.Errorf("renewed certificate is not served: %v", err)
+	}
+
+	if reloaded, err := certificate.Reload(); err != nil || reloaded {
+		t.Errorf("unchanged certificate was reloaded: %v", err)
+	}
+}
+
+func TestH2C(t *testing.T) {
+	t.Parallel()
+
+	cfg := serverCfg
+	cfg.H2C = true
+	addr := start(t, cfg, nil)
+
+	//nolint:exhaustruct
+	client := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, network, addr) //nolint:exhaustruct
+		},
+	}}
+
+	if body, err := get(client, "http://"+addr); err != nil || body != "HTTP/2.0" {
+		t.Errorf("HTTP/2 request was not served: %q, %v", body, err)
+	}
+
+	if body, err := get(http.DefaultClient, "http://"+addr); err != nil || body != "HTTP/1.1" {
+		t.Errorf("HTTP/1.1 request was not served: %q, %v", body, err)
+	}
+}
@@ -0,0 +1,256 @@
+// Package metrics collects Prometheus metrics of the service: HTTP requests
+// by operation, filler (provider) calls, completer cache lookups,
+// connection pool statistics and the number of people.
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Hofsiedge/person-api/internal/filler"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	namespace = "person_api"
+	// operation label of requests matching no operation
+	unknownOperation = "unknown"
+)
+
+// Metrics is a registry of the service metrics
+type Metrics struct {
+	registry        *prometheus.Registry
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	fillerCalls     *prometheus.CounterVec
+	fillerErrors    *prometheus.CounterVec
+	fillerDuration  *prometheus.HistogramVec
+	cacheLookups    *prometheus.CounterVec
+}
+
+// New returns Metrics with Go runtime and process metrics registered
+func New() *Metrics {
+	metrics := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{ //nolint:exhaustruct
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Number of processed HTTP requests by operation and status.",
+		}, []string{"operation", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{ //nolint:exhaustruct
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Duration of HTTP requests by operation and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"operation", "status"}),
+		fillerCalls: prometheus.NewCounterVec(prometheus.CounterOpts{ //nolint:exhaustruct
+			Namespace: namespace,
+			Name:      "filler_calls_total",
+			Help:      "Number of filler calls by provider.",
+		}, []string{"provider"}),
+		fillerErrors: prometheus.NewCounterVec(prometheus.CounterOpts{ //nolint:exhaustruct
+			Namespace: namespace,
+			Name:      "filler_errors_total",
+			Help:      "Number of failed filler calls by provider and error.",
+		}, []string{"provider", "error"}),
+		fillerDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{ //nolint:exhaustruct
+			Namespace: namespace,
+			Name:      "filler_call_duration_seconds",
+			Help:      "Duration of filler calls by provider.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"provider"}),
+		cacheLookups: prometheus.NewCounterVec(prometheus.CounterOpts{ //nolint:exhaustruct
+			Namespace: namespace,
+			Name:      "completer_cache_lookups_total",
+			Help:      "Number of completer cache lookups by result (hit or miss).",
+		}, []string{"result"}),
+	}
+
+	metrics.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}), //nolint:exhaustruct
+		metrics.requests,
+		metrics.requestDuration,
+		metrics.fillerCalls,
+		metrics.fillerErrors,
+		metrics.fillerDuration,
+		metrics.cacheLookups,
+	)
+
+	return metrics
+}
+
+// Handler serves the metrics in Prometheus text format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}) //nolint:exhaustruct
+}
+
+// MustRegister registers additional collectors. Panics if a collector
+// is invalid or registered twice.
+func (m *Metrics) MustRegister(collectors ...prometheus.Collector) {
+	m.registry.MustRegister(collectors...)
+}
+
+// statusRecorder saves the status code of the response
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(statusCode int) {
+	if r.status == 0 {
+		r.status = statusCode
+	}
+
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (r *statusRecorder) Write(data []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+
+	return r.ResponseWriter.Write(data) //nolint:wrapcheck
+}
+
+// HTTPMiddleware counts requests and observes their duration by operation
+// and status. operation names the operation of a request or returns
+// an empty string if the request matches no operation.
+func (m *Metrics) HTTPMiddleware(operation func(r *http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			recorder := &statusRecorder{ResponseWriter: w, status: 0}
+
+			next.ServeHTTP(recorder, r)
+
+			if recorder.status == 0 {
+				recorder.status = http.StatusOK
+			}
+
+			name := operation(r)
+			if name == "" {
+				name = unknownOperation
+			}
+
+			status := strconv.Itoa(recorder.status)
+			m.requests.WithLabelValues(name, status).Inc()
+			m.requestDuration.WithLabelValues(name, status).Observe(time.Since(start).Seconds())
+		})
+	}
+}
+
+// filler sentinel errors to error labels, checked in order
+// (specific errors first)
+//
+//nolint:gochecknoglobals
+var fillerErrorLabels = []struct {
+	err   error
+	label string
+}{
+	{filler.ErrTimeout, "timeout"},
+	{filler.ErrNetworkError, "network"},
+	{filler.ErrInvalidAPIToken, "invalid_token"},
+	{filler.ErrInvalidURL, "invalid_url"},
+	{filler.ErrEnvironment, "environment"},
+	{filler.ErrInvalidHeader, "invalid_header"},
+	{filler.ErrInvalidStatus, "invalid_status"},
+	{filler.ErrInvalidResponse, "invalid_response"},
+	{filler.ErrInvalidName, "invalid_name"},
+	{filler.ErrNotFound, "not_found"},
+	{filler.ErrLimitReached, "limit_reached"},
+}
+
+func fillerErrorLabel(err error) string {
+	for _, mapping := range fillerErrorLabels {
+		if errors.Is(err, mapping.err) {
+			return mapping.label
+		}
+	}
+
+	return "other"
+}
+
+// FillerCalled implements completer.Observer.
+func (m *Metrics) FillerCalled(provider string, duration time.Duration, err error) {
+	m.fillerCalls.WithLabelValues(provider).Inc()
+	m.fillerDuration.WithLabelValues(provider).Observe(duration.Seconds())
+
+	if err != nil {
+		m.fillerErrors.WithLabelValues(provider, fillerErrorLabel(err)).Inc()
+	}
+}
+
+// CacheLookup implements completer.Observer.
+func (m *Metrics) CacheLookup(hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+
+	m.cacheLookups.WithLabelValues(result).Inc()
+}
+
+// collector is a prometheus.Collector reading the metrics on every scrape
+type collector struct {
+	collect     func(metrics chan<- prometheus.Metric)
+	descriptors []*prometheus.Desc
+}
+
+func (c collector) Describe(descriptors chan<- *prometheus.Desc) {
+	for _, descriptor := range c.descriptors {
+		descriptors <- descriptor
+	}
+}
+
+func (c collector) Collect(metrics chan<- prometheus.Metric) {
+	c.collect(metrics)
+}
+
+// FillerQuotaCollector reports the number of requests left until the rate
+// limiter reset by provider (see completer.Completer.RequestsLeft)
+func FillerQuotaCollector(requestsLeft func() map[string]int) prometheus.Collector {
+	descriptor := prometheus.NewDesc(prometheus.BuildFQName(namespace, "filler", "requests_left"),
+		"Number of filler requests left until the provider rate limiter reset.", []string{"provider"}, nil)
+
+	return collector{
+		descriptors: []*prometheus.Desc{descriptor},
+		collect: func(metrics chan<- prometheus.Metric) {
+			for provider, left := range requestsLeft() {
+				metrics <- prometheus.MustNewConstMetric(descriptor, prometheus.GaugeValue, float64(left), provider)
+			}
+		},
+	}
+}
+
+// PeopleCollector reports the number of active and deleted people.
+// count is called with timeout on every scrape.
+func PeopleCollector(
+	count func(ctx context.Context) (active int, deleted int, err error), timeout time.Duration,
+) prometheus.Collector {
+	descriptor := prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "people"),
+		"Number of people by state (active or deleted).", []string{"state"}, nil)
+
+	return collector{
+		descriptors: []*prometheus.Desc{descriptor},
+		collect: func(metrics chan<- prometheus.Metric) {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			active, deleted, err := count(ctx)
+			if err != nil {
+				metrics <- prometheus.NewInvalidMetric(descriptor, err)
+
+				return
+			}
+
+			metrics <- prometheus.MustNewConstMetric(descriptor, prometheus.GaugeValue, float64(active), "active")
+			metrics <- prometheus.MustNewConstMetric(descriptor, prometheus.GaugeValue, float64(deleted), "deleted")
+		},
+	}
+}
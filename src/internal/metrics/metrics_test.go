@@ -0,0 +1,90 @@
+package metrics_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Hofsiedge/person-api/internal/filler"
+	"github.com/Hofsiedge/person-api/internal/metrics"
+)
+
+func scrape(t *testing.T, serviceMetrics *metrics.Metrics) string {
+	t.Helper()
+
+	recorder := httptest.NewRecorder()
+	serviceMetrics.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body, err := io.ReadAll(recorder.Result().Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(body)
+}
+
+func checkLines(t *testing.T, text string, lines ...string) {
+	t.Helper()
+
+	for _, line := range lines {
+		if !strings.Contains(text, line+"\n") {
+			t.Errorf("metric %q was not found", line)
+		}
+	}
+}
+
+func TestHTTPMiddleware(t *testing.T) {
+	t.Parallel()
+
+	serviceMetrics := metrics.New()
+	handler := serviceMetrics.HTTPMiddleware(func(r *http.Request) string {
+		if r.URL.Path == "/person" {
+			return "PersonList"
+		}
+
+		return ""
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/person" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	for _, path := range []string{"/person", "/person", "/other"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	checkLines(t, scrape(t, serviceMetrics),
+		`person_api_http_requests_total{operation="PersonList",status="200"} 2`,
+		`person_api_http_requests_total{operation="unknown",status="404"} 1`,
+		`person_api_http_request_duration_seconds_count{operation="PersonList",status="200"} 2`,
+	)
+}
+
+func TestFillerMetrics(t *testing.T) {
+	t.Parallel()
+
+	serviceMetrics := metrics.New()
+	serviceMetrics.MustRegister(metrics.FillerQuotaCollector(func() map[string]int {
+		return map[string]int{"agify": 42}
+	}))
+
+	serviceMetrics.FillerCalled("agify", time.Millisecond, nil)
+	serviceMetrics.FillerCalled("agify", time.Millisecond, filler.ErrTimeout)
+	serviceMetrics.FillerCalled("agify", time.Millisecond, fmt.Errorf("%w: 500", filler.ErrInvalidStatus))
+	serviceMetrics.CacheLookup(true)
+	serviceMetrics.CacheLookup(true)
+	serviceMetrics.CacheLookup(false)
+
+	checkLines(t, scrape(t, serviceMetrics),
+		`person_api_filler_calls_total{provider="agify"} 3`,
+		`person_api_filler_errors_total{error="timeout",provider="agify"} 1`,
+		`person_api_filler_errors_total{error="invalid_status",provider="agify"} 1`,
+		`person_api_filler_requests_left{provider="agify"} 42`,
+		`person_api_completer_cache_lookups_total{result="hit"} 2`,
+		`person_api_completer_cache_lookups_total{result="miss"} 1`,
+	)
+}
@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PoolCollector reports statistics of a pgx connection pool.
+// stat may return nil if there is no pool.
+func PoolCollector(stat func() *pgxpool.Stat) prometheus.Collector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", name), help, nil, nil)
+	}
+
+	acquired := desc("acquired_connections", "Number of connections currently in use.")
+	idle := desc("idle_connections", "Number of idle connections.")
+	total := desc("total_connections", "Number of open connections.")
+	maxConns := desc("max_connections", "Maximum size of the pool.")
+	acquires := desc("acquires_total", "Number of successful connection acquires.")
+	emptyAcquires := desc("empty_acquires_total", "Number of acquires that waited for a connection.")
+	canceledAcquires := desc("canceled_acquires_total", "Number of acquires canceled by the context.")
+	acquireWait := desc("acquire_wait_seconds_total", "Total time spent acquiring connections.")
+
+	return collector{
+		descriptors: []*prometheus.Desc{
+			acquired, idle, total, maxConns, acquires, emptyAcquires, canceledAcquires, acquireWait,
+		},
+		collect: func(metrics chan<- prometheus.Metric) {
+			stat := stat()
+			if stat == nil {
+				return
+			}
+
+			for _, metric := range []struct {
+				desc      *prometheus.Desc
+				valueType prometheus.ValueType
+				value     float64
+			}{
+				{acquired, prometheus.GaugeValue, float64(stat.AcquiredConns())},
+				{idle, prometheus.GaugeValue, float64(stat.IdleConns())},
+				{total, prometheus.GaugeValue, float64(stat.TotalConns())},
+				{maxConns, prometheus.GaugeValue, float64(stat.MaxConns())},
+				{acquires, prometheus.CounterValue, float64(stat.AcquireCount())},
+				{emptyAcquires, prometheus.CounterValue, float64(stat.EmptyAcquireCount())},
+				{canceledAcquires, prometheus.CounterValue, float64(stat.CanceledAcquireCount())},
+				{acquireWait, prometheus.CounterValue, stat.AcquireDuration().Seconds()},
+			} {
+				metrics <- prometheus.MustNewConstMetric(metric.desc, metric.valueType, metric.value)
+			}
+		},
+	}
+}
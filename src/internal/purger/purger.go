@@ -0,0 +1,74 @@
+// Package purger permanently deletes soft deleted records after
+// the retention period.
+package purger
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/Hofsiedge/person-api/internal/config"
+)
+
+type Repo interface {
+	Purge(ctx context.Context, retention time.Duration) (int, error)
+}
+
+type Purger struct {
+	repo   Repo
+	logger *slog.Logger
+	cfg    config.RetentionConfig
+}
+
+func New(repo Repo, cfg config.RetentionConfig, logger *slog.Logger) *Purger {
+	return &Purger{
+		repo:   repo,
+		logger: logger,
+		cfg:    cfg,
+	}
+}
+
+// Purge runs a single purge
+func (p *Purger) Purge(ctx context.Context) {
+	purged, err := p.repo.Purge(ctx, p.cfg.Retention)
+	if err != nil && ctx.Err() != nil {
+		// the purge is rolled back, the next run purges the people
+		p.logger.Info("purge was interrupted", slog.String("error", err.Error()))
+
+		return
+	}
+
+	if err != nil {
+		p.logger.Error("error purging deleted people", slog.String("error", err.Error()))
+
+		return
+	}
+
+	p.logger.Info("purged deleted people",
+		slog.Int("purged", purged),
+		slog.Duration("retention", p.cfg.Retention),
+	)
+}
+
+// Run purges deleted records every PurgeInterval until ctx is done.
+// Purging is disabled if PurgeInterval is not positive.
+func (p *Purger) Run(ctx context.Context) {
+	if p.cfg.PurgeInterval <= 0 {
+		p.logger.Warn("purging is disabled", slog.Duration("interval", p.cfg.PurgeInterval))
+
+		return
+	}
+
+	ticker := time.NewTicker(p.cfg.PurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		p.Purge(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
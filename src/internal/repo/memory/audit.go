@@ -0,0 +1,60 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/Hofsiedge/person-api/internal/domain"
+	"github.com/Hofsiedge/person-api/internal/repo"
+)
+
+// AuditLog reads the audit entries recorded by People
+type AuditLog struct {
+	people *People
+}
+
+// ensure AuditLog implements the interface
+var _ repo.AuditRepo = &AuditLog{people: nil}
+
+// AuditLog returns AuditLog of the people
+func (p *People) AuditLog() *AuditLog {
+	return &AuditLog{people: p}
+}
+
+func auditEntryMatches(filter domain.AuditFilter, entry domain.AuditEntry) bool {
+	return (filter.Actor == nil || *filter.Actor == entry.Actor) &&
+		(filter.PersonID == nil || *filter.PersonID == entry.PersonID) &&
+		(filter.From == nil || !entry.OccurredAt.Before(*filter.From)) &&
+		(filter.To == nil || entry.OccurredAt.Before(*filter.To))
+}
+
+// Audit implements repo.AuditRepo.
+func (a *AuditLog) Audit(
+	ctx context.Context, filter domain.AuditFilter, pagination domain.PaginationFilter,
+) (domain.Page[domain.AuditEntry], error) {
+	defer a.people.rlock(ctx)()
+
+	result := make([]domain.AuditEntry, 0)
+	recordIndex := 0
+
+	// newest first
+	for i := len(a.people.auditEntries) - 1; i >= 0; i-- {
+		entry := a.people.auditEntries[i]
+		if !auditEntryMatches(filter, entry) {
+			continue
+		}
+
+		if recordIndex >= pagination.Offset && recordIndex < pagination.Offset+pagination.Limit {
+			result = append(result, entry)
+		}
+		recordIndex++
+	}
+
+	page := domain.Page[domain.AuditEntry]{
+		Items:         result,
+		CurrentLimit:  pagination.Limit,
+		CurrentOffset: pagination.Offset,
+		TotalItems:    recordIndex,
+	}
+
+	return page, nil
+}
@@ -0,0 +1,108 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Hofsiedge/person-api/internal/domain"
+	"github.com/Hofsiedge/person-api/internal/repo"
+	"github.com/google/uuid"
+)
+
+// APIClients is an in-memory repo.APIClientRepo, safe for concurrent use
+type APIClients struct {
+	clients []domain.APIClient
+	mutex   sync.Mutex
+}
+
+// ensure APIClients implements the interface
+var _ repo.APIClientRepo = &APIClients{
+	clients: nil,
+	mutex:   sync.Mutex{},
+}
+
+func NewAPIClients() *APIClients {
+	return &APIClients{
+		clients: make([]domain.APIClient, 0),
+		mutex:   sync.Mutex{},
+	}
+}
+
+// active returns the index of the active client matching fn or -1
+func (c *APIClients) active(fn func(client domain.APIClient) bool) int {
+	return slices.IndexFunc(c.clients, func(client domain.APIClient) bool {
+		return client.RevokedAt == nil && fn(client)
+	})
+}
+
+// CreateClient implements repo.APIClientRepo.
+func (c *APIClients) CreateClient(ctx context.Context, client domain.APIClient) (uuid.UUID, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if client.Name == "" || client.KeyID == "" || client.SecretHash == "" {
+		return uuid.UUID{}, fmt.Errorf("%w: invalid client", repo.ErrArgument)
+	}
+
+	if c.active(func(other domain.APIClient) bool { return other.Name == client.Name }) >= 0 ||
+		slices.IndexFunc(c.clients, func(other domain.APIClient) bool { return other.KeyID == client.KeyID }) >= 0 {
+		return uuid.UUID{}, fmt.Errorf("%w: api client %s already exists", repo.ErrConflict, client.Name)
+	}
+
+	client.ID = uuid.New()
+	client.CreatedAt = time.Now()
+	client.RevokedAt = nil
+	client.Scopes = slices.Clone(client.Scopes)
+	c.clients = append(c.clients, client)
+
+	return client.ID, nil
+}
+
+// RevokeClient implements repo.APIClientRepo.
+func (c *APIClients) RevokeClient(ctx context.Context, name string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	index := c.active(func(client domain.APIClient) bool { return client.Name == name })
+	if index < 0 {
+		return repo.ErrNotFound
+	}
+
+	now := time.Now()
+	c.clients[index].RevokedAt = &now
+
+	return nil
+}
+
+// ClientByKeyID implements repo.APIClientRepo.
+func (c *APIClients) ClientByKeyID(ctx context.Context, keyID string) (domain.APIClient, error) {
+	return c.find(func(client domain.APIClient) bool { return client.KeyID == keyID })
+}
+
+// ClientByName implements repo.APIClientRepo.
+func (c *APIClients) ClientByName(ctx context.Context, name string) (domain.APIClient, error) {
+	return c.find(func(client domain.APIClient) bool { return client.Name == name })
+}
+
+func (c *APIClients) find(fn func(client domain.APIClient) bool) (domain.APIClient, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	index := c.active(fn)
+	if index < 0 {
+		return domain.APIClient{}, repo.ErrNotFound
+	}
+
+	return c.clients[index], nil
+}
+
+// ListClients implements repo.APIClientRepo.
+func (c *APIClients) ListClients(ctx context.Context) ([]domain.APIClient, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return slices.Clone(c.clients), nil
+}
@@ -0,0 +1,99 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Hofsiedge/person-api/internal/domain"
+	"github.com/Hofsiedge/person-api/internal/repo"
+)
+
+type idempotencyRecord struct {
+	expiresAt   time.Time
+	response    *domain.StoredResponse
+	fingerprint []byte
+}
+
+// IdempotencyKeys is an in-memory repo.IdempotencyRepo.
+// It is safe for concurrent use: concurrent requests with the same key
+// are the point of it.
+type IdempotencyKeys struct {
+	records map[string]idempotencyRecord
+	mutex   sync.Mutex
+}
+
+// ensure IdempotencyKeys implements the interface
+var _ repo.IdempotencyRepo = &IdempotencyKeys{
+	records: nil,
+	mutex:   sync.Mutex{},
+}
+
+func NewIdempotencyKeys() *IdempotencyKeys {
+	return &IdempotencyKeys{
+		records: make(map[string]idempotencyRecord),
+		mutex:   sync.Mutex{},
+	}
+}
+
+// Reserve implements repo.IdempotencyRepo.
+func (k *IdempotencyKeys) Reserve(
+	ctx context.Context, key string, fingerprint []byte, ttl time.Duration,
+) (*domain.StoredResponse, error) {
+	if ttl <= 0 {
+		return nil, fmt.Errorf("%w: invalid ttl %v", repo.ErrArgument, ttl)
+	}
+
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	record, found := k.records[key]
+	if !found || time.Now().After(record.expiresAt) {
+		k.records[key] = idempotencyRecord{
+			expiresAt:   time.Now().Add(ttl),
+			response:    nil,
+			fingerprint: fingerprint,
+		}
+
+		return nil, nil //nolint:nilnil
+	}
+
+	switch {
+	case !bytes.Equal(record.fingerprint, fingerprint):
+		return nil, fmt.Errorf("%w: %q", repo.ErrKeyReused, key)
+	case record.response == nil:
+		return nil, fmt.Errorf("%w: %q", repo.ErrInProgress, key)
+	}
+
+	return record.response, nil
+}
+
+// Save implements repo.IdempotencyRepo.
+func (k *IdempotencyKeys) Save(ctx context.Context, key string, response domain.StoredResponse) error {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	record, found := k.records[key]
+	if !found || record.response != nil {
+		return fmt.Errorf("%w: idempotency key %q is not reserved", repo.ErrNotFound, key)
+	}
+
+	record.response = &response
+	k.records[key] = record
+
+	return nil
+}
+
+// Release implements repo.IdempotencyRepo.
+func (k *IdempotencyKeys) Release(ctx context.Context, key string) error {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	if record, found := k.records[key]; found && record.response == nil {
+		delete(k.records, key)
+	}
+
+	return nil
+}
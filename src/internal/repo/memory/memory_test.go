@@ -0,0 +1,263 @@
+package memory_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/Hofsiedge/person-api/internal/domain"
+	"github.com/Hofsiedge/person-api/internal/repo"
+	"github.com/Hofsiedge/person-api/internal/repo/memory"
+	"github.com/Hofsiedge/person-api/internal/repo/repotest"
+	"github.com/Hofsiedge/person-api/internal/utils"
+	"github.com/google/uuid"
+)
+
+var allPeople = domain.PaginationFilter{Offset: 0, Limit: 100}
+
+func ptr[T any](value T) *T {
+	return &value
+}
+
+func names(page domain.Page[domain.Person]) []string {
+	result := make([]string, 0, len(page.Items))
+	for _, person := range page.Items {
+		result = append(result, person.Name)
+	}
+
+	return result
+}
+
+func createPeople(t *testing.T, people *memory.People, persons ...domain.Person) []uuid.UUID {
+	t.Helper()
+
+	ids := make([]uuid.UUID, 0, len(persons))
+
+	for _, person := range persons {
+		id, err := people.Create(context.Background(), person)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids
+}
+
+//nolint:funlen
+func TestList(t *testing.T) {
+	t.Parallel()
+
+	person := func(name, surname, patronymic string, age int) domain.Person {
+		return domain.Person{
+			Name: name, Surname: surname, Patronymic: patronymic,
+			Nationality: "RU", Sex: domain.Male, Age: age, ID: uuid.Nil,
+		}
+	}
+
+	people := memory.NewPeople()
+	ids := createPeople(t, people,
+		person("two words", "Petrov", "", 30),
+		person("Word", "Ivanov", "Ivanovich", 40),
+		person("Anna", "Ivanova", "", 20),
+		person("anna", "Ivanova", "", 25),
+		person("Boris", "Sidorov", "Petrovich", 50),
+	)
+
+	if err := people.Delete(context.Background(), ids[4]); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name     string
+		filter   domain.PersonFilter
+		expected []string
+		total    int
+	}{
+		{
+			// by surname, then by name (lower case first like en_US.UTF-8)
+			name:     "no filter",
+			filter:   domain.PersonFilter{},
+			expected: []string{"Word", "anna", "Anna", "two words"},
+			total:    4,
+		},
+		{
+			// word_similarity('word', 'word') = 1, ('word', 'two words') = 0.8
+			name:     "similarity order",
+			filter:   domain.PersonFilter{Name: ptr("word")},
+			expected: []string{"Word", "two words", "anna", "Anna"},
+			total:    4,
+		},
+		{
+			name:     "threshold below the score",
+			filter:   domain.PersonFilter{Name: ptr("word"), Threshold: ptr[float32](0.26)},
+			expected: []string{"Word", "two words"},
+			total:    4,
+		},
+		{
+			name:     "threshold above the score",
+			filter:   domain.PersonFilter{Name: ptr("WORD!"), Threshold: ptr[float32](0.27)},
+			expected: []string{"Word"},
+			total:    4,
+		},
+		{
+			name:     "empty patronymic",
+			filter:   domain.PersonFilter{Patronymic: ptr(""), Threshold: ptr[float32](0.3)},
+			expected: []string{"anna", "Anna", "two words"},
+			total:    3,
+		},
+		{
+			name:     "filters",
+			filter:   domain.PersonFilter{AgeMin: ptr(21), AgeMax: ptr(40), Sex: ptr(domain.Male)},
+			expected: []string{"Word", "anna", "two words"},
+			total:    3,
+		},
+		{
+			name:     "deleted",
+			filter:   domain.PersonFilter{Surname: ptr("sidorov"), IncludeDeleted: true, Threshold: ptr[float32](0.3)},
+			expected: []string{"Boris"},
+			total:    5,
+		},
+	}
+
+	for _, tCase := range testCases {
+		test := tCase
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			page, err := people.List(context.Background(), test.filter, allPeople)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(names(page), test.expected) || page.TotalItems != test.total {
+				t.Errorf("expected %v (%d in total), got %v (%d in total)",
+					test.expected, test.total, names(page), page.TotalItems)
+			}
+		})
+	}
+
+	t.Run("pagination", func(t *testing.T) {
+		t.Parallel()
+
+		page, err := people.List(context.Background(), domain.PersonFilter{},
+			domain.PaginationFilter{Offset: 1, Limit: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if expected := []string{"anna", "Anna"}; !reflect.DeepEqual(names(page), expected) || page.TotalItems != 4 {
+			t.Errorf("expected %v, got %v (%d in total)", expected, names(page), page.TotalItems)
+		}
+	})
+}
+
+func TestConcurrentUse(t *testing.T) {
+	t.Parallel()
+
+	people := memory.NewPeople()
+	ids := createPeople(t, people, utils.MakePerson())
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			ctx := context.Background()
+
+			for j := 0; j < 50; j++ {
+				_, _ = people.Create(ctx, utils.MakePerson())
+				_ = people.PartialUpdate(ctx, ids[0], domain.PersonPartial{Age: ptr(j)})
+				_, _ = people.List(ctx, domain.PersonFilter{Name: ptr("a")}, allPeople)
+				_, _ = people.AuditLog().Audit(ctx, domain.AuditFilter{}, allPeople)
+				_ = people.WithinTx(ctx, func(ctx context.Context) error {
+					_, err := people.Create(ctx, utils.MakePerson())
+
+					return errors.Join(err, repo.ErrConflict)
+				})
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	// the transactions were rolled back
+	if active, _, err := people.Count(context.Background()); err != nil || active != 1+8*50 {
+		t.Errorf("unexpected number of people: %d (%v)", active, err)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	people := memory.NewPeople()
+	ids := createPeople(t, people, utils.MakePerson(), utils.MakePerson())
+
+	if err := people.Delete(ctx, ids[1]); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "people.json")
+	if err := people.SaveFile(ctx, path); err != nil {
+		t.Fatal(err)
+	}
+
+	restored := memory.NewPeople()
+	if err := restored.LoadFile(ctx, path); err != nil {
+		t.Fatal(err)
+	}
+
+	// change IDs continue from the snapshot
+	entryIDs := make([]int64, 0, 2)
+
+	for _, store := range []*memory.People{people, restored} {
+		if _, err := store.Create(ctx, utils.MakePerson()); err != nil {
+			t.Fatal(err)
+		}
+
+		entries, err := store.AuditLog().Audit(ctx, domain.AuditFilter{}, allPeople)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		entryIDs = append(entryIDs, entries.Items[0].ID)
+	}
+
+	if entryIDs[0] != entryIDs[1] {
+		t.Errorf("change IDs were not restored: %v", entryIDs)
+	}
+
+	if history, err := restored.History(ctx, ids[1], allPeople); err != nil || len(history.Items) != 2 {
+		t.Errorf("history was not restored: %v %v", history, err)
+	}
+
+	if err := restored.Restore(ctx, ids[1]); err != nil {
+		t.Errorf("deleted person was not restored: %v", err)
+	}
+
+	if err := memory.NewPeople().LoadFile(ctx, filepath.Join(t.TempDir(), "missing.json")); err != nil {
+		t.Errorf("missing snapshot file is an error: %v", err)
+	}
+
+	err := memory.NewPeople().ReadSnapshot(ctx, bytes.NewBufferString(`{"version": 2}`))
+	if !errors.Is(err, memory.ErrSnapshot) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestConformance(t *testing.T) {
+	t.Parallel()
+
+	repotest.RunConformance(t, func(*testing.T) repo.PersonRepo {
+		return memory.NewPeople()
+	})
+}
@@ -0,0 +1,414 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Hofsiedge/person-api/internal/domain"
+	"github.com/Hofsiedge/person-api/internal/repo"
+	"github.com/Hofsiedge/person-api/internal/repo/search"
+	"github.com/Hofsiedge/person-api/internal/reqctx"
+	"github.com/google/uuid"
+)
+
+// People is an in-memory repo.PersonRepo, safe for concurrent use.
+// It records the history and the audit log like the Postgres triggers do
+// and searches like people.list_people (see List).
+type People struct {
+	people map[uuid.UUID]domain.Person
+	// deletion times of soft deleted people
+	deleted map[uuid.UUID]time.Time
+	// changes of people, oldest first
+	changes map[uuid.UUID][]domain.PersonChange
+	// audit entries of the changes, oldest first
+	auditEntries []domain.AuditEntry
+	// ID of the last recorded change
+	lastChangeID int64
+	mutex        sync.RWMutex
+}
+
+// ensure People implements the interface
+var _ repo.PersonRepo = &People{
+	people:       nil,
+	deleted:      nil,
+	changes:      nil,
+	auditEntries: nil,
+	lastChangeID: 0,
+	mutex:        sync.RWMutex{},
+}
+
+func NewPeople() *People {
+	return &People{
+		people:       make(map[uuid.UUID]domain.Person),
+		deleted:      make(map[uuid.UUID]time.Time),
+		changes:      make(map[uuid.UUID][]domain.PersonChange),
+		auditEntries: make([]domain.AuditEntry, 0),
+		lastChangeID: 0,
+		mutex:        sync.RWMutex{},
+	}
+}
+
+// lock locks the people for writing and returns the unlocking function.
+// Within a transaction of the people (see WithinTx) the lock is already
+// held and nothing is done.
+func (p *People) lock(ctx context.Context) func() {
+	if ctx.Value(txKey{}) == p {
+		return func() {}
+	}
+
+	p.mutex.Lock()
+
+	return p.mutex.Unlock
+}
+
+// rlock locks the people for reading, see lock
+func (p *People) rlock(ctx context.Context) func() {
+	if ctx.Value(txKey{}) == p {
+		return func() {}
+	}
+
+	p.mutex.RLock()
+
+	return p.mutex.RUnlock
+}
+
+// record adds a change to the history and the audit log
+// like the people_history and audit triggers do
+func (p *People) record(
+	ctx context.Context, personID uuid.UUID, operation domain.ChangeOperation, old, value *domain.Person,
+) {
+	if operation == domain.ChangeUpdate && *old == *value {
+		return
+	}
+
+	p.lastChangeID++
+	now := time.Now()
+	p.changes[personID] = append(p.changes[personID], domain.PersonChange{
+		ChangedAt: now,
+		Old:       old,
+		New:       value,
+		Operation: operation,
+		Actor:     reqctx.Actor(ctx),
+		RequestID: reqctx.RequestID(ctx),
+		ID:        p.lastChangeID,
+		PersonID:  personID,
+	})
+	p.auditEntries = append(p.auditEntries, domain.AuditEntry{
+		OccurredAt: now,
+		Diff:       domain.Diff(old, value),
+		Operation:  operation,
+		Actor:      reqctx.Actor(ctx),
+		ClientIP:   reqctx.ClientIP(ctx),
+		RequestID:  reqctx.RequestID(ctx),
+		ID:         p.lastChangeID,
+		PersonID:   personID,
+	})
+}
+
+// maxAge is people.const_max_age()
+const maxAge = 125
+
+// checkPerson checks the constraints of the people.people table
+func checkPerson(person domain.Person) error {
+	nationality := string(person.Nationality)
+
+	switch {
+	case person.Name == "":
+		return fmt.Errorf("%w: empty name", repo.ErrArgument)
+	case person.Surname == "":
+		return fmt.Errorf("%w: empty surname", repo.ErrArgument)
+	case person.Age < 0 || person.Age > maxAge:
+		return fmt.Errorf("%w: age %d is not in [0, %d]", repo.ErrArgument, person.Age, maxAge)
+	case !person.Sex.Valid():
+		return fmt.Errorf("%w: invalid sex %q", repo.ErrArgument, person.Sex)
+	case len(nationality) != 2 || strings.ToUpper(nationality) != nationality ||
+		strings.IndexFunc(nationality, func(r rune) bool { return r < 'A' || r > 'Z' }) >= 0:
+		return fmt.Errorf("%w: invalid nationality %q", repo.ErrArgument, nationality)
+	}
+
+	return nil
+}
+
+// update saves an updated person and records the change
+func (p *People) update(ctx context.Context, old, person domain.Person) error {
+	if err := checkPerson(person); err != nil {
+		return err
+	}
+
+	p.people[person.ID] = person
+	p.record(ctx, person.ID, domain.ChangeUpdate, &old, &person)
+
+	return nil
+}
+
+// get returns a person that is not deleted
+func (p *People) get(id uuid.UUID) (domain.Person, bool) {
+	if _, deleted := p.deleted[id]; deleted {
+		return domain.Person{}, false
+	}
+
+	person, found := p.people[id]
+
+	return person, found
+}
+
+// Count returns the number of active and deleted (not purged) people
+func (p *People) Count(ctx context.Context) (int, int, error) {
+	defer p.rlock(ctx)()
+
+	return len(p.people) - len(p.deleted), len(p.deleted), nil
+}
+
+// Create implements repo.Repo.
+func (p *People) Create(ctx context.Context, obj domain.Person) (uuid.UUID, error) {
+	if err := checkPerson(obj); err != nil {
+		return uuid.UUID{}, err
+	}
+
+	defer p.lock(ctx)()
+
+	id := uuid.New()
+	obj.ID = id
+	p.people[id] = obj
+	p.record(ctx, id, domain.ChangeCreate, nil, &obj)
+
+	return id, nil
+}
+
+// Delete implements repo.Repo.
+func (p *People) Delete(ctx context.Context, id uuid.UUID) error {
+	defer p.lock(ctx)()
+
+	person, found := p.get(id)
+	if !found {
+		return repo.ErrNotFound
+	}
+
+	p.deleted[id] = time.Now()
+	p.record(ctx, id, domain.ChangeDelete, &person, nil)
+
+	return nil
+}
+
+// FullUpdate implements repo.Repo.
+func (p *People) FullUpdate(ctx context.Context, personID uuid.UUID, replacement domain.Person) error {
+	defer p.lock(ctx)()
+
+	old, found := p.get(personID)
+	if !found {
+		return repo.ErrNotFound
+	}
+
+	task := replacement
+	task.ID = personID
+
+	return p.update(ctx, old, task)
+}
+
+// GetByID implements repo.Repo.
+func (p *People) GetByID(ctx context.Context, id uuid.UUID) (domain.Person, error) {
+	defer p.rlock(ctx)()
+
+	task, found := p.get(id)
+	if !found {
+		return domain.Person{}, repo.ErrNotFound
+	}
+
+	return task, nil
+}
+
+// List implements repo.Repo. People are searched like people.list_people
+// (see search.Page).
+func (p *People) List(
+	ctx context.Context, filter domain.PersonFilter, pagination domain.PaginationFilter,
+) (domain.Page[domain.Person], error) {
+	if !filter.Match.Valid() {
+		return domain.Page[domain.Person]{}, fmt.Errorf("%w: invalid match %q", repo.ErrArgument, filter.Match)
+	}
+
+	unlock := p.rlock(ctx)
+
+	matching := make([]domain.Person, 0)
+
+	for id, person := range p.people {
+		if _, deleted := p.deleted[id]; deleted && !filter.IncludeDeleted {
+			continue
+		}
+
+		if search.Matches(filter, person) {
+			matching = append(matching, person)
+		}
+	}
+
+	unlock()
+
+	return search.Page(filter, pagination, matching), nil
+}
+
+// PartialUpdate implements repo.Repo.
+func (p *People) PartialUpdate(ctx context.Context, personID uuid.UUID, partial domain.PersonPartial) error {
+	defer p.lock(ctx)()
+
+	person, found := p.get(personID)
+	if !found {
+		return repo.ErrNotFound
+	}
+
+	if (partial == domain.PersonPartial{
+		Name:        nil,
+		Surname:     nil,
+		Patronymic:  nil,
+		Nationality: nil,
+		Sex:         nil,
+		Age:         nil,
+	}) {
+		return repo.ErrArgument
+	}
+
+	old := person
+
+	if partial.Name != nil {
+		person.Name = *partial.Name
+	}
+
+	if partial.Surname != nil {
+		person.Surname = *partial.Surname
+	}
+
+	if partial.Patronymic != nil {
+		person.Patronymic = *partial.Patronymic
+	}
+
+	if partial.Nationality != nil {
+		person.Nationality = domain.Nationality(*partial.Nationality)
+	}
+
+	if partial.Sex != nil {
+		person.Sex = *partial.Sex
+	}
+
+	if partial.Age != nil {
+		person.Age = *partial.Age
+	}
+
+	return p.update(ctx, old, person)
+}
+
+// Patch implements repo.PersonRepo.
+func (p *People) Patch(ctx context.Context, personID uuid.UUID, operations []domain.PatchOperation) error {
+	defer p.lock(ctx)()
+
+	person, found := p.get(personID)
+	if !found {
+		return repo.ErrNotFound
+	}
+
+	patched, err := person.Apply(operations)
+	if err != nil {
+		if errors.Is(err, domain.ErrPatchTestFailed) {
+			return fmt.Errorf("%w: %w", repo.ErrConflict, err)
+		}
+
+		return fmt.Errorf("%w: %w", repo.ErrArgument, err)
+	}
+
+	return p.update(ctx, person, patched)
+}
+
+// Restore implements repo.PersonRepo.
+func (p *People) Restore(ctx context.Context, personID uuid.UUID) error {
+	defer p.lock(ctx)()
+
+	person, found := p.people[personID]
+	if !found {
+		return repo.ErrNotFound
+	}
+
+	if _, deleted := p.deleted[personID]; !deleted {
+		return fmt.Errorf("%w: person is not deleted", repo.ErrConflict)
+	}
+
+	delete(p.deleted, personID)
+	p.record(ctx, personID, domain.ChangeRestore, nil, &person)
+
+	return nil
+}
+
+// Purge implements repo.PersonRepo.
+func (p *People) Purge(ctx context.Context, retention time.Duration) (int, error) {
+	if retention < 0 {
+		return 0, fmt.Errorf("%w: invalid retention %v", repo.ErrArgument, retention)
+	}
+
+	defer p.lock(ctx)()
+
+	purged := 0
+	threshold := time.Now().Add(-retention)
+
+	for id, deletedAt := range p.deleted {
+		if deletedAt.Before(threshold) {
+			person := p.people[id]
+			p.record(ctx, id, domain.ChangePurge, &person, nil)
+			delete(p.people, id)
+			delete(p.deleted, id)
+
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+// GetAsOf implements repo.PersonRepo.
+func (p *People) GetAsOf(ctx context.Context, personID uuid.UUID, moment time.Time) (domain.Person, error) {
+	defer p.rlock(ctx)()
+
+	changes := p.changes[personID]
+	for i := len(changes) - 1; i >= 0; i-- {
+		if changes[i].ChangedAt.After(moment) {
+			continue
+		}
+
+		if changes[i].New == nil {
+			break
+		}
+
+		return *changes[i].New, nil
+	}
+
+	return domain.Person{}, repo.ErrNotFound
+}
+
+// History implements repo.PersonRepo.
+func (p *People) History(
+	ctx context.Context, personID uuid.UUID, pagination domain.PaginationFilter,
+) (domain.Page[domain.PersonChange], error) {
+	defer p.rlock(ctx)()
+
+	changes := p.changes[personID]
+	if len(changes) == 0 {
+		return domain.Page[domain.PersonChange]{}, repo.ErrNotFound
+	}
+
+	result := make([]domain.PersonChange, 0)
+
+	for i := range changes {
+		if i >= pagination.Offset && i < pagination.Offset+pagination.Limit {
+			// newest first
+			result = append(result, changes[len(changes)-1-i])
+		}
+	}
+
+	page := domain.Page[domain.PersonChange]{
+		Items:         result,
+		CurrentLimit:  pagination.Limit,
+		CurrentOffset: pagination.Offset,
+		TotalItems:    len(changes),
+	}
+
+	return page, nil
+}
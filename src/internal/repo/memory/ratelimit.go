@@ -0,0 +1,68 @@
+// Package memory contains in-memory repositories for single instance
+// deployments.
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Hofsiedge/person-api/internal/domain"
+	"github.com/Hofsiedge/person-api/internal/repo"
+)
+
+// how often full buckets are forgotten
+const sweepInterval = time.Minute
+
+// RateLimits is an in-memory repo.RateLimitRepo. The buckets are local to
+// the process, so each replica limits clients separately.
+type RateLimits struct {
+	buckets map[string]domain.BucketState
+	swept   time.Time
+	mutex   sync.Mutex
+}
+
+// ensure RateLimits implements the interface
+var _ repo.RateLimitRepo = &RateLimits{
+	buckets: nil,
+	swept:   time.Time{},
+	mutex:   sync.Mutex{},
+}
+
+func NewRateLimits() *RateLimits {
+	return &RateLimits{
+		buckets: make(map[string]domain.BucketState),
+		swept:   time.Now(),
+		mutex:   sync.Mutex{},
+	}
+}
+
+// Take implements repo.RateLimitRepo.
+func (l *RateLimits) Take(
+	ctx context.Context, key string, cost float64, bucket domain.TokenBucket,
+) (domain.RateLimitResult, error) {
+	if cost < 0 || bucket.Capacity <= 0 || bucket.Rate <= 0 {
+		return domain.RateLimitResult{}, fmt.Errorf("%w: invalid cost %v or bucket %v", repo.ErrArgument, cost, bucket)
+	}
+
+	now := time.Now()
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if now.Sub(l.swept) >= sweepInterval {
+		for bucketKey, state := range l.buckets {
+			if bucket.Full(state, now) {
+				delete(l.buckets, bucketKey)
+			}
+		}
+
+		l.swept = now
+	}
+
+	state, allowed := bucket.Take(l.buckets[key], cost, now)
+	l.buckets[key] = state
+
+	return domain.RateLimitResult{Tokens: state.Tokens, Allowed: allowed}, nil
+}
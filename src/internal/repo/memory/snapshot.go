@@ -0,0 +1,134 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Hofsiedge/person-api/internal/domain"
+	"github.com/google/uuid"
+)
+
+// snapshotVersion is the version of the snapshot format
+const snapshotVersion = 1
+
+// ErrSnapshot means a snapshot could not be read
+var ErrSnapshot = errors.New("invalid snapshot")
+
+// dump is the snapshot of People
+type dump struct {
+	Version      int                                 `json:"version"`
+	People       map[uuid.UUID]domain.Person         `json:"people"`
+	Deleted      map[uuid.UUID]time.Time             `json:"deleted"`
+	Changes      map[uuid.UUID][]domain.PersonChange `json:"changes"`
+	AuditEntries []domain.AuditEntry                 `json:"audit_entries"`
+	LastChangeID int64                               `json:"last_change_id"`
+}
+
+// WriteSnapshot writes the people, their history and the audit log to w as JSON
+func (p *People) WriteSnapshot(ctx context.Context, w io.Writer) error {
+	unlock := p.rlock(ctx)
+
+	data, err := json.Marshal(dump{
+		Version:      snapshotVersion,
+		People:       p.people,
+		Deleted:      p.deleted,
+		Changes:      p.changes,
+		AuditEntries: p.auditEntries,
+		LastChangeID: p.lastChangeID,
+	})
+
+	unlock()
+
+	if err != nil {
+		return fmt.Errorf("could not encode the snapshot: %w", err)
+	}
+
+	if _, err = w.Write(data); err != nil {
+		return fmt.Errorf("could not write the snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// ReadSnapshot replaces the people with the ones of a snapshot written by
+// WriteSnapshot
+func (p *People) ReadSnapshot(ctx context.Context, r io.Reader) error {
+	var saved dump
+	if err := json.NewDecoder(r).Decode(&saved); err != nil {
+		return fmt.Errorf("%w: %w", ErrSnapshot, err)
+	}
+
+	if saved.Version != snapshotVersion {
+		return fmt.Errorf("%w: version %d, expected %d", ErrSnapshot, saved.Version, snapshotVersion)
+	}
+
+	defer p.lock(ctx)()
+
+	p.people = saved.People
+	p.deleted = saved.Deleted
+	p.changes = saved.Changes
+	p.auditEntries = saved.AuditEntries
+	p.lastChangeID = saved.LastChangeID
+
+	if p.people == nil {
+		p.people = make(map[uuid.UUID]domain.Person)
+	}
+
+	if p.deleted == nil {
+		p.deleted = make(map[uuid.UUID]time.Time)
+	}
+
+	if p.changes == nil {
+		p.changes = make(map[uuid.UUID][]domain.PersonChange)
+	}
+
+	return nil
+}
+
+// SaveFile writes a snapshot to the file at path. The snapshot is written
+// to a temporary file first, so a crash never leaves a truncated one.
+func (p *People) SaveFile(ctx context.Context, path string) error {
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("could not save the snapshot: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	if err = p.WriteSnapshot(ctx, file); err != nil {
+		file.Close()
+
+		return err
+	}
+
+	if err = errors.Join(file.Sync(), file.Close()); err != nil {
+		return fmt.Errorf("could not save the snapshot: %w", err)
+	}
+
+	if err = os.Rename(file.Name(), path); err != nil {
+		return fmt.Errorf("could not save the snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// LoadFile restores the snapshot of the file at path. A missing file is
+// not an error: the people are left empty.
+func (p *People) LoadFile(ctx context.Context, path string) error {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not load the snapshot: %w", err)
+	}
+	defer file.Close()
+
+	return p.ReadSnapshot(ctx, file)
+}
@@ -0,0 +1,56 @@
+package memory
+
+import (
+	"context"
+	"maps"
+	"time"
+
+	"github.com/Hofsiedge/person-api/internal/domain"
+	"github.com/google/uuid"
+)
+
+// txKey is the context key of the People in a transaction of which
+// the context is
+type txKey struct{}
+
+// savepoint is the state of People to roll back to
+type savepoint struct {
+	people       map[uuid.UUID]domain.Person
+	deleted      map[uuid.UUID]time.Time
+	changes      map[uuid.UUID][]domain.PersonChange
+	auditEntries int
+	lastChangeID int64
+}
+
+// WithinTx implements repo.TxManager. The people are locked for the whole
+// transaction, so transactions are serialized and never have to be retried.
+// If fn fails, the people, their changes and audit entries are restored
+// to the state before the call. Nested calls restore their own changes
+// only, like savepoints.
+func (p *People) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(txKey{}) != p {
+		p.mutex.Lock()
+		defer p.mutex.Unlock()
+
+		ctx = context.WithValue(ctx, txKey{}, p)
+	}
+
+	// changes are only appended, so copies of the slice headers are enough
+	saved := savepoint{
+		people:       maps.Clone(p.people),
+		deleted:      maps.Clone(p.deleted),
+		changes:      maps.Clone(p.changes),
+		auditEntries: len(p.auditEntries),
+		lastChangeID: p.lastChangeID,
+	}
+
+	if err := fn(ctx); err != nil {
+		p.people, p.deleted, p.changes = saved.people, saved.deleted, saved.changes
+		p.auditEntries = p.auditEntries[:saved.auditEntries]
+		p.lastChangeID = saved.lastChangeID
+
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package postgres
+
+import (
+	"context"
+	"net/netip"
+	"time"
+
+	"github.com/Hofsiedge/person-api/internal/domain"
+	"github.com/Hofsiedge/person-api/internal/repo"
+	"github.com/google/uuid"
+)
+
+// AuditLog reads people.audit_log. Entries are written by triggers
+// (see People.modify).
+type AuditLog struct {
+	db PgxPoolInterface
+}
+
+// ensure that AuditLog implements repo.AuditRepo
+var _ repo.AuditRepo = &AuditLog{nil}
+
+// AuditLog returns AuditLog sharing the connection pool
+func (p *People) AuditLog() *AuditLog {
+	return &AuditLog{p.db}
+}
+
+// people.audit_log row
+type AuditEntry struct {
+	OccurredAt time.Time                     `db:"occurred_at"`
+	Diff       map[string]domain.FieldChange `db:"diff"`
+	ClientIP   *netip.Prefix                 `db:"client_ip"`
+	Actor      *string                       `db:"actor"`
+	RequestID  *string                       `db:"request_id"`
+	Operation  string                        `db:"operation"`
+	AuditID    int64                         `db:"audit_id"`
+	PersonID   uuid.UUID                     `db:"person_id"`
+}
+
+// convert AuditEntry to domain.AuditEntry
+func (e AuditEntry) ToAbstract() domain.AuditEntry {
+	entry := domain.AuditEntry{
+		OccurredAt: e.OccurredAt,
+		Diff:       e.Diff,
+		Operation:  domain.ChangeOperation(e.Operation),
+		Actor:      "",
+		ClientIP:   "",
+		RequestID:  "",
+		ID:         e.AuditID,
+		PersonID:   e.PersonID,
+	}
+
+	if e.Actor != nil {
+		entry.Actor = *e.Actor
+	}
+
+	if e.ClientIP != nil {
+		entry.ClientIP = e.ClientIP.Addr().String()
+	}
+
+	if e.RequestID != nil {
+		entry.RequestID = *e.RequestID
+	}
+
+	return entry
+}
+
+type AuditPage struct {
+	Entries       []AuditEntry `db:"entries"`
+	CurrentOffset int          `db:"current_offset"`
+	CurrentLimit  int          `db:"current_limit"`
+	Total         int          `db:"total"`
+}
+
+// convert AuditPage to domain.Page[domain.AuditEntry]
+func (p AuditPage) ToAbstract() domain.Page[domain.AuditEntry] {
+	page := domain.Page[domain.AuditEntry]{
+		Items:         make([]domain.AuditEntry, len(p.Entries)),
+		CurrentOffset: p.CurrentOffset,
+		CurrentLimit:  p.CurrentLimit,
+		TotalItems:    p.Total,
+	}
+	for i, entry := range p.Entries {
+		page.Items[i] = entry.ToAbstract()
+	}
+
+	return page
+}
+
+// Audit implements repo.AuditRepo.
+func (a *AuditLog) Audit(
+	ctx context.Context, filter domain.AuditFilter, pagination domain.PaginationFilter,
+) (domain.Page[domain.AuditEntry], error) {
+	row := conn(ctx, a.db).QueryRow(ctx, `
+		select people.list_audit(
+			actor_ => $1, person_id_ => $2, from_ => $3, to_ => $4,
+			offset_ => $5, limit_ => $6)
+		`,
+		filter.Actor, filter.PersonID, filter.From, filter.To,
+		pagination.Offset, pagination.Limit,
+	)
+
+	var page AuditPage
+
+	if err := row.Scan(&page); err != nil {
+		return domain.Page[domain.AuditEntry]{}, wrapPostgresError(err)
+	}
+
+	return page.ToAbstract(), nil
+}
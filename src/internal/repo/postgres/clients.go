@@ -0,0 +1,119 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Hofsiedge/person-api/internal/domain"
+	"github.com/Hofsiedge/person-api/internal/repo"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// APIClients stores API clients in people.api_clients
+type APIClients struct {
+	db PgxPoolInterface
+}
+
+// ensure that APIClients implements repo.APIClientRepo
+var _ repo.APIClientRepo = &APIClients{nil}
+
+// APIClients returns APIClients sharing the connection pool
+func (p *People) APIClients() *APIClients {
+	return &APIClients{p.db}
+}
+
+// people.api_clients row
+type APIClient struct {
+	CreatedAt  time.Time  `db:"created_at"`
+	RevokedAt  *time.Time `db:"revoked_at"`
+	Name       string     `db:"name"`
+	KeyID      string     `db:"key_id"`
+	SecretHash string     `db:"secret_hash"`
+	Scopes     []string   `db:"scopes"`
+	ClientID   uuid.UUID  `db:"client_id"`
+}
+
+// convert APIClient to domain.APIClient
+func (c APIClient) ToAbstract() domain.APIClient {
+	return domain.APIClient{
+		CreatedAt:  c.CreatedAt,
+		RevokedAt:  c.RevokedAt,
+		Name:       c.Name,
+		KeyID:      c.KeyID,
+		SecretHash: c.SecretHash,
+		Scopes:     c.Scopes,
+		ID:         c.ClientID,
+	}
+}
+
+// CreateClient implements repo.APIClientRepo.
+func (c *APIClients) CreateClient(ctx context.Context, client domain.APIClient) (uuid.UUID, error) {
+	var id uuid.UUID
+
+	err := conn(ctx, c.db).QueryRow(ctx, `select people.create_api_client($1, $2, $3, $4)`,
+		client.Name, client.KeyID, client.SecretHash, client.Scopes).Scan(&id)
+	if err != nil {
+		return uuid.UUID{}, wrapPostgresError(err)
+	}
+
+	return id, nil
+}
+
+// RevokeClient implements repo.APIClientRepo.
+func (c *APIClients) RevokeClient(ctx context.Context, name string) error {
+	if _, err := conn(ctx, c.db).Exec(ctx, `select people.revoke_api_client($1)`, name); err != nil {
+		return wrapPostgresError(err)
+	}
+
+	return nil
+}
+
+// ClientByKeyID implements repo.APIClientRepo.
+func (c *APIClients) ClientByKeyID(ctx context.Context, keyID string) (domain.APIClient, error) {
+	return c.find(ctx, `select * from people.find_api_clients(key_id_ => $1)`, keyID)
+}
+
+// ClientByName implements repo.APIClientRepo.
+func (c *APIClients) ClientByName(ctx context.Context, name string) (domain.APIClient, error) {
+	return c.find(ctx, `select * from people.find_api_clients(name_ => $1)`, name)
+}
+
+func (c *APIClients) find(ctx context.Context, sql string, arg string) (domain.APIClient, error) {
+	rows, err := conn(ctx, c.db).Query(ctx, sql, arg)
+	if err != nil {
+		return domain.APIClient{}, wrapPostgresError(err)
+	}
+
+	client, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[APIClient])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.APIClient{}, repo.ErrNotFound
+		}
+
+		return domain.APIClient{}, wrapPostgresError(err)
+	}
+
+	return client.ToAbstract(), nil
+}
+
+// ListClients implements repo.APIClientRepo.
+func (c *APIClients) ListClients(ctx context.Context) ([]domain.APIClient, error) {
+	rows, err := conn(ctx, c.db).Query(ctx, `select * from people.find_api_clients()`)
+	if err != nil {
+		return nil, wrapPostgresError(err)
+	}
+
+	clients, err := pgx.CollectRows(rows, pgx.RowToStructByName[APIClient])
+	if err != nil {
+		return nil, wrapPostgresError(err)
+	}
+
+	result := make([]domain.APIClient, len(clients))
+	for i, client := range clients {
+		result[i] = client.ToAbstract()
+	}
+
+	return result, nil
+}
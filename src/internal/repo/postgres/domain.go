@@ -1,18 +1,23 @@
 package postgres
 
 import (
+	"time"
+
 	"github.com/Hofsiedge/person-api/internal/domain"
 	"github.com/google/uuid"
 )
 
+// json tags match to_jsonb(people.people). DeletedAt is nil unless the person
+// is soft deleted
 type Person struct {
-	PersonID    uuid.UUID `db:"person_id"`
-	Name        string    `db:"name"`
-	Surname     string    `db:"surname"`
-	Patronymic  string    `db:"patronymic"`
-	Age         int       `db:"age"`
-	Sex         string    `db:"sex"`
-	Nationality string    `db:"nationality"`
+	PersonID    uuid.UUID  `db:"person_id"   json:"person_id"`
+	Name        string     `db:"name"        json:"name"`
+	Surname     string     `db:"surname"     json:"surname"`
+	Patronymic  string     `db:"patronymic"  json:"patronymic"`
+	Age         int        `db:"age"         json:"age"`
+	Sex         string     `db:"sex"         json:"sex"`
+	Nationality string     `db:"nationality" json:"nationality"`
+	DeletedAt   *time.Time `db:"deleted_at"  json:"deleted_at"`
 }
 
 // convert Person to domain.Person
@@ -38,6 +43,7 @@ func ToConcrete(person domain.Person) Person {
 		Age:         person.Age,
 		Sex:         string(person.Sex),
 		Nationality: string(person.Nationality),
+		DeletedAt:   nil,
 	}
 }
 
@@ -45,7 +51,8 @@ type PersonPage struct {
 	People        []Person `db:"people"`
 	CurrentOffset int      `db:"current_offset"`
 	CurrentLimit  int      `db:"current_limit"`
-	Total         int      `db:"total"`
+	// null if the total was not counted
+	Total *int `db:"total"`
 }
 
 // convert PersonPage to domain.Page[domain.Person]
@@ -54,11 +61,104 @@ func (p PersonPage) ToAbstract() domain.Page[domain.Person] {
 		Items:         make([]domain.Person, len(p.People)),
 		CurrentOffset: p.CurrentOffset,
 		CurrentLimit:  p.CurrentLimit,
-		TotalItems:    p.Total,
+		TotalItems:    0,
+	}
+	if p.Total != nil {
+		page.TotalItems = *p.Total
 	}
+
 	for i, person := range p.People {
 		page.Items[i] = person.ToAbstract()
 	}
 
 	return page
 }
+
+// people.people_history row
+type PersonChange struct {
+	HistoryID int64     `db:"history_id"`
+	PersonID  uuid.UUID `db:"person_id"`
+	Operation string    `db:"operation"`
+	OldValue  *Person   `db:"old_value"`
+	NewValue  *Person   `db:"new_value"`
+	Actor     *string   `db:"actor"`
+	RequestID *string   `db:"request_id"`
+	ChangedAt time.Time `db:"changed_at"`
+}
+
+// convert PersonChange to domain.PersonChange
+func (c PersonChange) ToAbstract() domain.PersonChange {
+	change := domain.PersonChange{
+		ChangedAt: c.ChangedAt,
+		Old:       nil,
+		New:       nil,
+		Operation: domain.ChangeOperation(c.Operation),
+		Actor:     "",
+		RequestID: "",
+		ID:        c.HistoryID,
+		PersonID:  c.PersonID,
+	}
+
+	if c.OldValue != nil {
+		old := c.OldValue.ToAbstract()
+		change.Old = &old
+	}
+
+	if c.NewValue != nil {
+		value := c.NewValue.ToAbstract()
+		change.New = &value
+	}
+
+	if c.Actor != nil {
+		change.Actor = *c.Actor
+	}
+
+	if c.RequestID != nil {
+		change.RequestID = *c.RequestID
+	}
+
+	return change
+}
+
+type PersonHistoryPage struct {
+	Changes       []PersonChange `db:"changes"`
+	CurrentOffset int            `db:"current_offset"`
+	CurrentLimit  int            `db:"current_limit"`
+	Total         int            `db:"total"`
+}
+
+// convert PersonHistoryPage to domain.Page[domain.PersonChange]
+func (p PersonHistoryPage) ToAbstract() domain.Page[domain.PersonChange] {
+	page := domain.Page[domain.PersonChange]{
+		Items:         make([]domain.PersonChange, len(p.Changes)),
+		CurrentOffset: p.CurrentOffset,
+		CurrentLimit:  p.CurrentLimit,
+		TotalItems:    p.Total,
+	}
+	for i, change := range p.Changes {
+		page.Items[i] = change.ToAbstract()
+	}
+
+	return page
+}
+
+// JSON Patch operation as expected by people.patch_person
+type PatchOperation struct {
+	Value any    `json:"value,omitempty"`
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+}
+
+// convert domain.PatchOperation slice to the people.patch_person argument
+func ToPatch(operations []domain.PatchOperation) []PatchOperation {
+	patch := make([]PatchOperation, len(operations))
+	for i, operation := range operations {
+		patch[i] = PatchOperation{
+			Value: operation.Value,
+			Op:    string(operation.Op),
+			Path:  "/" + string(operation.Field),
+		}
+	}
+
+	return patch
+}
@@ -0,0 +1,72 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Hofsiedge/person-api/internal/repo"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// SchemaVersion is the version of the latest migration the repo expects
+const SchemaVersion = 21
+
+// ErrSchema means the database schema does not match the repo
+var ErrSchema = fmt.Errorf("%w: unexpected schema", repo.ErrRepo)
+
+// Ping checks that the database is reachable
+func (p *People) Ping(ctx context.Context) error {
+	if err := p.db.Ping(ctx); err != nil {
+		return fmt.Errorf("%w: %w", repo.ErrConnect, err)
+	}
+
+	return nil
+}
+
+// CheckSchema checks that the migrations (golang-migrate's
+// public.schema_migrations) are applied up to SchemaVersion and are not
+// dirty. Newer versions are accepted, so replicas of the previous release
+// stay ready while a new one is deployed.
+func (p *People) CheckSchema(ctx context.Context) error {
+	var (
+		version int64
+		dirty   bool
+	)
+
+	row := p.db.QueryRow(ctx, `select version, dirty from public.schema_migrations`)
+	if err := row.Scan(&version, &dirty); err != nil {
+		return fmt.Errorf("%w: could not read the migration version: %w", ErrSchema, err)
+	}
+
+	return checkVersion(version, dirty)
+}
+
+// CheckTypes checks that the custom types were registered for connections
+// of the pool (see New). Acquiring a new connection runs AfterConnect.
+// Always succeeds if the repo is not backed by a pgxpool.Pool.
+func (p *People) CheckTypes(ctx context.Context) error {
+	pool, ok := p.db.(*pgxpool.Pool)
+	if !ok {
+		return nil
+	}
+
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: %w", repo.ErrConnect, err)
+	}
+	defer conn.Release()
+
+	var missing []string
+
+	for _, typeName := range customTypes {
+		if _, found := conn.Conn().TypeMap().TypeForName(typeName); !found {
+			missing = append(missing, typeName)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: types are not registered: %v", ErrSchema, missing)
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package postgres
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Hofsiedge/person-api/internal/domain"
+	"github.com/Hofsiedge/person-api/internal/repo"
+	"github.com/jackc/pgx/v5"
+)
+
+// IdempotencyKeys stores responses of requests with Idempotency-Key
+// in people.idempotency_keys
+type IdempotencyKeys struct {
+	db PgxPoolInterface
+}
+
+// ensure that IdempotencyKeys implements repo.IdempotencyRepo
+var _ repo.IdempotencyRepo = &IdempotencyKeys{nil}
+
+// IdempotencyKeys returns IdempotencyKeys sharing the connection pool
+func (p *People) IdempotencyKeys() *IdempotencyKeys {
+	return &IdempotencyKeys{p.db}
+}
+
+// people.reserve_idempotency_key result
+type reservation struct {
+	ContentType *string `db:"content_type"`
+	Status      *int    `db:"status"`
+	Fingerprint []byte  `db:"fingerprint"`
+	Body        []byte  `db:"body"`
+	Reserved    bool    `db:"reserved"`
+}
+
+// Reserve implements repo.IdempotencyRepo.
+func (k *IdempotencyKeys) Reserve(
+	ctx context.Context, key string, fingerprint []byte, ttl time.Duration,
+) (*domain.StoredResponse, error) {
+	rows, err := conn(ctx, k.db).Query(ctx, `select * from people.reserve_idempotency_key($1, $2, $3)`,
+		key, fingerprint, ttl)
+	if err != nil {
+		return nil, wrapPostgresError(err)
+	}
+
+	result, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[reservation])
+	if err != nil {
+		return nil, wrapPostgresError(err)
+	}
+
+	switch {
+	case result.Reserved:
+		return nil, nil //nolint:nilnil
+	case !bytes.Equal(result.Fingerprint, fingerprint):
+		return nil, fmt.Errorf("%w: %q", repo.ErrKeyReused, key)
+	case result.Status == nil:
+		return nil, fmt.Errorf("%w: %q", repo.ErrInProgress, key)
+	}
+
+	response := domain.StoredResponse{
+		ContentType: "",
+		Body:        result.Body,
+		Status:      *result.Status,
+	}
+	if result.ContentType != nil {
+		response.ContentType = *result.ContentType
+	}
+
+	return &response, nil
+}
+
+// Save implements repo.IdempotencyRepo.
+func (k *IdempotencyKeys) Save(ctx context.Context, key string, response domain.StoredResponse) error {
+	_, err := conn(ctx, k.db).Exec(ctx, `select people.save_idempotent_response($1, $2, $3, $4)`,
+		key, response.Status, response.ContentType, response.Body)
+	if err != nil {
+		return wrapPostgresError(err)
+	}
+
+	return nil
+}
+
+// Release implements repo.IdempotencyRepo.
+func (k *IdempotencyKeys) Release(ctx context.Context, key string) error {
+	_, err := conn(ctx, k.db).Exec(ctx, `select people.release_idempotency_key($1)`, key)
+	if err != nil {
+		return wrapPostgresError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,169 @@
+package postgres
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/Hofsiedge/person-api/internal/config"
+	"github.com/Hofsiedge/person-api/internal/repo"
+	"github.com/golang-migrate/migrate/v4"
+	pgxmigrate "github.com/golang-migrate/migrate/v4/database/pgx/v5"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// Migrations are the golang-migrate migrations of the schema, including
+// the pgTAP test functions (created only in the test environment)
+//
+//go:embed migrations/*.sql
+var Migrations embed.FS
+
+// MigrationStatus is the state of the schema
+type MigrationStatus struct {
+	// Version is the applied migration, 0 if there are none
+	Version int64
+	// Dirty means the last migration failed and has to be fixed manually
+	Dirty bool
+	// Expected is SchemaVersion
+	Expected int64
+}
+
+// checkVersion reports whether the schema of the version is usable by
+// the repo: the migrations are applied up to SchemaVersion and are not
+// dirty. Newer versions are accepted, so replicas of the previous release
+// keep working while a new one is deployed.
+func checkVersion(version int64, dirty bool) error {
+	switch {
+	case dirty:
+		return fmt.Errorf("%w: migration %d failed (dirty)", ErrSchema, version)
+	case version < SchemaVersion:
+		return fmt.Errorf("%w: version %d, expected %d", ErrSchema, version, SchemaVersion)
+	}
+
+	return nil
+}
+
+// Check checks the version of the schema, see CheckSchema
+func (s MigrationStatus) Check() error {
+	return checkVersion(s.Version, s.Dirty)
+}
+
+// migrateLogger logs progress of golang-migrate
+type migrateLogger struct {
+	logger *slog.Logger
+}
+
+func (l migrateLogger) Printf(format string, v ...any) {
+	l.logger.Info(strings.TrimSpace(fmt.Sprintf(format, v...)))
+}
+
+func (l migrateLogger) Verbose() bool {
+	return false
+}
+
+// Migrator applies Migrations. Changes of the schema are guarded by
+// a Postgres advisory lock (the same one the migrate CLI takes), so
+// instances starting together apply them once.
+type Migrator struct {
+	migrate *migrate.Migrate
+}
+
+// NewMigrator connects to the database of cfg
+func NewMigrator(cfg config.PostgresConfig, logger *slog.Logger) (*Migrator, error) {
+	if cfg.ConnString == "" {
+		return nil, errNoConnString
+	}
+
+	connConfig, err := pgx.ParseConfig(cfg.ConnString)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", repo.ErrConnect, err)
+	}
+
+	source, err := iofs.New(Migrations, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("could not read migrations: %w", err)
+	}
+
+	db := stdlib.OpenDB(*connConfig)
+
+	// the table CheckSchema reads
+	//nolint:exhaustruct
+	driver, err := pgxmigrate.WithInstance(db, &pgxmigrate.Config{
+		MigrationsTable: "schema_migrations",
+		SchemaName:      "public",
+	})
+	if err != nil {
+		db.Close()
+
+		return nil, fmt.Errorf("%w: %w", repo.ErrConnect, err)
+	}
+
+	instance, err := migrate.NewWithInstance("iofs", source, "postgres", driver)
+	if err != nil {
+		driver.Close()
+
+		return nil, fmt.Errorf("could not prepare migrations: %w", err)
+	}
+
+	instance.Log = migrateLogger{logger: logger}
+	instance.LockTimeout = cfg.MigrateLockTimeout
+
+	return &Migrator{migrate: instance}, nil
+}
+
+// Up applies all new migrations
+func (m *Migrator) Up() error {
+	if err := m.migrate.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("could not apply migrations: %w", err)
+	}
+
+	return nil
+}
+
+// Down reverts the last steps migrations
+func (m *Migrator) Down(steps int) error {
+	if steps <= 0 {
+		return fmt.Errorf("%w: steps must be positive, got %d", repo.ErrArgument, steps)
+	}
+
+	if err := m.migrate.Steps(-steps); err != nil {
+		return fmt.Errorf("could not revert migrations: %w", err)
+	}
+
+	return nil
+}
+
+// Force sets the version without running migrations and clears the dirty
+// flag (after a failed migration was fixed manually)
+func (m *Migrator) Force(version int) error {
+	if err := m.migrate.Force(version); err != nil {
+		return fmt.Errorf("could not force the version: %w", err)
+	}
+
+	return nil
+}
+
+// Status returns the version of the schema
+func (m *Migrator) Status() (MigrationStatus, error) {
+	status := MigrationStatus{Version: 0, Dirty: false, Expected: SchemaVersion}
+
+	version, dirty, err := m.migrate.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return status, fmt.Errorf("could not read the migration version: %w", err)
+	}
+
+	status.Version, status.Dirty = int64(version), dirty
+
+	return status, nil
+}
+
+// Close closes the connection
+func (m *Migrator) Close() error {
+	sourceErr, dbErr := m.migrate.Close()
+
+	return errors.Join(sourceErr, dbErr)
+}
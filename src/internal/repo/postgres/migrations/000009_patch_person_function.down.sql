@@ -0,0 +1,14 @@
+begin;
+
+drop function people.patch_person(uuid, jsonb);
+
+-- testing functions
+do $do$
+begin
+    if utils.in_test_environment() then
+        drop function test.test_000009_patch_person_function();
+    end if;
+end
+$do$;
+
+commit;
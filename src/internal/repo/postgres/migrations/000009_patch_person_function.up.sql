@@ -0,0 +1,207 @@
+begin;
+
+-- applies a JSON Patch (RFC 6902) subset to a person atomically.
+-- supported operations:
+--   test    - compares the field with the value, fails with assert_failure
+--   replace - sets the field to the value
+--   remove  - resets the field (only patronymic can be reset - it becomes empty)
+create function people.patch_person(id uuid, operations jsonb)
+returns void
+as $func$
+declare
+    person_    people.people;
+    operation_ jsonb;
+    field_     text;
+begin
+    if id is null then
+        raise exception 'invalid person_id: NULL'
+            using errcode = 'invalid_parameter_value';
+    end if;
+
+    if jsonb_typeof(operations) is distinct from 'array'
+        or jsonb_array_length(operations) = 0 then
+        raise exception 'invalid arguments: nothing to update'
+            using errcode = 'invalid_parameter_value';
+    end if;
+
+    -- lock the row so that test operations are checked against
+    -- the same state the replacements are applied to
+    select p.* into person_
+    from
+        people.people p
+    where
+        p.person_id = id
+    for update;
+
+    if not found then
+        raise exception 'person with id % not found', id
+            using errcode = 'no_data_found';
+    end if;
+
+    for operation_ in select jsonb_array_elements(operations) loop
+        field_ := substr(operation_ ->> 'path', 2);
+
+        if (operation_ ->> 'path') is null
+            or left(operation_ ->> 'path', 1) <> '/'
+            or field_ not in (
+                'name', 'surname', 'patronymic', 'age', 'sex', 'nationality'
+            ) then
+            raise exception 'invalid path: %', operation_ ->> 'path'
+                using errcode = 'invalid_parameter_value';
+        end if;
+
+        case operation_ ->> 'op'
+            when 'test' then
+                if (to_jsonb(person_) -> field_) is distinct from (operation_ -> 'value') then
+                    raise exception 'test failed: % is not %',
+                        operation_ ->> 'path', operation_ -> 'value'
+                        using errcode = 'assert_failure';
+                end if;
+
+            when 'replace' then
+                if not (operation_ ? 'value') or jsonb_typeof(operation_ -> 'value') = 'null' then
+                    raise exception 'invalid value for %', operation_ ->> 'path'
+                        using errcode = 'invalid_parameter_value';
+                end if;
+
+                person_ := jsonb_populate_record(
+                    person_, jsonb_build_object(field_, operation_ -> 'value'));
+
+            when 'remove' then
+                if field_ <> 'patronymic' then
+                    raise exception 'can not remove %', operation_ ->> 'path'
+                        using errcode = 'invalid_parameter_value';
+                end if;
+
+                person_.patronymic := '';
+
+            else
+                raise exception 'invalid operation: %', operation_ ->> 'op'
+                    using errcode = 'invalid_parameter_value';
+        end case;
+    end loop;
+
+    update people.people p
+    set
+        name        = person_.name,
+        surname     = person_.surname,
+        patronymic  = person_.patronymic,
+        age         = person_.age,
+        sex         = person_.sex,
+        nationality = person_.nationality
+    where
+        p.person_id = id;
+end;
+$func$
+language plpgsql;
+
+
+-- testing functions
+do $do$
+begin
+    if not utils.in_test_environment() then
+        return;
+    end if;
+
+    create function test.test_000009_patch_person_function()
+        returns setof text as $test$
+        declare
+            person people.people;
+        begin
+            return next has_function('people', 'patch_person', array['uuid', 'jsonb']);
+
+            person := (gen_random_uuid(), 'Name', 'Surname', 'Patronymic', 42, 'male', 'AA');
+
+            insert into people.people select person.*;
+
+            return next throws_like(
+                $$select people.patch_person(gen_random_uuid(),
+                    '[{"op": "remove", "path": "/patronymic"}]')$$,
+                'person with id % not found',
+                'throws on not found'
+            );
+
+            return next throws_like(
+                $$select people.patch_person(null,
+                    '[{"op": "remove", "path": "/patronymic"}]')$$,
+                'invalid person_id: NULL',
+                'throws on null id'
+            );
+
+            return next throws_like(
+                format($$select people.patch_person(%L, '[]')$$, person.person_id),
+                'invalid arguments: nothing to update',
+                'throws on empty patch'
+            );
+
+            return next throws_like(
+                format(
+                    $$select people.patch_person(%L,
+                        '[{"op": "replace", "path": "/person_id", "value": "x"}]')$$,
+                    person.person_id
+                ),
+                'invalid path: %',
+                'throws on unknown field'
+            );
+
+            return next throws_like(
+                format(
+                    $$select people.patch_person(%L,
+                        '[{"op": "remove", "path": "/name"}]')$$,
+                    person.person_id
+                ),
+                'can not remove %',
+                'throws on removing a required field'
+            );
+
+            return next throws_ok(
+                format(
+                    $$select people.patch_person(%L, '[
+                        {"op": "replace", "path": "/age", "value": 50},
+                        {"op": "test", "path": "/name", "value": "Other"}
+                    ]')$$,
+                    person.person_id
+                ),
+                'P0004',
+                null,
+                'throws assert_failure on failed test'
+            );
+
+            return next row_eq(
+                format(
+                    $$select * from people.people where person_id = %L$$,
+                    person.person_id
+                ),
+                person,
+                'failed patch is not applied'
+            );
+
+            return next lives_ok(
+                format(
+                    $$select people.patch_person(%L, '[
+                        {"op": "test", "path": "/age", "value": 42},
+                        {"op": "test", "path": "/nationality", "value": "AA"},
+                        {"op": "replace", "path": "/age", "value": 50},
+                        {"op": "replace", "path": "/sex", "value": "female"},
+                        {"op": "remove", "path": "/patronymic"},
+                        {"op": "test", "path": "/patronymic", "value": ""}
+                    ]')$$,
+                    person.person_id
+                ),
+                'can apply a patch'
+            );
+
+            return next row_eq(
+                format(
+                    $$select * from people.people where person_id = %L$$,
+                    person.person_id
+                ),
+                (person.person_id, 'Name', 'Surname', '', 50, 'female', 'AA')::people.people,
+                'patch is applied'
+            );
+        end;
+    $test$
+    language plpgsql;
+end
+$do$;
+commit;
@@ -0,0 +1,295 @@
+begin;
+
+-- soft deleted people are deleted permanently
+delete from people.people p
+using people.deletions d
+where p.person_id = d.person_id;
+
+drop function people.list_people(text, text, text, int, int, people.sex, char(2), real, int, int, boolean);
+
+create function people.list_people(
+    name_        text       default null,
+    surname_     text       default null,
+    patronymic_  text       default null,
+    age_min      int        default null,
+    age_max      int        default null,
+    sex_         people.sex default null,
+    nationality_ char(2)    default null,
+    threshold    real       default 0,
+    offset_      int        default 0,
+    limit_       int        default null
+)
+returns people.people_page
+as $func$
+declare
+    count_ int;
+    page   people.people_page;
+begin
+    if threshold is null then
+        threshold := 0;
+    end if;
+
+    with matched_people as (
+        select 
+            p.person_id,
+            p.name,
+            p.surname,
+            p.patronymic,
+            p.age,
+            p.sex,
+            p.nationality,
+            (
+                (case 
+                    when name_ is null then 0.0
+                    else word_similarity(name_, p.name)       
+                end)
+                +
+                (case
+                    when surname_ is null then 0.0
+                    else word_similarity(surname_, p.surname)
+                end)
+                +
+                (case
+                    when patronymic_ is null
+                        then 0
+                    when (patronymic_ = '' or p.patronymic = '')
+                        then (patronymic_ = p.patronymic)::int
+                    else word_similarity(patronymic_, p.patronymic)
+                end)
+            ) / 3.0 as total_similarity
+        from people.people p
+        where
+            ((age_min      is null) or (age_min      <= p.age))         and
+            ((age_max      is null) or (age_max      >= p.age))         and
+            ((nationality_ is null) or (nationality_ =  p.nationality)) and
+            ((sex_         is null) or (sex_         =  p.sex))         and
+            ((patronymic_  is null) or ((patronymic_ = '') = (p.patronymic = '')))
+    )
+    select into page.people, page.total
+        array(
+            select (
+                m.person_id, m.name, m.surname,
+                m.patronymic, m.age, m.sex, m.nationality
+            )::people.people
+            from matched_people m
+            where m.total_similarity >= threshold
+            order by 
+                m.total_similarity desc,
+                m.surname          asc,
+                m.name             asc,
+                m.patronymic       asc,
+                m.age              asc,
+                m.sex              asc,
+                m.nationality      asc
+            offset offset_
+            limit limit_
+        ),
+        count(*)
+    from matched_people;
+
+    page.current_offset := offset_;
+    page.current_limit := limit_;
+
+    return page;
+end;
+$func$
+language plpgsql;
+
+create or replace function people.get_person(id uuid)
+returns people.people
+as $func$
+declare
+    result_ people.people;
+begin
+    if id is null then
+        raise exception 'invalid person_id: %', id
+            using errcode = 'invalid_parameter_value';
+    end if;
+
+    select p.* into result_
+    from
+        people.people p
+    where
+        p.person_id = id;
+
+    if not found then
+        raise exception 'person not found: %', id
+            using errcode = 'no_data_found';
+    end if;
+    return result_;
+end;
+$func$
+language plpgsql;
+
+create or replace function people.delete_person(id uuid)
+returns void
+as $func$
+declare
+    count_ int;
+begin
+    if id is null then
+        raise exception 'invalid person_id'
+            using errcode = 'invalid_parameter_value';
+    end if;
+
+    delete from people.people where person_id = id;
+
+    get diagnostics count_ = ROW_COUNT;
+    if count_ = 0 then
+        raise exception 'person with id % not found', id
+            using errcode = 'no_data_found';
+    end if;
+end;
+$func$
+language plpgsql;
+
+create or replace function people.update_person(
+    id           uuid,
+    name_        text       default null,
+    surname_     text       default null, 
+    patronymic_  text       default null, 
+    age_         int        default null, 
+    sex_         people.sex default null, 
+    nationality_ char(2)    default null
+)
+returns void
+as $func$
+declare
+    count_ int;
+begin
+    if id is null then
+        raise exception 'invalid person_id: NULL'
+            using errcode = 'invalid_parameter_value';
+    end if;
+
+    if (name_, surname_, patronymic_, age_, sex_, nationality_) = (null, null, null, null, null, null) then
+        raise exception 'invalid arguments: nothing to update'
+            using errcode = 'invalid_parameter_value';
+    end if;
+
+    update people.people p
+    set
+        name        = coalesce(name_,        old.name),
+        surname     = coalesce(surname_,     old.surname),
+        patronymic  = coalesce(patronymic_,  old.patronymic),
+        age         = coalesce(age_,         old.age),
+        sex         = coalesce(sex_,         old.sex),
+        nationality = coalesce(nationality_, old.nationality)
+    from (select * from people.people where person_id = id) old
+    where
+        p.person_id = id;
+
+    get diagnostics count_ = row_count;
+    if count_ = 0 then
+        raise exception 'person with id % not found', id
+            using errcode = 'no_data_found';
+    end if;
+end;
+$func$
+language plpgsql;
+
+create or replace function people.patch_person(id uuid, operations jsonb)
+returns void
+as $func$
+declare
+    person_    people.people;
+    operation_ jsonb;
+    field_     text;
+begin
+    if id is null then
+        raise exception 'invalid person_id: NULL'
+            using errcode = 'invalid_parameter_value';
+    end if;
+
+    if jsonb_typeof(operations) is distinct from 'array'
+        or jsonb_array_length(operations) = 0 then
+        raise exception 'invalid arguments: nothing to update'
+            using errcode = 'invalid_parameter_value';
+    end if;
+
+    -- lock the row so that test operations are checked against
+    -- the same state the replacements are applied to
+    select p.* into person_
+    from
+        people.people p
+    where
+        p.person_id = id
+    for update;
+
+    if not found then
+        raise exception 'person with id % not found', id
+            using errcode = 'no_data_found';
+    end if;
+
+    for operation_ in select jsonb_array_elements(operations) loop
+        field_ := substr(operation_ ->> 'path', 2);
+
+        if (operation_ ->> 'path') is null
+            or left(operation_ ->> 'path', 1) <> '/'
+            or field_ not in (
+                'name', 'surname', 'patronymic', 'age', 'sex', 'nationality'
+            ) then
+            raise exception 'invalid path: %', operation_ ->> 'path'
+                using errcode = 'invalid_parameter_value';
+        end if;
+
+        case operation_ ->> 'op'
+            when 'test' then
+                if (to_jsonb(person_) -> field_) is distinct from (operation_ -> 'value') then
+                    raise exception 'test failed: % is not %',
+                        operation_ ->> 'path', operation_ -> 'value'
+                        using errcode = 'assert_failure';
+                end if;
+
+            when 'replace' then
+                if not (operation_ ? 'value') or jsonb_typeof(operation_ -> 'value') = 'null' then
+                    raise exception 'invalid value for %', operation_ ->> 'path'
+                        using errcode = 'invalid_parameter_value';
+                end if;
+
+                person_ := jsonb_populate_record(
+                    person_, jsonb_build_object(field_, operation_ -> 'value'));
+
+            when 'remove' then
+                if field_ <> 'patronymic' then
+                    raise exception 'can not remove %', operation_ ->> 'path'
+                        using errcode = 'invalid_parameter_value';
+                end if;
+
+                person_.patronymic := '';
+
+            else
+                raise exception 'invalid operation: %', operation_ ->> 'op'
+                    using errcode = 'invalid_parameter_value';
+        end case;
+    end loop;
+
+    update people.people p
+    set
+        name        = person_.name,
+        surname     = person_.surname,
+        patronymic  = person_.patronymic,
+        age         = person_.age,
+        sex         = person_.sex,
+        nationality = person_.nationality
+    where
+        p.person_id = id;
+end;
+$func$
+language plpgsql;
+
+drop function people.purge_people(interval);
+drop function people.restore_person(uuid);
+drop function people.is_deleted(uuid);
+drop table people.deletions;
+
+-- testing functions
+do $do$
+begin
+    if utils.in_test_environment() then
+        drop function test.test_000010_soft_delete();
+    end if;
+end
+$do$;
+
+commit;
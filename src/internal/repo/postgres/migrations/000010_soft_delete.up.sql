@@ -0,0 +1,513 @@
+begin;
+
+-- soft deleted people. kept out of people.people so that its row type
+-- (returned by the functions and used by clients) does not change
+create table people.deletions (
+    person_id  uuid        primary key references people.people on delete cascade,
+    deleted_at timestamptz not null default now()
+);
+
+create index deletions_by_deleted_at on people.deletions (deleted_at);
+
+create function people.is_deleted(id uuid)
+returns boolean
+as $sql$
+    select exists (select 1 from people.deletions d where d.person_id = id);
+$sql$
+language sql stable;
+
+-- deleted people are not found
+create or replace function people.get_person(id uuid)
+returns people.people
+as $func$
+declare
+    result_ people.people;
+begin
+    if id is null then
+        raise exception 'invalid person_id: %', id
+            using errcode = 'invalid_parameter_value';
+    end if;
+
+    select p.* into result_
+    from
+        people.people p
+    where
+        p.person_id = id and not people.is_deleted(id);
+
+    if not found then
+        raise exception 'person not found: %', id
+            using errcode = 'no_data_found';
+    end if;
+    return result_;
+end;
+$func$
+language plpgsql;
+
+-- marks the person as deleted. the person can be restored until purged
+create or replace function people.delete_person(id uuid)
+returns void
+as $func$
+declare
+    count_ int;
+begin
+    if id is null then
+        raise exception 'invalid person_id'
+            using errcode = 'invalid_parameter_value';
+    end if;
+
+    insert into people.deletions (person_id)
+    select p.person_id
+    from
+        people.people p
+    where
+        p.person_id = id
+    on conflict do nothing;
+
+    get diagnostics count_ = row_count;
+    if count_ = 0 then
+        raise exception 'person with id % not found', id
+            using errcode = 'no_data_found';
+    end if;
+end;
+$func$
+language plpgsql;
+
+create function people.restore_person(id uuid)
+returns void
+as $func$
+declare
+    count_ int;
+begin
+    if id is null then
+        raise exception 'invalid person_id'
+            using errcode = 'invalid_parameter_value';
+    end if;
+
+    delete from people.deletions d where d.person_id = id;
+
+    get diagnostics count_ = row_count;
+    if count_ > 0 then
+        return;
+    end if;
+
+    if exists (select 1 from people.people p where p.person_id = id) then
+        raise exception 'person with id % is not deleted', id
+            using errcode = 'object_not_in_prerequisite_state';
+    end if;
+
+    raise exception 'person with id % not found', id
+        using errcode = 'no_data_found';
+end;
+$func$
+language plpgsql;
+
+-- permanently deletes people that were soft deleted more than `retention` ago.
+-- returns the number of purged people
+create function people.purge_people(retention interval)
+returns int
+as $func$
+declare
+    count_ int;
+begin
+    if retention is null or retention < interval '0' then
+        raise exception 'invalid retention: %', retention
+            using errcode = 'invalid_parameter_value';
+    end if;
+
+    delete from people.people p
+    using people.deletions d
+    where
+        p.person_id = d.person_id and d.deleted_at < now() - retention;
+
+    get diagnostics count_ = row_count;
+    return count_;
+end;
+$func$
+language plpgsql;
+
+-- deleted people can not be updated
+create or replace function people.update_person(
+    id           uuid,
+    name_        text       default null,
+    surname_     text       default null,
+    patronymic_  text       default null,
+    age_         int        default null,
+    sex_         people.sex default null,
+    nationality_ char(2)    default null
+)
+returns void
+as $func$
+declare
+    count_ int;
+begin
+    if id is null then
+        raise exception 'invalid person_id: NULL'
+            using errcode = 'invalid_parameter_value';
+    end if;
+
+    if (name_, surname_, patronymic_, age_, sex_, nationality_) = (null, null, null, null, null, null) then
+        raise exception 'invalid arguments: nothing to update'
+            using errcode = 'invalid_parameter_value';
+    end if;
+
+    update people.people p
+    set
+        name        = coalesce(name_,        old.name),
+        surname     = coalesce(surname_,     old.surname),
+        patronymic  = coalesce(patronymic_,  old.patronymic),
+        age         = coalesce(age_,         old.age),
+        sex         = coalesce(sex_,         old.sex),
+        nationality = coalesce(nationality_, old.nationality)
+    from (select * from people.people where person_id = id) old
+    where
+        p.person_id = id and not people.is_deleted(id);
+
+    get diagnostics count_ = row_count;
+    if count_ = 0 then
+        raise exception 'person with id % not found', id
+            using errcode = 'no_data_found';
+    end if;
+end;
+$func$
+language plpgsql;
+
+-- deleted people can not be patched
+create or replace function people.patch_person(id uuid, operations jsonb)
+returns void
+as $func$
+declare
+    person_    people.people;
+    operation_ jsonb;
+    field_     text;
+begin
+    if id is null then
+        raise exception 'invalid person_id: NULL'
+            using errcode = 'invalid_parameter_value';
+    end if;
+
+    if jsonb_typeof(operations) is distinct from 'array'
+        or jsonb_array_length(operations) = 0 then
+        raise exception 'invalid arguments: nothing to update'
+            using errcode = 'invalid_parameter_value';
+    end if;
+
+    -- lock the row so that test operations are checked against
+    -- the same state the replacements are applied to
+    select p.* into person_
+    from
+        people.people p
+    where
+        p.person_id = id and not people.is_deleted(id)
+    for update;
+
+    if not found then
+        raise exception 'person with id % not found', id
+            using errcode = 'no_data_found';
+    end if;
+
+    for operation_ in select jsonb_array_elements(operations) loop
+        field_ := substr(operation_ ->> 'path', 2);
+
+        if (operation_ ->> 'path') is null
+            or left(operation_ ->> 'path', 1) <> '/'
+            or field_ not in (
+                'name', 'surname', 'patronymic', 'age', 'sex', 'nationality'
+            ) then
+            raise exception 'invalid path: %', operation_ ->> 'path'
+                using errcode = 'invalid_parameter_value';
+        end if;
+
+        case operation_ ->> 'op'
+            when 'test' then
+                if (to_jsonb(person_) -> field_) is distinct from (operation_ -> 'value') then
+                    raise exception 'test failed: % is not %',
+                        operation_ ->> 'path', operation_ -> 'value'
+                        using errcode = 'assert_failure';
+                end if;
+
+            when 'replace' then
+                if not (operation_ ? 'value') or jsonb_typeof(operation_ -> 'value') = 'null' then
+                    raise exception 'invalid value for %', operation_ ->> 'path'
+                        using errcode = 'invalid_parameter_value';
+                end if;
+
+                person_ := jsonb_populate_record(
+                    person_, jsonb_build_object(field_, operation_ -> 'value'));
+
+            when 'remove' then
+                if field_ <> 'patronymic' then
+                    raise exception 'can not remove %', operation_ ->> 'path'
+                        using errcode = 'invalid_parameter_value';
+                end if;
+
+                person_.patronymic := '';
+
+            else
+                raise exception 'invalid operation: %', operation_ ->> 'op'
+                    using errcode = 'invalid_parameter_value';
+        end case;
+    end loop;
+
+    update people.people p
+    set
+        name        = person_.name,
+        surname     = person_.surname,
+        patronymic  = person_.patronymic,
+        age         = person_.age,
+        sex         = person_.sex,
+        nationality = person_.nationality
+    where
+        p.person_id = id;
+end;
+$func$
+language plpgsql;
+
+drop function people.list_people(text, text, text, int, int, people.sex, char(2), real, int, int);
+
+-- new: include_deleted (deleted people are excluded by default)
+create function people.list_people(
+    name_           text       default null,
+    surname_        text       default null,
+    patronymic_     text       default null,
+    age_min         int        default null,
+    age_max         int        default null,
+    sex_            people.sex default null,
+    nationality_    char(2)    default null,
+    threshold       real       default 0,
+    offset_         int        default 0,
+    limit_          int        default null,
+    include_deleted boolean    default false
+)
+returns people.people_page
+as $func$
+declare
+    count_ int;
+    page   people.people_page;
+begin
+    if threshold is null then
+        threshold := 0;
+    end if;
+
+    with matched_people as (
+        select 
+            p.person_id,
+            p.name,
+            p.surname,
+            p.patronymic,
+            p.age,
+            p.sex,
+            p.nationality,
+            (
+                (case 
+                    when name_ is null then 0.0
+                    else word_similarity(name_, p.name)       
+                end)
+                +
+                (case
+                    when surname_ is null then 0.0
+                    else word_similarity(surname_, p.surname)
+                end)
+                +
+                (case
+                    when patronymic_ is null
+                        then 0
+                    when (patronymic_ = '' or p.patronymic = '')
+                        then (patronymic_ = p.patronymic)::int
+                    else word_similarity(patronymic_, p.patronymic)
+                end)
+            ) / 3.0 as total_similarity
+        from people.people p
+        where
+            ((age_min      is null) or (age_min      <= p.age))         and
+            ((age_max      is null) or (age_max      >= p.age))         and
+            ((nationality_ is null) or (nationality_ =  p.nationality)) and
+            ((sex_         is null) or (sex_         =  p.sex))         and
+            ((patronymic_  is null) or ((patronymic_ = '') = (p.patronymic = ''))) and
+            (coalesce(include_deleted, false) or not people.is_deleted(p.person_id))
+    )
+    select into page.people, page.total
+        array(
+            select (
+                m.person_id, m.name, m.surname,
+                m.patronymic, m.age, m.sex, m.nationality
+            )::people.people
+            from matched_people m
+            where m.total_similarity >= threshold
+            order by 
+                m.total_similarity desc,
+                m.surname          asc,
+                m.name             asc,
+                m.patronymic       asc,
+                m.age              asc,
+                m.sex              asc,
+                m.nationality      asc
+            offset offset_
+            limit limit_
+        ),
+        count(*)
+    from matched_people;
+
+    page.current_offset := offset_;
+    page.current_limit := limit_;
+
+    return page;
+end;
+$func$
+language plpgsql;
+
+
+-- testing functions
+do $do$
+begin
+    if not utils.in_test_environment() then
+        return;
+    end if;
+
+    -- delete_person no longer removes the row
+    drop function test.test_000005_delete_person_function();
+
+    create function test.test_000010_soft_delete()
+        returns setof text as $test$
+        declare
+            person people.people;
+            other  people.people;
+        begin
+            return next has_table('people', 'deletions', 'has deletions table');
+            return next has_function('people', 'restore_person', array['uuid']);
+            return next has_function('people', 'purge_people', array['interval']);
+
+            person := (gen_random_uuid(), 'Name', 'Surname', 'Patronymic', 42, 'male', 'AA');
+            other  := (gen_random_uuid(), 'Other', 'Surname', '', 24, 'female', 'BB');
+
+            insert into people.people select person.*;
+            insert into people.people select other.*;
+
+            return next throws_ok(
+                $$select people.delete_person(NULL)$$,
+                'invalid person_id',
+                'delete throws on null id'
+            );
+
+            return next throws_like(
+                $$select people.delete_person(gen_random_uuid())$$,
+                'person with id % not found',
+                'delete throws on not found'
+            );
+
+            return next lives_ok(
+                format($$select people.delete_person(%L)$$, person.person_id),
+                'can delete existing person'
+            );
+
+            return next is(
+                people.is_deleted(person.person_id),
+                true,
+                'marks the person as deleted'
+            );
+
+            return next is(
+                (select count(*)::int from people.people),
+                2,
+                'keeps the row'
+            );
+
+            return next throws_like(
+                format($$select people.delete_person(%L)$$, person.person_id),
+                'person with id % not found',
+                'can not delete twice'
+            );
+
+            return next throws_like(
+                format($$select people.get_person(%L)$$, person.person_id),
+                'person not found: %',
+                'get does not find deleted people'
+            );
+
+            return next throws_like(
+                format($$select people.update_person(%L, name_ => 'New')$$, person.person_id),
+                'person with id % not found',
+                'update does not find deleted people'
+            );
+
+            return next throws_like(
+                format(
+                    $$select people.patch_person(%L,
+                        '[{"op": "remove", "path": "/patronymic"}]')$$,
+                    person.person_id
+                ),
+                'person with id % not found',
+                'patch does not find deleted people'
+            );
+
+            return next is(
+                (people.list_people()).total,
+                1,
+                'list excludes deleted people by default'
+            );
+
+            return next is(
+                (people.list_people(include_deleted => true)).total,
+                2,
+                'list includes deleted people on request'
+            );
+
+            return next throws_ok(
+                format($$select people.restore_person(%L)$$, other.person_id),
+                '55000',
+                null,
+                'restore throws on a person that is not deleted'
+            );
+
+            return next throws_like(
+                $$select people.restore_person(gen_random_uuid())$$,
+                'person with id % not found',
+                'restore throws on not found'
+            );
+
+            return next lives_ok(
+                format($$select people.restore_person(%L)$$, person.person_id),
+                'can restore a deleted person'
+            );
+
+            return next is(
+                people.get_person(person.person_id),
+                person,
+                'restored person can be found'
+            );
+
+            perform people.delete_person(person.person_id);
+            update people.deletions
+            set deleted_at = now() - interval '2 days'
+            where person_id = person.person_id;
+
+            perform people.delete_person(other.person_id);
+
+            return next throws_like(
+                $$select people.purge_people(interval '-1 day')$$,
+                'invalid retention: %',
+                'purge throws on negative retention'
+            );
+
+            return next is(
+                people.purge_people(interval '1 day'),
+                1,
+                'purges people deleted before the retention period'
+            );
+
+            return next is(
+                (select array_agg(person_id) from people.people),
+                array[other.person_id],
+                'keeps people deleted within the retention period'
+            );
+
+            return next is(
+                (select count(*)::int from people.deletions),
+                1,
+                'removes deletion records of purged people'
+            );
+        end;
+    $test$
+    language plpgsql;
+end
+$do$;
+commit;
@@ -0,0 +1,24 @@
+begin;
+
+drop function people.person_history(uuid, int, int);
+drop function people.get_person_as_of(uuid, timestamptz);
+drop trigger deletions_history on people.deletions;
+drop trigger people_history on people.people;
+drop function people.deletions_history_trigger();
+drop function people.people_history_trigger();
+drop function people.record_change(uuid, people.change_operation, jsonb, jsonb);
+drop function utils.setting(text);
+drop type people.people_history_page;
+drop table people.people_history;
+drop type people.change_operation;
+
+-- testing functions
+do $do$
+begin
+    if utils.in_test_environment() then
+        drop function test.test_000011_people_history();
+    end if;
+end
+$do$;
+
+commit;
@@ -0,0 +1,337 @@
+begin;
+
+create type people.change_operation as enum (
+    'snapshot', -- the state of the person when the history started
+    'create',
+    'update',
+    'delete',
+    'restore',
+    'purge'
+);
+
+-- changes of people.people and people.deletions.
+-- old_value and new_value are people.people rows as jsonb,
+-- new_value is null if the person is deleted or purged
+create table people.people_history (
+    history_id bigint                  generated always as identity primary key,
+    person_id  uuid                    not null,
+    operation  people.change_operation not null,
+    old_value  jsonb,
+    new_value  jsonb,
+    actor      text,
+    request_id text,
+    changed_at timestamptz             not null default now()
+);
+
+create index people_history_by_person on people.people_history (person_id, changed_at);
+
+create type people.people_history_page as (
+    changes        people.people_history[],
+    current_offset int,
+    current_limit  int,
+    total          int
+);
+
+-- value of a custom setting or null if it is not set
+create function utils.setting(name text)
+returns text
+as $sql$
+    select nullif(current_setting(name, true), '');
+$sql$
+language sql stable;
+
+-- actor and request id are set by the application for the transaction:
+--   select set_config('people.actor', 'actor', true);
+--   select set_config('people.request_id', 'request id', true);
+create function people.record_change(
+    id        uuid,
+    operation people.change_operation,
+    old_value jsonb,
+    new_value jsonb
+)
+returns void
+as $sql$
+    insert into people.people_history (
+        person_id, operation, old_value, new_value, actor, request_id
+    )
+    values (
+        id, operation, old_value, new_value,
+        utils.setting('people.actor'), utils.setting('people.request_id')
+    );
+$sql$
+language sql;
+
+create function people.people_history_trigger()
+returns trigger
+as $func$
+begin
+    case tg_op
+        when 'INSERT' then
+            perform people.record_change(new.person_id, 'create', null, to_jsonb(new));
+        when 'UPDATE' then
+            if old is not distinct from new then
+                return null;
+            end if;
+            perform people.record_change(new.person_id, 'update', to_jsonb(old), to_jsonb(new));
+        when 'DELETE' then
+            perform people.record_change(old.person_id, 'purge', to_jsonb(old), null);
+    end case;
+
+    return null;
+end;
+$func$
+language plpgsql;
+
+create function people.deletions_history_trigger()
+returns trigger
+as $func$
+declare
+    person_ people.people;
+begin
+    select p.* into person_
+    from
+        people.people p
+    where
+        p.person_id = (case tg_op when 'INSERT' then new.person_id else old.person_id end);
+
+    -- deletion records of purged people are removed by the cascade
+    if not found then
+        return null;
+    end if;
+
+    case tg_op
+        when 'INSERT' then
+            perform people.record_change(person_.person_id, 'delete', to_jsonb(person_), null);
+        when 'DELETE' then
+            perform people.record_change(person_.person_id, 'restore', null, to_jsonb(person_));
+    end case;
+
+    return null;
+end;
+$func$
+language plpgsql;
+
+-- the history starts with the current state
+insert into people.people_history (person_id, operation, old_value, new_value)
+select
+    p.person_id,
+    'snapshot',
+    null,
+    (case when people.is_deleted(p.person_id) then null else to_jsonb(p) end)
+from people.people p;
+
+create trigger people_history
+after insert or update or delete on people.people
+for each row execute function people.people_history_trigger();
+
+create trigger deletions_history
+after insert or delete on people.deletions
+for each row execute function people.deletions_history_trigger();
+
+-- the person as it was at the moment as_of
+create function people.get_person_as_of(id uuid, as_of timestamptz)
+returns people.people
+as $func$
+declare
+    value_ jsonb;
+begin
+    if id is null or as_of is null then
+        raise exception 'invalid arguments: person_id %, as_of %', id, as_of
+            using errcode = 'invalid_parameter_value';
+    end if;
+
+    select h.new_value into value_
+    from
+        people.people_history h
+    where
+        h.person_id = id and h.changed_at <= as_of
+    order by
+        h.changed_at desc,
+        h.history_id desc
+    limit 1;
+
+    if value_ is null then
+        raise exception 'person not found: % as of %', id, as_of
+            using errcode = 'no_data_found';
+    end if;
+
+    return jsonb_populate_record(null::people.people, value_);
+end;
+$func$
+language plpgsql stable;
+
+-- changes of the person, newest first
+create function people.person_history(
+    id      uuid,
+    offset_ int default 0,
+    limit_  int default null
+)
+returns people.people_history_page
+as $func$
+declare
+    page people.people_history_page;
+begin
+    if id is null then
+        raise exception 'invalid person_id: NULL'
+            using errcode = 'invalid_parameter_value';
+    end if;
+
+    select into page.changes, page.total
+        array(
+            select h
+            from people.people_history h
+            where h.person_id = id
+            order by
+                h.changed_at desc,
+                h.history_id desc
+            offset offset_
+            limit limit_
+        ),
+        count(*)
+    from people.people_history h
+    where h.person_id = id;
+
+    if page.total = 0 then
+        raise exception 'person not found: %', id
+            using errcode = 'no_data_found';
+    end if;
+
+    page.current_offset := offset_;
+    page.current_limit := limit_;
+
+    return page;
+end;
+$func$
+language plpgsql stable;
+
+
+-- testing functions
+do $do$
+begin
+    if not utils.in_test_environment() then
+        return;
+    end if;
+
+    create function test.test_000011_people_history()
+        returns setof text as $test$
+        declare
+            person  people.people;
+            updated people.people;
+            page    people.people_history_page;
+        begin
+            return next has_table('people', 'people_history', 'has people_history table');
+            return next has_function('people', 'get_person_as_of', array['uuid', 'timestamp with time zone']);
+            return next has_function('people', 'person_history', array['uuid', 'integer', 'integer']);
+
+            perform set_config('people.actor', 'tester', true);
+            perform set_config('people.request_id', 'request-1', true);
+
+            person := (gen_random_uuid(), 'Name', 'Surname', 'Patronymic', 42, 'male', 'AA');
+            insert into people.people select person.*;
+
+            return next row_eq(
+                format(
+                    $$select operation, old_value, new_value, actor, request_id
+                    from people.people_history where person_id = %L$$,
+                    person.person_id
+                ),
+                row(
+                    'create'::people.change_operation, null::jsonb, to_jsonb(person),
+                    'tester'::text, 'request-1'::text
+                ),
+                'records creation with actor and request id'
+            );
+
+            -- history entries of this transaction share now(), move them back
+            update people.people_history
+            set changed_at = changed_at - interval '1 hour'
+            where person_id = person.person_id;
+
+            perform people.update_person(person.person_id, age_ => 43);
+            updated := people.get_person(person.person_id);
+
+            return next is(
+                (select row(old_value, new_value)::text from people.people_history
+                 where person_id = person.person_id and operation = 'update'),
+                row(to_jsonb(person), to_jsonb(updated))::text,
+                'records old and new values of updates'
+            );
+
+            update people.people set age = 43 where person_id = person.person_id;
+
+            return next is(
+                (select count(*)::int from people.people_history
+                 where person_id = person.person_id and operation = 'update'),
+                1,
+                'does not record updates without changes'
+            );
+
+            return next is(
+                people.get_person_as_of(person.person_id, now() - interval '30 minutes'),
+                person,
+                'reconstructs the person as it was'
+            );
+
+            return next is(
+                people.get_person_as_of(person.person_id, now()),
+                updated,
+                'reconstructs the current state'
+            );
+
+            return next throws_like(
+                format(
+                    $$select people.get_person_as_of(%L, now() - interval '2 hours')$$,
+                    person.person_id
+                ),
+                'person not found: %',
+                'person did not exist before creation'
+            );
+
+            perform people.delete_person(person.person_id);
+
+            return next throws_like(
+                format($$select people.get_person_as_of(%L, now())$$, person.person_id),
+                'person not found: %',
+                'deleted person is not found'
+            );
+
+            perform people.restore_person(person.person_id);
+            perform people.delete_person(person.person_id);
+            update people.deletions
+            set deleted_at = now() - interval '2 days'
+            where person_id = person.person_id;
+            perform people.purge_people(interval '1 day');
+
+            page := people.person_history(person.person_id, 0, 2);
+
+            return next is(
+                page.total,
+                6,
+                'history has every change'
+            );
+
+            return next is(
+                (select array_agg(c.operation order by ordinality)
+                 from unnest(page.changes) with ordinality c),
+                array['purge', 'delete']::people.change_operation[],
+                'history is paginated from the newest change'
+            );
+
+            return next is(
+                (select count(*)::int from people.people_history
+                 where person_id = person.person_id and operation = 'restore'),
+                1,
+                'purge is not recorded as restore'
+            );
+
+            return next throws_like(
+                $$select people.person_history(gen_random_uuid())$$,
+                'person not found: %',
+                'history throws on not found'
+            );
+        end;
+    $test$
+    language plpgsql;
+end
+$do$;
+commit;
@@ -0,0 +1,17 @@
+begin;
+
+drop function people.release_idempotency_key(text);
+drop function people.save_idempotent_response(text, int, text, bytea);
+drop function people.reserve_idempotency_key(text, bytea, interval);
+drop table people.idempotency_keys;
+
+-- testing functions
+do $do$
+begin
+    if utils.in_test_environment() then
+        drop function test.test_000012_idempotency_keys();
+    end if;
+end
+$do$;
+
+commit;
@@ -0,0 +1,156 @@
+begin;
+
+-- responses of requests with the Idempotency-Key header.
+-- status is null while the first request is in progress
+create table people.idempotency_keys (
+    key          text        primary key,
+    fingerprint  bytea       not null,
+    status       int,
+    content_type text,
+    body         bytea,
+    expires_at   timestamptz not null
+);
+
+create index idempotency_keys_by_expires_at on people.idempotency_keys (expires_at);
+
+-- reserves the key for a request with the fingerprint.
+-- returns reserved = true if the request should be processed, otherwise
+-- the record of the request that reserved the key (status is null if it is
+-- still in progress)
+create function people.reserve_idempotency_key(key_ text, fingerprint_ bytea, ttl interval)
+returns table (
+    reserved     boolean,
+    fingerprint  bytea,
+    status       int,
+    content_type text,
+    body         bytea
+)
+as $func$
+declare
+    count_ int;
+begin
+    if key_ is null or fingerprint_ is null or ttl is null or ttl <= interval '0' then
+        raise exception 'invalid arguments: key %, ttl %', key_, ttl
+            using errcode = 'invalid_parameter_value';
+    end if;
+
+    delete from people.idempotency_keys k where k.expires_at < now();
+
+    insert into people.idempotency_keys (key, fingerprint, expires_at)
+    values (key_, fingerprint_, now() + ttl)
+    on conflict do nothing;
+
+    get diagnostics count_ = row_count;
+    if count_ = 1 then
+        return query select true, fingerprint_, null::int, null::text, null::bytea;
+        return;
+    end if;
+
+    return query
+        select false, k.fingerprint, k.status, k.content_type, k.body
+        from people.idempotency_keys k
+        where k.key = key_;
+end;
+$func$
+language plpgsql;
+
+create function people.save_idempotent_response(
+    key_          text,
+    status_       int,
+    content_type_ text,
+    body_         bytea
+)
+returns void
+as $func$
+begin
+    update people.idempotency_keys k
+    set
+        status       = status_,
+        content_type = content_type_,
+        body         = body_
+    where
+        k.key = key_ and k.status is null;
+
+    if not found then
+        raise exception 'idempotency key % is not reserved', key_
+            using errcode = 'no_data_found';
+    end if;
+end;
+$func$
+language plpgsql;
+
+-- releases the key of a failed request so that it can be retried
+create function people.release_idempotency_key(key_ text)
+returns void
+as $sql$
+    delete from people.idempotency_keys k where k.key = key_ and k.status is null;
+$sql$
+language sql;
+
+
+-- testing functions
+do $do$
+begin
+    if not utils.in_test_environment() then
+        return;
+    end if;
+
+    create function test.test_000012_idempotency_keys()
+        returns setof text as $test$
+        begin
+            return next has_table('people', 'idempotency_keys', 'has idempotency_keys table');
+
+            return next is(
+                (select reserved from people.reserve_idempotency_key('key', '\x01', interval '1 hour')),
+                true,
+                'reserves a new key'
+            );
+
+            return next row_eq(
+                $$select reserved, status from people.reserve_idempotency_key('key', '\x01', interval '1 hour')$$,
+                row(false, null::int),
+                'reports a request in progress'
+            );
+
+            perform people.save_idempotent_response('key', 201, 'application/json', '\x7b7d');
+
+            return next row_eq(
+                $$select * from people.reserve_idempotency_key('key', '\x02', interval '1 hour')$$,
+                row(false, '\x01'::bytea, 201, 'application/json'::text, '\x7b7d'::bytea),
+                'returns the saved response and the fingerprint'
+            );
+
+            return next throws_like(
+                $$select people.save_idempotent_response('key', 200, null, null)$$,
+                'idempotency key % is not reserved',
+                'can not save a response twice'
+            );
+
+            perform people.reserve_idempotency_key('failed', '\x01', interval '1 hour');
+            perform people.release_idempotency_key('failed');
+
+            return next is(
+                (select reserved from people.reserve_idempotency_key('failed', '\x02', interval '1 hour')),
+                true,
+                'released key can be reserved again'
+            );
+
+            update people.idempotency_keys set expires_at = now() - interval '1 second' where key = 'key';
+
+            return next is(
+                (select reserved from people.reserve_idempotency_key('key', '\x02', interval '1 hour')),
+                true,
+                'expired key can be reserved again'
+            );
+
+            return next throws_like(
+                $$select people.reserve_idempotency_key('key', '\x01', interval '0')$$,
+                'invalid arguments: %',
+                'throws on invalid ttl'
+            );
+        end;
+    $test$
+    language plpgsql;
+end
+$do$;
+commit;
@@ -0,0 +1,17 @@
+begin;
+
+drop function people.find_api_clients(text, text);
+drop function people.revoke_api_client(text);
+drop function people.create_api_client(text, text, text);
+drop table people.api_clients;
+
+-- testing functions
+do $do$
+begin
+    if utils.in_test_environment() then
+        drop function test.test_000013_api_clients();
+    end if;
+end
+$do$;
+
+commit;
@@ -0,0 +1,148 @@
+begin;
+
+-- clients of the API. a client authenticates with its secret either as
+-- an API key "<key_id>.<secret>" or with HTTP Basic "<name>:<secret>".
+-- only a hash of the secret is stored
+create table people.api_clients (
+    client_id   uuid        primary key default (gen_random_uuid()),
+    name        text        not null,
+    key_id      text        not null unique,
+    secret_hash text        not null,
+    created_at  timestamptz not null default now(),
+    revoked_at  timestamptz,
+    constraint valid_name   check (name ~ '^[A-Za-z0-9._-]+$'),
+    constraint valid_key_id check (key_id ~ '^[A-Za-z0-9]+$')
+);
+
+-- a name identifies a single active client
+create unique index api_clients_by_name on people.api_clients (name)
+    where revoked_at is null;
+
+create function people.create_api_client(name_ text, key_id_ text, secret_hash_ text)
+returns uuid
+as $func$
+declare
+    id uuid;
+begin
+    insert into people.api_clients (name, key_id, secret_hash)
+    values (name_, key_id_, secret_hash_)
+    returning client_id into id;
+
+    return id;
+exception
+    when unique_violation then
+        raise exception 'api client % already exists', name_
+            using errcode = 'unique_violation';
+    when not_null_violation then
+        raise exception 'invalid arguments'
+            using errcode = 'invalid_parameter_value';
+end;
+$func$
+language plpgsql;
+
+create function people.revoke_api_client(name_ text)
+returns void
+as $func$
+begin
+    update people.api_clients c
+    set revoked_at = now()
+    where c.name = name_ and c.revoked_at is null;
+
+    if not found then
+        raise exception 'api client % not found', name_
+            using errcode = 'no_data_found';
+    end if;
+end;
+$func$
+language plpgsql;
+
+-- active clients by key_id or name. all clients (including revoked ones)
+-- if both are null
+create function people.find_api_clients(key_id_ text default null, name_ text default null)
+returns setof people.api_clients
+as $sql$
+    select c.*
+    from people.api_clients c
+    where
+        (key_id_ is null and name_ is null) or
+        (c.revoked_at is null and (c.key_id = key_id_ or c.name = name_))
+    order by c.created_at, c.name;
+$sql$
+language sql stable;
+
+
+-- testing functions
+do $do$
+begin
+    if not utils.in_test_environment() then
+        return;
+    end if;
+
+    create function test.test_000013_api_clients()
+        returns setof text as $test$
+        begin
+            return next has_table('people', 'api_clients', 'has api_clients table');
+
+            return next lives_ok(
+                $$select people.create_api_client('client', 'key1', 'hash')$$,
+                'can create a client'
+            );
+
+            return next throws_ok(
+                $$select people.create_api_client('client', 'key2', 'hash')$$,
+                '23505',
+                'api client client already exists',
+                'name of an active client is unique'
+            );
+
+            return next throws_ok(
+                $$select people.create_api_client('invalid name', 'key3', 'hash')$$,
+                '23514',
+                null,
+                'name is validated'
+            );
+
+            return next is(
+                (select name from people.find_api_clients(key_id_ => 'key1')),
+                'client',
+                'finds a client by key id'
+            );
+
+            return next is(
+                (select key_id from people.find_api_clients(name_ => 'client')),
+                'key1',
+                'finds a client by name'
+            );
+
+            return next lives_ok(
+                $$select people.revoke_api_client('client')$$,
+                'can revoke a client'
+            );
+
+            return next is_empty(
+                $$select * from people.find_api_clients(key_id_ => 'key1')$$,
+                'does not find revoked clients'
+            );
+
+            return next throws_like(
+                $$select people.revoke_api_client('client')$$,
+                'api client % not found',
+                'can not revoke twice'
+            );
+
+            return next lives_ok(
+                $$select people.create_api_client('client', 'key2', 'hash')$$,
+                'name of a revoked client can be reused'
+            );
+
+            return next is(
+                (select count(*)::int from people.find_api_clients()),
+                2,
+                'lists all clients'
+            );
+        end;
+    $test$
+    language plpgsql;
+end
+$do$;
+commit;
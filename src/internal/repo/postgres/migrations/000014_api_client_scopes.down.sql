@@ -0,0 +1,39 @@
+begin;
+
+drop function people.create_api_client(text, text, text, text[]);
+
+alter table people.api_clients
+    drop column scopes;
+
+create function people.create_api_client(name_ text, key_id_ text, secret_hash_ text)
+returns uuid
+as $func$
+declare
+    id uuid;
+begin
+    insert into people.api_clients (name, key_id, secret_hash)
+    values (name_, key_id_, secret_hash_)
+    returning client_id into id;
+
+    return id;
+exception
+    when unique_violation then
+        raise exception 'api client % already exists', name_
+            using errcode = 'unique_violation';
+    when not_null_violation then
+        raise exception 'invalid arguments'
+            using errcode = 'invalid_parameter_value';
+end;
+$func$
+language plpgsql;
+
+-- testing functions
+do $do$
+begin
+    if utils.in_test_environment() then
+        drop function test.test_000014_api_client_scopes();
+    end if;
+end
+$do$;
+
+commit;
@@ -0,0 +1,72 @@
+begin;
+
+-- scopes granted to a client (see x-required-scopes in openapi.yaml).
+-- existing clients keep full access
+alter table people.api_clients
+    add column scopes text[] not null default '{}';
+
+update people.api_clients
+set scopes = '{people:read,people:write,people:delete,people:admin}';
+
+drop function people.create_api_client(text, text, text);
+
+create function people.create_api_client(
+    name_ text, key_id_ text, secret_hash_ text, scopes_ text[] default '{}'
+)
+returns uuid
+as $func$
+declare
+    id uuid;
+begin
+    insert into people.api_clients (name, key_id, secret_hash, scopes)
+    values (name_, key_id_, secret_hash_, coalesce(scopes_, '{}'))
+    returning client_id into id;
+
+    return id;
+exception
+    when unique_violation then
+        raise exception 'api client % already exists', name_
+            using errcode = 'unique_violation';
+    when not_null_violation then
+        raise exception 'invalid arguments'
+            using errcode = 'invalid_parameter_value';
+end;
+$func$
+language plpgsql;
+
+
+-- testing functions
+do $do$
+begin
+    if not utils.in_test_environment() then
+        return;
+    end if;
+
+    create function test.test_000014_api_client_scopes()
+        returns setof text as $test$
+        begin
+            return next has_column('people', 'api_clients', 'scopes', 'api_clients have scopes');
+
+            perform people.create_api_client(
+                'scoped', 'scopedkey', 'hash', '{people:read,people:write}'
+            );
+
+            return next is(
+                (select scopes from people.find_api_clients(key_id_ => 'scopedkey')),
+                '{people:read,people:write}'::text[],
+                'scopes are stored'
+            );
+
+            perform people.create_api_client('unscoped', 'unscopedkey', 'hash');
+
+            return next is(
+                (select scopes from people.find_api_clients(key_id_ => 'unscopedkey')),
+                '{}'::text[],
+                'clients have no scopes by default'
+            );
+        end;
+    $test$
+    language plpgsql;
+end
+$do$;
+commit;
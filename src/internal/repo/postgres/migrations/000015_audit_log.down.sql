@@ -0,0 +1,20 @@
+begin;
+
+drop function people.list_audit(text, uuid, timestamptz, timestamptz, int, int);
+drop type people.audit_page;
+drop trigger audit on people.people_history;
+drop function people.audit_trigger();
+drop function utils.jsonb_diff(jsonb, jsonb);
+drop table people.audit_log;
+drop function people.audit_log_append_only();
+
+-- testing functions
+do $do$
+begin
+    if utils.in_test_environment() then
+        drop function test.test_000015_audit_log();
+    end if;
+end
+$do$;
+
+commit;
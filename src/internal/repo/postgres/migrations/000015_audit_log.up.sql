@@ -0,0 +1,222 @@
+begin;
+
+-- who changed which person and when. entries are written by a trigger on
+-- people.people_history, so they are committed atomically with the change.
+-- actor, client ip and request id are set by the application for the
+-- transaction (see people.record_change)
+create table people.audit_log (
+    audit_id    bigint                  generated always as identity primary key,
+    occurred_at timestamptz             not null default now(),
+    actor       text,
+    client_ip   inet,
+    request_id  text,
+    operation   people.change_operation not null,
+    person_id   uuid                    not null,
+    -- {"<field>": {"old": <value>, "new": <value>}} for changed fields
+    diff        jsonb                   not null
+);
+
+create index audit_log_by_time   on people.audit_log (occurred_at);
+create index audit_log_by_person on people.audit_log (person_id, occurred_at);
+create index audit_log_by_actor  on people.audit_log (actor, occurred_at);
+
+create function people.audit_log_append_only()
+returns trigger
+as $func$
+begin
+    raise exception 'audit log is append-only'
+        using errcode = 'insufficient_privilege';
+end;
+$func$
+language plpgsql;
+
+create trigger audit_log_append_only
+before update or delete on people.audit_log
+for each row execute function people.audit_log_append_only();
+
+create trigger audit_log_no_truncate
+before truncate on people.audit_log
+for each statement execute function people.audit_log_append_only();
+
+-- changed fields of two objects: {"<key>": {"old": <value>, "new": <value>}}.
+-- null objects have no fields
+create function utils.jsonb_diff(old_value jsonb, new_value jsonb)
+returns jsonb
+as $sql$
+    select coalesce(
+        jsonb_object_agg(key, jsonb_build_object('old', o.value, 'new', n.value)),
+        '{}'::jsonb
+    )
+    from
+        jsonb_each(coalesce(old_value, '{}'::jsonb)) o
+        full join jsonb_each(coalesce(new_value, '{}'::jsonb)) n using (key)
+    where o.value is distinct from n.value;
+$sql$
+language sql immutable;
+
+create function people.audit_trigger()
+returns trigger
+as $func$
+begin
+    insert into people.audit_log (
+        occurred_at, actor, client_ip, request_id, operation, person_id, diff
+    )
+    values (
+        new.changed_at, new.actor, utils.setting('people.client_ip')::inet,
+        new.request_id, new.operation, new.person_id,
+        utils.jsonb_diff(new.old_value, new.new_value) - 'person_id'
+    );
+
+    return null;
+end;
+$func$
+language plpgsql;
+
+-- snapshots are not changes
+create trigger audit
+after insert on people.people_history
+for each row when (new.operation <> 'snapshot')
+execute function people.audit_trigger();
+
+create type people.audit_page as (
+    entries        people.audit_log[],
+    current_offset int,
+    current_limit  int,
+    total          int
+);
+
+-- audit entries, newest first. null filters are not applied,
+-- the time range is [from_, to_)
+create function people.list_audit(
+    actor_     text        default null,
+    person_id_ uuid        default null,
+    from_      timestamptz default null,
+    to_        timestamptz default null,
+    offset_    int         default 0,
+    limit_     int         default 20
+)
+returns people.audit_page
+as $func$
+declare
+    result people.audit_page;
+begin
+    if offset_ is null or offset_ < 0 or limit_ is null or limit_ < 0 then
+        raise exception 'invalid pagination: offset %, limit %', offset_, limit_
+            using errcode = 'invalid_parameter_value';
+    end if;
+
+    with filtered as (
+        select a.*
+        from people.audit_log a
+        where
+            (actor_ is null or a.actor = actor_) and
+            (person_id_ is null or a.person_id = person_id_) and
+            (from_ is null or a.occurred_at >= from_) and
+            (to_ is null or a.occurred_at < to_)
+    )
+    select
+        coalesce((
+            select array_agg(page order by page.occurred_at desc, page.audit_id desc)
+            from (
+                select f.*
+                from filtered f
+                order by f.occurred_at desc, f.audit_id desc
+                offset offset_
+                limit limit_
+            ) page
+        ), '{}'),
+        offset_,
+        limit_,
+        (select count(*) from filtered)
+    into result;
+
+    return result;
+end;
+$func$
+language plpgsql stable;
+
+
+-- testing functions
+do $do$
+begin
+    if not utils.in_test_environment() then
+        return;
+    end if;
+
+    create function test.test_000015_audit_log()
+        returns setof text as $test$
+        declare
+            id      uuid;
+            entries people.audit_log[];
+        begin
+            perform set_config('people.actor', 'auditor', true);
+            perform set_config('people.client_ip', '192.0.2.1', true);
+            perform set_config('people.request_id', 'audit-request', true);
+
+            id := people.create_person('Ivan', 'Ivanov', 'Ivanovich', 30, 'male', 'RU');
+            perform people.update_person(id, age_ => 31);
+            perform people.delete_person(id);
+
+            entries := (people.list_audit(person_id_ => id)).entries;
+
+            return next is(
+                array_length(entries, 1),
+                3,
+                'create, update and delete are audited'
+            );
+
+            return next is(
+                entries[1].operation,
+                'delete'::people.change_operation,
+                'entries are ordered newest first'
+            );
+
+            return next is(
+                entries[2].diff,
+                '{"age": {"old": 30, "new": 31}}'::jsonb,
+                'diff contains changed fields only'
+            );
+
+            return next ok(
+                entries[3].diff ? 'name' and not entries[3].diff ? 'person_id',
+                'diff of create contains all fields but the id'
+            );
+
+            return next ok(
+                entries[1].actor = 'auditor' and
+                host(entries[1].client_ip) = '192.0.2.1' and
+                entries[1].request_id = 'audit-request',
+                'request context is recorded'
+            );
+
+            return next is(
+                (people.list_audit(actor_ => 'nobody')).total,
+                0,
+                'filters by actor'
+            );
+
+            return next is(
+                (people.list_audit(person_id_ => id, to_ => '-infinity')).total,
+                0,
+                'filters by time'
+            );
+
+            return next throws_ok(
+                $$update people.audit_log set actor = 'someone else'$$,
+                '42501',
+                'audit log is append-only',
+                'audit log can not be updated'
+            );
+
+            return next throws_ok(
+                $$delete from people.audit_log$$,
+                '42501',
+                'audit log is append-only',
+                'audit log can not be deleted from'
+            );
+        end;
+    $test$
+    language plpgsql;
+end
+$do$;
+commit;
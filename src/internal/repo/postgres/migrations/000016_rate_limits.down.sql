@@ -0,0 +1,15 @@
+begin;
+
+drop function people.take_rate_limit_tokens(text, double precision, double precision, double precision);
+drop table people.rate_limit_buckets;
+
+-- testing functions
+do $do$
+begin
+    if utils.in_test_environment() then
+        drop function test.test_000016_rate_limits();
+    end if;
+end
+$do$;
+
+commit;
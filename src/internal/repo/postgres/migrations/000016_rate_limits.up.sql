@@ -0,0 +1,127 @@
+begin;
+
+-- token buckets of rate limited clients shared by API replicas.
+-- buckets are refilled lazily: tokens is the number of tokens at updated_at.
+-- the table is unlogged: losing buckets on a crash only resets the limits
+create unlogged table people.rate_limit_buckets (
+    key        text             primary key,
+    tokens     double precision not null,
+    updated_at timestamptz      not null
+);
+
+create index rate_limit_buckets_by_updated_at on people.rate_limit_buckets (updated_at);
+
+-- refills the bucket of the key (a new bucket is full) and takes cost_
+-- tokens from it if there are enough. Returns whether the tokens were taken
+-- and the number of tokens left. Forgets buckets that have been refilled
+create function people.take_rate_limit_tokens(
+    key_      text,
+    cost_     double precision,
+    capacity_ double precision,
+    rate_     double precision
+)
+returns table (
+    allowed boolean,
+    tokens  double precision
+)
+as $func$
+declare
+    now_     timestamptz := clock_timestamp();
+    tokens_  double precision;
+    allowed_ boolean;
+begin
+    if key_ is null or cost_ is null or cost_ < 0
+        or capacity_ is null or capacity_ <= 0 or rate_ is null or rate_ <= 0 then
+        raise exception 'invalid arguments: key %, cost %, capacity %, rate %', key_, cost_, capacity_, rate_
+            using errcode = 'invalid_parameter_value';
+    end if;
+
+    delete from people.rate_limit_buckets b
+    where b.updated_at < now_ - make_interval(secs => capacity_ / rate_);
+
+    insert into people.rate_limit_buckets (key, tokens, updated_at)
+    values (key_, capacity_, now_)
+    on conflict do nothing;
+
+    select least(capacity_, b.tokens + rate_ * extract(epoch from now_ - b.updated_at))
+    into tokens_
+    from people.rate_limit_buckets b
+    where b.key = key_
+    for update;
+
+    allowed_ := tokens_ >= cost_;
+    if allowed_ then
+        tokens_ := tokens_ - cost_;
+    end if;
+
+    update people.rate_limit_buckets b
+    set
+        tokens     = tokens_,
+        updated_at = now_
+    where
+        b.key = key_;
+
+    return query select allowed_, tokens_;
+end;
+$func$
+language plpgsql;
+
+
+-- testing functions
+do $do$
+begin
+    if not utils.in_test_environment() then
+        return;
+    end if;
+
+    create function test.test_000016_rate_limits()
+        returns setof text as $test$
+        begin
+            return next has_table('people', 'rate_limit_buckets', 'has rate_limit_buckets table');
+
+            return next row_eq(
+                $$select allowed, tokens::int from people.take_rate_limit_tokens('client', 4, 10, 0.001)$$,
+                row(true, 6),
+                'takes tokens from a new full bucket'
+            );
+
+            return next row_eq(
+                $$select allowed, tokens::int from people.take_rate_limit_tokens('client', 7, 10, 0.001)$$,
+                row(false, 6),
+                'rejects a request costing more than the tokens left'
+            );
+
+            return next row_eq(
+                $$select allowed, tokens::int from people.take_rate_limit_tokens('other', 1, 10, 0.001)$$,
+                row(true, 9),
+                'buckets of clients are separate'
+            );
+
+            update people.rate_limit_buckets set updated_at = updated_at - interval '2 seconds' where key = 'client';
+
+            return next row_eq(
+                $$select allowed, tokens::int from people.take_rate_limit_tokens('client', 7, 10, 1)$$,
+                row(true, 1),
+                'refills the bucket'
+            );
+
+            update people.rate_limit_buckets set updated_at = updated_at - interval '1 hour' where key = 'other';
+            perform people.take_rate_limit_tokens('client', 0, 10, 1);
+
+            return next is(
+                (select count(*)::int from people.rate_limit_buckets where key = 'other'),
+                0,
+                'forgets refilled buckets'
+            );
+
+            return next throws_like(
+                $$select people.take_rate_limit_tokens('client', 1, 10, 0)$$,
+                'invalid arguments: %',
+                'throws on invalid rate'
+            );
+        end;
+    $test$
+    language plpgsql;
+end
+$do$;
+commit;
@@ -0,0 +1,14 @@
+begin;
+
+drop function people.count_people();
+
+-- testing functions
+do $do$
+begin
+    if utils.in_test_environment() then
+        drop function test.test_000017_count_people();
+    end if;
+end
+$do$;
+
+commit;
@@ -0,0 +1,55 @@
+begin;
+
+-- number of active and deleted people (for metrics)
+create function people.count_people()
+returns table (
+    active  bigint,
+    deleted bigint
+)
+as $sql$
+    select
+        count(*) filter (where d.person_id is null),
+        count(d.person_id)
+    from
+        people.people p
+        left join people.deletions d using (person_id);
+$sql$
+language sql stable;
+
+
+-- testing functions
+do $do$
+begin
+    if not utils.in_test_environment() then
+        return;
+    end if;
+
+    create function test.test_000017_count_people()
+        returns setof text as $test$
+        declare
+            before_ record;
+            id_     uuid;
+        begin
+            select * into before_ from people.count_people();
+
+            id_ := people.create_person('Ivan', 'Ivanov', 'Ivanovich', 42, 'male', 'RU');
+
+            return next row_eq(
+                $$select * from people.count_people()$$,
+                row(before_.active + 1, before_.deleted),
+                'counts created people as active'
+            );
+
+            perform people.delete_person(id_);
+
+            return next row_eq(
+                $$select * from people.count_people()$$,
+                row(before_.active, before_.deleted + 1),
+                'counts deleted people'
+            );
+        end;
+    $test$
+    language plpgsql;
+end
+$do$;
+commit;
@@ -0,0 +1,393 @@
+begin;
+
+-- Double Metaphone codes
+create extension if not exists fuzzystrmatch;
+
+-- the name transliterated to Latin: Cyrillic letters are transliterated,
+-- diacritics are removed, other characters than Latin letters separate
+-- lowercase words and common variants of transliteration are made the same
+-- (Dmitriy, Dmitrij, Dmitrii and Dmitry are dmitri).
+-- search.Latin of the Go repos does the same.
+create function people.latin(value text)
+returns text
+as $func$
+declare
+    letter text;
+    latin  text;
+begin
+    -- letters transliterated to several Latin ones
+    for letter, latin in
+        select * from jsonb_each_text('{
+            "є": "ye", "ж": "zh", "ї": "yi", "х": "kh", "ц": "ts",
+            "ч": "ch", "ш": "sh", "щ": "shch", "ю": "yu", "я": "ya",
+            "Є": "ye", "Ж": "zh", "Ї": "yi", "Х": "kh", "Ц": "ts",
+            "Ч": "ch", "Ш": "sh", "Щ": "shch", "Ю": "yu", "Я": "ya"
+        }')
+    loop
+        value := replace(value, letter, latin);
+    end loop;
+
+    -- the others, the signs are removed
+    value := translate(
+        value,
+        'абвгґдеёзиійклмнопрстуўфыэ' || 'АБВГҐДЕЁЗИІЙКЛМНОПРСТУЎФЫЭ' || 'ъьЪЬ',
+        'abvggdeeziiyklmnoprstuufye' || 'abvggdeeziiyklmnoprstuufye'
+    );
+
+    -- combining diacritical marks of decomposed letters
+    value := regexp_replace(normalize(value, NFD), '[\u0300-\u036f]', '', 'g');
+    value := btrim(regexp_replace(lower(value), '[^a-z]+', ' ', 'g'));
+
+    value := replace(value, 'ph', 'f');
+    value := replace(value, 'x', 'ks');
+    value := replace(value, 'w', 'v');
+    value := replace(value, 'j', 'y');
+    value := replace(value, 'iy', 'i');
+    value := replace(value, 'ii', 'i');
+
+    -- y at the end of a word
+    return regexp_replace(value, 'y\M', 'i', 'g');
+end;
+$func$
+language plpgsql immutable strict parallel safe;
+
+-- Double Metaphone codes of the words of the Latin form of the name,
+-- sorted and without duplicates (search.Phonetic of the Go repos)
+create function people.phonetic(value text)
+returns text[]
+as $sql$
+    select coalesce(array_agg(distinct code order by code), '{}')
+    from
+        regexp_split_to_table(people.latin(value), ' ') word,
+        unnest(array[dmetaphone(word), dmetaphone_alt(word)]) code
+    where code <> '';
+$sql$
+language sql immutable strict parallel safe;
+
+-- normalized names of people for the phonetic and fulltext match of
+-- list_people, kept up to date by the name_keys trigger
+create table people.name_keys (
+    person_id           uuid   primary key references people.people on delete cascade,
+    name_latin          text   not null,
+    surname_latin       text   not null,
+    patronymic_latin    text   not null,
+    name_phonetic       text[] not null,
+    surname_phonetic    text[] not null,
+    patronymic_phonetic text[] not null
+);
+
+create index name_keys_by_name_phonetic on people.name_keys
+    using gin (name_phonetic);
+
+create index name_keys_by_surname_phonetic on people.name_keys
+    using gin (surname_phonetic);
+
+create index name_keys_by_patronymic_phonetic on people.name_keys
+    using gin (patronymic_phonetic);
+
+create index name_keys_by_name_words on people.name_keys
+    using gin (to_tsvector('simple', name_latin));
+
+create index name_keys_by_surname_words on people.name_keys
+    using gin (to_tsvector('simple', surname_latin));
+
+create index name_keys_by_patronymic_words on people.name_keys
+    using gin (to_tsvector('simple', patronymic_latin));
+
+create function people.name_keys_trigger()
+returns trigger
+as $func$
+begin
+    insert into people.name_keys
+    values (
+        new.person_id,
+        people.latin(new.name),
+        people.latin(new.surname),
+        people.latin(new.patronymic),
+        people.phonetic(new.name),
+        people.phonetic(new.surname),
+        people.phonetic(new.patronymic)
+    )
+    on conflict (person_id) do update set
+        name_latin          = excluded.name_latin,
+        surname_latin       = excluded.surname_latin,
+        patronymic_latin    = excluded.patronymic_latin,
+        name_phonetic       = excluded.name_phonetic,
+        surname_phonetic    = excluded.surname_phonetic,
+        patronymic_phonetic = excluded.patronymic_phonetic;
+
+    return null;
+end;
+$func$
+language plpgsql;
+
+insert into people.name_keys
+select
+    p.person_id,
+    people.latin(p.name),
+    people.latin(p.surname),
+    people.latin(p.patronymic),
+    people.phonetic(p.name),
+    people.phonetic(p.surname),
+    people.phonetic(p.patronymic)
+from people.people p;
+
+create trigger name_keys
+after insert or update of name, surname, patronymic on people.people
+for each row execute function people.name_keys_trigger();
+
+-- match_ is the way the names are matched:
+--   trigram  - the names are scored by word similarity (see 000018)
+--   phonetic - the names share a Double Metaphone code
+--   fulltext - the names contain every word of the searched ones
+-- The names are compared in the Latin form in the phonetic and fulltext
+-- modes. They are conditions then, the total counts people matching them
+-- and the threshold is ignored; the similarity only orders the people.
+drop function people.list_people(text, text, text, int, int, people.sex, char(2), real, int, int, boolean);
+
+create function people.list_people(
+    name_           text       default null,
+    surname_        text       default null,
+    patronymic_     text       default null,
+    age_min         int        default null,
+    age_max         int        default null,
+    sex_            people.sex default null,
+    nationality_    char(2)    default null,
+    threshold       real       default 0,
+    offset_         int        default 0,
+    limit_          int        default null,
+    include_deleted boolean    default false,
+    match_          text       default 'trigram'
+)
+returns people.people_page
+as $func$
+declare
+    page       people.people_page;
+    -- sum of similarities of the name fields required to reach threshold
+    required   double precision;
+    -- number of name fields compared by similarity
+    fields     int;
+    -- people joined with their normalized names in the phonetic and
+    -- fulltext modes
+    source     text := 'people.people p';
+    -- conditions of the filters, $n are the arguments
+    conditions text := $sql$
+        (($4 is null) or ($4 <= p.age))         and
+        (($5 is null) or ($5 >= p.age))         and
+        (($7 is null) or ($7 =  p.nationality)) and
+        (($6 is null) or ($6 =  p.sex))         and
+        (($3 is null) or (($3 = '') = (p.patronymic = ''))) and
+        (coalesce($11, false) or not people.is_deleted(p.person_id))
+    $sql$;
+    -- people that can reach the threshold
+    candidates text := 'true';
+begin
+    if threshold is null then
+        threshold := 0;
+    end if;
+
+    case coalesce(match_, 'trigram')
+        when 'trigram' then
+            required := 3.0 * threshold - (patronymic_ is not distinct from '')::int;
+            fields   := (name_       is not null)::int
+                      + (surname_    is not null)::int
+                      + (coalesce(patronymic_, '') <> '')::int;
+
+            if fields > 0 and required > 0 then
+                -- the margin covers rounding of the real similarities
+                perform set_config(
+                    'pg_trgm.word_similarity_threshold',
+                    greatest(least(required / fields - 1e-6, 1), 0)::text,
+                    true
+                );
+
+                candidates := '(' || concat_ws(' or ',
+                    case when name_       is not null then '$1 <% p.name'       end,
+                    case when surname_    is not null then '$2 <% p.surname'    end,
+                    case when patronymic_ <> ''       then '$3 <% p.patronymic' end
+                ) || ')';
+            end if;
+        when 'phonetic' then
+            source     := source || ' join people.name_keys k using (person_id)';
+            conditions := concat_ws(' and ', conditions,
+                case when name_       is not null then 'k.name_phonetic       && people.phonetic($1)' end,
+                case when surname_    is not null then 'k.surname_phonetic    && people.phonetic($2)' end,
+                case when patronymic_ <> ''       then 'k.patronymic_phonetic && people.phonetic($3)' end
+            );
+            threshold  := 0;
+        when 'fulltext' then
+            source     := source || ' join people.name_keys k using (person_id)';
+            conditions := concat_ws(' and ', conditions,
+                case when name_ is not null then $$
+                    people.latin($1) <> '' and
+                    to_tsvector('simple', k.name_latin) @@ plainto_tsquery('simple', people.latin($1))
+                $$ end,
+                case when surname_ is not null then $$
+                    people.latin($2) <> '' and
+                    to_tsvector('simple', k.surname_latin) @@ plainto_tsquery('simple', people.latin($2))
+                $$ end,
+                case when patronymic_ <> '' then $$
+                    people.latin($3) <> '' and
+                    to_tsvector('simple', k.patronymic_latin) @@ plainto_tsquery('simple', people.latin($3))
+                $$ end
+            );
+            threshold  := 0;
+        else
+            raise exception 'invalid match: %', match_
+                using errcode = 'invalid_parameter_value';
+    end case;
+
+    execute format('select count(*) from %s where %s', source, conditions)
+    into page.total
+    using
+        name_, surname_, patronymic_, age_min, age_max, sex_, nationality_,
+        threshold, offset_, limit_, include_deleted;
+
+    execute format($sql$
+        with matched_people as (
+            select
+                p.person_id,
+                p.name,
+                p.surname,
+                p.patronymic,
+                p.age,
+                p.sex,
+                p.nationality,
+                (
+                    (case
+                        when $1 is null then 0.0
+                        else word_similarity($1, p.name)
+                    end)
+                    +
+                    (case
+                        when $2 is null then 0.0
+                        else word_similarity($2, p.surname)
+                    end)
+                    +
+                    (case
+                        when $3 is null
+                            then 0
+                        when ($3 = '' or p.patronymic = '')
+                            then ($3 = p.patronymic)::int
+                        else word_similarity($3, p.patronymic)
+                    end)
+                ) / 3.0 as total_similarity
+            from %s
+            where %s and %s
+        )
+        select array(
+            select (
+                m.person_id, m.name, m.surname,
+                m.patronymic, m.age, m.sex, m.nationality
+            )::people.people
+            from matched_people m
+            where m.total_similarity >= $8
+            order by
+                m.total_similarity desc,
+                m.surname          asc,
+                m.name             asc,
+                m.patronymic       asc,
+                m.age              asc,
+                m.sex              asc,
+                m.nationality      asc
+            offset $9
+            limit $10
+        )
+    $sql$, source, candidates, conditions)
+    into page.people
+    using
+        name_, surname_, patronymic_, age_min, age_max, sex_, nationality_,
+        threshold, offset_, limit_, include_deleted;
+
+    page.current_offset := offset_;
+    page.current_limit := limit_;
+
+    return page;
+end;
+$func$
+language plpgsql
+set pg_trgm.word_similarity_threshold = 0.6;
+
+
+-- testing functions
+do $do$
+begin
+    if not utils.in_test_environment() then
+        return;
+    end if;
+
+    create function test.test_000019_name_match()
+        returns setof text as $test$
+        declare
+            id_ uuid;
+        begin
+            return next has_table('people', 'name_keys', 'has name_keys table');
+
+            return next is(people.latin('Дмитрий'), 'dmitri', 'transliterates Cyrillic');
+            return next is(people.latin('Dmitry'), 'dmitri', 'makes variants the same');
+            return next is(people.latin(' Renée O''Neil '), 'renee o neil', 'removes diacritics');
+            return next is(people.phonetic('Schmidt'), array['SMT', 'XMT'], 'codes names');
+            return next is(people.phonetic('?!'), '{}'::text[], 'codes names without words');
+
+            id_ := people.create_person('Дмитрий', 'Шмидт', 'Иванович', 30, 'male', 'RU');
+            perform people.create_person('Dmitry', 'Schmidt', '', 40, 'male', 'DE');
+            perform people.create_person('Anna', 'Lopez Garcia', '', 20, 'female', 'ES');
+
+            return next is(
+                (select k.name_latin from people.name_keys k where k.person_id = id_),
+                'dmitri',
+                'keeps the Latin names of created people'
+            );
+
+            return next is(
+                (select k.surname_phonetic from people.name_keys k where k.person_id = id_),
+                array['XMT'],
+                'keeps the codes of created people'
+            );
+
+            perform people.update_person(id_, surname_ => 'Smith');
+
+            return next is(
+                (select k.surname_phonetic from people.name_keys k where k.person_id = id_),
+                array['SM0', 'XMT'],
+                'keeps the codes of updated people'
+            );
+
+            return next is(
+                array(
+                    select p.name
+                    from unnest((people.list_people(name_ => 'Dmitriy', match_ => 'fulltext')).people) p
+                ),
+                array['Dmitry', 'Дмитрий'],
+                'matches transliterated words'
+            );
+
+            return next is(
+                (people.list_people(surname_ => 'Garcia Smith', match_ => 'fulltext')).total,
+                0,
+                'matches every word'
+            );
+
+            return next is(
+                array(
+                    select p.name
+                    from unnest((people.list_people(
+                        surname_ => 'Shmidt', threshold => 0.9, match_ => 'phonetic'
+                    )).people) p
+                ),
+                array['Dmitry', 'Дмитрий'],
+                'matches codes ignoring the threshold'
+            );
+
+            return next throws_ok(
+                $$select people.list_people(match_ => 'soundex')$$,
+                '22023',
+                null,
+                'throws on an invalid match'
+            );
+        end;
+    $test$
+    language plpgsql;
+end
+$do$;
+commit;
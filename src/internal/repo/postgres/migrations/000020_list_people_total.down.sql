@@ -0,0 +1,178 @@
+begin;
+
+drop function people.list_people(text, text, text, int, int, people.sex, char(2), real, int, int, boolean, text, boolean);
+
+-- list_people of 000019_name_match
+create function people.list_people(
+    name_           text       default null,
+    surname_        text       default null,
+    patronymic_     text       default null,
+    age_min         int        default null,
+    age_max         int        default null,
+    sex_            people.sex default null,
+    nationality_    char(2)    default null,
+    threshold       real       default 0,
+    offset_         int        default 0,
+    limit_          int        default null,
+    include_deleted boolean    default false,
+    match_          text       default 'trigram'
+)
+returns people.people_page
+as $func$
+declare
+    page       people.people_page;
+    -- sum of similarities of the name fields required to reach threshold
+    required   double precision;
+    -- number of name fields compared by similarity
+    fields     int;
+    -- people joined with their normalized names in the phonetic and
+    -- fulltext modes
+    source     text := 'people.people p';
+    -- conditions of the filters, $n are the arguments
+    conditions text := $sql$
+        (($4 is null) or ($4 <= p.age))         and
+        (($5 is null) or ($5 >= p.age))         and
+        (($7 is null) or ($7 =  p.nationality)) and
+        (($6 is null) or ($6 =  p.sex))         and
+        (($3 is null) or (($3 = '') = (p.patronymic = ''))) and
+        (coalesce($11, false) or not people.is_deleted(p.person_id))
+    $sql$;
+    -- people that can reach the threshold
+    candidates text := 'true';
+begin
+    if threshold is null then
+        threshold := 0;
+    end if;
+
+    case coalesce(match_, 'trigram')
+        when 'trigram' then
+            required := 3.0 * threshold - (patronymic_ is not distinct from '')::int;
+            fields   := (name_       is not null)::int
+                      + (surname_    is not null)::int
+                      + (coalesce(patronymic_, '') <> '')::int;
+
+            if fields > 0 and required > 0 then
+                -- the margin covers rounding of the real similarities
+                perform set_config(
+                    'pg_trgm.word_similarity_threshold',
+                    greatest(least(required / fields - 1e-6, 1), 0)::text,
+                    true
+                );
+
+                candidates := '(' || concat_ws(' or ',
+                    case when name_       is not null then '$1 <% p.name'       end,
+                    case when surname_    is not null then '$2 <% p.surname'    end,
+                    case when patronymic_ <> ''       then '$3 <% p.patronymic' end
+                ) || ')';
+            end if;
+        when 'phonetic' then
+            source     := source || ' join people.name_keys k using (person_id)';
+            conditions := concat_ws(' and ', conditions,
+                case when name_       is not null then 'k.name_phonetic       && people.phonetic($1)' end,
+                case when surname_    is not null then 'k.surname_phonetic    && people.phonetic($2)' end,
+                case when patronymic_ <> ''       then 'k.patronymic_phonetic && people.phonetic($3)' end
+            );
+            threshold  := 0;
+        when 'fulltext' then
+            source     := source || ' join people.name_keys k using (person_id)';
+            conditions := concat_ws(' and ', conditions,
+                case when name_ is not null then $$
+                    people.latin($1) <> '' and
+                    to_tsvector('simple', k.name_latin) @@ plainto_tsquery('simple', people.latin($1))
+                $$ end,
+                case when surname_ is not null then $$
+                    people.latin($2) <> '' and
+                    to_tsvector('simple', k.surname_latin) @@ plainto_tsquery('simple', people.latin($2))
+                $$ end,
+                case when patronymic_ <> '' then $$
+                    people.latin($3) <> '' and
+                    to_tsvector('simple', k.patronymic_latin) @@ plainto_tsquery('simple', people.latin($3))
+                $$ end
+            );
+            threshold  := 0;
+        else
+            raise exception 'invalid match: %', match_
+                using errcode = 'invalid_parameter_value';
+    end case;
+
+    execute format('select count(*) from %s where %s', source, conditions)
+    into page.total
+    using
+        name_, surname_, patronymic_, age_min, age_max, sex_, nationality_,
+        threshold, offset_, limit_, include_deleted;
+
+    execute format($sql$
+        with matched_people as (
+            select
+                p.person_id,
+                p.name,
+                p.surname,
+                p.patronymic,
+                p.age,
+                p.sex,
+                p.nationality,
+                (
+                    (case
+                        when $1 is null then 0.0
+                        else word_similarity($1, p.name)
+                    end)
+                    +
+                    (case
+                        when $2 is null then 0.0
+                        else word_similarity($2, p.surname)
+                    end)
+                    +
+                    (case
+                        when $3 is null
+                            then 0
+                        when ($3 = '' or p.patronymic = '')
+                            then ($3 = p.patronymic)::int
+                        else word_similarity($3, p.patronymic)
+                    end)
+                ) / 3.0 as total_similarity
+            from %s
+            where %s and %s
+        )
+        select array(
+            select (
+                m.person_id, m.name, m.surname,
+                m.patronymic, m.age, m.sex, m.nationality
+            )::people.people
+            from matched_people m
+            where m.total_similarity >= $8
+            order by
+                m.total_similarity desc,
+                m.surname          asc,
+                m.name             asc,
+                m.patronymic       asc,
+                m.age              asc,
+                m.sex              asc,
+                m.nationality      asc
+            offset $9
+            limit $10
+        )
+    $sql$, source, candidates, conditions)
+    into page.people
+    using
+        name_, surname_, patronymic_, age_min, age_max, sex_, nationality_,
+        threshold, offset_, limit_, include_deleted;
+
+    page.current_offset := offset_;
+    page.current_limit := limit_;
+
+    return page;
+end;
+$func$
+language plpgsql
+set pg_trgm.word_similarity_threshold = 0.6;
+
+-- testing functions
+do $do$
+begin
+    if utils.in_test_environment() then
+        drop function test.test_000020_list_people_total();
+    end if;
+end
+$do$;
+
+commit;
@@ -0,0 +1,378 @@
+begin;
+
+-- soft deletions are moved back to people.deletions
+create table people.deletions (
+    person_id  uuid        primary key references people.people on delete cascade,
+    deleted_at timestamptz not null default now()
+);
+
+create index deletions_by_deleted_at on people.deletions (deleted_at);
+
+insert into people.deletions (person_id, deleted_at)
+select p.person_id, p.deleted_at
+from people.people p
+where p.deleted_at is not null;
+
+drop index people.people_by_deleted_at;
+alter table people.people drop column deleted_at;
+
+create or replace function people.is_deleted(id uuid)
+returns boolean
+as $sql$
+    select exists (select 1 from people.deletions d where d.person_id = id);
+$sql$
+language sql stable;
+
+create or replace function people.people_history_trigger()
+returns trigger
+as $func$
+begin
+    case tg_op
+        when 'INSERT' then
+            perform people.record_change(new.person_id, 'create', null, to_jsonb(new));
+        when 'UPDATE' then
+            if old is not distinct from new then
+                return null;
+            end if;
+            perform people.record_change(new.person_id, 'update', to_jsonb(old), to_jsonb(new));
+        when 'DELETE' then
+            perform people.record_change(old.person_id, 'purge', to_jsonb(old), null);
+    end case;
+
+    return null;
+end;
+$func$
+language plpgsql;
+
+create function people.deletions_history_trigger()
+returns trigger
+as $func$
+declare
+    person_ people.people;
+begin
+    select p.* into person_
+    from
+        people.people p
+    where
+        p.person_id = (case tg_op when 'INSERT' then new.person_id else old.person_id end);
+
+    -- deletion records of purged people are removed by the cascade
+    if not found then
+        return null;
+    end if;
+
+    case tg_op
+        when 'INSERT' then
+            perform people.record_change(person_.person_id, 'delete', to_jsonb(person_), null);
+        when 'DELETE' then
+            perform people.record_change(person_.person_id, 'restore', null, to_jsonb(person_));
+    end case;
+
+    return null;
+end;
+$func$
+language plpgsql;
+
+create trigger deletions_history
+after insert or delete on people.deletions
+for each row execute function people.deletions_history_trigger();
+
+create or replace function people.get_person(id uuid)
+returns people.people
+as $func$
+declare
+    result_ people.people;
+begin
+    if id is null then
+        raise exception 'invalid person_id: %', id
+            using errcode = 'invalid_parameter_value';
+    end if;
+
+    select p.* into result_
+    from
+        people.people p
+    where
+        p.person_id = id and not people.is_deleted(id);
+
+    if not found then
+        raise exception 'person not found: %', id
+            using errcode = 'no_data_found';
+    end if;
+    return result_;
+end;
+$func$
+language plpgsql;
+
+create or replace function people.delete_person(id uuid)
+returns void
+as $func$
+declare
+    count_ int;
+begin
+    if id is null then
+        raise exception 'invalid person_id'
+            using errcode = 'invalid_parameter_value';
+    end if;
+
+    insert into people.deletions (person_id)
+    select p.person_id
+    from
+        people.people p
+    where
+        p.person_id = id
+    on conflict do nothing;
+
+    get diagnostics count_ = row_count;
+    if count_ = 0 then
+        raise exception 'person with id % not found', id
+            using errcode = 'no_data_found';
+    end if;
+end;
+$func$
+language plpgsql;
+
+create or replace function people.restore_person(id uuid)
+returns void
+as $func$
+declare
+    count_ int;
+begin
+    if id is null then
+        raise exception 'invalid person_id'
+            using errcode = 'invalid_parameter_value';
+    end if;
+
+    delete from people.deletions d where d.person_id = id;
+
+    get diagnostics count_ = row_count;
+    if count_ > 0 then
+        return;
+    end if;
+
+    if exists (select 1 from people.people p where p.person_id = id) then
+        raise exception 'person with id % is not deleted', id
+            using errcode = 'object_not_in_prerequisite_state';
+    end if;
+
+    raise exception 'person with id % not found', id
+        using errcode = 'no_data_found';
+end;
+$func$
+language plpgsql;
+
+create or replace function people.purge_people(retention interval)
+returns int
+as $func$
+declare
+    count_ int;
+begin
+    if retention is null or retention < interval '0' then
+        raise exception 'invalid retention: %', retention
+            using errcode = 'invalid_parameter_value';
+    end if;
+
+    delete from people.people p
+    using people.deletions d
+    where
+        p.person_id = d.person_id and d.deleted_at < now() - retention;
+
+    get diagnostics count_ = row_count;
+    return count_;
+end;
+$func$
+language plpgsql;
+
+create or replace function people.count_people()
+returns table (
+    active  bigint,
+    deleted bigint
+)
+as $sql$
+    select
+        count(*) filter (where d.person_id is null),
+        count(d.person_id)
+    from
+        people.people p
+        left join people.deletions d using (person_id);
+$sql$
+language sql stable;
+
+-- list_people of 000020_list_people_total
+create or replace function people.list_people(
+    name_           text       default null,
+    surname_        text       default null,
+    patronymic_     text       default null,
+    age_min         int        default null,
+    age_max         int        default null,
+    sex_            people.sex default null,
+    nationality_    char(2)    default null,
+    threshold       real       default 0,
+    offset_         int        default 0,
+    limit_          int        default null,
+    include_deleted boolean    default false,
+    match_          text       default 'trigram',
+    total_          boolean    default true
+)
+returns people.people_page
+as $func$
+declare
+    page       people.people_page;
+    -- sum of similarities of the name fields required to reach threshold
+    required   double precision;
+    -- number of name fields compared by similarity
+    fields     int;
+    -- people joined with their normalized names in the phonetic and
+    -- fulltext modes
+    source     text := 'people.people p';
+    -- conditions of the filters, $n are the arguments
+    conditions text := $sql$
+        (($4 is null) or ($4 <= p.age))         and
+        (($5 is null) or ($5 >= p.age))         and
+        (($7 is null) or ($7 =  p.nationality)) and
+        (($6 is null) or ($6 =  p.sex))         and
+        (($3 is null) or (($3 = '') = (p.patronymic = ''))) and
+        (coalesce($11, false) or not exists (
+            select 1 from people.deletions d where d.person_id = p.person_id
+        ))
+    $sql$;
+    -- people that can reach the threshold
+    candidates text := 'true';
+begin
+    if threshold is null then
+        threshold := 0;
+    end if;
+
+    case coalesce(match_, 'trigram')
+        when 'trigram' then
+            required := 3.0 * threshold - (patronymic_ is not distinct from '')::int;
+            fields   := (name_       is not null)::int
+                      + (surname_    is not null)::int
+                      + (coalesce(patronymic_, '') <> '')::int;
+
+            if fields > 0 and required > 0 then
+                -- the margin covers rounding of the real similarities
+                perform set_config(
+                    'pg_trgm.word_similarity_threshold',
+                    greatest(least(required / fields - 1e-6, 1), 0)::text,
+                    true
+                );
+
+                candidates := '(' || concat_ws(' or ',
+                    case when name_       is not null then '$1 <% p.name'       end,
+                    case when surname_    is not null then '$2 <% p.surname'    end,
+                    case when patronymic_ <> ''       then '$3 <% p.patronymic' end
+                ) || ')';
+            end if;
+        when 'phonetic' then
+            source     := source || ' join people.name_keys k using (person_id)';
+            conditions := concat_ws(' and ', conditions,
+                case when name_       is not null then 'k.name_phonetic       && people.phonetic($1)' end,
+                case when surname_    is not null then 'k.surname_phonetic    && people.phonetic($2)' end,
+                case when patronymic_ <> ''       then 'k.patronymic_phonetic && people.phonetic($3)' end
+            );
+            threshold  := 0;
+        when 'fulltext' then
+            source     := source || ' join people.name_keys k using (person_id)';
+            conditions := concat_ws(' and ', conditions,
+                case when name_ is not null then $$
+                    people.latin($1) <> '' and
+                    to_tsvector('simple', k.name_latin) @@ plainto_tsquery('simple', people.latin($1))
+                $$ end,
+                case when surname_ is not null then $$
+                    people.latin($2) <> '' and
+                    to_tsvector('simple', k.surname_latin) @@ plainto_tsquery('simple', people.latin($2))
+                $$ end,
+                case when patronymic_ <> '' then $$
+                    people.latin($3) <> '' and
+                    to_tsvector('simple', k.patronymic_latin) @@ plainto_tsquery('simple', people.latin($3))
+                $$ end
+            );
+            threshold  := 0;
+        else
+            raise exception 'invalid match: %', match_
+                using errcode = 'invalid_parameter_value';
+    end case;
+
+    if coalesce(total_, true) then
+        execute format('select count(*) from %s where %s', source, conditions)
+        into page.total
+        using
+            name_, surname_, patronymic_, age_min, age_max, sex_, nationality_,
+            threshold, offset_, limit_, include_deleted;
+    end if;
+
+    execute format($sql$
+        with matched_people as (
+            select
+                p.person_id,
+                p.name,
+                p.surname,
+                p.patronymic,
+                p.age,
+                p.sex,
+                p.nationality,
+                (
+                    (case
+                        when $1 is null then 0.0
+                        else word_similarity($1, p.name)
+                    end)
+                    +
+                    (case
+                        when $2 is null then 0.0
+                        else word_similarity($2, p.surname)
+                    end)
+                    +
+                    (case
+                        when $3 is null
+                            then 0
+                        when ($3 = '' or p.patronymic = '')
+                            then ($3 = p.patronymic)::int
+                        else word_similarity($3, p.patronymic)
+                    end)
+                ) / 3.0 as total_similarity
+            from %s
+            where %s and %s
+        )
+        select array(
+            select (
+                m.person_id, m.name, m.surname,
+                m.patronymic, m.age, m.sex, m.nationality
+            )::people.people
+            from matched_people m
+            where m.total_similarity >= $8
+            order by
+                m.total_similarity desc,
+                m.surname          asc,
+                m.name             asc,
+                m.patronymic       asc,
+                m.age              asc,
+                m.sex              asc,
+                m.nationality      asc
+            offset $9
+            limit $10
+        )
+    $sql$, source, candidates, conditions)
+    into page.people
+    using
+        name_, surname_, patronymic_, age_min, age_max, sex_, nationality_,
+        threshold, offset_, limit_, include_deleted;
+
+    page.current_offset := offset_;
+    page.current_limit := limit_;
+
+    return page;
+end;
+$func$
+language plpgsql
+set pg_trgm.word_similarity_threshold = 0.6;
+
+-- testing functions
+do $do$
+begin
+    if utils.in_test_environment() then
+        drop function test.test_000021_deleted_at_column();
+    end if;
+end
+$do$;
+
+commit;
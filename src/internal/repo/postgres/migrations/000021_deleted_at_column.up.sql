@@ -0,0 +1,546 @@
+begin;
+
+-- soft deletions are stored in the deleted_at column of people.people instead
+-- of people.deletions, so reading people does not need a join or a lookup.
+-- deleted_at is null unless the person is soft deleted
+alter table people.people add column deleted_at timestamptz;
+
+-- the deletions are not changes of the people, so they are not recorded
+alter table people.people disable trigger people_history;
+
+update people.people p
+set deleted_at = d.deleted_at
+from people.deletions d
+where p.person_id = d.person_id;
+
+alter table people.people enable trigger people_history;
+
+drop trigger deletions_history on people.deletions;
+drop function people.deletions_history_trigger();
+drop table people.deletions;
+
+create index people_by_deleted_at on people.people (deleted_at)
+    where deleted_at is not null;
+
+create or replace function people.is_deleted(id uuid)
+returns boolean
+as $sql$
+    select exists (
+        select 1 from people.people p
+        where p.person_id = id and p.deleted_at is not null
+    );
+$sql$
+language sql stable;
+
+-- history values are people without deleted_at: setting and clearing it
+-- are recorded as delete and restore
+create or replace function people.people_history_trigger()
+returns trigger
+as $func$
+begin
+    case tg_op
+        when 'INSERT' then
+            perform people.record_change(
+                new.person_id, 'create', null, to_jsonb(new) - 'deleted_at');
+        when 'UPDATE' then
+            if old.deleted_at is null and new.deleted_at is not null then
+                perform people.record_change(
+                    new.person_id, 'delete', to_jsonb(old) - 'deleted_at', null);
+            elsif old.deleted_at is not null and new.deleted_at is null then
+                perform people.record_change(
+                    new.person_id, 'restore', null, to_jsonb(new) - 'deleted_at');
+            elsif to_jsonb(old) - 'deleted_at' <> to_jsonb(new) - 'deleted_at' then
+                perform people.record_change(
+                    new.person_id, 'update', to_jsonb(old) - 'deleted_at', to_jsonb(new) - 'deleted_at');
+            end if;
+        when 'DELETE' then
+            perform people.record_change(
+                old.person_id, 'purge', to_jsonb(old) - 'deleted_at', null);
+    end case;
+
+    return null;
+end;
+$func$
+language plpgsql;
+
+create or replace function people.get_person(id uuid)
+returns people.people
+as $func$
+declare
+    result_ people.people;
+begin
+    if id is null then
+        raise exception 'invalid person_id: %', id
+            using errcode = 'invalid_parameter_value';
+    end if;
+
+    select p.* into result_
+    from
+        people.people p
+    where
+        p.person_id = id and p.deleted_at is null;
+
+    if not found then
+        raise exception 'person not found: %', id
+            using errcode = 'no_data_found';
+    end if;
+    return result_;
+end;
+$func$
+language plpgsql;
+
+create or replace function people.delete_person(id uuid)
+returns void
+as $func$
+declare
+    count_ int;
+begin
+    if id is null then
+        raise exception 'invalid person_id'
+            using errcode = 'invalid_parameter_value';
+    end if;
+
+    update people.people p
+    set deleted_at = now()
+    where
+        p.person_id = id and p.deleted_at is null;
+
+    get diagnostics count_ = row_count;
+    if count_ = 0 then
+        raise exception 'person with id % not found', id
+            using errcode = 'no_data_found';
+    end if;
+end;
+$func$
+language plpgsql;
+
+create or replace function people.restore_person(id uuid)
+returns void
+as $func$
+declare
+    count_ int;
+begin
+    if id is null then
+        raise exception 'invalid person_id'
+            using errcode = 'invalid_parameter_value';
+    end if;
+
+    update people.people p
+    set deleted_at = null
+    where
+        p.person_id = id and p.deleted_at is not null;
+
+    get diagnostics count_ = row_count;
+    if count_ > 0 then
+        return;
+    end if;
+
+    if exists (select 1 from people.people p where p.person_id = id) then
+        raise exception 'person with id % is not deleted', id
+            using errcode = 'object_not_in_prerequisite_state';
+    end if;
+
+    raise exception 'person with id % not found', id
+        using errcode = 'no_data_found';
+end;
+$func$
+language plpgsql;
+
+create or replace function people.purge_people(retention interval)
+returns int
+as $func$
+declare
+    count_ int;
+begin
+    if retention is null or retention < interval '0' then
+        raise exception 'invalid retention: %', retention
+            using errcode = 'invalid_parameter_value';
+    end if;
+
+    delete from people.people p
+    where
+        p.deleted_at < now() - retention;
+
+    get diagnostics count_ = row_count;
+    return count_;
+end;
+$func$
+language plpgsql;
+
+create or replace function people.count_people()
+returns table (
+    active  bigint,
+    deleted bigint
+)
+as $sql$
+    select
+        count(*) filter (where p.deleted_at is null),
+        count(p.deleted_at)
+    from
+        people.people p;
+$sql$
+language sql stable;
+
+-- people of the page have deleted_at
+create or replace function people.list_people(
+    name_           text       default null,
+    surname_        text       default null,
+    patronymic_     text       default null,
+    age_min         int        default null,
+    age_max         int        default null,
+    sex_            people.sex default null,
+    nationality_    char(2)    default null,
+    threshold       real       default 0,
+    offset_         int        default 0,
+    limit_          int        default null,
+    include_deleted boolean    default false,
+    match_          text       default 'trigram',
+    total_          boolean    default true
+)
+returns people.people_page
+as $func$
+declare
+    page       people.people_page;
+    -- sum of similarities of the name fields required to reach threshold
+    required   double precision;
+    -- number of name fields compared by similarity
+    fields     int;
+    -- people joined with their normalized names in the phonetic and
+    -- fulltext modes
+    source     text := 'people.people p';
+    -- conditions of the filters, $n are the arguments
+    conditions text := $sql$
+        (($4 is null) or ($4 <= p.age))         and
+        (($5 is null) or ($5 >= p.age))         and
+        (($7 is null) or ($7 =  p.nationality)) and
+        (($6 is null) or ($6 =  p.sex))         and
+        (($3 is null) or (($3 = '') = (p.patronymic = ''))) and
+        (coalesce($11, false) or p.deleted_at is null)
+    $sql$;
+    -- people that can reach the threshold
+    candidates text := 'true';
+begin
+    if threshold is null then
+        threshold := 0;
+    end if;
+
+    case coalesce(match_, 'trigram')
+        when 'trigram' then
+            required := 3.0 * threshold - (patronymic_ is not distinct from '')::int;
+            fields   := (name_       is not null)::int
+                      + (surname_    is not null)::int
+                      + (coalesce(patronymic_, '') <> '')::int;
+
+            if fields > 0 and required > 0 then
+                -- the margin covers rounding of the real similarities
+                perform set_config(
+                    'pg_trgm.word_similarity_threshold',
+                    greatest(least(required / fields - 1e-6, 1), 0)::text,
+                    true
+                );
+
+                candidates := '(' || concat_ws(' or ',
+                    case when name_       is not null then '$1 <% p.name'       end,
+                    case when surname_    is not null then '$2 <% p.surname'    end,
+                    case when patronymic_ <> ''       then '$3 <% p.patronymic' end
+                ) || ')';
+            end if;
+        when 'phonetic' then
+            source     := source || ' join people.name_keys k using (person_id)';
+            conditions := concat_ws(' and ', conditions,
+                case when name_       is not null then 'k.name_phonetic       && people.phonetic($1)' end,
+                case when surname_    is not null then 'k.surname_phonetic    && people.phonetic($2)' end,
+                case when patronymic_ <> ''       then 'k.patronymic_phonetic && people.phonetic($3)' end
+            );
+            threshold  := 0;
+        when 'fulltext' then
+            source     := source || ' join people.name_keys k using (person_id)';
+            conditions := concat_ws(' and ', conditions,
+                case when name_ is not null then $$
+                    people.latin($1) <> '' and
+                    to_tsvector('simple', k.name_latin) @@ plainto_tsquery('simple', people.latin($1))
+                $$ end,
+                case when surname_ is not null then $$
+                    people.latin($2) <> '' and
+                    to_tsvector('simple', k.surname_latin) @@ plainto_tsquery('simple', people.latin($2))
+                $$ end,
+                case when patronymic_ <> '' then $$
+                    people.latin($3) <> '' and
+                    to_tsvector('simple', k.patronymic_latin) @@ plainto_tsquery('simple', people.latin($3))
+                $$ end
+            );
+            threshold  := 0;
+        else
+            raise exception 'invalid match: %', match_
+                using errcode = 'invalid_parameter_value';
+    end case;
+
+    if coalesce(total_, true) then
+        execute format('select count(*) from %s where %s', source, conditions)
+        into page.total
+        using
+            name_, surname_, patronymic_, age_min, age_max, sex_, nationality_,
+            threshold, offset_, limit_, include_deleted;
+    end if;
+
+    execute format($sql$
+        with matched_people as (
+            select
+                p.person_id,
+                p.name,
+                p.surname,
+                p.patronymic,
+                p.age,
+                p.sex,
+                p.nationality,
+                p.deleted_at,
+                (
+                    (case
+                        when $1 is null then 0.0
+                        else word_similarity($1, p.name)
+                    end)
+                    +
+                    (case
+                        when $2 is null then 0.0
+                        else word_similarity($2, p.surname)
+                    end)
+                    +
+                    (case
+                        when $3 is null
+                            then 0
+                        when ($3 = '' or p.patronymic = '')
+                            then ($3 = p.patronymic)::int
+                        else word_similarity($3, p.patronymic)
+                    end)
+                ) / 3.0 as total_similarity
+            from %s
+            where %s and %s
+        )
+        select array(
+            select (
+                m.person_id, m.name, m.surname,
+                m.patronymic, m.age, m.sex, m.nationality,
+                m.deleted_at
+            )::people.people
+            from matched_people m
+            where m.total_similarity >= $8
+            order by
+                m.total_similarity desc,
+                m.surname          asc,
+                m.name             asc,
+                m.patronymic       asc,
+                m.age              asc,
+                m.sex              asc,
+                m.nationality      asc
+            offset $9
+            limit $10
+        )
+    $sql$, source, candidates, conditions)
+    into page.people
+    using
+        name_, surname_, patronymic_, age_min, age_max, sex_, nationality_,
+        threshold, offset_, limit_, include_deleted;
+
+    page.current_offset := offset_;
+    page.current_limit := limit_;
+
+    return page;
+end;
+$func$
+language plpgsql
+set pg_trgm.word_similarity_threshold = 0.6;
+
+
+-- testing functions
+do $do$
+begin
+    if not utils.in_test_environment() then
+        return;
+    end if;
+
+    -- people.people has a new column and people.deletions is dropped
+    drop function test.test_000002_people_table_columns();
+    drop function test.test_000010_soft_delete();
+    drop function test.test_000011_people_history();
+
+    create function test.test_000021_deleted_at_column()
+        returns setof text as $test$
+        declare
+            person people.people;
+            other  people.people;
+            page   people.people_page;
+        begin
+            return next columns_are('people', 'people', array[
+                'person_id', 'name', 'surname', 'patronymic',
+                'age', 'sex', 'nationality', 'deleted_at'
+            ]);
+            return next col_type_is('people', 'people', 'deleted_at', 'timestamp with time zone');
+            return next col_is_null('people', 'people', 'deleted_at');
+            return next hasnt_table('people', 'deletions', 'has no deletions table');
+
+            person := (gen_random_uuid(), 'Name', 'Surname', 'Patronymic', 42, 'male', 'AA', null);
+            other  := (gen_random_uuid(), 'Other', 'Surname', '', 24, 'female', 'BB', null);
+
+            insert into people.people select person.*;
+            insert into people.people select other.*;
+
+            return next throws_ok(
+                $$select people.delete_person(NULL)$$,
+                'invalid person_id',
+                'delete throws on null id'
+            );
+
+            return next throws_like(
+                $$select people.delete_person(gen_random_uuid())$$,
+                'person with id % not found',
+                'delete throws on not found'
+            );
+
+            return next lives_ok(
+                format($$select people.delete_person(%L)$$, person.person_id),
+                'can delete existing person'
+            );
+
+            return next is(
+                (select deleted_at from people.people where person_id = person.person_id),
+                now(),
+                'sets deleted_at'
+            );
+
+            return next is(
+                people.is_deleted(person.person_id),
+                true,
+                'marks the person as deleted'
+            );
+
+            return next throws_like(
+                format($$select people.delete_person(%L)$$, person.person_id),
+                'person with id % not found',
+                'can not delete twice'
+            );
+
+            return next throws_like(
+                format($$select people.get_person(%L)$$, person.person_id),
+                'person not found: %',
+                'get does not find deleted people'
+            );
+
+            return next throws_like(
+                format($$select people.update_person(%L, name_ => 'New')$$, person.person_id),
+                'person with id % not found',
+                'update does not find deleted people'
+            );
+
+            return next throws_like(
+                format(
+                    $$select people.patch_person(%L,
+                        '[{"op": "remove", "path": "/patronymic"}]')$$,
+                    person.person_id
+                ),
+                'person with id % not found',
+                'patch does not find deleted people'
+            );
+
+            return next is(
+                (people.list_people()).total,
+                1,
+                'list excludes deleted people by default'
+            );
+
+            page := people.list_people(include_deleted => true);
+
+            return next is(
+                page.total,
+                2,
+                'list includes deleted people on request'
+            );
+
+            return next is(
+                (select p.deleted_at from unnest(page.people) p where p.person_id = person.person_id),
+                now(),
+                'list returns deleted_at of deleted people'
+            );
+
+            return next row_eq(
+                $$select * from people.count_people()$$,
+                row(1::bigint, 1::bigint),
+                'counts active and deleted people'
+            );
+
+            return next throws_ok(
+                format($$select people.restore_person(%L)$$, other.person_id),
+                '55000',
+                null,
+                'restore throws on a person that is not deleted'
+            );
+
+            return next throws_like(
+                $$select people.restore_person(gen_random_uuid())$$,
+                'person with id % not found',
+                'restore throws on not found'
+            );
+
+            return next lives_ok(
+                format($$select people.restore_person(%L)$$, person.person_id),
+                'can restore a deleted person'
+            );
+
+            return next is(
+                people.get_person(person.person_id),
+                person,
+                'restored person can be found'
+            );
+
+            perform people.delete_person(person.person_id);
+            update people.people
+            set deleted_at = now() - interval '2 days'
+            where person_id = person.person_id;
+
+            perform people.delete_person(other.person_id);
+
+            return next is(
+                (select array_agg(h.operation order by h.history_id)
+                 from people.people_history h where h.person_id = person.person_id),
+                array['create', 'delete', 'restore', 'delete']::people.change_operation[],
+                'records deletions and restorations but not changes of deleted_at'
+            );
+
+            return next is(
+                (select count(*)::int from people.people_history h
+                 where h.person_id = person.person_id
+                   and (h.old_value ? 'deleted_at' or h.new_value ? 'deleted_at')),
+                0,
+                'history values have no deleted_at'
+            );
+
+            return next throws_like(
+                $$select people.purge_people(interval '-1 day')$$,
+                'invalid retention: %',
+                'purge throws on negative retention'
+            );
+
+            return next is(
+                people.purge_people(interval '1 day'),
+                1,
+                'purges people deleted before the retention period'
+            );
+
+            return next is(
+                (select array_agg(person_id) from people.people),
+                array[other.person_id],
+                'keeps people deleted within the retention period'
+            );
+
+            return next is(
+                (select h.operation from people.people_history h
+                 where h.person_id = person.person_id
+                 order by h.history_id desc limit 1),
+                'purge'::people.change_operation,
+                'records the purge'
+            );
+        end;
+    $test$
+    language plpgsql;
+end
+$do$;
+commit;
@@ -2,12 +2,17 @@ package postgres
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"sync/atomic"
+	"time"
 
 	"github.com/Hofsiedge/person-api/internal/config"
 	"github.com/Hofsiedge/person-api/internal/domain"
 	"github.com/Hofsiedge/person-api/internal/repo"
+	"github.com/Hofsiedge/person-api/internal/reqctx"
+	"github.com/Hofsiedge/person-api/internal/tracing"
 	"github.com/google/uuid"
 	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5"
@@ -16,42 +21,74 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-// pgxpool.Pool / pgxmock.PgxPoolIface
-type PgxPoolInterface interface {
+// pgxpool.Pool / pgxmock.PgxPoolIface / pgx.Tx
+type querier interface {
 	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
 	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
 	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// pgxpool.Pool / pgxmock.PgxPoolIface
+type PgxPoolInterface interface {
+	querier
+	Begin(ctx context.Context) (pgx.Tx, error)
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
+	Ping(ctx context.Context) error
 	Close()
 }
 
+// custom types registered for every connection
+//
+//nolint:gochecknoglobals
+var customTypes = []string{
+	"people.sex",
+	"people.sex[]",
+	"people.people",
+	"people.people[]",
+	"people.people_page",
+	"people.change_operation",
+	"people.people_history",
+	"people.people_history[]",
+	"people.people_history_page",
+	"people.audit_log",
+	"people.audit_log[]",
+	"people.audit_page",
+}
+
 type People struct {
 	db PgxPoolInterface
+	// read replicas, see reader
+	replicas []*replica
+	// replicas lagging more are not read from
+	maxLag time.Duration
+	// the replica to read from next
+	next atomic.Uint64
 }
 
 // this function should not be used outside tests
 func PeopleFromPgxPoolInterface(db PgxPoolInterface) *People {
-	return &People{db}
+	return &People{db: db, replicas: nil, maxLag: 0, next: atomic.Uint64{}}
 }
 
-func New(cfg config.PostgresConfig) (*People, error) {
-	poolConfig, err := pgxpool.ParseConfig(cfg.ConnString)
+// errNoConnString is returned if the connection string is not set
+// (it is optional in the config for the memory storage)
+var errNoConnString = fmt.Errorf("%w: the connection string (DB_CONN) is not set", repo.ErrArgument)
+
+// newPool returns a pool of connections with the custom types registered
+func newPool(connString string) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(connString)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %w", repo.ErrArgument, err)
 	}
 
+	poolConfig.ConnConfig.Tracer = tracing.QueryTracer{}
+
 	// register custom types
 	poolConfig.AfterConnect = func(ctx context.Context, conn *pgx.Conn) error {
-		customTypes := []string{
-			"people.sex",
-			"people.sex[]",
-			"people.people",
-			"people.people[]",
-			"people.people_page",
-		}
 		for _, typeName := range customTypes {
 			dataType, loadErr := conn.LoadType(ctx, typeName)
 			if loadErr != nil {
-				return err //nolint:wrapcheck
+				return loadErr //nolint:wrapcheck
 			}
 
 			conn.TypeMap().RegisterType(dataType)
@@ -65,15 +102,71 @@ func New(cfg config.PostgresConfig) (*People, error) {
 		return nil, fmt.Errorf("%w: %w", repo.ErrConnect, err)
 	}
 
-	return &People{pool}, nil
+	return pool, nil
+}
+
+// New connects to the primary and the replicas of cfg. Replicas are not
+// read from until CheckReplicas finds them healthy.
+func New(cfg config.PostgresConfig) (*People, error) {
+	if cfg.ConnString == "" {
+		return nil, errNoConnString
+	}
+
+	pool, err := newPool(cfg.ConnString)
+	if err != nil {
+		return nil, err
+	}
+
+	people := &People{db: pool, replicas: nil, maxLag: cfg.ReplicaMaxLag, next: atomic.Uint64{}}
+
+	for _, connString := range cfg.Replicas {
+		replicaPool, replicaErr := newPool(connString)
+		if replicaErr != nil {
+			people.Close()
+
+			return nil, fmt.Errorf("replica: %w", replicaErr)
+		}
+
+		connConfig := replicaPool.Config().ConnConfig
+		people.replicas = append(people.replicas,
+			newReplica(replicaPool, fmt.Sprintf("%s:%d", connConfig.Host, connConfig.Port)))
+	}
+
+	return people, nil
 }
 
 func (p *People) Close() {
 	p.db.Close()
+
+	for _, replica := range p.replicas {
+		replica.db.Close()
+	}
+}
+
+// Stat returns connection pool statistics or nil if the repo
+// is not backed by a pgxpool.Pool
+func (p *People) Stat() *pgxpool.Stat {
+	if pool, ok := p.db.(*pgxpool.Pool); ok {
+		return pool.Stat()
+	}
+
+	return nil
+}
+
+// Count returns the number of active and deleted (not purged) people
+func (p *People) Count(ctx context.Context) (int, int, error) {
+	var active, deleted int64
+
+	row := conn(ctx, p.db).QueryRow(ctx, `select * from people.count_people()`)
+	if err := row.Scan(&active, &deleted); err != nil {
+		return 0, 0, wrapPostgresError(err)
+	}
+
+	return int(active), int(deleted), nil
 }
 
 // ensure that People implements repo.PersonRepo
-var _ repo.PersonRepo = &People{nil}
+var _ repo.PersonRepo = &People{db: nil, replicas: nil, maxLag: 0, next: atomic.Uint64{}}
 
 func wrapPostgresError(err error) error {
 	var pgErr *pgconn.PgError
@@ -85,27 +178,90 @@ func wrapPostgresError(err error) error {
 			return fmt.Errorf("%w: %w", repo.ErrNotFound, err)
 		case pgerrcode.InvalidParameterValue:
 			return fmt.Errorf("%w: %w", repo.ErrArgument, err)
+		case pgerrcode.AssertFailure, pgerrcode.ObjectNotInPrerequisiteState, pgerrcode.UniqueViolation,
+			pgerrcode.SerializationFailure, pgerrcode.DeadlockDetected:
+			return fmt.Errorf("%w: %w", repo.ErrConflict, err)
 		}
 	}
 
 	return fmt.Errorf("%w: %w", repo.ErrUnexpected, err)
 }
 
+// modify runs fn with the actor, the client IP and the request ID of ctx
+// set for the transaction - they are recorded in people.people_history and
+// people.audit_log by triggers within the same transaction.
+// Within a transaction of ctx (see WithinTx) fn is run in a savepoint,
+// so that a failed statement does not abort the whole transaction.
+// Otherwise fn is run without a transaction if there is nothing to set.
+func (p *People) modify(ctx context.Context, fn func(db querier) error) error {
+	actor, clientIP, requestID := reqctx.Actor(ctx), reqctx.ClientIP(ctx), reqctx.RequestID(ctx)
+
+	var db beginner = p.db
+	if tx, found := ctx.Value(txKey{}).(pgx.Tx); found {
+		db = tx
+	} else if actor == "" && clientIP == "" && requestID == "" {
+		return fn(p.db)
+	}
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return wrapPostgresError(err)
+	}
+
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	_, err = tx.Exec(ctx, `select
+		set_config('people.actor', $1, true), set_config('people.request_id', $2, true),
+		set_config('people.client_ip', $3, true)`,
+		actor, requestID, clientIP)
+	if err != nil {
+		return wrapPostgresError(err)
+	}
+
+	if err = fn(tx); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return wrapPostgresError(err)
+	}
+
+	return nil
+}
+
+// exec executes a modifying statement (see modify)
+func (p *People) exec(ctx context.Context, sql string, args ...any) error {
+	return p.modify(ctx, func(db querier) error {
+		if _, err := db.Exec(ctx, sql, args...); err != nil {
+			return wrapPostgresError(err)
+		}
+
+		return nil
+	})
+}
+
 // Create implements repo.PersonRepo.
 func (p *People) Create(ctx context.Context, person domain.Person) (uuid.UUID, error) {
 	var personID pgtype.UUID
 
-	row := p.db.QueryRow(ctx, `
-		select people.create_person(
-			name_ => $1, surname_ => $2, patronymic_ => $3,
-			age_ => $4, sex_ => $5, nationality_ => $6)
-		`,
-		person.Name, person.Surname, person.Patronymic,
-		person.Age, person.Sex, person.Nationality,
-	)
+	err := p.modify(ctx, func(db querier) error {
+		row := db.QueryRow(ctx, `
+			select people.create_person(
+				name_ => $1, surname_ => $2, patronymic_ => $3,
+				age_ => $4, sex_ => $5, nationality_ => $6)
+			`,
+			person.Name, person.Surname, person.Patronymic,
+			person.Age, person.Sex, person.Nationality,
+		)
+
+		if err := row.Scan(&personID); err != nil {
+			return wrapPostgresError(err)
+		}
 
-	if err := row.Scan(&personID); err != nil {
-		return uuid.UUID{}, wrapPostgresError(err)
+		return nil
+	})
+	if err != nil {
+		return uuid.UUID{}, err
 	}
 
 	if !personID.Valid {
@@ -118,12 +274,7 @@ func (p *People) Create(ctx context.Context, person domain.Person) (uuid.UUID, e
 
 // Delete implements repo.PersonRepo.
 func (p *People) Delete(ctx context.Context, id uuid.UUID) error {
-	_, err := p.db.Exec(ctx, `select people.delete_person($1)`, id)
-	if err != nil {
-		return wrapPostgresError(err)
-	}
-
-	return nil
+	return p.exec(ctx, `select people.delete_person($1)`, id)
 }
 
 // FullUpdate implements repo.PersonRepo.
@@ -140,7 +291,7 @@ func (p *People) FullUpdate(ctx context.Context, id uuid.UUID, replacement domai
 
 // GetByID implements repo.PersonRepo.
 func (p *People) GetByID(ctx context.Context, id uuid.UUID) (domain.Person, error) {
-	rows, err := p.db.Query(ctx, `select * from people.get_person($1)`, id)
+	rows, err := p.reader(ctx).Query(ctx, `select * from people.get_person($1)`, id)
 	if err != nil {
 		return domain.Person{}, wrapPostgresError(err)
 	}
@@ -157,13 +308,20 @@ func (p *People) GetByID(ctx context.Context, id uuid.UUID) (domain.Person, erro
 func (p *People) List(
 	ctx context.Context, filter domain.PersonFilter, pagination domain.PaginationFilter,
 ) (domain.Page[domain.Person], error) {
-	row := p.db.QueryRow(ctx, `select people.list_people(
+	match := filter.Match
+	if match == "" {
+		match = domain.MatchTrigram
+	}
+
+	row := p.reader(ctx).QueryRow(ctx, `select people.list_people(
 			name_ => $1, surname_ => $2, patronymic_ => $3, age_min => $4,
 			age_max => $5, sex_ => $6, nationality_ => $7, threshold => $8,
-			offset_ => $9, limit_ => $10)`,
+			offset_ => $9, limit_ => $10, include_deleted => $11, match_ => $12,
+			total_ => $13)`,
 		filter.Name, filter.Surname, filter.Patronymic, filter.AgeMin,
 		filter.AgeMax, filter.Sex, filter.Nationality, filter.Threshold,
-		pagination.Offset, pagination.Limit,
+		pagination.Offset, pagination.Limit, filter.IncludeDeleted, match,
+		!pagination.WithoutTotal,
 	)
 
 	var page PersonPage
@@ -178,15 +336,83 @@ func (p *People) List(
 
 // PartialUpdate implements repo.PersonRepo.
 func (p *People) PartialUpdate(ctx context.Context, id uuid.UUID, partial domain.PersonPartial) error {
-	_, err := p.db.Exec(ctx, `select people.update_person(
+	return p.exec(ctx, `select people.update_person(
 			id => $1, name_ => $2, surname_ => $3, patronymic_ => $4,
 			age_ => $5, sex_ => $6, nationality_ => $7)`,
 		id, partial.Name, partial.Surname, partial.Patronymic,
 		partial.Age, partial.Sex, partial.Nationality,
 	)
+}
+
+// Patch implements repo.PersonRepo.
+func (p *People) Patch(ctx context.Context, id uuid.UUID, operations []domain.PatchOperation) error {
+	// validate before sending to reject invalid values early
+	// (people.patch_person does not know the domain types)
+	for _, operation := range operations {
+		if err := operation.Validate(); err != nil {
+			return fmt.Errorf("%w: %w", repo.ErrArgument, err)
+		}
+	}
+
+	patch, err := json.Marshal(ToPatch(operations))
 	if err != nil {
-		return wrapPostgresError(err)
+		return fmt.Errorf("%w: %w", repo.ErrArgument, err)
 	}
 
-	return nil
+	return p.exec(ctx, `select people.patch_person(id => $1, operations => $2)`, id, patch)
+}
+
+// Restore implements repo.PersonRepo.
+func (p *People) Restore(ctx context.Context, id uuid.UUID) error {
+	return p.exec(ctx, `select people.restore_person($1)`, id)
+}
+
+// Purge implements repo.PersonRepo.
+func (p *People) Purge(ctx context.Context, retention time.Duration) (int, error) {
+	var purged int
+
+	err := p.modify(ctx, func(db querier) error {
+		row := db.QueryRow(ctx, `select people.purge_people($1)`, retention)
+		if err := row.Scan(&purged); err != nil {
+			return wrapPostgresError(err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return purged, nil
+}
+
+// GetAsOf implements repo.PersonRepo.
+func (p *People) GetAsOf(ctx context.Context, id uuid.UUID, moment time.Time) (domain.Person, error) {
+	rows, err := p.reader(ctx).Query(ctx, `select * from people.get_person_as_of($1, $2)`, id, moment)
+	if err != nil {
+		return domain.Person{}, wrapPostgresError(err)
+	}
+
+	person, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[Person])
+	if err != nil {
+		return domain.Person{}, wrapPostgresError(err)
+	}
+
+	return person.ToAbstract(), nil
+}
+
+// History implements repo.PersonRepo.
+func (p *People) History(
+	ctx context.Context, id uuid.UUID, pagination domain.PaginationFilter,
+) (domain.Page[domain.PersonChange], error) {
+	row := p.reader(ctx).QueryRow(ctx, `select people.person_history(id => $1, offset_ => $2, limit_ => $3)`,
+		id, pagination.Offset, pagination.Limit)
+
+	var page PersonHistoryPage
+
+	if err := row.Scan(&page); err != nil {
+		return domain.Page[domain.PersonChange]{}, wrapPostgresError(err)
+	}
+
+	return page.ToAbstract(), nil
 }
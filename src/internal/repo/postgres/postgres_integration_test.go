@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"flag"
+	"fmt"
 	"log"
 	"os"
 	"reflect"
@@ -12,8 +13,11 @@ import (
 	"github.com/Hofsiedge/person-api/internal/config"
 	"github.com/Hofsiedge/person-api/internal/domain"
 	"github.com/Hofsiedge/person-api/internal/repo"
+	"github.com/Hofsiedge/person-api/internal/repo/memory"
 	"github.com/Hofsiedge/person-api/internal/repo/postgres"
+	"github.com/Hofsiedge/person-api/internal/repo/repotest"
 	"github.com/Hofsiedge/person-api/internal/utils"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 //nolint:funlen,cyclop
@@ -22,7 +26,8 @@ func TestIntegration(t *testing.T) {
 		t.SkipNow()
 	}
 
-	t.Parallel()
+	// not parallel: the tables are emptied for the total of the list
+	truncatePeople(t)
 
 	person := utils.MakePerson()
 
@@ -86,6 +91,27 @@ func TestIntegration(t *testing.T) {
 		}
 	}
 
+	// restore
+	if err = people.Restore(context.Background(), person.ID); err != nil {
+		t.Errorf("could not restore a Person: %v", err)
+	} else if _, err = people.GetByID(context.Background(), person.ID); err != nil {
+		t.Errorf("the Person was not restored. error: %v", err)
+	}
+
+	if err = people.Delete(context.Background(), person.ID); err != nil {
+		t.Errorf("could not delete a restored Person: %v", err)
+	}
+
+	// history: create, replace, update, delete, restore, delete
+	history, err := people.History(context.Background(), person.ID,
+		domain.PaginationFilter{Offset: 0, Limit: 1})
+	if err != nil {
+		t.Errorf("could not get the history of a Person: %v", err)
+	} else if history.TotalItems != 6 || len(history.Items) != 1 ||
+		history.Items[0].Operation != domain.ChangeDelete {
+		t.Errorf("history mismatch: got %v", history)
+	}
+
 	// list
 	for i := 0; i < 10; i++ {
 		person = utils.MakePerson()
@@ -106,14 +132,284 @@ func TestIntegration(t *testing.T) {
 	}
 }
 
+// emptyPeople returns the repo with the tables of people emptied before
+// and after a test of the conformance suite. The tests using the tables are
+// not parallel, so they do not empty them for each other.
+func emptyPeople(t *testing.T) repo.PersonRepo {
+	t.Helper()
+
+	truncatePeople(t)
+
+	return people
+}
+
+// truncatePeople empties the tables of people now and after the test
+func truncatePeople(tb testing.TB) {
+	tb.Helper()
+
+	truncate := func() {
+		_, err := pool.Exec(context.Background(), `truncate
+			people.people, people.people_history, people.audit_log cascade`)
+		if err != nil {
+			tb.Fatalf("could not empty the tables: %v", err)
+		}
+	}
+
+	truncate()
+	tb.Cleanup(truncate)
+}
+
+func TestConformance(t *testing.T) {
+	if !runIntegrationTests {
+		t.SkipNow()
+	}
+
+	repotest.RunConformance(t, emptyPeople)
+}
+
+// TestDifferential compares the repo with the in-memory one
+func TestDifferential(t *testing.T) {
+	if !runIntegrationTests {
+		t.SkipNow()
+	}
+
+	repotest.RunDifferential(t, func(*testing.T) repo.PersonRepo { return memory.NewPeople() }, emptyPeople)
+}
+
+// generatePeople inserts count generated people, which are removed after
+// the benchmark, and returns one of them. The names are random strings of
+// 16 letters, a quarter of patronymics is empty and a hundredth of people
+// is deleted.
+func generatePeople(b *testing.B, count int) domain.Person {
+	b.Helper()
+
+	truncatePeople(b)
+
+	_, err := pool.Exec(context.Background(), `
+		insert into people.people (name, surname, patronymic, age, sex, nationality)
+		select
+			initcap(translate(substr(h, 1, 6), '0123456789', 'ghijklmnop')),
+			initcap(translate(substr(h, 7, 8), '0123456789', 'ghijklmnop')),
+			case
+				when i % 4 = 0 then ''
+				else initcap(translate(substr(h, 15, 10), '0123456789', 'ghijklmnop'))
+			end,
+			i % 100,
+			(array['male', 'female'])[i % 2 + 1]::people.sex,
+			(array['RU', 'BY', 'KZ', 'UA'])[i % 4 + 1]
+		from generate_series(1, $1) i, md5(i::text) h`, count)
+	if err != nil {
+		b.Fatalf("could not generate people: %v", err)
+	}
+
+	_, err = pool.Exec(context.Background(), `
+		update people.people set deleted_at = now() where age = 0`)
+	if err != nil {
+		b.Fatalf("could not delete people: %v", err)
+	}
+
+	if _, err = pool.Exec(context.Background(), `analyze people.people`); err != nil {
+		b.Fatalf("could not analyze people: %v", err)
+	}
+
+	var person domain.Person
+
+	err = pool.QueryRow(context.Background(), `
+		select name, surname, patronymic from people.people
+		where patronymic <> '' and age <> 0 limit 1`).Scan(&person.Name, &person.Surname, &person.Patronymic)
+	if err != nil {
+		b.Fatalf("could not get a generated person: %v", err)
+	}
+
+	return person
+}
+
+// listPeopleBefore000018 is list_people of 000010_soft_delete, which
+// scored every person, with deleted_at of 000021_deleted_at_column. It is
+// created by BenchmarkListPeople for comparison
+const listPeopleBefore000018 = `
+	create function people.list_people_before_000018(
+		name_           text       default null,
+		surname_        text       default null,
+		patronymic_     text       default null,
+		age_min         int        default null,
+		age_max         int        default null,
+		sex_            people.sex default null,
+		nationality_    char(2)    default null,
+		threshold       real       default 0,
+		offset_         int        default 0,
+		limit_          int        default null,
+		include_deleted boolean    default false
+	)
+	returns people.people_page
+	as $func$
+	declare
+		count_ int;
+		page   people.people_page;
+	begin
+		if threshold is null then
+			threshold := 0;
+		end if;
+
+		with matched_people as (
+			select
+				p.person_id,
+				p.name,
+				p.surname,
+				p.patronymic,
+				p.age,
+				p.sex,
+				p.nationality,
+				p.deleted_at,
+				(
+					(case
+						when name_ is null then 0.0
+						else word_similarity(name_, p.name)
+					end)
+					+
+					(case
+						when surname_ is null then 0.0
+						else word_similarity(surname_, p.surname)
+					end)
+					+
+					(case
+						when patronymic_ is null
+							then 0
+						when (patronymic_ = '' or p.patronymic = '')
+							then (patronymic_ = p.patronymic)::int
+						else word_similarity(patronymic_, p.patronymic)
+					end)
+				) / 3.0 as total_similarity
+			from people.people p
+			where
+				((age_min      is null) or (age_min      <= p.age))         and
+				((age_max      is null) or (age_max      >= p.age))         and
+				((nationality_ is null) or (nationality_ =  p.nationality)) and
+				((sex_         is null) or (sex_         =  p.sex))         and
+				((patronymic_  is null) or ((patronymic_ = '') = (p.patronymic = ''))) and
+				(coalesce(include_deleted, false) or not people.is_deleted(p.person_id))
+		)
+		select into page.people, page.total
+			array(
+				select (
+					m.person_id, m.name, m.surname,
+					m.patronymic, m.age, m.sex, m.nationality,
+					m.deleted_at
+				)::people.people
+				from matched_people m
+				where m.total_similarity >= threshold
+				order by
+					m.total_similarity desc,
+					m.surname          asc,
+					m.name             asc,
+					m.patronymic       asc,
+					m.age              asc,
+					m.sex              asc,
+					m.nationality      asc
+				offset offset_
+				limit limit_
+			),
+			count(*)
+		from matched_people;
+
+		page.current_offset := offset_;
+		page.current_limit := limit_;
+
+		return page;
+	end;
+	$func$
+	language plpgsql;
+`
+
+// BenchmarkListPeople compares list_people before 000018_indexed_list_people
+// (scoring every person and counting them) with the current one, which
+// finds the candidates with the trigram indexes, with and without the total.
+// Run it with
+//
+//	go test ./internal/repo/postgres -integration -run '^$' -bench ListPeople
+func BenchmarkListPeople(b *testing.B) {
+	if !runIntegrationTests {
+		b.SkipNow()
+	}
+
+	person := generatePeople(b, benchmarkPeople)
+
+	searches := []struct {
+		name string
+		// arguments of list_people, $n are args
+		arguments string
+		args      []any
+	}{
+		{
+			name:      "name",
+			arguments: `name_ => $1, threshold => 0.3, limit_ => 20`,
+			args:      []any{person.Name},
+		},
+		{
+			name:      "full name",
+			arguments: `name_ => $1, surname_ => $2, patronymic_ => $3, threshold => 0.6, limit_ => 20`,
+			args:      []any{person.Name, person.Surname, person.Patronymic},
+		},
+	}
+
+	functions := []struct {
+		name  string
+		query string
+	}{
+		{name: "before 000018", query: `select cardinality((people.list_people_before_000018(%s)).people)`},
+		{name: "indexed", query: `select cardinality((people.list_people(%s)).people)`},
+		{name: "indexed without total", query: `select cardinality((people.list_people(%s, total_ => false)).people)`},
+	}
+
+	for _, search := range searches {
+		search := search
+
+		for _, function := range functions {
+			function := function
+
+			b.Run(search.name+"/"+function.name, func(b *testing.B) {
+				ctx := context.Background()
+
+				// the old function is rolled back with the transaction
+				tx, err := pool.Begin(ctx)
+				if err != nil {
+					b.Fatalf("could not begin a transaction: %v", err)
+				}
+				defer tx.Rollback(ctx) //nolint:errcheck
+
+				if _, err = tx.Exec(ctx, listPeopleBefore000018); err != nil {
+					b.Fatalf("could not create the old list_people: %v", err)
+				}
+
+				query := fmt.Sprintf(function.query, search.arguments)
+
+				var found int
+
+				b.ResetTimer()
+
+				for i := 0; i < b.N; i++ {
+					if err = tx.QueryRow(ctx, query, search.args...).Scan(&found); err != nil {
+						b.Fatalf("could not list people: %v", err)
+					}
+				}
+			})
+		}
+	}
+}
+
 //nolint:gochecknoglobals
 var (
 	runIntegrationTests bool
-	people              repo.PersonRepo
+	// number of people generated by benchmarks
+	benchmarkPeople int
+	people          repo.PersonRepo
+	// connections emptying the tables
+	pool *pgxpool.Pool
 )
 
 func TestMain(m *testing.M) {
 	flag.BoolVar(&runIntegrationTests, "integration", false, "run integration tests or not")
+	flag.IntVar(&benchmarkPeople, "people", 1_000_000, "number of people generated by benchmarks")
 	flag.Parse()
 
 	if runIntegrationTests {
@@ -126,6 +422,11 @@ func TestMain(m *testing.M) {
 		if err != nil {
 			log.Fatal(err)
 		}
+
+		pool, err = pgxpool.New(context.Background(), cfg.ConnString)
+		if err != nil {
+			log.Fatal(err)
+		}
 	}
 
 	os.Exit(m.Run())
@@ -3,15 +3,24 @@ package postgres_test
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io/fs"
+	"path"
 	"reflect"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/Hofsiedge/person-api/internal/domain"
 	"github.com/Hofsiedge/person-api/internal/repo"
 	"github.com/Hofsiedge/person-api/internal/repo/postgres"
+	"github.com/Hofsiedge/person-api/internal/reqctx"
 	"github.com/Hofsiedge/person-api/internal/utils"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
 	"github.com/google/uuid"
 	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/pashagolub/pgxmock/v3"
@@ -188,11 +197,11 @@ func TestGet(t *testing.T) {
 					WillReturnRows(
 						mock.NewRows([]string{
 							"person_id", "name", "surname", "patronymic",
-							"age", "sex", "nationality",
+							"age", "sex", "nationality", "deleted_at",
 						}).AddRow(
 							pgPerson.PersonID, pgPerson.Name, pgPerson.Surname,
 							pgPerson.Patronymic, pgPerson.Age, pgPerson.Sex,
-							pgPerson.Nationality,
+							pgPerson.Nationality, pgPerson.DeletedAt,
 						),
 					)
 			},
@@ -263,6 +272,52 @@ func TestDelete(t *testing.T) {
 	testProcedure[uuid.UUID](t, testCases, wrapper)
 }
 
+func TestModifyWithRequestContext(t *testing.T) {
+	t.Parallel()
+
+	personID := uuid.New()
+	ctx := reqctx.WithRequestID(reqctx.WithActor(context.Background(), "admin"), "request-1")
+	ctx = reqctx.WithClientIP(ctx, "192.0.2.1")
+
+	//nolint:exhaustruct
+	testCases := []testCaseData[uuid.UUID, struct{}]{
+		{
+			name: "actor, request id and client ip are set for the transaction",
+			setExpectations: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`^select\s+set_config`).
+					WithArgs("admin", "request-1", "192.0.2.1").
+					WillReturnResult(pgxmock.NewResult("SELECT", 1))
+				mock.ExpectExec(`^select people.delete_person`).
+					WithArgs(personID).
+					WillReturnResult(pgxmock.NewResult("SELECT", 1))
+				mock.ExpectCommit()
+			},
+			input: personID,
+		},
+		{
+			name: "transaction is rolled back on error",
+			setExpectations: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`^select\s+set_config`).
+					WithArgs("admin", "request-1", "192.0.2.1").
+					WillReturnResult(pgxmock.NewResult("SELECT", 1))
+				mock.ExpectExec(`^select people.delete_person`).
+					WithArgs(personID).
+					WillReturnError(&pgconn.PgError{Code: pgerrcode.NoDataFound})
+				mock.ExpectRollback()
+			},
+			input: personID,
+			error: repo.ErrNotFound,
+		},
+	}
+
+	wrapper := func(mock pgxmock.PgxPoolIface, id uuid.UUID) error {
+		return postgres.PeopleFromPgxPoolInterface(mock).Delete(ctx, id) //nolint:wrapcheck
+	}
+	testProcedure[uuid.UUID](t, testCases, wrapper)
+}
+
 // does not run any tests, read the comment below
 func TestPartialUpdate(t *testing.T) {
 	t.Parallel()
@@ -306,3 +361,768 @@ func TestPartialUpdate(t *testing.T) {
 	}
 	testProcedure[inputs](t, testCases, wrapper)
 }
+
+func TestPatch(t *testing.T) {
+	t.Parallel()
+
+	personID := uuid.New()
+
+	type inputs struct {
+		id         uuid.UUID
+		operations []domain.PatchOperation
+	}
+
+	valid := []domain.PatchOperation{
+		{Op: domain.PatchTest, Field: domain.FieldPatronymic, Value: ""},
+		{Op: domain.PatchReplace, Field: domain.FieldAge, Value: 42},
+		{Op: domain.PatchRemove, Field: domain.FieldPatronymic, Value: nil},
+	}
+	validJSON := []byte(`[{"value":"","op":"test","path":"/patronymic"},` +
+		`{"value":42,"op":"replace","path":"/age"},{"op":"remove","path":"/patronymic"}]`)
+
+	//nolint:exhaustruct
+	testCases := []testCaseData[inputs, struct{}]{
+		{
+			name: "valid patch",
+			setExpectations: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectExec(`^select people.patch_person`).
+					WithArgs(personID, validJSON).
+					WillReturnResult(pgxmock.NewResult("SELECT", 1))
+			},
+			input: inputs{personID, valid},
+		},
+		{
+			name: "failed test operation",
+			setExpectations: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectExec(`^select people.patch_person`).
+					WithArgs(personID, validJSON).
+					WillReturnError(&pgconn.PgError{Code: pgerrcode.AssertFailure})
+			},
+			input: inputs{personID, valid},
+			error: repo.ErrConflict,
+		},
+		{
+			name: "not found",
+			setExpectations: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectExec(`^select people.patch_person`).
+					WithArgs(personID, validJSON).
+					WillReturnError(&pgconn.PgError{Code: pgerrcode.NoDataFound})
+			},
+			input: inputs{personID, valid},
+			error: repo.ErrNotFound,
+		},
+		{
+			name:            "invalid operation is not sent",
+			setExpectations: func(mock pgxmock.PgxPoolIface) {},
+			input: inputs{personID, []domain.PatchOperation{
+				{Op: domain.PatchRemove, Field: domain.FieldName, Value: nil},
+			}},
+			error: repo.ErrArgument,
+		},
+	}
+
+	wrapper := func(mock pgxmock.PgxPoolIface, in inputs) error {
+		return postgres.PeopleFromPgxPoolInterface(mock).Patch(context.Background(), //nolint:wrapcheck
+			in.id, in.operations)
+	}
+	testProcedure[inputs](t, testCases, wrapper)
+}
+
+func TestRestore(t *testing.T) {
+	t.Parallel()
+
+	personID := uuid.New()
+
+	//nolint:exhaustruct
+	testCases := []testCaseData[uuid.UUID, struct{}]{
+		{
+			name: "restore non-existent person",
+			setExpectations: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectExec(`^select people.restore_person`).
+					WithArgs(personID).
+					WillReturnError(&pgconn.PgError{Code: pgerrcode.NoDataFound})
+			},
+			input: personID,
+			error: repo.ErrNotFound,
+		},
+		{
+			name: "restore person that is not deleted",
+			setExpectations: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectExec(`^select people.restore_person`).
+					WithArgs(personID).
+					WillReturnError(&pgconn.PgError{Code: pgerrcode.ObjectNotInPrerequisiteState})
+			},
+			input: personID,
+			error: repo.ErrConflict,
+		},
+		{
+			name: "restore deleted person",
+			setExpectations: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectExec(`^select people.restore_person`).
+					WithArgs(personID).
+					WillReturnResult(pgxmock.NewResult("SELECT", 1))
+			},
+			input: personID,
+		},
+	}
+
+	wrapper := func(mock pgxmock.PgxPoolIface, id uuid.UUID) error {
+		return postgres.PeopleFromPgxPoolInterface(mock).Restore(context.Background(), id) //nolint:wrapcheck
+	}
+	testProcedure[uuid.UUID](t, testCases, wrapper)
+}
+
+func TestPurge(t *testing.T) {
+	t.Parallel()
+
+	retention := 24 * time.Hour
+
+	//nolint:exhaustruct
+	testCases := []testCaseData[time.Duration, int]{
+		{
+			name: "purge",
+			setExpectations: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery(`^select people.purge_people`).
+					WithArgs(retention).
+					WillReturnRows(pgxmock.NewRows([]string{"purge_people"}).AddRow(3))
+			},
+			input:  retention,
+			expect: 3,
+		},
+		{
+			name: "invalid retention",
+			setExpectations: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery(`^select people.purge_people`).
+					WithArgs(-retention).
+					WillReturnError(&pgconn.PgError{Code: pgerrcode.InvalidParameterValue})
+			},
+			input: -retention,
+			error: repo.ErrArgument,
+		},
+	}
+
+	wrapper := func(mock pgxmock.PgxPoolIface, retention time.Duration) (int, error) {
+		return postgres.PeopleFromPgxPoolInterface(mock).Purge(context.Background(), retention) //nolint:wrapcheck
+	}
+	testFunction[time.Duration, int](t, testCases, wrapper)
+}
+
+func TestGetAsOf(t *testing.T) {
+	t.Parallel()
+
+	person := utils.MakePerson()
+	moment := time.Date(2024, time.January, 31, 12, 0, 0, 0, time.UTC)
+	columns := []string{
+		"person_id", "name", "surname", "patronymic", "age", "sex", "nationality", "deleted_at",
+	}
+
+	//nolint:exhaustruct
+	testCases := []testCaseData[uuid.UUID, domain.Person]{
+		{
+			name: "did not exist",
+			setExpectations: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery(`^select \* from people.get_person_as_of`).
+					WithArgs(person.ID, moment).
+					WillReturnError(&pgconn.PgError{Code: pgerrcode.NoDataFound})
+			},
+			input: person.ID,
+			error: repo.ErrNotFound,
+		},
+		{
+			name: "existed",
+			setExpectations: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery(`^select \* from people.get_person_as_of`).
+					WithArgs(person.ID, moment).
+					WillReturnRows(pgxmock.NewRows(columns).AddRow(
+						person.ID, person.Name, person.Surname, person.Patronymic,
+						person.Age, string(person.Sex), string(person.Nationality), nil,
+					))
+			},
+			input:  person.ID,
+			expect: person,
+		},
+	}
+
+	wrapper := func(mock pgxmock.PgxPoolIface, personID uuid.UUID) (domain.Person, error) {
+		return postgres.PeopleFromPgxPoolInterface(mock).GetAsOf( //nolint:wrapcheck
+			context.Background(), personID, moment)
+	}
+	testFunction[uuid.UUID, domain.Person](t, testCases, wrapper)
+}
+
+func TestHistory(t *testing.T) {
+	t.Parallel()
+
+	personID := uuid.New()
+	pagination := domain.PaginationFilter{Offset: 0, Limit: 20}
+
+	//nolint:exhaustruct
+	testCases := []testCaseData[uuid.UUID, struct{}]{
+		{
+			name: "no history",
+			setExpectations: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery(`^select people.person_history`).
+					WithArgs(personID, pagination.Offset, pagination.Limit).
+					WillReturnError(&pgconn.PgError{Code: pgerrcode.NoDataFound})
+			},
+			input: personID,
+			error: repo.ErrNotFound,
+		},
+	}
+
+	wrapper := func(mock pgxmock.PgxPoolIface, id uuid.UUID) error {
+		_, err := postgres.PeopleFromPgxPoolInterface(mock).History(context.Background(), id, pagination)
+
+		return err //nolint:wrapcheck
+	}
+	testProcedure[uuid.UUID](t, testCases, wrapper)
+}
+
+func TestReserveIdempotencyKey(t *testing.T) {
+	t.Parallel()
+
+	fingerprint := []byte{1, 2, 3}
+	ttl := time.Hour
+	columns := []string{"reserved", "fingerprint", "status", "content_type", "body"}
+	status, contentType := 201, "application/json"
+	stored := &domain.StoredResponse{ContentType: contentType, Body: []byte("{}"), Status: status}
+
+	expectReserve := func(mock pgxmock.PgxPoolIface, row ...any) {
+		mock.ExpectQuery(`^select \* from people.reserve_idempotency_key`).
+			WithArgs("key", fingerprint, ttl).
+			WillReturnRows(pgxmock.NewRows(columns).AddRow(row...))
+	}
+
+	//nolint:exhaustruct
+	testCases := []testCaseData[[]byte, *domain.StoredResponse]{
+		{
+			name: "new key",
+			setExpectations: func(mock pgxmock.PgxPoolIface) {
+				expectReserve(mock, true, fingerprint, nil, nil, nil)
+			},
+			input:  fingerprint,
+			expect: nil,
+		},
+		{
+			name: "in progress",
+			setExpectations: func(mock pgxmock.PgxPoolIface) {
+				expectReserve(mock, false, fingerprint, nil, nil, nil)
+			},
+			input: fingerprint,
+			error: repo.ErrInProgress,
+		},
+		{
+			name: "reused",
+			setExpectations: func(mock pgxmock.PgxPoolIface) {
+				expectReserve(mock, false, []byte{4, 5, 6}, &status, &contentType, []byte("{}"))
+			},
+			input: fingerprint,
+			error: repo.ErrKeyReused,
+		},
+		{
+			name: "replay",
+			setExpectations: func(mock pgxmock.PgxPoolIface) {
+				expectReserve(mock, false, fingerprint, &status, &contentType, []byte("{}"))
+			},
+			input:  fingerprint,
+			expect: stored,
+		},
+	}
+
+	wrapper := func(mock pgxmock.PgxPoolIface, fingerprint []byte) (*domain.StoredResponse, error) {
+		return postgres.PeopleFromPgxPoolInterface(mock).IdempotencyKeys().Reserve( //nolint:wrapcheck
+			context.Background(), "key", fingerprint, ttl)
+	}
+	testFunction[[]byte, *domain.StoredResponse](t, testCases, wrapper)
+}
+
+func TestClientByKeyID(t *testing.T) {
+	t.Parallel()
+
+	columns := []string{"client_id", "name", "key_id", "secret_hash", "created_at", "revoked_at", "scopes"}
+	client := &domain.APIClient{
+		CreatedAt:  time.Now(),
+		RevokedAt:  nil,
+		Name:       "client",
+		KeyID:      "key",
+		SecretHash: "hash",
+		Scopes:     []string{"people:read"},
+		ID:         uuid.New(),
+	}
+
+	//nolint:exhaustruct
+	testCases := []testCaseData[string, *domain.APIClient]{
+		{
+			name: "found",
+			setExpectations: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery(`^select \* from people.find_api_clients\(key_id_ => \$1\)`).
+					WithArgs("key").
+					WillReturnRows(pgxmock.NewRows(columns).AddRow(
+						client.ID, client.Name, client.KeyID, client.SecretHash, client.CreatedAt, client.RevokedAt,
+						client.Scopes))
+			},
+			input:  "key",
+			expect: client,
+		},
+		{
+			name: "not found",
+			setExpectations: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery(`^select \* from people.find_api_clients\(key_id_ => \$1\)`).
+					WithArgs("unknown").
+					WillReturnRows(pgxmock.NewRows(columns))
+			},
+			input: "unknown",
+			error: repo.ErrNotFound,
+		},
+	}
+
+	wrapper := func(mock pgxmock.PgxPoolIface, keyID string) (*domain.APIClient, error) {
+		result, err := postgres.PeopleFromPgxPoolInterface(mock).APIClients().ClientByKeyID(context.Background(), keyID)
+
+		return &result, err //nolint:wrapcheck
+	}
+	testFunction[string, *domain.APIClient](t, testCases, wrapper)
+}
+
+func TestRevokeClient(t *testing.T) {
+	t.Parallel()
+
+	//nolint:exhaustruct
+	testCases := []testCaseData[string, struct{}]{
+		{
+			name: "revoked",
+			setExpectations: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectExec(`^select people.revoke_api_client\(\$1\)`).
+					WithArgs("client").
+					WillReturnResult(pgxmock.NewResult("SELECT", 1))
+			},
+			input: "client",
+		},
+		{
+			name: "not found",
+			setExpectations: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectExec(`^select people.revoke_api_client\(\$1\)`).
+					WithArgs("unknown").
+					WillReturnError(&pgconn.PgError{Code: pgerrcode.NoDataFound})
+			},
+			input: "unknown",
+			error: repo.ErrNotFound,
+		},
+	}
+
+	wrapper := func(mock pgxmock.PgxPoolIface, name string) error {
+		return postgres.PeopleFromPgxPoolInterface(mock).APIClients().RevokeClient( //nolint:wrapcheck
+			context.Background(), name)
+	}
+	testProcedure[string](t, testCases, wrapper)
+}
+
+func TestAudit(t *testing.T) {
+	t.Parallel()
+
+	actor := "admin"
+	filter := domain.AuditFilter{Actor: &actor, PersonID: nil, From: nil, To: nil}
+	pagination := domain.PaginationFilter{Offset: -1, Limit: 20}
+
+	//nolint:exhaustruct
+	testCases := []testCaseData[domain.AuditFilter, struct{}]{
+		{
+			name: "invalid pagination",
+			setExpectations: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery(`^\s*select people.list_audit`).
+					WithArgs(filter.Actor, filter.PersonID, filter.From, filter.To, pagination.Offset, pagination.Limit).
+					WillReturnError(&pgconn.PgError{Code: pgerrcode.InvalidParameterValue})
+			},
+			input: filter,
+			error: repo.ErrArgument,
+		},
+	}
+
+	wrapper := func(mock pgxmock.PgxPoolIface, filter domain.AuditFilter) error {
+		_, err := postgres.PeopleFromPgxPoolInterface(mock).AuditLog().Audit(context.Background(), filter, pagination)
+
+		return err //nolint:wrapcheck
+	}
+	testProcedure[domain.AuditFilter](t, testCases, wrapper)
+}
+
+func TestTakeRateLimitTokens(t *testing.T) {
+	t.Parallel()
+
+	bucket := domain.TokenBucket{Capacity: 10, Rate: 1}
+
+	//nolint:exhaustruct
+	testCases := []testCaseData[float64, domain.RateLimitResult]{
+		{
+			name: "allowed",
+			setExpectations: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery(`^select \* from people.take_rate_limit_tokens`).
+					WithArgs("client", 4.0, bucket.Capacity, bucket.Rate).
+					WillReturnRows(pgxmock.NewRows([]string{"allowed", "tokens"}).AddRow(true, 6.0))
+			},
+			input:  4,
+			expect: domain.RateLimitResult{Tokens: 6, Allowed: true},
+		},
+		{
+			name: "invalid cost",
+			setExpectations: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery(`^select \* from people.take_rate_limit_tokens`).
+					WithArgs("client", -1.0, bucket.Capacity, bucket.Rate).
+					WillReturnError(&pgconn.PgError{Code: pgerrcode.InvalidParameterValue})
+			},
+			input: -1,
+			error: repo.ErrArgument,
+		},
+	}
+
+	wrapper := func(mock pgxmock.PgxPoolIface, cost float64) (domain.RateLimitResult, error) {
+		return postgres.PeopleFromPgxPoolInterface(mock).RateLimits().Take( //nolint:wrapcheck
+			context.Background(), "client", cost, bucket)
+	}
+	testFunction[float64, domain.RateLimitResult](t, testCases, wrapper)
+}
+
+func TestCount(t *testing.T) {
+	t.Parallel()
+
+	//nolint:exhaustruct
+	testCases := []testCaseData[struct{}, [2]int]{
+		{
+			name: "valid",
+			setExpectations: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery(`^select \* from people.count_people\(\)`).
+					WillReturnRows(pgxmock.NewRows([]string{"active", "deleted"}).AddRow(int64(3), int64(1)))
+			},
+			expect: [2]int{3, 1},
+		},
+	}
+
+	wrapper := func(mock pgxmock.PgxPoolIface, _ struct{}) ([2]int, error) {
+		active, deleted, err := postgres.PeopleFromPgxPoolInterface(mock).Count(context.Background())
+
+		return [2]int{active, deleted}, err //nolint:wrapcheck
+	}
+	testFunction[struct{}, [2]int](t, testCases, wrapper)
+}
+
+func TestCheckSchema(t *testing.T) {
+	t.Parallel()
+
+	version := func(version int64, dirty bool) func(mock pgxmock.PgxPoolIface) {
+		return func(mock pgxmock.PgxPoolIface) {
+			mock.ExpectQuery(`^select version, dirty from public.schema_migrations`).
+				WillReturnRows(pgxmock.NewRows([]string{"version", "dirty"}).AddRow(version, dirty))
+		}
+	}
+
+	//nolint:exhaustruct
+	testCases := []testCaseData[struct{}, struct{}]{
+		{name: "expected", setExpectations: version(postgres.SchemaVersion, false)},
+		{name: "newer", setExpectations: version(postgres.SchemaVersion+1, false)},
+		{name: "older", setExpectations: version(postgres.SchemaVersion-1, false), error: postgres.ErrSchema},
+		{name: "dirty", setExpectations: version(postgres.SchemaVersion, true), error: postgres.ErrSchema},
+		{
+			name: "not migrated",
+			setExpectations: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery(`^select version, dirty from public.schema_migrations`).
+					WillReturnError(&pgconn.PgError{Code: pgerrcode.UndefinedTable}) //nolint:exhaustruct
+			},
+			error: postgres.ErrSchema,
+		},
+	}
+
+	wrapper := func(mock pgxmock.PgxPoolIface, _ struct{}) error {
+		return postgres.PeopleFromPgxPoolInterface(mock).CheckSchema(context.Background()) //nolint:wrapcheck
+	}
+	testProcedure[struct{}](t, testCases, wrapper)
+}
+
+// SchemaVersion must be bumped with every migration
+func TestSchemaVersion(t *testing.T) {
+	t.Parallel()
+
+	migrations, err := fs.Glob(postgres.Migrations, "migrations/*.up.sql")
+	if err != nil || len(migrations) == 0 {
+		t.Fatalf("could not list migrations: %v", err)
+	}
+
+	slices.Sort(migrations)
+
+	latest := path.Base(migrations[len(migrations)-1])
+	if expected := fmt.Sprintf("%06d_", postgres.SchemaVersion); !strings.HasPrefix(latest, expected) {
+		t.Errorf("SchemaVersion %d does not match the latest migration %s", postgres.SchemaVersion, latest)
+	}
+}
+
+func TestMigrations(t *testing.T) {
+	t.Parallel()
+
+	source, err := iofs.New(postgres.Migrations, "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer source.Close()
+
+	var last uint
+
+	version, err := source.First()
+	for count := 1; err == nil; count++ {
+		last = version
+
+		if int64(version) != int64(count) {
+			t.Errorf("migration %d is missing", count)
+		}
+
+		down, _, downErr := source.ReadDown(version)
+		if downErr != nil {
+			t.Errorf("migration %d can not be reverted: %v", version, downErr)
+		} else {
+			down.Close()
+		}
+
+		version, err = source.Next(version)
+	}
+
+	if int64(last) != postgres.SchemaVersion || !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected last migration %d: %v", last, err)
+	}
+
+	for _, status := range []postgres.MigrationStatus{
+		{Version: postgres.SchemaVersion - 1, Dirty: false, Expected: postgres.SchemaVersion},
+		{Version: postgres.SchemaVersion, Dirty: true, Expected: postgres.SchemaVersion},
+	} {
+		if err := status.Check(); !errors.Is(err, postgres.ErrSchema) {
+			t.Errorf("unusable schema %+v was accepted", status)
+		}
+	}
+
+	if err := (postgres.MigrationStatus{
+		Version: postgres.SchemaVersion + 1, Dirty: false, Expected: postgres.SchemaVersion,
+	}).Check(); err != nil {
+		t.Errorf("newer schema was not accepted: %v", err)
+	}
+}
+
+//nolint:funlen
+func TestWithinTx(t *testing.T) {
+	t.Parallel()
+
+	personID := uuid.New()
+	serializable := pgx.TxOptions{IsoLevel: pgx.Serializable} //nolint:exhaustruct
+
+	// delete expects the statements of Delete within a transaction
+	// (in a savepoint), the statement returns err
+	deleteStatement := func(mock pgxmock.PgxPoolIface, err error) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`^select\s+set_config`).
+			WithArgs("", "", "").
+			WillReturnResult(pgxmock.NewResult("SELECT", 1))
+
+		expectation := mock.ExpectExec(`^select people.delete_person`).WithArgs(personID)
+		if err != nil {
+			expectation.WillReturnError(err)
+			mock.ExpectRollback()
+
+			return
+		}
+
+		expectation.WillReturnResult(pgxmock.NewResult("SELECT", 1))
+		mock.ExpectCommit()
+	}
+
+	serializationFailure := &pgconn.PgError{Code: pgerrcode.SerializationFailure} //nolint:exhaustruct
+
+	//nolint:exhaustruct
+	testCases := []testCaseData[func(ctx context.Context, people *postgres.People) error, struct{}]{
+		{
+			name: "statements are committed together",
+			setExpectations: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBeginTx(serializable)
+				deleteStatement(mock, nil)
+				mock.ExpectQuery(`^select \* from people.get_person`).
+					WithArgs(personID).
+					WillReturnError(&pgconn.PgError{Code: pgerrcode.NoDataFound})
+				mock.ExpectRollback()
+			},
+			input: func(ctx context.Context, people *postgres.People) error {
+				if err := people.Delete(ctx, personID); err != nil {
+					return err //nolint:wrapcheck
+				}
+
+				_, err := people.GetByID(ctx, personID)
+
+				return err //nolint:wrapcheck
+			},
+			error: repo.ErrNotFound,
+		},
+		{
+			name: "failed savepoint is rolled back, the transaction goes on",
+			setExpectations: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBeginTx(serializable)
+				mock.ExpectBegin()
+				deleteStatement(mock, &pgconn.PgError{Code: pgerrcode.NoDataFound})
+				mock.ExpectRollback()
+				deleteStatement(mock, nil)
+				mock.ExpectCommit()
+			},
+			input: func(ctx context.Context, people *postgres.People) error {
+				err := people.WithinTx(ctx, func(ctx context.Context) error {
+					return people.Delete(ctx, personID)
+				})
+				if !errors.Is(err, repo.ErrNotFound) {
+					return fmt.Errorf("unexpected error of the savepoint: %w", err)
+				}
+
+				return people.Delete(ctx, personID) //nolint:wrapcheck
+			},
+		},
+		{
+			name: "serialization failure is retried",
+			setExpectations: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBeginTx(serializable)
+				deleteStatement(mock, serializationFailure)
+				mock.ExpectRollback()
+				mock.ExpectBeginTx(serializable)
+				deleteStatement(mock, nil)
+				mock.ExpectCommit()
+			},
+			input: func(ctx context.Context, people *postgres.People) error {
+				return people.Delete(ctx, personID) //nolint:wrapcheck
+			},
+		},
+		{
+			name: "conflict is reported after the last attempt",
+			setExpectations: func(mock pgxmock.PgxPoolIface) {
+				for attempt := 0; attempt < 5; attempt++ {
+					mock.ExpectBeginTx(serializable)
+					mock.ExpectCommit().WillReturnError(serializationFailure)
+				}
+			},
+			input: func(context.Context, *postgres.People) error { return nil },
+			error: repo.ErrConflict,
+		},
+	}
+
+	wrapper := func(mock pgxmock.PgxPoolIface, fn func(ctx context.Context, people *postgres.People) error) error {
+		people := postgres.PeopleFromPgxPoolInterface(mock)
+
+		return people.WithinTx(context.Background(), func(ctx context.Context) error { //nolint:wrapcheck
+			return fn(ctx, people)
+		})
+	}
+	testProcedure[func(ctx context.Context, people *postgres.People) error](t, testCases, wrapper)
+}
+
+func TestReplicas(t *testing.T) {
+	t.Parallel()
+
+	mocks := make([]pgxmock.PgxPoolIface, 3)
+
+	for i := range mocks {
+		mock, err := pgxmock.NewPool()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer mock.Close()
+
+		mocks[i] = mock
+	}
+
+	primary, replicas := mocks[0], []postgres.PgxPoolInterface{mocks[1], mocks[2]}
+	people := postgres.PeopleWithReplicas(primary, replicas, 5*time.Second)
+	personID := uuid.New()
+
+	// expectGet expects a GetByID query of the mock
+	expectGet := func(mock pgxmock.PgxPoolIface) {
+		mock.ExpectQuery(`^select \* from people.get_person`).
+			WithArgs(personID).
+			WillReturnError(&pgconn.PgError{Code: pgerrcode.NoDataFound})
+	}
+
+	const primaryLSN = "0/3000060"
+
+	expectLSN := func() {
+		primary.ExpectQuery(`^select pg_current_wal_lsn`).
+			WillReturnRows(pgxmock.NewRows([]string{"lsn"}).AddRow(primaryLSN))
+	}
+
+	expectLag := func(mock pgxmock.PgxPoolIface, lag *float64) {
+		mock.ExpectQuery(`^select case`).WithArgs(primaryLSN).
+			WillReturnRows(pgxmock.NewRows([]string{"lag"}).AddRow(lag))
+	}
+
+	seconds := func(lag float64) *float64 { return &lag }
+
+	get := func(ctx context.Context) {
+		if _, err := people.GetByID(ctx, personID); !errors.Is(err, repo.ErrNotFound) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+
+	check := func(expected ...postgres.ReplicaStatus) {
+		statuses := people.CheckReplicas(context.Background())
+		for i := range statuses {
+			statuses[i].Err = nil
+		}
+
+		if !reflect.DeepEqual(statuses, expected) {
+			t.Errorf("expected %+v, got %+v", expected, statuses)
+		}
+	}
+
+	// replicas are not read from until they are checked
+	expectGet(primary)
+	get(context.Background())
+
+	expectLSN()
+	expectLag(mocks[1], seconds(0))
+	expectLag(mocks[2], seconds(10))
+	check(
+		postgres.ReplicaStatus{Name: "replica 0", Lag: 0, Healthy: true, Changed: true},
+		postgres.ReplicaStatus{Name: "replica 1", Lag: 10 * time.Second, Healthy: false, Changed: false},
+	)
+
+	// lagging replicas and requests reading their writes use the primary
+	expectGet(mocks[1])
+	expectGet(mocks[1])
+	expectGet(primary)
+	get(context.Background())
+	get(context.Background())
+	get(reqctx.WithPrimaryReads(context.Background()))
+
+	expectLSN()
+	mocks[1].ExpectQuery(`^select case`).WithArgs(primaryLSN).WillReturnError(errors.New("connection refused"))
+	expectLag(mocks[2], seconds(1))
+	check(
+		postgres.ReplicaStatus{Name: "replica 0", Lag: 0, Healthy: false, Changed: true},
+		postgres.ReplicaStatus{Name: "replica 1", Lag: time.Second, Healthy: true, Changed: true},
+	)
+
+	expectGet(mocks[2])
+	get(context.Background())
+
+	// a replica behind the primary without replayed transactions is unhealthy
+	expectLSN()
+	expectLag(mocks[1], nil)
+	expectLag(mocks[2], seconds(1))
+	check(
+		postgres.ReplicaStatus{Name: "replica 0", Lag: 0, Healthy: false, Changed: false},
+		postgres.ReplicaStatus{Name: "replica 1", Lag: time.Second, Healthy: true, Changed: false},
+	)
+
+	// the lag of replicas is unknown without the primary
+	primary.ExpectQuery(`^select pg_current_wal_lsn`).WillReturnError(errors.New("connection refused"))
+	check(
+		postgres.ReplicaStatus{Name: "replica 0", Lag: 0, Healthy: false, Changed: false},
+		postgres.ReplicaStatus{Name: "replica 1", Lag: 0, Healthy: false, Changed: true},
+	)
+
+	expectGet(primary)
+	get(context.Background())
+
+	for i, mock := range mocks {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations of %d: %s", i, err)
+		}
+	}
+}
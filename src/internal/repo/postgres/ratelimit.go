@@ -0,0 +1,47 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/Hofsiedge/person-api/internal/domain"
+	"github.com/Hofsiedge/person-api/internal/repo"
+	"github.com/jackc/pgx/v5"
+)
+
+// RateLimits stores token buckets in people.rate_limit_buckets,
+// so that replicas share the limits
+type RateLimits struct {
+	db PgxPoolInterface
+}
+
+// ensure that RateLimits implements repo.RateLimitRepo
+var _ repo.RateLimitRepo = &RateLimits{nil}
+
+// RateLimits returns RateLimits sharing the connection pool
+func (p *People) RateLimits() *RateLimits {
+	return &RateLimits{p.db}
+}
+
+// people.take_rate_limit_tokens result
+type rateLimitResult struct {
+	Tokens  float64 `db:"tokens"`
+	Allowed bool    `db:"allowed"`
+}
+
+// Take implements repo.RateLimitRepo.
+func (l *RateLimits) Take(
+	ctx context.Context, key string, cost float64, bucket domain.TokenBucket,
+) (domain.RateLimitResult, error) {
+	rows, err := conn(ctx, l.db).Query(ctx, `select * from people.take_rate_limit_tokens($1, $2, $3, $4)`,
+		key, cost, bucket.Capacity, bucket.Rate)
+	if err != nil {
+		return domain.RateLimitResult{}, wrapPostgresError(err)
+	}
+
+	result, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[rateLimitResult])
+	if err != nil {
+		return domain.RateLimitResult{}, wrapPostgresError(err)
+	}
+
+	return domain.RateLimitResult{Tokens: result.Tokens, Allowed: result.Allowed}, nil
+}
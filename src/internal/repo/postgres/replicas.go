@@ -0,0 +1,124 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/Hofsiedge/person-api/internal/repo"
+	"github.com/Hofsiedge/person-api/internal/reqctx"
+	"github.com/jackc/pgx/v5"
+)
+
+// replica is a read replica of the primary database
+type replica struct {
+	db PgxPoolInterface
+	// host and port for logs
+	name string
+	// whether the last check succeeded with the lag within maxLag
+	healthy atomic.Bool
+}
+
+func newReplica(db PgxPoolInterface, name string) *replica {
+	return &replica{db: db, name: name, healthy: atomic.Bool{}}
+}
+
+// this function should not be used outside tests
+func PeopleWithReplicas(db PgxPoolInterface, replicas []PgxPoolInterface, maxLag time.Duration) *People {
+	people := &People{db: db, replicas: nil, maxLag: maxLag, next: atomic.Uint64{}}
+
+	for i, replicaDB := range replicas {
+		people.replicas = append(people.replicas, newReplica(replicaDB, fmt.Sprintf("replica %d", i)))
+	}
+
+	return people
+}
+
+// reader returns the connection reads of ctx go to: the transaction of ctx,
+// the primary if the request reads its own writes (reqctx.PrimaryReads) or
+// the next healthy replica (round robin). The primary is used if there are
+// no healthy replicas.
+func (p *People) reader(ctx context.Context) querier {
+	if _, found := ctx.Value(txKey{}).(pgx.Tx); found || reqctx.PrimaryReads(ctx) || len(p.replicas) == 0 {
+		return conn(ctx, p.db)
+	}
+
+	start := p.next.Add(1)
+
+	for i := range p.replicas {
+		if replica := p.replicas[(start+uint64(i))%uint64(len(p.replicas))]; replica.healthy.Load() {
+			return replica.db
+		}
+	}
+
+	return p.db
+}
+
+// ReplicaStatus is the result of a check of a replica
+type ReplicaStatus struct {
+	// error of the check, nil if it succeeded
+	Err  error
+	Name string
+	// time since the last replayed transaction if the replica has not
+	// replayed the WAL the primary had written before the check, 0 otherwise
+	Lag time.Duration
+	// whether the replica is read from
+	Healthy bool
+	// whether Healthy changed with the check
+	Changed bool
+}
+
+// CheckReplicas checks the replicas: replicas that are unreachable or lag
+// more than DB_REPLICA_MAX_LAG are not read from until a later check finds
+// them healthy again. Replicas are unhealthy until they are checked.
+//
+// The replayed WAL is compared with the WAL location of the primary, so a
+// replica whose WAL receiver is disconnected lags once the primary is
+// written to. If the primary can not be reached, no replica is healthy.
+func (p *People) CheckReplicas(ctx context.Context) []ReplicaStatus {
+	statuses := make([]ReplicaStatus, 0, len(p.replicas))
+
+	var primaryLSN string
+
+	primaryErr := p.db.QueryRow(ctx, `select pg_current_wal_lsn()::text`).Scan(&primaryLSN)
+	if primaryErr != nil {
+		primaryErr = fmt.Errorf("could not read the WAL location of the primary: %w", wrapPostgresError(primaryErr))
+	}
+
+	for _, replica := range p.replicas {
+		status := ReplicaStatus{Err: primaryErr, Name: replica.name, Lag: 0, Healthy: false, Changed: false}
+
+		if primaryErr == nil {
+			status.Lag, status.Err = replica.lag(ctx, primaryLSN)
+		}
+
+		status.Healthy = status.Err == nil && status.Lag <= p.maxLag
+		status.Changed = replica.healthy.Swap(status.Healthy) != status.Healthy
+		statuses = append(statuses, status)
+	}
+
+	return statuses
+}
+
+// lag returns the time since the last transaction replayed by the replica
+// if it has not replayed the WAL of the primary up to primaryLSN yet, 0
+// otherwise: a replica that caught up is not lagging, however long ago the
+// last transaction was.
+func (r *replica) lag(ctx context.Context, primaryLSN string) (time.Duration, error) {
+	var lag *float64
+
+	row := r.db.QueryRow(ctx, `select case
+		when not pg_is_in_recovery() or pg_last_wal_replay_lsn() >= $1::pg_lsn then 0
+		else extract(epoch from now() - pg_last_xact_replay_timestamp())
+	end::float8`, primaryLSN)
+	if err := row.Scan(&lag); err != nil {
+		return 0, wrapPostgresError(err)
+	}
+
+	if lag == nil {
+		return 0, fmt.Errorf("%w: the replica has not replayed any transaction", repo.ErrUnexpected)
+	}
+
+	return time.Duration(*lag * float64(time.Second)), nil
+}
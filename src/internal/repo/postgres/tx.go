@@ -0,0 +1,101 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	// how many times a transaction is run if it fails to serialize
+	txAttempts = 5
+	// delay before the next attempt, multiplied by the attempt number
+	txRetryDelay = 10 * time.Millisecond
+)
+
+// txKey is the context key of the current transaction
+type txKey struct{}
+
+// beginner starts a transaction (pgxpool.Pool) or a savepoint (pgx.Tx)
+type beginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// conn returns the transaction of ctx (see People.WithinTx) or db if there
+// is none. Every query of the repos goes through it, so the repos take part
+// in the transaction of the context.
+func conn(ctx context.Context, db querier) querier {
+	if tx, found := ctx.Value(txKey{}).(pgx.Tx); found {
+		return tx
+	}
+
+	return db
+}
+
+// retryable reports whether err is a serialization failure or a deadlock,
+// after which the transaction can be run again
+func retryable(err error) bool {
+	var pgErr *pgconn.PgError
+
+	return errors.As(err, &pgErr) &&
+		(pgErr.Code == pgerrcode.SerializationFailure || pgErr.Code == pgerrcode.DeadlockDetected)
+}
+
+// runTx runs fn with the transaction started by begin in its context.
+// The transaction is committed if fn succeeds and rolled back otherwise.
+func runTx(
+	ctx context.Context, begin func(ctx context.Context) (pgx.Tx, error), fn func(ctx context.Context) error,
+) error {
+	tx, err := begin(ctx)
+	if err != nil {
+		return wrapPostgresError(err)
+	}
+
+	if err = fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		tx.Rollback(ctx) //nolint:errcheck
+
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return wrapPostgresError(err)
+	}
+
+	return nil
+}
+
+// WithinTx implements repo.TxManager. Transactions are serializable.
+// Serialization failures and deadlocks of the outermost transaction are
+// retried up to txAttempts times, then repo.ErrConflict is returned.
+// Nested calls are run in savepoints and are not retried on their own.
+func (p *People) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if tx, found := ctx.Value(txKey{}).(pgx.Tx); found {
+		return runTx(ctx, tx.Begin, fn)
+	}
+
+	begin := func(ctx context.Context) (pgx.Tx, error) {
+		return p.db.BeginTx(ctx, pgx.TxOptions{ //nolint:exhaustruct
+			IsoLevel: pgx.Serializable,
+		})
+	}
+
+	var err error
+
+	for attempt := 1; attempt <= txAttempts; attempt++ {
+		if err = runTx(ctx, begin, fn); !retryable(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(time.Duration(attempt) * txRetryDelay):
+		}
+	}
+
+	return err
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/Hofsiedge/person-api/internal/domain"
 	"github.com/google/uuid"
@@ -17,6 +18,10 @@ var (
 	ErrNotFound   = fmt.Errorf("%w: not found", ErrRepo)
 	ErrUnexpected = fmt.Errorf("%w: unexpected error", ErrRepo)
 	ErrArgument   = fmt.Errorf("%w: argument error", ErrRepo)
+	ErrConflict   = fmt.Errorf("%w: conflict with the current state", ErrRepo)
+	// idempotency key errors
+	ErrKeyReused  = fmt.Errorf("%w: idempotency key was used for another request", ErrRepo)
+	ErrInProgress = fmt.Errorf("%w: request with the idempotency key is in progress", ErrRepo)
 )
 
 type WithID[I comparable] interface {
@@ -33,4 +38,80 @@ type Repo[T WithID[I], I comparable, P any, F any] interface {
 	Delete(ctx context.Context, id I) error
 }
 
-type PersonRepo Repo[domain.Person, uuid.UUID, domain.PersonPartial, domain.PersonFilter]
+// TxManager runs units of work atomically
+type TxManager interface {
+	// WithinTx runs fn in a transaction carried by the context passed to fn:
+	// methods of the repo (and of the repos sharing its storage) called
+	// with it are a part of the transaction. The transaction is committed
+	// if fn returns nil and rolled back otherwise. Nested calls are
+	// savepoints: their changes are rolled back on error, the outer
+	// transaction goes on. Transactions failing because of concurrent ones
+	// are retried, so fn may be run several times and should have no side
+	// effects outside of the repo; ErrConflict is returned if they keep
+	// failing.
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type PersonRepo interface {
+	Repo[domain.Person, uuid.UUID, domain.PersonPartial, domain.PersonFilter]
+	TxManager
+	// Patch atomically applies patch operations (see domain.Person.Apply).
+	// Returns ErrConflict if a test operation fails and ErrArgument if
+	// the patch is invalid.
+	Patch(ctx context.Context, id uuid.UUID, operations []domain.PatchOperation) error
+	// Restore restores a deleted person.
+	// Returns ErrConflict if the person is not deleted.
+	Restore(ctx context.Context, id uuid.UUID) error
+	// Purge permanently deletes people deleted more than retention ago.
+	// Returns the number of purged people.
+	Purge(ctx context.Context, retention time.Duration) (int, error)
+	// GetAsOf returns the person as it was at the moment.
+	// Returns ErrNotFound if the person did not exist or was deleted then.
+	GetAsOf(ctx context.Context, id uuid.UUID, moment time.Time) (domain.Person, error)
+	// History returns changes of the person, newest first.
+	// Deleted and purged people keep their history.
+	History(
+		ctx context.Context, id uuid.UUID, pagination domain.PaginationFilter,
+	) (domain.Page[domain.PersonChange], error)
+}
+
+type IdempotencyRepo interface {
+	// Reserve reserves the key for a request with the fingerprint for ttl.
+	// Returns nil if the request should be processed, the stored response
+	// if it was already processed, ErrInProgress if it is being processed
+	// and ErrKeyReused if the key was used with another fingerprint.
+	Reserve(ctx context.Context, key string, fingerprint []byte, ttl time.Duration) (*domain.StoredResponse, error)
+	// Save stores the response for a reserved key
+	Save(ctx context.Context, key string, response domain.StoredResponse) error
+	// Release releases a reserved key without a response so that
+	// the request can be retried
+	Release(ctx context.Context, key string) error
+}
+
+type APIClientRepo interface {
+	// CreateClient stores a client and returns its ID.
+	// Returns ErrConflict if an active client with the name exists.
+	CreateClient(ctx context.Context, client domain.APIClient) (uuid.UUID, error)
+	// RevokeClient revokes the active client with the name.
+	RevokeClient(ctx context.Context, name string) error
+	// ClientByKeyID returns the active client with the key ID
+	ClientByKeyID(ctx context.Context, keyID string) (domain.APIClient, error)
+	// ClientByName returns the active client with the name
+	ClientByName(ctx context.Context, name string) (domain.APIClient, error)
+	// ListClients returns all clients including revoked ones
+	ListClients(ctx context.Context) ([]domain.APIClient, error)
+}
+
+type AuditRepo interface {
+	// Audit returns audit entries matching the filter, newest first.
+	// Entries are written atomically with the changes they describe.
+	Audit(
+		ctx context.Context, filter domain.AuditFilter, pagination domain.PaginationFilter,
+	) (domain.Page[domain.AuditEntry], error)
+}
+
+type RateLimitRepo interface {
+	// Take takes cost tokens from the bucket of the key if there are enough
+	// (see domain.TokenBucket.Take). Unknown keys have full buckets.
+	Take(ctx context.Context, key string, cost float64, bucket domain.TokenBucket) (domain.RateLimitResult, error)
+}
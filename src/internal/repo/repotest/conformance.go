@@ -0,0 +1,668 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Hofsiedge/person-api/internal/domain"
+	"github.com/Hofsiedge/person-api/internal/repo"
+	"github.com/google/uuid"
+)
+
+var errRollback = errors.New("rollback")
+
+// expectPerson checks the person stored under its ID
+func expectPerson(t *testing.T, people repo.PersonRepo, expected domain.Person) {
+	t.Helper()
+
+	got, err := people.GetByID(context.Background(), expected.ID)
+	if err != nil || got != expected {
+		t.Errorf("expected %v, got %v (%v)", expected, got, err)
+	}
+}
+
+//nolint:funlen
+func testCRUD(t *testing.T, people repo.PersonRepo) {
+	ctx := context.Background()
+	created := create(t, people, person("Ivan", "Petrov", "Ivanovich", 30, domain.Male, "RU"))[0]
+	expectPerson(t, people, created)
+
+	replacement := person("Anna", "Ivanova", "", 25, domain.Female, "KZ")
+	expectError(t, "full update", people.FullUpdate(ctx, created.ID, replacement), nil)
+
+	replacement.ID = created.ID
+	expectPerson(t, people, replacement)
+
+	//nolint:exhaustruct
+	expectError(t, "partial update", people.PartialUpdate(ctx, created.ID, domain.PersonPartial{Age: ptr(26)}), nil)
+
+	replacement.Age = 26
+	expectPerson(t, people, replacement)
+
+	err := people.Patch(ctx, created.ID, []domain.PatchOperation{
+		{Op: domain.PatchTest, Field: domain.FieldAge, Value: 26},
+		{Op: domain.PatchReplace, Field: domain.FieldPatronymic, Value: "Petrovna"},
+	})
+	expectError(t, "patch", err, nil)
+
+	replacement.Patronymic = "Petrovna"
+	expectPerson(t, people, replacement)
+
+	// nothing changes, nothing is recorded
+	expectError(t, "same full update", people.FullUpdate(ctx, created.ID, replacement), nil)
+
+	expectError(t, "delete", people.Delete(ctx, created.ID), nil)
+
+	_, err = people.GetByID(ctx, created.ID)
+	expectError(t, "get deleted", err, repo.ErrNotFound)
+
+	if page := list(t, people, domain.PersonFilter{}, allPeople); page.TotalItems != 0 {
+		t.Errorf("deleted person is listed: %+v", page)
+	}
+
+	if page := list(t, people, domain.PersonFilter{IncludeDeleted: true}, allPeople); page.TotalItems != 1 {
+		t.Errorf("deleted person is not listed with IncludeDeleted: %+v", page)
+	}
+
+	expectError(t, "restore", people.Restore(ctx, created.ID), nil)
+	expectPerson(t, people, replacement)
+
+	asOf, err := people.GetAsOf(ctx, created.ID, time.Now())
+	if err != nil || asOf != replacement {
+		t.Errorf("unexpected current state: %v (%v)", asOf, err)
+	}
+
+	history, err := people.History(ctx, created.ID, allPeople)
+	if err != nil {
+		t.Fatalf("could not get the history: %v", err)
+	}
+
+	operations := make([]domain.ChangeOperation, 0, len(history.Items))
+	for _, change := range history.Items {
+		operations = append(operations, change.Operation)
+	}
+
+	// newest first
+	expected := []domain.ChangeOperation{
+		domain.ChangeRestore, domain.ChangeDelete, domain.ChangeUpdate,
+		domain.ChangeUpdate, domain.ChangeUpdate, domain.ChangeCreate,
+	}
+	if !reflect.DeepEqual(operations, expected) || history.TotalItems != len(expected) {
+		t.Errorf("expected history %v, got %v (%d in total)", expected, operations, history.TotalItems)
+	}
+
+	if newest := history.Items[0]; newest.New == nil || *newest.New != replacement || newest.PersonID != created.ID {
+		t.Errorf("unexpected restore record: %+v", newest)
+	}
+}
+
+//nolint:funlen
+func testErrors(t *testing.T, people repo.PersonRepo) {
+	ctx := context.Background()
+	existing := create(t, people, person("Ivan", "Petrov", "", 30, domain.Male, "RU"))[0]
+	unknown := uuid.New()
+
+	_, err := people.GetByID(ctx, unknown)
+	expectError(t, "get unknown", err, repo.ErrNotFound)
+
+	_, err = people.GetAsOf(ctx, unknown, time.Now())
+	expectError(t, "get unknown as of now", err, repo.ErrNotFound)
+
+	_, err = people.GetAsOf(ctx, existing.ID, time.Now().Add(-time.Hour))
+	expectError(t, "get before creation", err, repo.ErrNotFound)
+
+	_, err = people.History(ctx, unknown, allPeople)
+	expectError(t, "history of unknown", err, repo.ErrNotFound)
+
+	expectError(t, "delete unknown", people.Delete(ctx, unknown), repo.ErrNotFound)
+	expectError(t, "restore unknown", people.Restore(ctx, unknown), repo.ErrNotFound)
+	expectError(t, "restore not deleted", people.Restore(ctx, existing.ID), repo.ErrConflict)
+	expectError(t, "full update unknown", people.FullUpdate(ctx, unknown, existing), repo.ErrNotFound)
+
+	//nolint:exhaustruct
+	expectError(t, "partial update unknown",
+		people.PartialUpdate(ctx, unknown, domain.PersonPartial{Age: ptr(1)}), repo.ErrNotFound)
+
+	//nolint:exhaustruct
+	expectError(t, "empty partial update",
+		people.PartialUpdate(ctx, existing.ID, domain.PersonPartial{}), repo.ErrArgument)
+
+	replaceAge := []domain.PatchOperation{{Op: domain.PatchReplace, Field: domain.FieldAge, Value: 31}}
+	expectError(t, "patch unknown", people.Patch(ctx, unknown, replaceAge), repo.ErrNotFound)
+	expectError(t, "empty patch", people.Patch(ctx, existing.ID, nil), repo.ErrArgument)
+	expectError(t, "patch removing the name", people.Patch(ctx, existing.ID, []domain.PatchOperation{
+		{Op: domain.PatchRemove, Field: domain.FieldName, Value: nil},
+	}), repo.ErrArgument)
+	expectError(t, "failed patch test", people.Patch(ctx, existing.ID, []domain.PatchOperation{
+		{Op: domain.PatchTest, Field: domain.FieldAge, Value: 40},
+		{Op: domain.PatchReplace, Field: domain.FieldAge, Value: 41},
+	}), repo.ErrConflict)
+
+	_, err = people.Purge(ctx, -time.Second)
+	expectError(t, "purge with negative retention", err, repo.ErrArgument)
+
+	expectError(t, "delete", people.Delete(ctx, existing.ID), nil)
+	expectError(t, "delete deleted", people.Delete(ctx, existing.ID), repo.ErrNotFound)
+	expectError(t, "update deleted", people.FullUpdate(ctx, existing.ID, existing), repo.ErrNotFound)
+	expectError(t, "patch deleted", people.Patch(ctx, existing.ID, replaceAge), repo.ErrNotFound)
+
+	// failed operations are not recorded: create, delete
+	history, err := people.History(ctx, existing.ID, allPeople)
+	if err != nil || history.TotalItems != 2 {
+		t.Errorf("failed operations were recorded: %+v (%v)", history, err)
+	}
+}
+
+func testInvalidPeople(t *testing.T, people repo.PersonRepo) {
+	ctx := context.Background()
+	valid := person("Ivan", "Petrov", "", 30, domain.Male, "RU")
+	existing := create(t, people, valid)[0]
+
+	invalid := map[string]func(p *domain.Person){
+		"empty name":             func(p *domain.Person) { p.Name = "" },
+		"empty surname":          func(p *domain.Person) { p.Surname = "" },
+		"negative age":           func(p *domain.Person) { p.Age = -1 },
+		"too old":                func(p *domain.Person) { p.Age = 126 },
+		"lower case nationality": func(p *domain.Person) { p.Nationality = "ru" },
+	}
+
+	for name, modify := range invalid {
+		person := valid
+		modify(&person)
+
+		_, err := people.Create(ctx, person)
+		expectError(t, "create with "+name, err, repo.ErrArgument)
+		expectError(t, "full update with "+name, people.FullUpdate(ctx, existing.ID, person), repo.ErrArgument)
+	}
+
+	//nolint:exhaustruct
+	expectError(t, "partial update with an invalid age",
+		people.PartialUpdate(ctx, existing.ID, domain.PersonPartial{Age: ptr(200)}), repo.ErrArgument)
+
+	expectError(t, "patch with an invalid age", people.Patch(ctx, existing.ID, []domain.PatchOperation{
+		{Op: domain.PatchReplace, Field: domain.FieldAge, Value: 200},
+	}), repo.ErrArgument)
+
+	expectPerson(t, people, existing)
+
+	if page := list(t, people, domain.PersonFilter{IncludeDeleted: true}, allPeople); page.TotalItems != 1 {
+		t.Errorf("invalid people were created: %+v", page)
+	}
+}
+
+// filterCase is a list filter with the names of the expected people in order
+type filterCase struct {
+	filter   domain.PersonFilter
+	name     string
+	expected []string
+	// TotalItems: people matching the filter regardless of the threshold
+	total int
+}
+
+func runFilterCases(t *testing.T, people repo.PersonRepo, cases []filterCase) {
+	t.Helper()
+
+	for _, test := range cases {
+		page := list(t, people, test.filter, allPeople)
+		if got := names(page); !reflect.DeepEqual(got, test.expected) || page.TotalItems != test.total {
+			t.Errorf("%s: expected %v (%d in total), got %v (%d in total)",
+				test.name, test.expected, test.total, got, page.TotalItems)
+		}
+	}
+}
+
+//nolint:funlen
+func testFilters(t *testing.T, people repo.PersonRepo) {
+	created := create(t, people,
+		person("Adam", "Aronov", "", 20, domain.Male, "RU"),
+		person("Bella", "Belova", "Borisovna", 30, domain.Female, "KZ"),
+		person("Carl", "Cedrov", "Carlovich", 40, domain.Male, "KZ"),
+		person("Dina", "Dorova", "", 50, domain.Female, "RU"),
+		person("Eva", "Egorova", "Egorovna", 60, domain.Female, "US"),
+	)
+
+	if err := people.Delete(context.Background(), created[4].ID); err != nil {
+		t.Fatal(err)
+	}
+
+	runFilterCases(t, people, []filterCase{
+		{
+			name:     "no filter",
+			filter:   domain.PersonFilter{},
+			expected: []string{"Adam", "Bella", "Carl", "Dina"},
+			total:    4,
+		},
+		{
+			name:     "age range (inclusive)",
+			filter:   domain.PersonFilter{AgeMin: ptr(30), AgeMax: ptr(50)},
+			expected: []string{"Bella", "Carl", "Dina"},
+			total:    3,
+		},
+		{
+			name:     "empty age range",
+			filter:   domain.PersonFilter{AgeMin: ptr(41), AgeMax: ptr(49)},
+			expected: []string{},
+			total:    0,
+		},
+		{
+			name:     "sex",
+			filter:   domain.PersonFilter{Sex: ptr(domain.Female)},
+			expected: []string{"Bella", "Dina"},
+			total:    2,
+		},
+		{
+			name:     "nationality",
+			filter:   domain.PersonFilter{Nationality: ptr(domain.Nationality("KZ"))},
+			expected: []string{"Bella", "Carl"},
+			total:    2,
+		},
+		{
+			// an empty patronymic matches empty ones only, with similarity 1
+			name:     "empty patronymic",
+			filter:   domain.PersonFilter{Patronymic: ptr("")},
+			expected: []string{"Adam", "Dina"},
+			total:    2,
+		},
+		{
+			// a patronymic does not match empty ones at all
+			name:     "patronymic",
+			filter:   domain.PersonFilter{Patronymic: ptr("Carlovich")},
+			expected: []string{"Carl", "Bella"},
+			total:    2,
+		},
+		{
+			name:     "deleted",
+			filter:   domain.PersonFilter{IncludeDeleted: true, Sex: ptr(domain.Female)},
+			expected: []string{"Bella", "Dina", "Eva"},
+			total:    3,
+		},
+		{
+			name:     "combined",
+			filter:   domain.PersonFilter{Sex: ptr(domain.Male), AgeMin: ptr(25), Nationality: ptr(domain.Nationality("KZ"))},
+			expected: []string{"Carl"},
+			total:    1,
+		},
+	})
+}
+
+//nolint:funlen
+func testSimilarity(t *testing.T, people repo.PersonRepo) {
+	create(t, people,
+		person("Word", "Alpha", "", 30, domain.Male, "RU"),
+		person("Two Words", "Beta", "", 30, domain.Male, "RU"),
+		person("Sword", "Gamma", "", 30, domain.Male, "RU"),
+		person("Xylophone", "Delta", "", 30, domain.Male, "RU"),
+	)
+
+	runFilterCases(t, people, []filterCase{
+		{
+			// word_similarity('word', 'two words') = 0.8, the other fields score 0
+			name:     "ordered by similarity",
+			filter:   domain.PersonFilter{Name: ptr("word")},
+			expected: []string{"Word", "Two Words", "Sword", "Xylophone"},
+			total:    4,
+		},
+		{
+			name:     "threshold below a score",
+			filter:   domain.PersonFilter{Name: ptr("word"), Threshold: ptr[float32](0.26)},
+			expected: []string{"Word", "Two Words"},
+			total:    4,
+		},
+		{
+			name:     "threshold above a score",
+			filter:   domain.PersonFilter{Name: ptr("word"), Threshold: ptr[float32](0.27)},
+			expected: []string{"Word"},
+			total:    4,
+		},
+		{
+			name:     "case and punctuation",
+			filter:   domain.PersonFilter{Name: ptr("WORD!"), Threshold: ptr[float32](0.3)},
+			expected: []string{"Word"},
+			total:    4,
+		},
+		{
+			name:     "threshold above every score",
+			filter:   domain.PersonFilter{Name: ptr("word"), Threshold: ptr[float32](0.9)},
+			expected: []string{},
+			total:    4,
+		},
+		{
+			// the empty patronymics score 1 of 3, word_similarity('sword', 'word') = 0.5
+			name: "all fields",
+			filter: domain.PersonFilter{
+				Name: ptr("Sword"), Surname: ptr("Gamma"), Patronymic: ptr(""), Threshold: ptr[float32](0.6),
+			},
+			expected: []string{"Sword"},
+			total:    4,
+		},
+		{
+			name:     "surname",
+			filter:   domain.PersonFilter{Surname: ptr("delta"), Threshold: ptr[float32](0.1)},
+			expected: []string{"Xylophone"},
+			total:    4,
+		},
+	})
+}
+
+//nolint:funlen
+func testMatchModes(t *testing.T, people repo.PersonRepo) {
+	create(t, people,
+		person("Дмитрий", "Шмидт", "Иванович", 30, domain.Male, "RU"),
+		person("Dmitry", "Schmidt", "", 40, domain.Male, "DE"),
+		person("Dmitriy", "Smith", "Ivanovich", 50, domain.Male, "US"),
+		person("Anna", "Lopez Garcia", "", 20, domain.Female, "ES"),
+		person("Alexei", "Ivanov", "Petrovich", 60, domain.Male, "RU"),
+	)
+
+	runFilterCases(t, people, []filterCase{
+		{
+			// the names are matched transliterated, then ordered by similarity
+			name:     "fulltext transliteration",
+			filter:   domain.PersonFilter{Name: ptr("Дмитрий"), Match: domain.MatchFulltext},
+			expected: []string{"Дмитрий", "Dmitry", "Dmitriy"},
+			total:    3,
+		},
+		{
+			name:     "fulltext word of a name",
+			filter:   domain.PersonFilter{Surname: ptr("GARCIA"), Match: domain.MatchFulltext},
+			expected: []string{"Anna"},
+			total:    1,
+		},
+		{
+			name:     "fulltext every word",
+			filter:   domain.PersonFilter{Surname: ptr("Garcia Smith"), Match: domain.MatchFulltext},
+			expected: []string{},
+			total:    0,
+		},
+		{
+			name:     "fulltext without words",
+			filter:   domain.PersonFilter{Name: ptr("?!"), Match: domain.MatchFulltext},
+			expected: []string{},
+			total:    0,
+		},
+		{
+			// the names are conditions, so there is nothing to compare it with
+			name: "threshold ignored",
+			filter: domain.PersonFilter{
+				Name: ptr("Dmitri"), Threshold: ptr[float32](0.9), Match: domain.MatchFulltext,
+			},
+			expected: []string{"Dmitriy", "Dmitry", "Дмитрий"},
+			total:    3,
+		},
+		{
+			// XMT is a code of every surname
+			name:     "phonetic",
+			filter:   domain.PersonFilter{Surname: ptr("Шмидт"), Match: domain.MatchPhonetic},
+			expected: []string{"Дмитрий", "Dmitry", "Dmitriy"},
+			total:    3,
+		},
+		{
+			name:     "phonetic double letters",
+			filter:   domain.PersonFilter{Name: ptr("Ana"), Match: domain.MatchPhonetic},
+			expected: []string{"Anna"},
+			total:    1,
+		},
+		{
+			name:     "phonetic transliteration",
+			filter:   domain.PersonFilter{Name: ptr("Алексей"), Match: domain.MatchPhonetic},
+			expected: []string{"Alexei"},
+			total:    1,
+		},
+		{
+			name:     "phonetic patronymic",
+			filter:   domain.PersonFilter{Patronymic: ptr("Ivanovich"), Match: domain.MatchPhonetic},
+			expected: []string{"Dmitriy", "Дмитрий"},
+			total:    2,
+		},
+		{
+			// an empty patronymic only matches an empty one
+			name: "phonetic empty patronymic",
+			filter: domain.PersonFilter{
+				Name: ptr("Dmitri"), Patronymic: ptr(""), Match: domain.MatchPhonetic,
+			},
+			expected: []string{"Dmitry"},
+			total:    1,
+		},
+	})
+
+	_, err := people.List(context.Background(), domain.PersonFilter{Match: "soundex"}, allPeople)
+	expectError(t, "list with an invalid match", err, repo.ErrArgument)
+}
+
+func testOrdering(t *testing.T, people repo.PersonRepo) {
+	// created out of order
+	created := create(t, people,
+		person("A", "B", "", 10, domain.Male, "AB"),
+		person("A", "A", "A", 30, domain.Female, "AB"),
+		person("A", "A", "B", 20, domain.Male, "AB"),
+		person("A", "A", "A", 30, domain.Male, "RU"),
+		person("B", "A", "", 10, domain.Male, "AB"),
+		person("A", "A", "A", 40, domain.Male, "AB"),
+		person("A", "A", "", 50, domain.Female, "ZZ"),
+		person("A", "A", "A", 30, domain.Male, "AB"),
+	)
+
+	expect := func(filter domain.PersonFilter, order ...int) {
+		t.Helper()
+
+		expected := make([]domain.Person, 0, len(order))
+		for _, index := range order {
+			expected = append(expected, created[index])
+		}
+
+		if page := list(t, people, filter, allPeople); !reflect.DeepEqual(page.Items, expected) {
+			t.Errorf("%+v: expected %v, got %v", filter, expected, page.Items)
+		}
+	}
+
+	// with equal similarity people are ordered by surname, name, patronymic,
+	// age, sex (male first) and nationality
+	expect(domain.PersonFilter{}, 6, 7, 3, 1, 5, 2, 4, 0)
+	// more similar people first
+	expect(domain.PersonFilter{Name: ptr("B")}, 4, 6, 7, 3, 1, 5, 2, 0)
+}
+
+func testPagination(t *testing.T, people repo.PersonRepo) {
+	create(t, people,
+		person("A", "A", "", 30, domain.Male, "RU"),
+		person("B", "B", "", 30, domain.Male, "RU"),
+		person("C", "C", "", 30, domain.Male, "RU"),
+	)
+
+	testCases := []struct {
+		pagination domain.PaginationFilter
+		expected   []string
+	}{
+		{domain.PaginationFilter{Offset: 0, Limit: 2}, []string{"A", "B"}},
+		{domain.PaginationFilter{Offset: 2, Limit: 2}, []string{"C"}},
+		{domain.PaginationFilter{Offset: 3, Limit: 2}, []string{}},
+		{domain.PaginationFilter{Offset: 10, Limit: 2}, []string{}},
+		{domain.PaginationFilter{Offset: 1, Limit: 0}, []string{}},
+		{domain.PaginationFilter{Offset: 0, Limit: 3}, []string{"A", "B", "C"}},
+	}
+
+	for _, test := range testCases {
+		page := list(t, people, domain.PersonFilter{}, test.pagination)
+
+		if got := names(page); !reflect.DeepEqual(got, test.expected) || page.TotalItems != 3 ||
+			page.CurrentOffset != test.pagination.Offset || page.CurrentLimit != test.pagination.Limit {
+			t.Errorf("%+v: expected %v, got %v (%+v)", test.pagination, test.expected, got, page)
+		}
+	}
+
+	// history pages are newest first
+	created := create(t, people, person("D", "D", "", 30, domain.Male, "RU"))[0]
+	if err := people.Delete(context.Background(), created.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := people.History(context.Background(), created.ID, domain.PaginationFilter{Offset: 1, Limit: 5})
+	if err != nil || history.TotalItems != 2 || len(history.Items) != 1 ||
+		history.Items[0].Operation != domain.ChangeCreate {
+		t.Errorf("unexpected history page: %+v (%v)", history, err)
+	}
+}
+
+func testPurge(t *testing.T, people repo.PersonRepo) {
+	ctx := context.Background()
+	created := create(t, people,
+		person("A", "A", "", 30, domain.Male, "RU"),
+		person("B", "B", "", 30, domain.Male, "RU"),
+	)
+
+	if err := people.Delete(ctx, created[0].ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if purged, err := people.Purge(ctx, time.Hour); err != nil || purged != 0 {
+		t.Errorf("people deleted within the retention were purged: %d (%v)", purged, err)
+	}
+
+	if purged, err := people.Purge(ctx, 0); err != nil || purged != 1 {
+		t.Errorf("expected 1 purged person, got %d (%v)", purged, err)
+	}
+
+	expectError(t, "restore purged", people.Restore(ctx, created[0].ID), repo.ErrNotFound)
+	expectPerson(t, people, created[1])
+
+	if page := list(t, people, domain.PersonFilter{IncludeDeleted: true}, allPeople); page.TotalItems != 1 {
+		t.Errorf("purged person is listed: %+v", page)
+	}
+}
+
+//nolint:funlen
+func testTransactions(t *testing.T, people repo.PersonRepo) {
+	ctx := context.Background()
+	created := person("A", "A", "", 30, domain.Male, "RU")
+
+	err := people.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if created.ID, err = people.Create(ctx, created); err != nil {
+			return err //nolint:wrapcheck
+		}
+
+		// the transaction sees its own changes
+		if got, err := people.GetByID(ctx, created.ID); err != nil || got != created {
+			t.Errorf("the transaction does not see its changes: %v (%v)", got, err)
+		}
+
+		return errRollback
+	})
+	expectError(t, "failed transaction", err, errRollback)
+
+	_, err = people.GetByID(ctx, created.ID)
+	expectError(t, "get rolled back", err, repo.ErrNotFound)
+
+	var inner domain.Person
+
+	err = people.WithinTx(ctx, func(ctx context.Context) error {
+		id, err := people.Create(ctx, person("B", "B", "", 30, domain.Male, "RU"))
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+
+		created = person("B", "B", "", 30, domain.Male, "RU")
+		created.ID = id
+
+		// a failed nested transaction only rolls back its own changes
+		nestedErr := people.WithinTx(ctx, func(ctx context.Context) error {
+			inner.ID, err = people.Create(ctx, person("C", "C", "", 30, domain.Male, "RU"))
+			if err != nil {
+				return err //nolint:wrapcheck
+			}
+
+			return errRollback
+		})
+		expectError(t, "failed nested transaction", nestedErr, errRollback)
+
+		// a failed statement does not abort the transaction
+		expectError(t, "delete unknown", people.Delete(ctx, uuid.New()), repo.ErrNotFound)
+
+		//nolint:exhaustruct
+		return people.PartialUpdate(ctx, id, domain.PersonPartial{Age: ptr(31)}) //nolint:wrapcheck
+	})
+	expectError(t, "transaction", err, nil)
+
+	created.Age = 31
+	expectPerson(t, people, created)
+
+	_, err = people.GetByID(ctx, inner.ID)
+	expectError(t, "get rolled back by a nested transaction", err, repo.ErrNotFound)
+
+	if page := list(t, people, domain.PersonFilter{IncludeDeleted: true}, allPeople); page.TotalItems != 1 {
+		t.Errorf("expected only the committed person, got %+v", page)
+	}
+}
+
+//nolint:funlen
+func testConcurrency(t *testing.T, people repo.PersonRepo) {
+	const (
+		workers    = 8
+		iterations = 5
+	)
+
+	counter := create(t, people, person("Counter", "Counter", "", 0, domain.Male, "RU"))[0]
+
+	var (
+		wg        sync.WaitGroup
+		mutex     sync.Mutex
+		increased int
+		errs      []error
+	)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < iterations; j++ {
+				ctx := context.Background()
+
+				if _, err := people.Create(ctx, person("Worker", "Worker", "", j, domain.Female, "KZ")); err != nil {
+					mutex.Lock()
+					errs = append(errs, err)
+					mutex.Unlock()
+				}
+
+				// read-modify-write: transactions must not lose updates
+				err := people.WithinTx(ctx, func(ctx context.Context) error {
+					current, err := people.GetByID(ctx, counter.ID)
+					if err != nil {
+						return err //nolint:wrapcheck
+					}
+
+					//nolint:exhaustruct
+					return people.PartialUpdate(ctx, counter.ID, domain.PersonPartial{Age: ptr(current.Age + 1)}) //nolint:wrapcheck
+				})
+
+				mutex.Lock()
+				if err == nil {
+					increased++
+				} else if !errors.Is(err, repo.ErrConflict) {
+					// giving up on serialization failures is allowed
+					errs = append(errs, err)
+				}
+				mutex.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if len(errs) > 0 {
+		t.Fatalf("concurrent operations failed: %v", errors.Join(errs...))
+	}
+
+	counter.Age = increased
+	expectPerson(t, people, counter)
+
+	page := list(t, people, domain.PersonFilter{Name: ptr("Worker"), Threshold: ptr[float32](0.3)}, allPeople)
+	if page.TotalItems != 1+workers*iterations || len(page.Items) != workers*iterations {
+		t.Errorf("expected %d created people, got %d (%d in total)", workers*iterations, len(page.Items), page.TotalItems)
+	}
+}
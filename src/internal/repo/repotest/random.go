@@ -0,0 +1,398 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Hofsiedge/person-api/internal/domain"
+	"github.com/Hofsiedge/person-api/internal/repo"
+	"github.com/Hofsiedge/person-api/internal/utils"
+	"github.com/google/uuid"
+)
+
+const (
+	// random sequences run by a test
+	sequences = 5
+	// operations of a random sequence
+	sequenceLength = 40
+)
+
+// modelPerson is the expected state of a person of a random sequence
+type modelPerson struct {
+	person  domain.Person
+	deleted bool
+	// number of recorded changes
+	changes int
+}
+
+// model is the expected state of a repo: people by the order of creation
+type model []modelPerson
+
+// operation is a step of a random sequence. People are addressed by the
+// order of creation, so a sequence can be applied to several repos.
+type operation struct {
+	// apply applies the operation to a repo with the IDs of the people
+	// created so far
+	apply func(ctx context.Context, people repo.PersonRepo, ids *[]uuid.UUID) error
+	// expect applies the operation to the model and returns the expected error
+	expect func(m *model) error
+	name   string
+}
+
+// update is the model of updating a person of the model
+func (m model) update(index int, modify func(person *domain.Person)) error {
+	if m[index].deleted {
+		return repo.ErrNotFound
+	}
+
+	updated := m[index].person
+	modify(&updated)
+
+	if updated != m[index].person {
+		m[index].person = updated
+		m[index].changes++
+	}
+
+	return nil
+}
+
+// randomOperation returns a random operation of a sequence with count
+// people created so far
+//
+//nolint:funlen
+func randomOperation(rng *rand.Rand, count int) operation {
+	const kinds = 6
+
+	kind := 0
+	if count > 0 {
+		kind = rng.Intn(kinds)
+	}
+
+	index := 0
+	if count > 0 {
+		index = rng.Intn(count)
+	}
+
+	switch kind {
+	case 1:
+		replacement := utils.MakePerson()
+
+		return operation{
+			name: fmt.Sprintf("full update %d to %v", index, replacement),
+			apply: func(ctx context.Context, people repo.PersonRepo, ids *[]uuid.UUID) error {
+				return people.FullUpdate(ctx, (*ids)[index], replacement) //nolint:wrapcheck
+			},
+			expect: func(m *model) error {
+				return m.update(index, func(person *domain.Person) {
+					replacement.ID = person.ID
+					*person = replacement
+				})
+			},
+		}
+	case 2: //nolint:gomnd
+		age := rng.Intn(130) //nolint:gomnd
+
+		return operation{
+			name: fmt.Sprintf("set age of %d to %d", index, age),
+			apply: func(ctx context.Context, people repo.PersonRepo, ids *[]uuid.UUID) error {
+				//nolint:exhaustruct,wrapcheck
+				return people.PartialUpdate(ctx, (*ids)[index], domain.PersonPartial{Age: &age})
+			},
+			expect: func(m *model) error {
+				if age > 125 && !(*m)[index].deleted { //nolint:gomnd
+					return repo.ErrArgument
+				}
+
+				return m.update(index, func(person *domain.Person) { person.Age = age })
+			},
+		}
+	case 3: //nolint:gomnd
+		return operation{
+			name: fmt.Sprintf("remove patronymic of %d", index),
+			apply: func(ctx context.Context, people repo.PersonRepo, ids *[]uuid.UUID) error {
+				return people.Patch(ctx, (*ids)[index], []domain.PatchOperation{ //nolint:wrapcheck
+					{Op: domain.PatchRemove, Field: domain.FieldPatronymic, Value: nil},
+				})
+			},
+			expect: func(m *model) error {
+				return m.update(index, func(person *domain.Person) { person.Patronymic = "" })
+			},
+		}
+	case 4: //nolint:gomnd
+		return operation{
+			name: fmt.Sprintf("delete %d", index),
+			apply: func(ctx context.Context, people repo.PersonRepo, ids *[]uuid.UUID) error {
+				return people.Delete(ctx, (*ids)[index]) //nolint:wrapcheck
+			},
+			expect: func(m *model) error {
+				if (*m)[index].deleted {
+					return repo.ErrNotFound
+				}
+
+				(*m)[index].deleted = true
+				(*m)[index].changes++
+
+				return nil
+			},
+		}
+	case 5: //nolint:gomnd
+		return operation{
+			name: fmt.Sprintf("restore %d", index),
+			apply: func(ctx context.Context, people repo.PersonRepo, ids *[]uuid.UUID) error {
+				return people.Restore(ctx, (*ids)[index]) //nolint:wrapcheck
+			},
+			expect: func(m *model) error {
+				if !(*m)[index].deleted {
+					return repo.ErrConflict
+				}
+
+				(*m)[index].deleted = false
+				(*m)[index].changes++
+
+				return nil
+			},
+		}
+	}
+
+	created := utils.MakePerson()
+
+	return operation{
+		name: fmt.Sprintf("create %v", created),
+		apply: func(ctx context.Context, people repo.PersonRepo, ids *[]uuid.UUID) error {
+			id, err := people.Create(ctx, created)
+			if err == nil {
+				*ids = append(*ids, id)
+			}
+
+			return err //nolint:wrapcheck
+		},
+		expect: func(m *model) error {
+			*m = append(*m, modelPerson{person: created, deleted: false, changes: 1})
+
+			return nil
+		},
+	}
+}
+
+// randomSequence returns a random sequence of operations
+func randomSequence(rng *rand.Rand) []operation {
+	var (
+		operations = make([]operation, 0, sequenceLength)
+		state      model
+	)
+
+	for i := 0; i < sequenceLength; i++ {
+		operation := randomOperation(rng, len(state))
+		operation.expect(&state) //nolint:errcheck
+		operations = append(operations, operation)
+	}
+
+	return operations
+}
+
+// sameError reports whether err and expected wrap the same sentinel error
+func sameError(err, expected error) bool {
+	for _, sentinel := range []error{repo.ErrNotFound, repo.ErrArgument, repo.ErrConflict} {
+		if errors.Is(expected, sentinel) {
+			return errors.Is(err, sentinel)
+		}
+	}
+
+	return err == nil && expected == nil
+}
+
+// describe describes the operations applied so far for failure messages
+func describe(operations []operation) string {
+	names := make([]string, 0, len(operations))
+	for _, operation := range operations {
+		names = append(names, operation.name)
+	}
+
+	return strings.Join(names, "\n")
+}
+
+// checkModel compares the people, their histories and the number of listed
+// people with the model
+func checkModel(t *testing.T, people repo.PersonRepo, ids []uuid.UUID, state model) error {
+	t.Helper()
+
+	ctx := context.Background()
+	active := 0
+
+	for i, expected := range state {
+		expected.person.ID = ids[i]
+
+		got, err := people.GetByID(ctx, ids[i])
+
+		switch {
+		case expected.deleted && !errors.Is(err, repo.ErrNotFound):
+			return fmt.Errorf("deleted person %d: %v (%w)", i, got, err)
+		case !expected.deleted && (err != nil || got != expected.person):
+			return fmt.Errorf("person %d: expected %v, got %v (%w)", i, expected.person, got, err)
+		case !expected.deleted:
+			active++
+		}
+
+		history, err := people.History(ctx, ids[i], domain.PaginationFilter{Offset: 0, Limit: 1})
+		if err != nil || history.TotalItems != expected.changes {
+			return fmt.Errorf("history of %d: expected %d changes, got %d (%w)",
+				i, expected.changes, history.TotalItems, err)
+		}
+	}
+
+	if page := list(t, people, domain.PersonFilter{}, allPeople); page.TotalItems != active {
+		return fmt.Errorf("expected %d listed people, got %d", active, page.TotalItems)
+	}
+
+	if page := list(t, people, domain.PersonFilter{IncludeDeleted: true}, allPeople); page.TotalItems != len(state) {
+		return fmt.Errorf("expected %d listed people with deleted ones, got %d", len(state), page.TotalItems)
+	}
+
+	return nil
+}
+
+// testRandomSequences applies random sequences of operations to the repo
+// and to a model of it, comparing errors and the resulting state
+func testRandomSequences(t *testing.T, people repo.PersonRepo) {
+	seed := time.Now().UnixNano()
+	rng := rand.New(rand.NewSource(seed)) //nolint:gosec
+
+	ctx := context.Background()
+
+	for sequence := 0; sequence < sequences; sequence++ {
+		// people of earlier sequences stay in the repo
+		var (
+			ids   []uuid.UUID
+			state model
+		)
+
+		operations := randomSequence(rng)
+
+		for i, operation := range operations {
+			expected := operation.expect(&state)
+
+			if err := operation.apply(ctx, people, &ids); !sameError(err, expected) {
+				t.Fatalf("seed %d: %s: expected %v, got %v after:\n%s",
+					seed, operation.name, expected, err, describe(operations[:i]))
+			}
+		}
+
+		if err := checkModel(t, people, ids, state); err != nil {
+			t.Fatalf("seed %d: %v after:\n%s", seed, err, describe(operations))
+		}
+
+		// the next sequence starts from the empty state of the model
+		for _, id := range ids {
+			_ = people.Delete(ctx, id)
+		}
+
+		if _, err := people.Purge(ctx, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// withoutIDs returns the people of a page without their IDs, which differ
+// between repos
+func withoutIDs(page domain.Page[domain.Person]) []domain.Person {
+	result := make([]domain.Person, 0, len(page.Items))
+
+	for _, person := range page.Items {
+		person.ID = uuid.Nil
+		result = append(result, person)
+	}
+
+	return result
+}
+
+// randomFilter returns a filter matching some of the people: parts of
+// the name and the surname of one of them, an age range, a sex,
+// a threshold or the phonetic and fulltext match of the names
+func randomFilter(rng *rand.Rand, persons []domain.Person) domain.PersonFilter {
+	var filter domain.PersonFilter
+
+	if len(persons) == 0 {
+		return filter
+	}
+
+	chosen := persons[rng.Intn(len(persons))]
+	prefix := func(value string) *string {
+		return ptr(strings.ToLower(value[:1+rng.Intn(len(value))]))
+	}
+
+	//nolint:gomnd
+	switch rng.Intn(8) {
+	case 0:
+		filter.Name = prefix(chosen.Name)
+	case 1:
+		filter.Surname = prefix(chosen.Surname)
+	case 2:
+		filter.Name, filter.Surname = prefix(chosen.Name), prefix(chosen.Surname)
+		filter.Threshold = ptr(float32(rng.Intn(5)) / 10)
+	case 3:
+		filter.Patronymic = ptr("")
+		filter.Sex = ptr(chosen.Sex)
+	case 4:
+		filter.AgeMin, filter.AgeMax = ptr(chosen.Age-10), ptr(chosen.Age+10)
+		filter.IncludeDeleted = true
+	case 5:
+		filter.Nationality = ptr(chosen.Nationality)
+		filter.Threshold = ptr[float32](0.1)
+	case 6:
+		filter.Surname = ptr(chosen.Surname)
+		filter.Match = domain.MatchPhonetic
+	case 7:
+		filter.Name = ptr(strings.ToUpper(chosen.Name))
+		filter.Match = domain.MatchFulltext
+	}
+
+	return filter
+}
+
+// RunDifferential applies the same random sequences of operations to
+// the repos of reference and factory and checks that every operation
+// fails the same way and that the repos list the same people in the same
+// order for random filters. Tests of the factories are not run in parallel.
+func RunDifferential(t *testing.T, reference, factory Factory) {
+	t.Helper()
+
+	seed := time.Now().UnixNano()
+	rng := rand.New(rand.NewSource(seed)) //nolint:gosec
+
+	for sequence := 0; sequence < sequences; sequence++ {
+		t.Run(fmt.Sprintf("sequence %d", sequence), func(t *testing.T) {
+			ctx := context.Background()
+			repos := []repo.PersonRepo{reference(t), factory(t)}
+			ids := make([][]uuid.UUID, len(repos))
+			operations := randomSequence(rng)
+
+			for i, operation := range operations {
+				referenceErr := operation.apply(ctx, repos[0], &ids[0])
+				if err := operation.apply(ctx, repos[1], &ids[1]); !sameError(err, referenceErr) {
+					t.Fatalf("seed %d: %s: expected %v, got %v after:\n%s",
+						seed, operation.name, referenceErr, err, describe(operations[:i]))
+				}
+			}
+
+			persons := withoutIDs(list(t, repos[0], domain.PersonFilter{IncludeDeleted: true}, allPeople))
+
+			for i := 0; i < sequenceLength; i++ {
+				filter := randomFilter(rng, persons)
+				expected := list(t, repos[0], filter, allPeople)
+				got := list(t, repos[1], filter, allPeople)
+
+				if expected.TotalItems != got.TotalItems || !reflect.DeepEqual(withoutIDs(expected), withoutIDs(got)) {
+					t.Fatalf("seed %d: %+v: expected %v (%d in total), got %v (%d in total) after:\n%s",
+						seed, filter, withoutIDs(expected), expected.TotalItems,
+						withoutIDs(got), got.TotalItems, describe(operations))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+// Package repotest contains the conformance suite of repo.PersonRepo
+// implementations: every backend has to behave like people.list_people
+// and the other functions of the Postgres schema.
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Hofsiedge/person-api/internal/domain"
+	"github.com/Hofsiedge/person-api/internal/repo"
+	"github.com/google/uuid"
+)
+
+// Factory returns an empty repo for a test of the suite. Tests of the
+// suite are not run in parallel, so backends sharing a database can empty
+// it in the factory (and in t.Cleanup to leave it empty for other tests).
+type Factory func(t *testing.T) repo.PersonRepo
+
+// conformanceTest is a test of the suite run with an empty repo
+type conformanceTest struct {
+	run  func(t *testing.T, people repo.PersonRepo)
+	name string
+}
+
+// RunConformance runs the conformance suite against the repos of factory
+func RunConformance(t *testing.T, factory Factory) {
+	t.Helper()
+
+	tests := []conformanceTest{
+		{name: "crud", run: testCRUD},
+		{name: "errors", run: testErrors},
+		{name: "invalid people", run: testInvalidPeople},
+		{name: "filters", run: testFilters},
+		{name: "similarity", run: testSimilarity},
+		{name: "match modes", run: testMatchModes},
+		{name: "ordering", run: testOrdering},
+		{name: "pagination", run: testPagination},
+		{name: "purge", run: testPurge},
+		{name: "transactions", run: testTransactions},
+		{name: "concurrency", run: testConcurrency},
+		{name: "random sequences", run: testRandomSequences},
+	}
+
+	for _, test := range tests {
+		run := test.run
+		t.Run(test.name, func(t *testing.T) {
+			run(t, factory(t))
+		})
+	}
+}
+
+// allPeople is a page large enough for every test
+//
+//nolint:gochecknoglobals
+var allPeople = domain.PaginationFilter{Offset: 0, Limit: 1000}
+
+func ptr[T any](value T) *T {
+	return &value
+}
+
+// person returns a valid person
+func person(name, surname, patronymic string, age int, sex domain.Sex, nationality domain.Nationality) domain.Person {
+	return domain.Person{
+		Name:        name,
+		Surname:     surname,
+		Patronymic:  patronymic,
+		Nationality: nationality,
+		Sex:         sex,
+		Age:         age,
+		ID:          uuid.Nil,
+	}
+}
+
+// create creates people and returns them with their IDs
+func create(t *testing.T, people repo.PersonRepo, persons ...domain.Person) []domain.Person {
+	t.Helper()
+
+	created := make([]domain.Person, 0, len(persons))
+
+	for _, person := range persons {
+		id, err := people.Create(context.Background(), person)
+		if err != nil {
+			t.Fatalf("could not create %v: %v", person, err)
+		}
+
+		person.ID = id
+		created = append(created, person)
+	}
+
+	return created
+}
+
+// list lists people, failing the test on errors
+func list(
+	t *testing.T, people repo.PersonRepo, filter domain.PersonFilter, pagination domain.PaginationFilter,
+) domain.Page[domain.Person] {
+	t.Helper()
+
+	page, err := people.List(context.Background(), filter, pagination)
+	if err != nil {
+		t.Fatalf("could not list people (%+v): %v", filter, err)
+	}
+
+	return page
+}
+
+// names returns the names of the people of a page
+func names(page domain.Page[domain.Person]) []string {
+	result := make([]string, 0, len(page.Items))
+	for _, person := range page.Items {
+		result = append(result, person.Name)
+	}
+
+	return result
+}
+
+// expectError checks that err wraps target (or is nil if target is nil)
+func expectError(t *testing.T, operation string, err, target error) {
+	t.Helper()
+
+	if !errors.Is(err, target) {
+		t.Errorf("%s: expected %v, got %v", operation, target, err)
+	}
+}
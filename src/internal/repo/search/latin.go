@@ -0,0 +1,84 @@
+package search
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// cyrillic transliterates Cyrillic letters (Russian, Ukrainian and
+// Belarusian ones) like people.latin
+//
+//nolint:gochecknoglobals
+var cyrillic = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'ґ': "g", 'д': "d", 'е': "e", 'ё': "e",
+	'є': "ye", 'ж': "zh", 'з': "z", 'и': "i", 'і': "i", 'ї': "yi", 'й': "y", 'к': "k",
+	'л': "l", 'м': "m", 'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t",
+	'у': "u", 'ў': "u", 'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch",
+	'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+	'А': "a", 'Б': "b", 'В': "v", 'Г': "g", 'Ґ': "g", 'Д': "d", 'Е': "e", 'Ё': "e",
+	'Є': "ye", 'Ж': "zh", 'З': "z", 'И': "i", 'І': "i", 'Ї': "yi", 'Й': "y", 'К': "k",
+	'Л': "l", 'М': "m", 'Н': "n", 'О': "o", 'П': "p", 'Р': "r", 'С': "s", 'Т': "t",
+	'У': "u", 'Ў': "u", 'Ф': "f", 'Х': "kh", 'Ц': "ts", 'Ч': "ch", 'Ш': "sh", 'Щ': "shch",
+	'Ъ': "", 'Ы': "y", 'Ь': "", 'Э': "e", 'Ю': "yu", 'Я': "ya",
+}
+
+// variants are replaced in order to make common transliterations of
+// a name the same: Dmitriy, Dmitrij, Dmitrii and Dmitry are dmitri,
+// Aleksey and Alexei are aleksei
+//
+//nolint:gochecknoglobals
+var variants = [][2]string{
+	{"ph", "f"},
+	{"x", "ks"},
+	{"w", "v"},
+	{"j", "y"},
+	{"iy", "i"},
+	{"ii", "i"},
+}
+
+//nolint:gochecknoglobals
+var (
+	nonLetters = regexp.MustCompile(`[^a-z]+`)
+	// y at the end of a word
+	finalY = regexp.MustCompile(`y\b`)
+)
+
+// Latin is the name transliterated to Latin like people.latin: Cyrillic
+// letters are transliterated, diacritics are removed, other characters
+// than Latin letters separate lowercase words and common variants of
+// transliteration are made the same
+func Latin(value string) string {
+	var transliterated strings.Builder
+
+	for _, char := range value {
+		if latin, found := cyrillic[char]; found {
+			transliterated.WriteString(latin)
+		} else {
+			transliterated.WriteRune(char)
+		}
+	}
+
+	// combining diacritical marks (U+0300 - U+036F) of decomposed letters
+	result := strings.Map(func(char rune) rune {
+		if char >= '\u0300' && char <= '\u036f' {
+			return -1
+		}
+
+		return char
+	}, norm.NFD.String(transliterated.String()))
+
+	result = strings.TrimSpace(nonLetters.ReplaceAllString(strings.ToLower(result), " "))
+
+	for _, variant := range variants {
+		result = strings.ReplaceAll(result, variant[0], variant[1])
+	}
+
+	return finalY.ReplaceAllString(result, "i")
+}
+
+// Words are the words of the Latin form of the name
+func Words(value string) []string {
+	return strings.Fields(Latin(value))
+}
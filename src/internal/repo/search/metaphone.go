@@ -0,0 +1,664 @@
+package search
+
+import (
+	"slices"
+	"strings"
+)
+
+// metaphoneLength is the maximal length of Double Metaphone codes
+const metaphoneLength = 4
+
+// metaphone is the state of DoubleMetaphone, the names follow dmetaphone.c
+// of fuzzystrmatch
+type metaphone struct {
+	// the uppercase word padded with spaces, so that the letters after
+	// the end of the word are spaces
+	original           string
+	primary, secondary strings.Builder
+	current            int
+	length             int
+	last               int
+}
+
+// at returns the letter at pos or 0 outside of the padded word
+func (m *metaphone) at(pos int) byte {
+	if pos < 0 || pos >= len(m.original) {
+		return 0
+	}
+
+	return m.original[pos]
+}
+
+// stringAt checks if one of the values of the length is at start
+func (m *metaphone) stringAt(start, length int, values ...string) bool {
+	if start < 0 || start >= len(m.original) {
+		return false
+	}
+
+	end := min(start+length, len(m.original))
+
+	return slices.Contains(values, m.original[start:end])
+}
+
+func (m *metaphone) isVowel(pos int) bool {
+	return strings.IndexByte("AEIOUY", m.at(pos)) >= 0
+}
+
+func (m *metaphone) slavoGermanic() bool {
+	return strings.Contains(m.original, "W") || strings.Contains(m.original, "K") ||
+		strings.Contains(m.original, "CZ") || strings.Contains(m.original, "WITZ")
+}
+
+func (m *metaphone) add(primary, secondary string) {
+	m.primary.WriteString(primary)
+	m.secondary.WriteString(secondary)
+}
+
+// skip advances by 2 if the next letter is one of letters, by 1 otherwise
+func (m *metaphone) skip(letters string) {
+	if strings.IndexByte(letters, m.at(m.current+1)) >= 0 {
+		m.current += 2
+	} else {
+		m.current++
+	}
+}
+
+// DoubleMetaphone returns the primary and the alternate Double Metaphone
+// codes of the word like dmetaphone and dmetaphone_alt of fuzzystrmatch.
+// Only Latin letters are coded, other characters are skipped.
+func DoubleMetaphone(word string) (string, string) {
+	m := &metaphone{
+		original: strings.ToUpper(word) + "     ",
+		length:   len(word),
+		last:     len(word) - 1,
+	}
+
+	// skip these when at start of word
+	if m.stringAt(0, 2, "GN", "KN", "PN", "WR", "PS") {
+		m.current++
+	}
+
+	// initial 'X' is pronounced 'Z' e.g. 'Xavier'
+	if m.at(0) == 'X' {
+		m.add("S", "S")
+		m.current++
+	}
+
+	for m.primary.Len() < metaphoneLength || m.secondary.Len() < metaphoneLength {
+		if m.current >= m.length {
+			break
+		}
+
+		m.next()
+	}
+
+	return truncate(m.primary.String()), truncate(m.secondary.String())
+}
+
+func truncate(code string) string {
+	if len(code) > metaphoneLength {
+		return code[:metaphoneLength]
+	}
+
+	return code
+}
+
+// next codes the letter at current and advances
+//
+//nolint:cyclop,gomnd
+func (m *metaphone) next() {
+	switch m.at(m.current) {
+	case 'A', 'E', 'I', 'O', 'U', 'Y':
+		// all init vowels now map to 'A'
+		if m.current == 0 {
+			m.add("A", "A")
+		}
+
+		m.current++
+	case 'B':
+		// "-mb", e.g", "dumb", already skipped over...
+		m.add("P", "P")
+		m.skip("B")
+	case 'C':
+		m.c()
+	case 'D':
+		m.d()
+	case 'F':
+		m.skip("F")
+		m.add("F", "F")
+	case 'G':
+		m.g()
+	case 'H':
+		// only keep if first & before vowel or between 2 vowels
+		if (m.current == 0 || m.isVowel(m.current-1)) && m.isVowel(m.current+1) {
+			m.add("H", "H")
+			m.current += 2
+		} else {
+			// also takes care of 'HH'
+			m.current++
+		}
+	case 'J':
+		m.j()
+	case 'K':
+		m.skip("K")
+		m.add("K", "K")
+	case 'L':
+		m.l()
+	case 'M':
+		if (m.stringAt(m.current-1, 3, "UMB") &&
+			(m.current+1 == m.last || m.stringAt(m.current+2, 2, "ER"))) ||
+			m.at(m.current+1) == 'M' {
+			// 'dumb', 'thumb'
+			m.current += 2
+		} else {
+			m.current++
+		}
+
+		m.add("M", "M")
+	case 'N':
+		m.skip("N")
+		m.add("N", "N")
+	case 'P':
+		if m.at(m.current+1) == 'H' {
+			m.add("F", "F")
+			m.current += 2
+
+			return
+		}
+
+		// also account for "campbell", "raspberry"
+		m.skip("PB")
+		m.add("P", "P")
+	case 'Q':
+		m.skip("Q")
+		m.add("K", "K")
+	case 'R':
+		// french e.g. 'rogier', but exclude 'hochmeier'
+		if m.current == m.last && !m.slavoGermanic() &&
+			m.stringAt(m.current-2, 2, "IE") && !m.stringAt(m.current-4, 2, "ME", "MA") {
+			m.add("", "R")
+		} else {
+			m.add("R", "R")
+		}
+
+		m.skip("R")
+	case 'S':
+		m.s()
+	case 'T':
+		m.t()
+	case 'V':
+		m.skip("V")
+		m.add("F", "F")
+	case 'W':
+		m.w()
+	case 'X':
+		// french e.g. breaux
+		if !(m.current == m.last &&
+			(m.stringAt(m.current-3, 3, "IAU", "EAU") || m.stringAt(m.current-2, 2, "AU", "OU"))) {
+			m.add("KS", "KS")
+		}
+
+		m.skip("CX")
+	case 'Z':
+		m.z()
+	default:
+		m.current++
+	}
+}
+
+//nolint:cyclop,funlen,gomnd
+func (m *metaphone) c() {
+	current := m.current
+
+	switch {
+	// various germanic
+	case current > 1 && !m.isVowel(current-2) && m.stringAt(current-1, 3, "ACH") &&
+		m.at(current+2) != 'I' && (m.at(current+2) != 'E' || m.stringAt(current-2, 6, "BACHER", "MACHER")):
+		m.add("K", "K")
+		m.current += 2
+	// special case 'caesar'
+	case current == 0 && m.stringAt(current, 6, "CAESAR"):
+		m.add("S", "S")
+		m.current += 2
+	// italian 'chianti'
+	case m.stringAt(current, 4, "CHIA"):
+		m.add("K", "K")
+		m.current += 2
+	case m.stringAt(current, 2, "CH"):
+		m.ch()
+	// e.g, 'czerny'
+	case m.stringAt(current, 2, "CZ") && !m.stringAt(current-2, 4, "WICZ"):
+		m.add("S", "X")
+		m.current += 2
+	// e.g., 'focaccia'
+	case m.stringAt(current+1, 3, "CIA"):
+		m.add("X", "X")
+		m.current += 3
+	// double 'C', but not if e.g. 'McClellan'
+	case m.stringAt(current, 2, "CC") && !(current == 1 && m.at(0) == 'M'):
+		// 'bellocchio' but not 'bacchus'
+		if m.stringAt(current+2, 1, "I", "E", "H") && !m.stringAt(current+2, 2, "HU") {
+			if (current == 1 && m.at(current-1) == 'A') || m.stringAt(current-1, 5, "UCCEE", "UCCES") {
+				// 'accident', 'accede' 'succeed'
+				m.add("KS", "KS")
+			} else {
+				// 'bacci', 'bertucci', other italian
+				m.add("X", "X")
+			}
+
+			m.current += 3
+		} else {
+			// Pierce's rule
+			m.add("K", "K")
+			m.current += 2
+		}
+	case m.stringAt(current, 2, "CK", "CG", "CQ"):
+		m.add("K", "K")
+		m.current += 2
+	case m.stringAt(current, 2, "CI", "CE", "CY"):
+		// italian vs. english
+		if m.stringAt(current, 3, "CIO", "CIE", "CIA") {
+			m.add("S", "X")
+		} else {
+			m.add("S", "S")
+		}
+
+		m.current += 2
+	default:
+		m.add("K", "K")
+
+		// name sent in 'mac caffrey', 'mac gregor'
+		switch {
+		case m.stringAt(current+1, 2, " C", " Q", " G"):
+			m.current += 3
+		case m.stringAt(current+1, 1, "C", "K", "Q") && !m.stringAt(current+1, 2, "CE", "CI"):
+			m.current += 2
+		default:
+			m.current++
+		}
+	}
+}
+
+//nolint:cyclop,gomnd
+func (m *metaphone) ch() {
+	current := m.current
+
+	switch {
+	// find 'michael'
+	case current > 0 && m.stringAt(current, 4, "CHAE"):
+		m.add("K", "X")
+	// greek roots e.g. 'chemistry', 'chorus'
+	case current == 0 &&
+		(m.stringAt(current+1, 5, "HARAC", "HARIS") || m.stringAt(current+1, 3, "HOR", "HYM", "HIA", "HEM")) &&
+		!m.stringAt(0, 5, "CHORE"):
+		m.add("K", "K")
+	// germanic, greek, or otherwise 'ch' for 'kh' sound
+	case m.stringAt(0, 4, "VAN ", "VON ") || m.stringAt(0, 3, "SCH") ||
+		// 'architect but not 'arch', 'orchestra', 'orchid'
+		m.stringAt(current-2, 6, "ORCHES", "ARCHIT", "ORCHID") ||
+		m.stringAt(current+2, 1, "T", "S") ||
+		((m.stringAt(current-1, 1, "A", "O", "U", "E") || current == 0) &&
+			// e.g., 'wachtler', 'wechsler', but not 'tichner'
+			m.stringAt(current+2, 1, "L", "R", "N", "M", "B", "H", "F", "V", "W", " ")):
+		m.add("K", "K")
+	case current > 0 && m.stringAt(0, 2, "MC"):
+		// e.g., "McHugh"
+		m.add("K", "K")
+	case current > 0:
+		m.add("X", "K")
+	default:
+		m.add("X", "X")
+	}
+
+	m.current += 2
+}
+
+//nolint:gomnd
+func (m *metaphone) d() {
+	switch {
+	case m.stringAt(m.current, 2, "DG"):
+		if m.stringAt(m.current+2, 1, "I", "E", "Y") {
+			// e.g. 'edge'
+			m.add("J", "J")
+			m.current += 3
+		} else {
+			// e.g. 'edgar'
+			m.add("TK", "TK")
+			m.current += 2
+		}
+	case m.stringAt(m.current, 2, "DT", "DD"):
+		m.add("T", "T")
+		m.current += 2
+	default:
+		m.add("T", "T")
+		m.current++
+	}
+}
+
+//nolint:cyclop,funlen,gomnd
+func (m *metaphone) g() {
+	current := m.current
+
+	switch {
+	case m.at(current+1) == 'H':
+		m.gh()
+	case m.at(current+1) == 'N':
+		switch {
+		case current == 1 && m.isVowel(0) && !m.slavoGermanic():
+			m.add("KN", "N")
+		// not e.g. 'cagney'
+		case !m.stringAt(current+2, 2, "EY") && m.at(current+1) != 'Y' && !m.slavoGermanic():
+			m.add("N", "KN")
+		default:
+			m.add("KN", "KN")
+		}
+
+		m.current += 2
+	// 'tagliaro'
+	case m.stringAt(current+1, 2, "LI") && !m.slavoGermanic():
+		m.add("KL", "L")
+		m.current += 2
+	// -ges-, -gep-, -gel-, -gie- at beginning
+	case current == 0 && (m.at(current+1) == 'Y' ||
+		m.stringAt(current+1, 2, "ES", "EP", "EB", "EL", "EY", "IB", "IL", "IN", "IE", "EI", "ER")):
+		m.add("K", "J")
+		m.current += 2
+	// -ger-, -gy-
+	case (m.stringAt(current+1, 2, "ER") || m.at(current+1) == 'Y') &&
+		!m.stringAt(0, 6, "DANGER", "RANGER", "MANGER") &&
+		!m.stringAt(current-1, 1, "E", "I") &&
+		!m.stringAt(current-1, 3, "RGY", "OGY"):
+		m.add("K", "J")
+		m.current += 2
+	// italian e.g, 'biaggi'
+	case m.stringAt(current+1, 1, "E", "I", "Y") || m.stringAt(current-1, 4, "AGGI", "OGGI"):
+		switch {
+		// obvious germanic
+		case m.stringAt(0, 4, "VAN ", "VON ") || m.stringAt(0, 3, "SCH") || m.stringAt(current+1, 2, "ET"):
+			m.add("K", "K")
+		// always soft if french ending
+		case m.stringAt(current+1, 4, "IER "):
+			m.add("J", "J")
+		default:
+			m.add("J", "K")
+		}
+
+		m.current += 2
+	default:
+		m.skip("G")
+		m.add("K", "K")
+	}
+}
+
+//nolint:cyclop,gomnd
+func (m *metaphone) gh() {
+	current := m.current
+
+	switch {
+	case current > 0 && !m.isVowel(current-1):
+		m.add("K", "K")
+	// 'ghislane', ghiradelli
+	case current == 0:
+		if m.at(current+2) == 'I' {
+			m.add("J", "J")
+		} else {
+			m.add("K", "K")
+		}
+	// Parker's rule (with some further refinements) - e.g., 'hugh'
+	case (current > 1 && m.stringAt(current-2, 1, "B", "H", "D")) ||
+		// e.g., 'bough'
+		(current > 2 && m.stringAt(current-3, 1, "B", "H", "D")) ||
+		// e.g., 'broughton'
+		(current > 3 && m.stringAt(current-4, 1, "B", "H")):
+	// e.g., 'laugh', 'McLaughlin', 'cough', 'gough', 'rough', 'tough'
+	case current > 2 && m.at(current-1) == 'U' && m.stringAt(current-3, 1, "C", "G", "L", "R", "T"):
+		m.add("F", "F")
+	case current > 0 && m.at(current-1) != 'I':
+		m.add("K", "K")
+	}
+
+	m.current += 2
+}
+
+//nolint:cyclop,gomnd
+func (m *metaphone) j() {
+	current := m.current
+
+	// obvious spanish, 'jose', 'san jacinto'
+	if m.stringAt(current, 4, "JOSE") || m.stringAt(0, 4, "SAN ") {
+		if (current == 0 && m.at(current+4) == ' ') || m.stringAt(0, 4, "SAN ") {
+			m.add("H", "H")
+		} else {
+			m.add("J", "H")
+		}
+
+		m.current++
+
+		return
+	}
+
+	switch {
+	case current == 0:
+		// Yankelovich/Jankelowicz
+		m.add("J", "A")
+	// spanish pron. of e.g. 'bajador'
+	case m.isVowel(current-1) && !m.slavoGermanic() && (m.at(current+1) == 'A' || m.at(current+1) == 'O'):
+		m.add("J", "H")
+	case current == m.last:
+		m.add("J", "")
+	case !m.stringAt(current+1, 1, "L", "T", "K", "S", "N", "M", "B", "Z") &&
+		!m.stringAt(current-1, 1, "S", "K", "L"):
+		m.add("J", "J")
+	}
+
+	// it could happen!
+	m.skip("J")
+}
+
+//nolint:gomnd
+func (m *metaphone) l() {
+	current := m.current
+
+	if m.at(current+1) == 'L' {
+		// spanish e.g. 'cabrillo', 'gallegos'
+		if (current == m.length-3 && m.stringAt(current-1, 4, "ILLO", "ILLA", "ALLE")) ||
+			((m.stringAt(m.last-1, 2, "AS", "OS") || m.stringAt(m.last, 1, "A", "O")) &&
+				m.stringAt(current-1, 4, "ALLE")) {
+			m.add("L", "")
+			m.current += 2
+
+			return
+		}
+
+		m.current += 2
+	} else {
+		m.current++
+	}
+
+	m.add("L", "L")
+}
+
+//nolint:cyclop,funlen,gomnd
+func (m *metaphone) s() {
+	current := m.current
+
+	switch {
+	// special cases 'island', 'isle', 'carlisle', 'carlysle'
+	case m.stringAt(current-1, 3, "ISL", "YSL"):
+		m.current++
+	// special case 'sugar-'
+	case current == 0 && m.stringAt(current, 5, "SUGAR"):
+		m.add("X", "S")
+		m.current++
+	case m.stringAt(current, 2, "SH"):
+		// germanic
+		if m.stringAt(current+1, 4, "HEIM", "HOEK", "HOLM", "HOLZ") {
+			m.add("S", "S")
+		} else {
+			m.add("X", "X")
+		}
+
+		m.current += 2
+	// italian & armenian
+	case m.stringAt(current, 3, "SIO", "SIA") || m.stringAt(current, 4, "SIAN"):
+		if !m.slavoGermanic() {
+			m.add("S", "X")
+		} else {
+			m.add("S", "S")
+		}
+
+		m.current += 3
+	// german & anglicisations, e.g. 'smith' match 'schmidt', 'snider' match
+	// 'schneider', also, -sz- in slavic language although in hungarian it
+	// is pronounced 's'
+	case (current == 0 && m.stringAt(current+1, 1, "M", "N", "L", "W")) || m.stringAt(current+1, 1, "Z"):
+		m.add("S", "X")
+		m.skip("Z")
+	case m.stringAt(current, 2, "SC"):
+		m.sc()
+	default:
+		// french e.g. 'resnais', 'artois'
+		if current == m.last && m.stringAt(current-2, 2, "AI", "OI") {
+			m.add("", "S")
+		} else {
+			m.add("S", "S")
+		}
+
+		m.skip("SZ")
+	}
+}
+
+//nolint:gomnd
+func (m *metaphone) sc() {
+	current := m.current
+
+	switch {
+	// Schlesinger's rule
+	case m.at(current+2) == 'H':
+		switch {
+		// dutch origin, e.g. 'school', 'schooner'
+		case m.stringAt(current+3, 2, "OO", "ER", "EN", "UY", "ED", "EM"):
+			// 'schermerhorn', 'schenker'
+			if m.stringAt(current+3, 2, "ER", "EN") {
+				m.add("X", "SK")
+			} else {
+				m.add("SK", "SK")
+			}
+		case current == 0 && !m.isVowel(3) && m.at(3) != 'W':
+			m.add("X", "S")
+		default:
+			m.add("X", "X")
+		}
+	case m.stringAt(current+2, 1, "I", "E", "Y"):
+		m.add("S", "S")
+	default:
+		m.add("SK", "SK")
+	}
+
+	m.current += 3
+}
+
+//nolint:gomnd
+func (m *metaphone) t() {
+	current := m.current
+
+	switch {
+	case m.stringAt(current, 4, "TION"):
+		m.add("X", "X")
+		m.current += 3
+	case m.stringAt(current, 3, "TIA", "TCH"):
+		m.add("X", "X")
+		m.current += 3
+	case m.stringAt(current, 2, "TH") || m.stringAt(current, 3, "TTH"):
+		// special case 'thomas', 'thames' or germanic
+		if m.stringAt(current+2, 2, "OM", "AM") || m.stringAt(0, 4, "VAN ", "VON ") || m.stringAt(0, 3, "SCH") {
+			m.add("T", "T")
+		} else {
+			m.add("0", "T")
+		}
+
+		m.current += 2
+	default:
+		m.skip("TD")
+		m.add("T", "T")
+	}
+}
+
+//nolint:gomnd
+func (m *metaphone) w() {
+	current := m.current
+
+	// can also be in middle of word
+	if m.stringAt(current, 2, "WR") {
+		m.add("R", "R")
+		m.current += 2
+
+		return
+	}
+
+	if current == 0 && (m.isVowel(current+1) || m.stringAt(current, 2, "WH")) {
+		if m.isVowel(current + 1) {
+			// Wasserman should match Vasserman
+			m.add("A", "F")
+		} else {
+			// need Uomo to match Womo
+			m.add("A", "A")
+		}
+	}
+
+	switch {
+	// Arnow should match Arnoff
+	case (current == m.last && m.isVowel(current-1)) ||
+		m.stringAt(current-1, 5, "EWSKI", "EWSKY", "OWSKI", "OWSKY") ||
+		m.stringAt(0, 3, "SCH"):
+		m.add("", "F")
+		m.current++
+	// polish e.g. 'filipowicz'
+	case m.stringAt(current, 4, "WICZ", "WITZ"):
+		m.add("TS", "FX")
+		m.current += 4
+	// else skip it
+	default:
+		m.current++
+	}
+}
+
+func (m *metaphone) z() {
+	// chinese pinyin e.g. 'zhao'
+	if m.at(m.current+1) == 'H' {
+		m.add("J", "J")
+		m.current += 2
+
+		return
+	}
+
+	if m.stringAt(m.current+1, 2, "ZO", "ZI", "ZA") ||
+		(m.slavoGermanic() && m.current > 0 && m.at(m.current-1) != 'T') {
+		m.add("S", "TS")
+	} else {
+		m.add("S", "S")
+	}
+
+	m.skip("Z")
+}
+
+// Phonetic returns the Double Metaphone codes of the words of the Latin
+// form of the name (see Latin) like people.phonetic, sorted and without
+// duplicates
+func Phonetic(value string) []string {
+	codes := make([]string, 0)
+
+	for _, word := range Words(value) {
+		primary, secondary := DoubleMetaphone(word)
+
+		for _, code := range []string{primary, secondary} {
+			if code != "" {
+				codes = append(codes, code)
+			}
+		}
+	}
+
+	slices.Sort(codes)
+
+	return slices.Compact(codes)
+}
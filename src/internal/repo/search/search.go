@@ -0,0 +1,175 @@
+// Package search implements the search of people.list_people in Go for
+// the backends without pg_trgm, so that every backend lists people the same
+// way: matching, trigram scoring and ordering.
+package search
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"github.com/Hofsiedge/person-api/internal/domain"
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// Matches checks the conditions of people.list_people that do not
+// depend on similarity (deletion is checked by the backends), including
+// the names in the phonetic and fulltext modes
+func Matches(filter domain.PersonFilter, person domain.Person) bool {
+	// filter condition violations
+	youngerThanMinAge := (filter.AgeMin != nil) && (*filter.AgeMin > person.Age)
+	olderThanMaxAge := (filter.AgeMax != nil) && (*filter.AgeMax < person.Age)
+
+	// an empty patronymic only matches an empty one
+	patronymicMismatch := (filter.Patronymic != nil) &&
+		((*filter.Patronymic == "") != (person.Patronymic == ""))
+
+	nationalityMismatch := (filter.Nationality != nil) &&
+		(*filter.Nationality != person.Nationality)
+
+	sexMismatch := (filter.Sex != nil) && (*filter.Sex != person.Sex)
+
+	return !(youngerThanMinAge || olderThanMaxAge || patronymicMismatch || nationalityMismatch || sexMismatch) &&
+		matchesNames(filter, person)
+}
+
+// matchesNames checks the names of the filter in the phonetic and fulltext
+// modes, where they are conditions instead of scores. An empty patronymic
+// is checked by Matches.
+func matchesNames(filter domain.PersonFilter, person domain.Person) bool {
+	var match func(searched, value string) bool
+
+	switch filter.Match {
+	case domain.MatchPhonetic:
+		match = func(searched, value string) bool {
+			codes := Phonetic(value)
+
+			return slices.ContainsFunc(Phonetic(searched), func(code string) bool {
+				return slices.Contains(codes, code)
+			})
+		}
+	case domain.MatchFulltext:
+		match = func(searched, value string) bool {
+			words, searchedWords := Words(value), Words(searched)
+
+			return len(searchedWords) > 0 && !slices.ContainsFunc(searchedWords, func(word string) bool {
+				return !slices.Contains(words, word)
+			})
+		}
+	default:
+		return true
+	}
+
+	return (filter.Name == nil || match(*filter.Name, person.Name)) &&
+		(filter.Surname == nil || match(*filter.Surname, person.Surname)) &&
+		(filter.Patronymic == nil || *filter.Patronymic == "" || match(*filter.Patronymic, person.Patronymic))
+}
+
+// Similarity is the mean word similarity of the name, the surname and
+// the patronymic of the filter to the ones of the person (0 if a field
+// of the filter is not set) like in people.list_people, where the real
+// similarities are summed as double precision
+func Similarity(filter domain.PersonFilter, person domain.Person) float64 {
+	var total float64
+
+	if filter.Name != nil {
+		total += float64(WordSimilarity(*filter.Name, person.Name))
+	}
+
+	if filter.Surname != nil {
+		total += float64(WordSimilarity(*filter.Surname, person.Surname))
+	}
+
+	switch {
+	case filter.Patronymic == nil:
+	case *filter.Patronymic == "" || person.Patronymic == "":
+		if *filter.Patronymic == person.Patronymic {
+			total++
+		}
+	default:
+		total += float64(WordSimilarity(*filter.Patronymic, person.Patronymic))
+	}
+
+	return total / 3 //nolint:gomnd
+}
+
+// scoredPerson is a person matching a filter with the similarity to it
+type scoredPerson struct {
+	person     domain.Person
+	similarity float64
+}
+
+// sexOrder is the order of people.sex values
+//
+//nolint:gochecknoglobals
+var sexOrder = map[domain.Sex]int{domain.Male: 0, domain.Female: 1}
+
+// compareScored orders people like people.list_people: by similarity
+// (descending), surname, name, patronymic, age, sex and nationality.
+// Text is compared by the collator (the database collation).
+func compareScored(collator *collate.Collator) func(a, b scoredPerson) int {
+	return func(a, b scoredPerson) int {
+		if a.similarity != b.similarity {
+			return cmp.Compare(b.similarity, a.similarity)
+		}
+
+		for _, pair := range [][2]string{
+			{a.person.Surname, b.person.Surname},
+			{a.person.Name, b.person.Name},
+			{a.person.Patronymic, b.person.Patronymic},
+		} {
+			if result := collator.CompareString(pair[0], pair[1]); result != 0 {
+				return result
+			}
+		}
+
+		if a.person.Age != b.person.Age {
+			return cmp.Compare(a.person.Age, b.person.Age)
+		}
+
+		if a.person.Sex != b.person.Sex {
+			return cmp.Compare(sexOrder[a.person.Sex], sexOrder[b.person.Sex])
+		}
+
+		return strings.Compare(string(a.person.Nationality), string(b.person.Nationality))
+	}
+}
+
+// Page scores the people matching the filter (see Matches) by Similarity
+// and returns the page of the ones scoring at least the threshold, most
+// similar first. TotalItems is the number of matching people regardless
+// of the threshold, like in people.list_people. The threshold is ignored
+// in the phonetic and fulltext modes.
+func Page(
+	filter domain.PersonFilter, pagination domain.PaginationFilter, matching []domain.Person,
+) domain.Page[domain.Person] {
+	threshold := 0.0
+	if filter.Threshold != nil && (filter.Match == "" || filter.Match == domain.MatchTrigram) {
+		threshold = float64(*filter.Threshold)
+	}
+
+	scored := make([]scoredPerson, 0)
+
+	for _, person := range matching {
+		if score := Similarity(filter, person); score >= threshold {
+			scored = append(scored, scoredPerson{person: person, similarity: score})
+		}
+	}
+
+	// collators are not safe for concurrent use
+	slices.SortFunc(scored, compareScored(collate.New(language.English)))
+
+	result := make([]domain.Person, 0)
+
+	for i := pagination.Offset; i < len(scored) && i < pagination.Offset+pagination.Limit; i++ {
+		result = append(result, scored[i].person)
+	}
+
+	return domain.Page[domain.Person]{
+		Items:         result,
+		CurrentLimit:  pagination.Limit,
+		CurrentOffset: pagination.Offset,
+		TotalItems:    len(matching),
+	}
+}
@@ -0,0 +1,82 @@
+package search_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Hofsiedge/person-api/internal/repo/search"
+)
+
+func TestLatin(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{name: "cyrillic", value: "Дмитрий", expected: "dmitri"},
+		{name: "final y", value: "Dmitry", expected: "dmitri"},
+		{name: "iy", value: "Dmitriy", expected: "dmitri"},
+		{name: "ii", value: "DMITRII", expected: "dmitri"},
+		{name: "x", value: "Alexei", expected: "aleksei"},
+		{name: "ya", value: "Юлия", expected: "yulia"},
+		{name: "j", value: "Julia", expected: "yulia"},
+		{name: "diacritics and separators", value: " Renée O'Neil ", expected: "renee o neil"},
+		{name: "no letters", value: "?!", expected: ""},
+	}
+
+	for _, tCase := range testCases {
+		test := tCase
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := search.Latin(test.value); got != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestDoubleMetaphone(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		word      string
+		primary   string
+		alternate string
+	}{
+		{word: "", primary: "", alternate: ""},
+		{word: "Smith", primary: "SM0", alternate: "XMT"},
+		{word: "Schmidt", primary: "XMT", alternate: "SMT"},
+		{word: "Shmidt", primary: "XMT", alternate: "XMT"},
+		{word: "Xavier", primary: "SF", alternate: "SFR"},
+		{word: "dumb", primary: "TM", alternate: "TM"},
+		{word: "Jose", primary: "HS", alternate: "HS"},
+		{word: "Anna", primary: "AN", alternate: "AN"},
+	}
+
+	for _, tCase := range testCases {
+		test := tCase
+		t.Run(test.word, func(t *testing.T) {
+			t.Parallel()
+
+			primary, alternate := search.DoubleMetaphone(test.word)
+			if primary != test.primary || alternate != test.alternate {
+				t.Errorf("expected %q and %q, got %q and %q", test.primary, test.alternate, primary, alternate)
+			}
+		})
+	}
+}
+
+func TestPhonetic(t *testing.T) {
+	t.Parallel()
+
+	if got, expected := search.Phonetic("Maria-Anna Maria"), []string{"AN", "MR"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+
+	if got := search.Phonetic("?!"); len(got) != 0 {
+		t.Errorf("expected no codes, got %v", got)
+	}
+}
@@ -0,0 +1,144 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// trigrams returns the trigrams of the words of s in order like pg_trgm
+// does: words are runs of letters and digits, they are lower cased and
+// padded with two spaces in front and one behind ("  ab ", "  a", " ab",
+// "ab "). Trigrams repeat if they occur several times.
+func trigrams(s string) []string {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	result := make([]string, 0, len(s)+2*len(words)) //nolint:gomnd
+
+	for _, word := range words {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			result = append(result, string(padded[i:i+3]))
+		}
+	}
+
+	return result
+}
+
+// extentSimilarity is the similarity of the trigram set of the needle and
+// an extent of the haystack sharing common of their unique trigrams
+func extentSimilarity(common, needle, extent int) float32 {
+	return float32(common) / float32(needle+extent-common)
+}
+
+// WordSimilarity is word_similarity(needle, haystack) of pg_trgm:
+// the greatest similarity between the trigram set of needle and
+// a continuous extent of the ordered trigrams of haystack.
+//
+// It is a port of iterate_word_similarity (non-strict): the extent is
+// grown to every trigram of haystack present in needle, and its lower
+// bound is moved right while that increases the similarity.
+func WordSimilarity(needle, haystack string) float32 {
+	// trigrams are numbered, found marks the ones of the needle
+	numbers := make(map[string]int)
+	found := make([]bool, 0)
+
+	number := func(trigram string) int {
+		index, known := numbers[trigram]
+		if !known {
+			index = len(found)
+			numbers[trigram] = index
+			found = append(found, false)
+		}
+
+		return index
+	}
+
+	needleLen := 0
+
+	for _, trigram := range trigrams(needle) {
+		if index := number(trigram); !found[index] {
+			found[index] = true
+			needleLen++
+		}
+	}
+
+	haystackTrigrams := trigrams(haystack)
+	indexes := make([]int, len(haystackTrigrams))
+
+	for i, trigram := range haystackTrigrams {
+		indexes[i] = number(trigram)
+	}
+
+	return iterateWordSimilarity(indexes, found, needleLen)
+}
+
+// iterateWordSimilarity finds the most similar extent of the haystack
+// trigrams (indexes) to the needleLen trigrams marked in found
+func iterateWordSimilarity(indexes []int, found []bool, needleLen int) float32 {
+	// the last position of every trigram in the current extent or -1
+	lastPos := make([]int, len(found))
+	for i := range lastPos {
+		lastPos[i] = -1
+	}
+
+	var (
+		extentLen, common = 0, 0
+		lower             = -1
+		best              float32
+	)
+
+	for upper, index := range indexes {
+		if lower >= 0 || found[index] {
+			if lastPos[index] < 0 {
+				extentLen++
+
+				if found[index] {
+					common++
+				}
+			}
+
+			lastPos[index] = upper
+		}
+
+		// only trigrams of the needle can end the extent
+		if !found[index] {
+			continue
+		}
+
+		if lower == -1 {
+			lower, extentLen = upper, 1
+		}
+
+		current := extentSimilarity(common, needleLen, extentLen)
+
+		// try to move the lower bound right
+		tmpCommon, tmpExtentLen, prevLower := common, extentLen, lower
+
+		for tmpLower := lower; tmpLower <= upper; tmpLower++ {
+			if similarity := extentSimilarity(tmpCommon, needleLen, tmpExtentLen); similarity > current {
+				current, extentLen, lower, common = similarity, tmpExtentLen, tmpLower, tmpCommon
+			}
+
+			if tmpIndex := indexes[tmpLower]; lastPos[tmpIndex] == tmpLower {
+				tmpExtentLen--
+
+				if found[tmpIndex] {
+					tmpCommon--
+				}
+			}
+		}
+
+		best = max(best, current)
+
+		// forget trigrams left out of the extent
+		for tmpLower := prevLower; tmpLower < lower; tmpLower++ {
+			if tmpIndex := indexes[tmpLower]; lastPos[tmpIndex] == tmpLower {
+				lastPos[tmpIndex] = -1
+			}
+		}
+	}
+
+	return best
+}
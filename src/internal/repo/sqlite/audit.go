@@ -0,0 +1,84 @@
+package sqlite
+
+import (
+	"context"
+	"time"
+
+	"github.com/Hofsiedge/person-api/internal/domain"
+	"github.com/Hofsiedge/person-api/internal/repo"
+)
+
+// AuditLog reads the audit entries of the changes recorded by People
+type AuditLog struct {
+	people *People
+}
+
+// ensure AuditLog implements the interface
+var _ repo.AuditRepo = &AuditLog{people: nil}
+
+// AuditLog returns AuditLog of the people
+func (p *People) AuditLog() *AuditLog {
+	return &AuditLog{people: p}
+}
+
+// Audit implements repo.AuditRepo.
+func (a *AuditLog) Audit(
+	ctx context.Context, filter domain.AuditFilter, pagination domain.PaginationFilter,
+) (domain.Page[domain.AuditEntry], error) {
+	conditions, args := []string{}, []any{}
+
+	add := func(condition string, arg any) {
+		conditions = append(conditions, condition)
+		args = append(args, arg)
+	}
+
+	if filter.Actor != nil {
+		add("actor = ?", *filter.Actor)
+	}
+
+	if filter.PersonID != nil {
+		add("person_id = ?", *filter.PersonID)
+	}
+
+	if filter.From != nil {
+		add("changed_at >= ?", filter.From.UnixNano())
+	}
+
+	if filter.To != nil {
+		add("changed_at < ?", filter.To.UnixNano())
+	}
+
+	changes, total, err := a.people.queryChanges(ctx, conditions, args, pagination)
+	if err != nil {
+		return domain.Page[domain.AuditEntry]{}, err
+	}
+
+	result := make([]domain.AuditEntry, 0, len(changes))
+
+	for _, change := range changes {
+		old, value, decodeErr := change.persons()
+		if decodeErr != nil {
+			return domain.Page[domain.AuditEntry]{}, decodeErr
+		}
+
+		result = append(result, domain.AuditEntry{
+			OccurredAt: time.Unix(0, change.changedAt),
+			Diff:       domain.Diff(old, value),
+			Operation:  change.operation,
+			Actor:      change.actor,
+			ClientIP:   change.clientIP,
+			RequestID:  change.requestID,
+			ID:         change.id,
+			PersonID:   change.personID,
+		})
+	}
+
+	page := domain.Page[domain.AuditEntry]{
+		Items:         result,
+		CurrentLimit:  pagination.Limit,
+		CurrentOffset: pagination.Offset,
+		TotalItems:    total,
+	}
+
+	return page, nil
+}
@@ -0,0 +1,135 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Hofsiedge/person-api/internal/domain"
+	"github.com/Hofsiedge/person-api/internal/repo"
+	"github.com/google/uuid"
+)
+
+// APIClients stores API clients in the api_clients table of People
+type APIClients struct {
+	people *People
+}
+
+// ensure APIClients implements the interface
+var _ repo.APIClientRepo = &APIClients{people: nil}
+
+// APIClients returns APIClients sharing the database of the people
+func (p *People) APIClients() *APIClients {
+	return &APIClients{people: p}
+}
+
+// clientColumns are the columns scanned by scanClient
+const clientColumns = `client_id, name, key_id, secret_hash, scopes, created_at, revoked_at`
+
+func scanClient(row scanner) (domain.APIClient, error) {
+	var (
+		client    domain.APIClient
+		scopes    string
+		createdAt int64
+		revokedAt sql.NullInt64
+	)
+
+	err := row.Scan(&client.ID, &client.Name, &client.KeyID, &client.SecretHash, &scopes, &createdAt, &revokedAt)
+	if err != nil {
+		return domain.APIClient{}, wrapSQLiteError(err)
+	}
+
+	if err = json.Unmarshal([]byte(scopes), &client.Scopes); err != nil {
+		return domain.APIClient{}, fmt.Errorf("%w: invalid scopes: %w", repo.ErrUnexpected, err)
+	}
+
+	client.CreatedAt = time.Unix(0, createdAt)
+
+	if revokedAt.Valid {
+		revoked := time.Unix(0, revokedAt.Int64)
+		client.RevokedAt = &revoked
+	}
+
+	return client, nil
+}
+
+// CreateClient implements repo.APIClientRepo.
+func (c *APIClients) CreateClient(ctx context.Context, client domain.APIClient) (uuid.UUID, error) {
+	if client.Scopes == nil {
+		client.Scopes = []string{}
+	}
+
+	scopes, err := json.Marshal(client.Scopes)
+	if err != nil {
+		return uuid.UUID{}, fmt.Errorf("%w: %w", repo.ErrArgument, err)
+	}
+
+	id := uuid.New()
+
+	_, err = c.people.conn(ctx).ExecContext(ctx,
+		`insert into api_clients (client_id, name, key_id, secret_hash, scopes, created_at)
+		values (?, ?, ?, ?, ?, ?)`,
+		id, client.Name, client.KeyID, client.SecretHash, string(scopes), time.Now().UnixNano())
+	if err != nil {
+		return uuid.UUID{}, wrapSQLiteError(err)
+	}
+
+	return id, nil
+}
+
+// RevokeClient implements repo.APIClientRepo.
+func (c *APIClients) RevokeClient(ctx context.Context, name string) error {
+	result, err := c.people.conn(ctx).ExecContext(ctx,
+		`update api_clients set revoked_at = ? where name = ? and revoked_at is null`,
+		time.Now().UnixNano(), name)
+	if err != nil {
+		return wrapSQLiteError(err)
+	}
+
+	if revoked, err := result.RowsAffected(); err != nil || revoked == 0 {
+		return fmt.Errorf("%w: api client %s", repo.ErrNotFound, name)
+	}
+
+	return nil
+}
+
+// ClientByKeyID implements repo.APIClientRepo.
+func (c *APIClients) ClientByKeyID(ctx context.Context, keyID string) (domain.APIClient, error) {
+	return scanClient(c.people.conn(ctx).QueryRowContext(ctx,
+		`select `+clientColumns+` from api_clients where key_id = ? and revoked_at is null`, keyID))
+}
+
+// ClientByName implements repo.APIClientRepo.
+func (c *APIClients) ClientByName(ctx context.Context, name string) (domain.APIClient, error) {
+	return scanClient(c.people.conn(ctx).QueryRowContext(ctx,
+		`select `+clientColumns+` from api_clients where name = ? and revoked_at is null`, name))
+}
+
+// ListClients implements repo.APIClientRepo.
+func (c *APIClients) ListClients(ctx context.Context) ([]domain.APIClient, error) {
+	rows, err := c.people.conn(ctx).QueryContext(ctx,
+		`select `+clientColumns+` from api_clients order by created_at, name`)
+	if err != nil {
+		return nil, wrapSQLiteError(err)
+	}
+	defer rows.Close()
+
+	clients := make([]domain.APIClient, 0)
+
+	for rows.Next() {
+		client, err := scanClient(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		clients = append(clients, client)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, wrapSQLiteError(err)
+	}
+
+	return clients, nil
+}
@@ -0,0 +1,249 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Hofsiedge/person-api/internal/domain"
+	"github.com/Hofsiedge/person-api/internal/repo"
+	"github.com/Hofsiedge/person-api/internal/reqctx"
+	"github.com/google/uuid"
+)
+
+// personJSON is a person in the old and new columns of changes
+type personJSON struct {
+	Name        string `json:"name"`
+	Surname     string `json:"surname"`
+	Patronymic  string `json:"patronymic"`
+	Age         int    `json:"age"`
+	Sex         string `json:"sex"`
+	Nationality string `json:"nationality"`
+}
+
+// encodePerson encodes a person of a change, nil if there is none
+func encodePerson(person *domain.Person) (any, error) {
+	if person == nil {
+		return nil, nil //nolint:nilnil
+	}
+
+	data, err := json.Marshal(personJSON{
+		Name:        person.Name,
+		Surname:     person.Surname,
+		Patronymic:  person.Patronymic,
+		Age:         person.Age,
+		Sex:         string(person.Sex),
+		Nationality: string(person.Nationality),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", repo.ErrUnexpected, err)
+	}
+
+	return string(data), nil
+}
+
+// decodePerson decodes a person of a change with the id, nil if there is none
+func decodePerson(data sql.NullString, id uuid.UUID) (*domain.Person, error) {
+	if !data.Valid {
+		return nil, nil //nolint:nilnil
+	}
+
+	var decoded personJSON
+	if err := json.Unmarshal([]byte(data.String), &decoded); err != nil {
+		return nil, fmt.Errorf("%w: %w", repo.ErrUnexpected, err)
+	}
+
+	return &domain.Person{
+		Name:        decoded.Name,
+		Surname:     decoded.Surname,
+		Patronymic:  decoded.Patronymic,
+		Nationality: domain.Nationality(decoded.Nationality),
+		Sex:         domain.Sex(decoded.Sex),
+		Age:         decoded.Age,
+		ID:          id,
+	}, nil
+}
+
+// record records a change for the history and the audit log like
+// the people_history and audit triggers do
+func (p *People) record(
+	ctx context.Context, personID uuid.UUID, operation domain.ChangeOperation, old, value *domain.Person,
+) error {
+	if operation == domain.ChangeUpdate && *old == *value {
+		return nil
+	}
+
+	oldData, err := encodePerson(old)
+	if err != nil {
+		return err
+	}
+
+	newData, err := encodePerson(value)
+	if err != nil {
+		return err
+	}
+
+	_, err = p.conn(ctx).ExecContext(ctx, `
+		insert into changes (person_id, operation, changed_at, old, new, actor, client_ip, request_id)
+		values (?, ?, ?, ?, ?, ?, ?, ?)`,
+		personID, operation, time.Now().UnixNano(), oldData, newData,
+		reqctx.Actor(ctx), reqctx.ClientIP(ctx), reqctx.RequestID(ctx))
+	if err != nil {
+		return wrapSQLiteError(err)
+	}
+
+	return nil
+}
+
+// change is a row of changes
+type change struct {
+	old, new  sql.NullString
+	operation domain.ChangeOperation
+	actor     string
+	clientIP  string
+	requestID string
+	id        int64
+	changedAt int64
+	personID  uuid.UUID
+}
+
+// changeColumns are the columns scanned by scanChange
+const changeColumns = `id, person_id, operation, changed_at, old, new, actor, client_ip, request_id`
+
+func scanChange(row scanner) (change, error) {
+	var result change
+
+	err := row.Scan(&result.id, &result.personID, &result.operation, &result.changedAt,
+		&result.old, &result.new, &result.actor, &result.clientIP, &result.requestID)
+	if err != nil {
+		return change{}, wrapSQLiteError(err)
+	}
+
+	return result, nil
+}
+
+// persons decodes the people before and after the change
+func (c change) persons() (*domain.Person, *domain.Person, error) {
+	old, err := decodePerson(c.old, c.personID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	value, err := decodePerson(c.new, c.personID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return old, value, nil
+}
+
+// queryChanges returns the changes matching the conditions, newest first,
+// and the number of them
+func (p *People) queryChanges(
+	ctx context.Context, conditions []string, args []any, pagination domain.PaginationFilter,
+) ([]change, int, error) {
+	where := strings.Join(append([]string{"true"}, conditions...), " and ")
+
+	var total int
+
+	row := p.conn(ctx).QueryRowContext(ctx, `select count(*) from changes where `+where, args...)
+	if err := row.Scan(&total); err != nil {
+		return nil, 0, wrapSQLiteError(err)
+	}
+
+	rows, err := p.conn(ctx).QueryContext(ctx,
+		`select `+changeColumns+` from changes where `+where+` order by id desc limit ? offset ?`,
+		append(args, pagination.Limit, pagination.Offset)...)
+	if err != nil {
+		return nil, 0, wrapSQLiteError(err)
+	}
+	defer rows.Close()
+
+	changes := make([]change, 0)
+
+	for rows.Next() {
+		result, scanErr := scanChange(rows)
+		if scanErr != nil {
+			return nil, 0, scanErr
+		}
+
+		changes = append(changes, result)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, wrapSQLiteError(err)
+	}
+
+	return changes, total, nil
+}
+
+// GetAsOf implements repo.PersonRepo.
+func (p *People) GetAsOf(ctx context.Context, id uuid.UUID, moment time.Time) (domain.Person, error) {
+	row := p.conn(ctx).QueryRowContext(ctx, `
+		select `+changeColumns+` from changes
+		where person_id = ? and changed_at <= ?
+		order by id desc limit 1`,
+		id, moment.UnixNano())
+
+	last, err := scanChange(row)
+	if err != nil {
+		return domain.Person{}, err
+	}
+
+	_, person, err := last.persons()
+	if err != nil {
+		return domain.Person{}, err
+	}
+
+	if person == nil {
+		return domain.Person{}, repo.ErrNotFound
+	}
+
+	return *person, nil
+}
+
+// History implements repo.PersonRepo.
+func (p *People) History(
+	ctx context.Context, id uuid.UUID, pagination domain.PaginationFilter,
+) (domain.Page[domain.PersonChange], error) {
+	changes, total, err := p.queryChanges(ctx, []string{"person_id = ?"}, []any{id}, pagination)
+	if err != nil {
+		return domain.Page[domain.PersonChange]{}, err
+	}
+
+	if total == 0 {
+		return domain.Page[domain.PersonChange]{}, repo.ErrNotFound
+	}
+
+	result := make([]domain.PersonChange, 0, len(changes))
+
+	for _, change := range changes {
+		old, value, decodeErr := change.persons()
+		if decodeErr != nil {
+			return domain.Page[domain.PersonChange]{}, decodeErr
+		}
+
+		result = append(result, domain.PersonChange{
+			ChangedAt: time.Unix(0, change.changedAt),
+			Old:       old,
+			New:       value,
+			Operation: change.operation,
+			Actor:     change.actor,
+			RequestID: change.requestID,
+			ID:        change.id,
+			PersonID:  change.personID,
+		})
+	}
+
+	page := domain.Page[domain.PersonChange]{
+		Items:         result,
+		CurrentLimit:  pagination.Limit,
+		CurrentOffset: pagination.Offset,
+		TotalItems:    total,
+	}
+
+	return page, nil
+}
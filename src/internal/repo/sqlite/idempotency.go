@@ -0,0 +1,137 @@
+package sqlite
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Hofsiedge/person-api/internal/domain"
+	"github.com/Hofsiedge/person-api/internal/repo"
+)
+
+// IdempotencyKeys stores responses of requests with Idempotency-Key
+// in the idempotency_keys table of People
+type IdempotencyKeys struct {
+	people *People
+}
+
+// ensure IdempotencyKeys implements the interface
+var _ repo.IdempotencyRepo = &IdempotencyKeys{people: nil}
+
+// IdempotencyKeys returns IdempotencyKeys sharing the database of the people
+func (p *People) IdempotencyKeys() *IdempotencyKeys {
+	return &IdempotencyKeys{people: p}
+}
+
+// Reserve implements repo.IdempotencyRepo. Like
+// people.reserve_idempotency_key, it removes expired keys first.
+func (k *IdempotencyKeys) Reserve(
+	ctx context.Context, key string, fingerprint []byte, ttl time.Duration,
+) (*domain.StoredResponse, error) {
+	if ttl <= 0 || fingerprint == nil {
+		return nil, fmt.Errorf("%w: invalid ttl %v or fingerprint", repo.ErrArgument, ttl)
+	}
+
+	var (
+		stored      *domain.StoredResponse
+		storedPrint []byte
+		reserved    bool
+	)
+
+	err := k.people.WithinTx(ctx, func(ctx context.Context) error {
+		conn := k.people.conn(ctx)
+		now := time.Now()
+
+		if _, err := conn.ExecContext(ctx, `delete from idempotency_keys where expires_at < ?`, now.UnixNano()); err != nil {
+			return wrapSQLiteError(err)
+		}
+
+		result, err := conn.ExecContext(ctx,
+			`insert into idempotency_keys (key, fingerprint, expires_at) values (?, ?, ?) on conflict do nothing`,
+			key, fingerprint, now.Add(ttl).UnixNano())
+		if err != nil {
+			return wrapSQLiteError(err)
+		}
+
+		inserted, err := result.RowsAffected()
+		if err != nil {
+			return wrapSQLiteError(err)
+		}
+
+		if inserted == 1 {
+			reserved = true
+
+			return nil
+		}
+
+		var (
+			status      sql.NullInt64
+			contentType sql.NullString
+			body        []byte
+		)
+
+		err = conn.QueryRowContext(ctx,
+			`select fingerprint, status, content_type, body from idempotency_keys where key = ?`, key,
+		).Scan(&storedPrint, &status, &contentType, &body)
+		if err != nil {
+			return wrapSQLiteError(err)
+		}
+
+		if status.Valid {
+			stored = &domain.StoredResponse{
+				ContentType: contentType.String,
+				Body:        body,
+				Status:      int(status.Int64),
+			}
+		}
+
+		return nil
+	})
+
+	switch {
+	case err != nil:
+		return nil, err
+	case reserved:
+		return nil, nil //nolint:nilnil
+	case !bytes.Equal(storedPrint, fingerprint):
+		return nil, fmt.Errorf("%w: %q", repo.ErrKeyReused, key)
+	case stored == nil:
+		return nil, fmt.Errorf("%w: %q", repo.ErrInProgress, key)
+	}
+
+	return stored, nil
+}
+
+// Save implements repo.IdempotencyRepo.
+func (k *IdempotencyKeys) Save(ctx context.Context, key string, response domain.StoredResponse) error {
+	body := response.Body
+	if body == nil {
+		body = []byte{}
+	}
+
+	result, err := k.people.conn(ctx).ExecContext(ctx,
+		`update idempotency_keys set status = ?, content_type = ?, body = ? where key = ? and status is null`,
+		response.Status, response.ContentType, body, key)
+	if err != nil {
+		return wrapSQLiteError(err)
+	}
+
+	if saved, err := result.RowsAffected(); err != nil || saved == 0 {
+		return fmt.Errorf("%w: idempotency key %q is not reserved", repo.ErrNotFound, key)
+	}
+
+	return nil
+}
+
+// Release implements repo.IdempotencyRepo.
+func (k *IdempotencyKeys) Release(ctx context.Context, key string) error {
+	_, err := k.people.conn(ctx).ExecContext(ctx,
+		`delete from idempotency_keys where key = ? and status is null`, key)
+	if err != nil {
+		return wrapSQLiteError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package sqlite
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+
+	"github.com/golang-migrate/migrate/v4"
+	sqlitemigrate "github.com/golang-migrate/migrate/v4/database/sqlite"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+// Migrations are the golang-migrate migrations of the schema
+//
+//go:embed migrations/*.sql
+var Migrations embed.FS
+
+// migrateSchema applies new Migrations to the database. Unlike the Postgres
+// schema, which can be shared by several releases, the database belongs to
+// a single instance, so it is always migrated on start.
+func migrateSchema(db *sql.DB) error {
+	source, err := iofs.New(Migrations, "migrations")
+	if err != nil {
+		return fmt.Errorf("could not read migrations: %w", err)
+	}
+
+	//nolint:exhaustruct
+	driver, err := sqlitemigrate.WithInstance(db, &sqlitemigrate.Config{
+		MigrationsTable: "schema_migrations",
+	})
+	if err != nil {
+		return fmt.Errorf("could not prepare migrations: %w", err)
+	}
+
+	instance, err := migrate.NewWithInstance("iofs", source, "sqlite", driver)
+	if err != nil {
+		return fmt.Errorf("could not prepare migrations: %w", err)
+	}
+
+	// closing the instance would close db
+	if err = instance.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("could not apply migrations: %w", err)
+	}
+
+	return nil
+}
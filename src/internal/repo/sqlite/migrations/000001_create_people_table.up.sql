@@ -0,0 +1,15 @@
+-- people.people of the Postgres schema. Trigram similarity is computed
+-- by the repo (see internal/repo/search), so there are no trigram indexes.
+create table people (
+    id text primary key,
+    name text not null check (name <> ''),
+    surname text not null check (surname <> ''),
+    patronymic text not null default '',
+    age integer not null check (age between 0 and 125),
+    sex text not null check (sex in ('male', 'female')),
+    nationality text not null check (length(nationality) = 2 and nationality glob '[A-Z][A-Z]'),
+    -- unix time in nanoseconds, null if the person is not deleted
+    deleted_at integer
+) strict;
+
+create index people_deleted_at on people (deleted_at);
@@ -0,0 +1,19 @@
+-- people.people_history and people.audit_log of the Postgres schema:
+-- both are read from the recorded changes
+create table changes (
+    id integer primary key autoincrement,
+    person_id text not null,
+    operation text not null check (operation in ('create', 'update', 'delete', 'restore', 'purge')),
+    -- unix time in nanoseconds
+    changed_at integer not null,
+    -- the person before and after the change as JSON, null if there was none
+    old text,
+    new text,
+    actor text not null default '',
+    client_ip text not null default '',
+    request_id text not null default ''
+) strict;
+
+create index changes_person_id on changes (person_id, id);
+create index changes_changed_at on changes (changed_at);
+create index changes_actor on changes (actor);
@@ -0,0 +1 @@
+drop table api_clients;
@@ -0,0 +1,16 @@
+-- people.api_clients of the Postgres schema. only a hash of the secret
+-- is stored
+create table api_clients (
+    client_id text primary key,
+    name text not null check (name != '' and name not glob '*[^A-Za-z0-9._-]*'),
+    key_id text not null unique check (key_id != '' and key_id not glob '*[^A-Za-z0-9]*'),
+    secret_hash text not null check (secret_hash != ''),
+    -- JSON array of the granted scopes
+    scopes text not null default '[]',
+    -- unix time in nanoseconds
+    created_at integer not null,
+    revoked_at integer
+) strict;
+
+-- a name identifies a single active client
+create unique index api_clients_by_name on api_clients (name) where revoked_at is null;
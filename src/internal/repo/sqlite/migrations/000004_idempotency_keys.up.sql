@@ -0,0 +1,13 @@
+-- people.idempotency_keys of the Postgres schema.
+-- status is null while the first request is in progress
+create table idempotency_keys (
+    key text primary key,
+    fingerprint blob not null,
+    status integer,
+    content_type text,
+    body blob,
+    -- unix time in nanoseconds
+    expires_at integer not null
+) strict;
+
+create index idempotency_keys_by_expires_at on idempotency_keys (expires_at);
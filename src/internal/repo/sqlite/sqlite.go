@@ -0,0 +1,461 @@
+// Package sqlite is a repo.PersonRepo stored in an SQLite database file
+// for installations without Postgres. It uses a pure-Go driver (no cgo),
+// records the history and the audit log like the Postgres triggers do and
+// searches like people.list_people with the trigram similarity computed
+// in Go (see internal/repo/search).
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Hofsiedge/person-api/internal/domain"
+	"github.com/Hofsiedge/person-api/internal/repo"
+	"github.com/Hofsiedge/person-api/internal/repo/search"
+	"github.com/google/uuid"
+	"modernc.org/sqlite"
+	sqlitelib "modernc.org/sqlite/lib"
+)
+
+// busyTimeout is how long statements wait for other processes holding
+// a lock of the database file
+const busyTimeout = 5 * time.Second
+
+type People struct {
+	db *sql.DB
+}
+
+// ensure that People implements repo.PersonRepo
+var _ repo.PersonRepo = &People{db: nil}
+
+// New opens (or creates) the database file at path and applies new
+// migrations
+func New(path string) (*People, error) {
+	if path == "" {
+		return nil, fmt.Errorf("%w: the database file is not set", repo.ErrArgument)
+	}
+
+	// transactions take the write lock when they begin, so that
+	// transactions of other processes do not deadlock upgrading their locks
+	dsn := url.URL{ //nolint:exhaustruct
+		Scheme: "file",
+		Opaque: path,
+		RawQuery: url.Values{
+			"_pragma": {
+				"foreign_keys(1)",
+				"journal_mode(WAL)",
+				fmt.Sprintf("busy_timeout(%d)", busyTimeout.Milliseconds()),
+			},
+			"_txlock": {"immediate"},
+		}.Encode(),
+	}
+
+	db, err := sql.Open("sqlite", dsn.String())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", repo.ErrConnect, err)
+	}
+
+	// SQLite has a single writer, see WithinTx
+	db.SetMaxOpenConns(1)
+
+	if err = db.Ping(); err != nil {
+		db.Close()
+
+		return nil, fmt.Errorf("%w: %w", repo.ErrConnect, err)
+	}
+
+	if err = migrateSchema(db); err != nil {
+		db.Close()
+
+		return nil, err
+	}
+
+	return &People{db: db}, nil
+}
+
+// Close closes the database
+func (p *People) Close() error {
+	if err := p.db.Close(); err != nil {
+		return fmt.Errorf("%w: %w", repo.ErrDisconnect, err)
+	}
+
+	return nil
+}
+
+// Ping checks that the database is readable
+func (p *People) Ping(ctx context.Context) error {
+	var version int64
+
+	row := p.db.QueryRowContext(ctx, `select version from schema_migrations`)
+	if err := row.Scan(&version); err != nil {
+		return wrapSQLiteError(err)
+	}
+
+	return nil
+}
+
+func wrapSQLiteError(err error) error {
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.Code() {
+		case sqlitelib.SQLITE_CONSTRAINT_CHECK, sqlitelib.SQLITE_CONSTRAINT_NOTNULL:
+			return fmt.Errorf("%w: %w", repo.ErrArgument, err)
+		case sqlitelib.SQLITE_CONSTRAINT_PRIMARYKEY, sqlitelib.SQLITE_CONSTRAINT_UNIQUE,
+			sqlitelib.SQLITE_BUSY, sqlitelib.SQLITE_LOCKED:
+			return fmt.Errorf("%w: %w", repo.ErrConflict, err)
+		}
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %w", repo.ErrNotFound, err)
+	}
+
+	return fmt.Errorf("%w: %w", repo.ErrUnexpected, err)
+}
+
+// personColumns are the columns scanned by scanPerson
+const personColumns = `id, name, surname, patronymic, age, sex, nationality`
+
+// scanner is *sql.Row or *sql.Rows
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanPerson(row scanner) (domain.Person, error) {
+	var person domain.Person
+
+	err := row.Scan(&person.ID, &person.Name, &person.Surname, &person.Patronymic,
+		&person.Age, &person.Sex, &person.Nationality)
+	if err != nil {
+		return domain.Person{}, wrapSQLiteError(err)
+	}
+
+	return person, nil
+}
+
+// get returns a person that is not deleted
+func (p *People) get(ctx context.Context, id uuid.UUID) (domain.Person, error) {
+	row := p.conn(ctx).QueryRowContext(ctx,
+		`select `+personColumns+` from people where id = ? and deleted_at is null`, id)
+
+	return scanPerson(row)
+}
+
+// update saves an updated person and records the change
+func (p *People) update(ctx context.Context, old, person domain.Person) error {
+	_, err := p.conn(ctx).ExecContext(ctx, `
+		update people
+		set name = ?, surname = ?, patronymic = ?, age = ?, sex = ?, nationality = ?
+		where id = ?`,
+		person.Name, person.Surname, person.Patronymic, person.Age, person.Sex, person.Nationality, person.ID)
+	if err != nil {
+		return wrapSQLiteError(err)
+	}
+
+	return p.record(ctx, person.ID, domain.ChangeUpdate, &old, &person)
+}
+
+// Count returns the number of active and deleted (not purged) people
+func (p *People) Count(ctx context.Context) (int, int, error) {
+	var active, deleted int
+
+	row := p.conn(ctx).QueryRowContext(ctx, `select count(*) - count(deleted_at), count(deleted_at) from people`)
+	if err := row.Scan(&active, &deleted); err != nil {
+		return 0, 0, wrapSQLiteError(err)
+	}
+
+	return active, deleted, nil
+}
+
+// Create implements repo.PersonRepo.
+func (p *People) Create(ctx context.Context, person domain.Person) (uuid.UUID, error) {
+	person.ID = uuid.New()
+
+	err := p.WithinTx(ctx, func(ctx context.Context) error {
+		_, err := p.conn(ctx).ExecContext(ctx, `
+			insert into people (id, name, surname, patronymic, age, sex, nationality)
+			values (?, ?, ?, ?, ?, ?, ?)`,
+			person.ID, person.Name, person.Surname, person.Patronymic, person.Age, person.Sex, person.Nationality)
+		if err != nil {
+			return wrapSQLiteError(err)
+		}
+
+		return p.record(ctx, person.ID, domain.ChangeCreate, nil, &person)
+	})
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	return person.ID, nil
+}
+
+// Delete implements repo.PersonRepo.
+func (p *People) Delete(ctx context.Context, id uuid.UUID) error {
+	return p.WithinTx(ctx, func(ctx context.Context) error {
+		person, err := p.get(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		_, err = p.conn(ctx).ExecContext(ctx,
+			`update people set deleted_at = ? where id = ?`, time.Now().UnixNano(), id)
+		if err != nil {
+			return wrapSQLiteError(err)
+		}
+
+		return p.record(ctx, id, domain.ChangeDelete, &person, nil)
+	})
+}
+
+// FullUpdate implements repo.PersonRepo.
+func (p *People) FullUpdate(ctx context.Context, id uuid.UUID, replacement domain.Person) error {
+	return p.WithinTx(ctx, func(ctx context.Context) error {
+		old, err := p.get(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		replacement.ID = id
+
+		return p.update(ctx, old, replacement)
+	})
+}
+
+// GetByID implements repo.PersonRepo.
+func (p *People) GetByID(ctx context.Context, id uuid.UUID) (domain.Person, error) {
+	return p.get(ctx, id)
+}
+
+// listConditions returns the conditions of the filter that do not depend
+// on similarity (see search.Matches) and their arguments
+func listConditions(filter domain.PersonFilter) (string, []any) {
+	conditions, args := []string{"true"}, []any{}
+
+	add := func(condition string, arg any) {
+		conditions = append(conditions, condition)
+		args = append(args, arg)
+	}
+
+	if !filter.IncludeDeleted {
+		conditions = append(conditions, "deleted_at is null")
+	}
+
+	if filter.AgeMin != nil {
+		add("age >= ?", *filter.AgeMin)
+	}
+
+	if filter.AgeMax != nil {
+		add("age <= ?", *filter.AgeMax)
+	}
+
+	// an empty patronymic only matches an empty one
+	if filter.Patronymic != nil {
+		add("(patronymic = '') = ?", *filter.Patronymic == "")
+	}
+
+	if filter.Nationality != nil {
+		add("nationality = ?", *filter.Nationality)
+	}
+
+	if filter.Sex != nil {
+		add("sex = ?", *filter.Sex)
+	}
+
+	return strings.Join(conditions, " and "), args
+}
+
+// List implements repo.PersonRepo. People matching the filter are selected
+// by the database, their names are matched (see search.Matches) and
+// searched like people.list_people (see search.Page).
+func (p *People) List(
+	ctx context.Context, filter domain.PersonFilter, pagination domain.PaginationFilter,
+) (domain.Page[domain.Person], error) {
+	if !filter.Match.Valid() {
+		return domain.Page[domain.Person]{}, fmt.Errorf("%w: invalid match %q", repo.ErrArgument, filter.Match)
+	}
+
+	conditions, args := listConditions(filter)
+
+	rows, err := p.conn(ctx).QueryContext(ctx, `select `+personColumns+` from people where `+conditions, args...)
+	if err != nil {
+		return domain.Page[domain.Person]{}, wrapSQLiteError(err)
+	}
+	defer rows.Close()
+
+	matching := make([]domain.Person, 0)
+
+	for rows.Next() {
+		person, scanErr := scanPerson(rows)
+		if scanErr != nil {
+			return domain.Page[domain.Person]{}, scanErr
+		}
+
+		if search.Matches(filter, person) {
+			matching = append(matching, person)
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return domain.Page[domain.Person]{}, wrapSQLiteError(err)
+	}
+
+	return search.Page(filter, pagination, matching), nil
+}
+
+// PartialUpdate implements repo.PersonRepo.
+func (p *People) PartialUpdate(ctx context.Context, id uuid.UUID, partial domain.PersonPartial) error {
+	return p.WithinTx(ctx, func(ctx context.Context) error {
+		person, err := p.get(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if (partial == domain.PersonPartial{
+			Name:        nil,
+			Surname:     nil,
+			Patronymic:  nil,
+			Nationality: nil,
+			Sex:         nil,
+			Age:         nil,
+		}) {
+			return repo.ErrArgument
+		}
+
+		old := person
+
+		if partial.Name != nil {
+			person.Name = *partial.Name
+		}
+
+		if partial.Surname != nil {
+			person.Surname = *partial.Surname
+		}
+
+		if partial.Patronymic != nil {
+			person.Patronymic = *partial.Patronymic
+		}
+
+		if partial.Nationality != nil {
+			person.Nationality = domain.Nationality(*partial.Nationality)
+		}
+
+		if partial.Sex != nil {
+			person.Sex = *partial.Sex
+		}
+
+		if partial.Age != nil {
+			person.Age = *partial.Age
+		}
+
+		return p.update(ctx, old, person)
+	})
+}
+
+// Patch implements repo.PersonRepo.
+func (p *People) Patch(ctx context.Context, id uuid.UUID, operations []domain.PatchOperation) error {
+	return p.WithinTx(ctx, func(ctx context.Context) error {
+		person, err := p.get(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		patched, err := person.Apply(operations)
+		if err != nil {
+			if errors.Is(err, domain.ErrPatchTestFailed) {
+				return fmt.Errorf("%w: %w", repo.ErrConflict, err)
+			}
+
+			return fmt.Errorf("%w: %w", repo.ErrArgument, err)
+		}
+
+		return p.update(ctx, person, patched)
+	})
+}
+
+// Restore implements repo.PersonRepo.
+func (p *People) Restore(ctx context.Context, id uuid.UUID) error {
+	return p.WithinTx(ctx, func(ctx context.Context) error {
+		var deletedAt sql.NullInt64
+
+		row := p.conn(ctx).QueryRowContext(ctx,
+			`select `+personColumns+`, deleted_at from people where id = ?`, id)
+
+		var person domain.Person
+
+		err := row.Scan(&person.ID, &person.Name, &person.Surname, &person.Patronymic,
+			&person.Age, &person.Sex, &person.Nationality, &deletedAt)
+		if err != nil {
+			return wrapSQLiteError(err)
+		}
+
+		if !deletedAt.Valid {
+			return fmt.Errorf("%w: person is not deleted", repo.ErrConflict)
+		}
+
+		if _, err = p.conn(ctx).ExecContext(ctx, `update people set deleted_at = null where id = ?`, id); err != nil {
+			return wrapSQLiteError(err)
+		}
+
+		return p.record(ctx, id, domain.ChangeRestore, nil, &person)
+	})
+}
+
+// Purge implements repo.PersonRepo.
+func (p *People) Purge(ctx context.Context, retention time.Duration) (int, error) {
+	if retention < 0 {
+		return 0, fmt.Errorf("%w: invalid retention %v", repo.ErrArgument, retention)
+	}
+
+	purged := 0
+
+	err := p.WithinTx(ctx, func(ctx context.Context) error {
+		rows, err := p.conn(ctx).QueryContext(ctx,
+			`select `+personColumns+` from people where deleted_at < ?`, time.Now().Add(-retention).UnixNano())
+		if err != nil {
+			return wrapSQLiteError(err)
+		}
+
+		persons := make([]domain.Person, 0)
+
+		for rows.Next() {
+			person, scanErr := scanPerson(rows)
+			if scanErr != nil {
+				rows.Close()
+
+				return scanErr
+			}
+
+			persons = append(persons, person)
+		}
+
+		rows.Close()
+
+		if err = rows.Err(); err != nil {
+			return wrapSQLiteError(err)
+		}
+
+		for i := range persons {
+			if _, err = p.conn(ctx).ExecContext(ctx, `delete from people where id = ?`, persons[i].ID); err != nil {
+				return wrapSQLiteError(err)
+			}
+
+			if err = p.record(ctx, persons[i].ID, domain.ChangePurge, &persons[i], nil); err != nil {
+				return err
+			}
+		}
+
+		purged = len(persons)
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,201 @@
+package sqlite_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Hofsiedge/person-api/internal/domain"
+	"github.com/Hofsiedge/person-api/internal/repo"
+	"github.com/Hofsiedge/person-api/internal/repo/memory"
+	"github.com/Hofsiedge/person-api/internal/repo/repotest"
+	"github.com/Hofsiedge/person-api/internal/repo/sqlite"
+	"github.com/Hofsiedge/person-api/internal/utils"
+)
+
+// open opens a new database closed with the test
+func open(t *testing.T, path string) *sqlite.People {
+	t.Helper()
+
+	people, err := sqlite.New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if err := people.Close(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	return people
+}
+
+func emptyPeople(t *testing.T) repo.PersonRepo {
+	t.Helper()
+
+	return open(t, filepath.Join(t.TempDir(), "people.db"))
+}
+
+func TestConformance(t *testing.T) {
+	t.Parallel()
+
+	repotest.RunConformance(t, emptyPeople)
+}
+
+func TestDifferential(t *testing.T) {
+	t.Parallel()
+
+	repotest.RunDifferential(t, func(*testing.T) repo.PersonRepo { return memory.NewPeople() }, emptyPeople)
+}
+
+func TestReopen(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "people.db")
+
+	people, err := sqlite.New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	person := utils.MakePerson()
+
+	person.ID, err = people.Create(ctx, person)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = people.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// migrations are not applied again
+	reopened := open(t, path)
+
+	if got, err := reopened.GetByID(ctx, person.ID); err != nil || got != person {
+		t.Errorf("expected %v, got %v (%v)", person, got, err)
+	}
+
+	entries, err := reopened.AuditLog().Audit(ctx, domain.AuditFilter{}, domain.PaginationFilter{Offset: 0, Limit: 10})
+	if err != nil || entries.TotalItems != 1 || entries.Items[0].Diff["name"].New != person.Name {
+		t.Errorf("unexpected audit log: %+v (%v)", entries, err)
+	}
+
+	if err = reopened.Ping(ctx); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAPIClients(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "people.db")
+	clients := open(t, path).APIClients()
+
+	client := domain.APIClient{Name: "client", KeyID: "key1", SecretHash: "hash", Scopes: []string{"people:read"}}
+
+	id, err := clients.CreateClient(ctx, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = clients.CreateClient(ctx, client); !errors.Is(err, repo.ErrConflict) {
+		t.Errorf("duplicate client was created: %v", err)
+	}
+
+	invalid := client
+	invalid.Name, invalid.KeyID = "invalid name", "key2"
+
+	if _, err = clients.CreateClient(ctx, invalid); !errors.Is(err, repo.ErrArgument) {
+		t.Errorf("invalid client was created: %v", err)
+	}
+
+	found, err := clients.ClientByKeyID(ctx, "key1")
+	if err != nil || found.ID != id || found.Name != "client" || !slices.Equal(found.Scopes, client.Scopes) {
+		t.Errorf("unexpected client: %+v (%v)", found, err)
+	}
+
+	if err = clients.RevokeClient(ctx, "client"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = clients.ClientByName(ctx, "client"); !errors.Is(err, repo.ErrNotFound) {
+		t.Errorf("revoked client was found: %v", err)
+	}
+
+	if err = clients.RevokeClient(ctx, "client"); !errors.Is(err, repo.ErrNotFound) {
+		t.Errorf("revoked client was revoked again: %v", err)
+	}
+
+	client.KeyID = "key3"
+	if _, err = clients.CreateClient(ctx, client); err != nil {
+		t.Errorf("name of a revoked client was not reused: %v", err)
+	}
+
+	// clients are kept in the database file
+	list, err := open(t, path).APIClients().ListClients(ctx)
+	if err != nil || len(list) != 2 || list[0].RevokedAt == nil || list[1].RevokedAt != nil {
+		t.Errorf("unexpected clients: %+v (%v)", list, err)
+	}
+}
+
+func TestIdempotencyKeys(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	keys := open(t, filepath.Join(t.TempDir(), "people.db")).IdempotencyKeys()
+	fingerprint := []byte("request")
+
+	if stored, err := keys.Reserve(ctx, "key", fingerprint, time.Hour); stored != nil || err != nil {
+		t.Fatalf("key was not reserved: %v (%v)", stored, err)
+	}
+
+	if _, err := keys.Reserve(ctx, "key", fingerprint, time.Hour); !errors.Is(err, repo.ErrInProgress) {
+		t.Errorf("reserved key is not in progress: %v", err)
+	}
+
+	if _, err := keys.Reserve(ctx, "key", []byte("other"), time.Hour); !errors.Is(err, repo.ErrKeyReused) {
+		t.Errorf("key was reused: %v", err)
+	}
+
+	response := domain.StoredResponse{ContentType: "application/json", Body: []byte(`{}`), Status: 201}
+	if err := keys.Save(ctx, "key", response); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := keys.Save(ctx, "key", response); !errors.Is(err, repo.ErrNotFound) {
+		t.Errorf("response was saved twice: %v", err)
+	}
+
+	stored, err := keys.Reserve(ctx, "key", fingerprint, time.Hour)
+	if err != nil || stored == nil || stored.Status != 201 || string(stored.Body) != `{}` {
+		t.Errorf("unexpected stored response: %+v (%v)", stored, err)
+	}
+
+	// released and expired keys can be reserved again
+	if _, err = keys.Reserve(ctx, "released", fingerprint, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = keys.Release(ctx, "released"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = keys.Reserve(ctx, "expired", fingerprint, time.Nanosecond); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(time.Millisecond)
+
+	for _, key := range []string{"released", "expired"} {
+		if stored, err := keys.Reserve(ctx, key, []byte("other"), time.Hour); stored != nil || err != nil {
+			t.Errorf("%s key was not reserved: %v (%v)", key, stored, err)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// txKey is the context key of the current transaction
+type txKey struct{}
+
+// transaction is a transaction of People carried by a context
+type transaction struct {
+	people *People
+	tx     *sql.Tx
+	// number of savepoints of nested calls of WithinTx
+	savepoints int
+}
+
+// querier is *sql.DB or *sql.Tx
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// current returns the transaction of the people in ctx or nil if there is none
+func (p *People) current(ctx context.Context) *transaction {
+	if current, found := ctx.Value(txKey{}).(*transaction); found && current.people == p {
+		return current
+	}
+
+	return nil
+}
+
+// conn returns the transaction of ctx (see WithinTx) or the database if
+// there is none. Every query goes through it, so the repos take part in
+// the transaction of the context.
+func (p *People) conn(ctx context.Context) querier {
+	if current := p.current(ctx); current != nil {
+		return current.tx
+	}
+
+	return p.db
+}
+
+// savepoint runs fn in a savepoint of the transaction
+func (t *transaction) savepoint(ctx context.Context, fn func(ctx context.Context) error) error {
+	nested := &transaction{people: t.people, tx: t.tx, savepoints: t.savepoints + 1}
+	name := fmt.Sprintf("savepoint_%d", nested.savepoints)
+
+	if _, err := t.tx.ExecContext(ctx, "savepoint "+name); err != nil {
+		return wrapSQLiteError(err)
+	}
+
+	if err := fn(context.WithValue(ctx, txKey{}, nested)); err != nil {
+		// a savepoint is still open after it is rolled back to
+		_, rollbackErr := t.tx.ExecContext(ctx, "rollback to "+name)
+		if rollbackErr == nil {
+			_, rollbackErr = t.tx.ExecContext(ctx, "release "+name)
+		}
+
+		if rollbackErr != nil {
+			return fmt.Errorf("%w (%w)", err, wrapSQLiteError(rollbackErr))
+		}
+
+		return err
+	}
+
+	if _, err := t.tx.ExecContext(ctx, "release "+name); err != nil {
+		return wrapSQLiteError(err)
+	}
+
+	return nil
+}
+
+// WithinTx implements repo.TxManager. The database has a single
+// connection, which the transaction holds until it ends, so transactions
+// are serialized and calls with other contexts wait for it: fn must only
+// use the repo with the context passed to it. Other processes using
+// the database file are waited for up to busyTimeout, then
+// repo.ErrConflict is returned. Nested calls are run in savepoints.
+func (p *People) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if current := p.current(ctx); current != nil {
+		return current.savepoint(ctx, fn)
+	}
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return wrapSQLiteError(err)
+	}
+
+	if err = fn(context.WithValue(ctx, txKey{}, &transaction{people: p, tx: tx, savepoints: 0})); err != nil {
+		tx.Rollback() //nolint:errcheck
+
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return wrapSQLiteError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,38 @@
+package reqctx
+
+import (
+	"context"
+	"log/slog"
+)
+
+// RequestIDHeader carries the request ID in requests and responses
+const RequestIDHeader = "X-Request-ID"
+
+// logHandler adds the request ID of the context to log records
+type logHandler struct {
+	slog.Handler
+}
+
+// NewLogHandler returns a slog.Handler adding the request ID of the context
+// (as request_id) to the records passed to handler. Records are tied to the
+// request if they are logged with a context (logger.InfoContext, ...).
+func NewLogHandler(handler slog.Handler) slog.Handler {
+	return logHandler{handler}
+}
+
+func (h logHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestID := RequestID(ctx); requestID != "" {
+		record = record.Clone()
+		record.AddAttrs(slog.String("request_id", requestID))
+	}
+
+	return h.Handler.Handle(ctx, record) //nolint:wrapcheck
+}
+
+func (h logHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return logHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h logHandler) WithGroup(name string) slog.Handler {
+	return logHandler{h.Handler.WithGroup(name)}
+}
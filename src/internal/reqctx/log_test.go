@@ -0,0 +1,42 @@
+package reqctx_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/Hofsiedge/person-api/internal/reqctx"
+)
+
+func TestLogHandler(t *testing.T) {
+	t.Parallel()
+
+	var buffer bytes.Buffer
+
+	logger := slog.New(reqctx.NewLogHandler(slog.NewJSONHandler(&buffer, nil))).With(slog.String("service", "test"))
+
+	logged := func() map[string]any {
+		defer buffer.Reset()
+
+		var record map[string]any
+		if err := json.Unmarshal(buffer.Bytes(), &record); err != nil {
+			t.Fatal(err)
+		}
+
+		return record
+	}
+
+	logger.InfoContext(reqctx.WithRequestID(context.Background(), "request-1"), "message")
+
+	if record := logged(); record["request_id"] != "request-1" || record["service"] != "test" {
+		t.Errorf("request ID was not logged: %v", record)
+	}
+
+	logger.InfoContext(context.Background(), "message")
+
+	if record, found := logged()["request_id"]; found {
+		t.Errorf("unexpected request ID: %v", record)
+	}
+}
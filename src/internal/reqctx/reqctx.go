@@ -0,0 +1,79 @@
+// Package reqctx stores information about the request being processed
+// in context.Context. It is recorded with the changes made by the request.
+package reqctx
+
+import "context"
+
+type key int
+
+const (
+	actorKey key = iota
+	requestIDKey
+	scopesKey
+	clientIPKey
+	primaryReadsKey
+)
+
+// WithActor returns a copy of ctx carrying the actor (an authenticated
+// client or user) of the request
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey, actor)
+}
+
+// Actor returns the actor of the request or an empty string
+func Actor(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey).(string)
+
+	return actor
+}
+
+// WithRequestID returns a copy of ctx carrying the request ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestID returns the request ID or an empty string
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+
+	return requestID
+}
+
+// WithScopes returns a copy of ctx carrying the scopes granted to the actor
+func WithScopes(ctx context.Context, scopes []string) context.Context {
+	return context.WithValue(ctx, scopesKey, scopes)
+}
+
+// Scopes returns the scopes granted to the actor or nil
+func Scopes(ctx context.Context) []string {
+	scopes, _ := ctx.Value(scopesKey).([]string)
+
+	return scopes
+}
+
+// WithClientIP returns a copy of ctx carrying the IP address of the client
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey, ip)
+}
+
+// ClientIP returns the IP address of the client or an empty string
+func ClientIP(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPKey).(string)
+
+	return ip
+}
+
+// WithPrimaryReads returns a copy of ctx whose reads go to the primary
+// database rather than to its replicas, so that they see the writes of
+// the client
+func WithPrimaryReads(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryReadsKey, true)
+}
+
+// PrimaryReads reports whether the reads of the request have to go to
+// the primary database
+func PrimaryReads(ctx context.Context) bool {
+	primary, _ := ctx.Value(primaryReadsKey).(bool)
+
+	return primary
+}
@@ -0,0 +1,57 @@
+package tracing
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/Hofsiedge/person-api/internal/tracing"
+
+// the function called by a query: the repository calls people.* functions
+//
+//nolint:gochecknoglobals
+var functionPattern = regexp.MustCompile(`\b(people|utils)\.\w+`)
+
+// QueryTracer is a pgx.QueryTracer making a span of every query.
+// Spans are named after the called function (people.create_person).
+type QueryTracer struct{}
+
+// ensure QueryTracer implements pgx.QueryTracer
+var _ pgx.QueryTracer = QueryTracer{}
+
+func querySpanName(sql string) string {
+	if function := functionPattern.FindString(sql); function != "" {
+		return function
+	}
+
+	return "query"
+}
+
+// TraceQueryStart implements pgx.QueryTracer.
+func (QueryTracer) TraceQueryStart(
+	ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData,
+) context.Context {
+	ctx, _ = otel.Tracer(tracerName).Start(ctx, querySpanName(data.SQL),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemPostgreSQL, semconv.DBStatement(data.SQL)),
+	)
+
+	return ctx
+}
+
+// TraceQueryEnd implements pgx.QueryTracer.
+func (QueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	span := trace.SpanFromContext(ctx)
+	if data.Err != nil {
+		span.RecordError(data.Err)
+		span.SetStatus(codes.Error, data.Err.Error())
+	}
+
+	span.End()
+}
@@ -0,0 +1,103 @@
+// Package tracing sets up OpenTelemetry tracing: the global tracer provider
+// with an OTLP or a stdout exporter and W3C trace context propagation.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Hofsiedge/person-api/internal/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+)
+
+const (
+	ServiceName = "person-api"
+	// exporters
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+var ErrExporter = errors.New("unknown tracing exporter")
+
+// Setup sets the global tracer provider and propagator. Spans are exported
+// with the configured exporter: OTLP over HTTP (configured with the standard
+// OTEL_EXPORTER_OTLP_* variables), stdout (or a file) or none. The returned
+// function flushes the spans and stops exporting.
+func Setup(ctx context.Context, cfg config.TracingConfig) (func(ctx context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{},
+	))
+
+	if cfg.Exporter == ExporterNone {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	var (
+		processor sdktrace.SpanProcessor
+		closer    io.Closer
+	)
+
+	switch cfg.Exporter {
+	case ExporterOTLP:
+		exporter, err := otlptracehttp.New(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("could not create an OTLP exporter: %w", err)
+		}
+
+		processor = sdktrace.NewBatchSpanProcessor(exporter)
+	case ExporterStdout:
+		var writer io.Writer = os.Stdout
+
+		if cfg.File != "" {
+			file, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644) //nolint:gomnd
+			if err != nil {
+				return nil, fmt.Errorf("could not open a trace file: %w", err)
+			}
+
+			writer, closer = file, file
+		}
+
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(writer))
+		if err != nil {
+			return nil, fmt.Errorf("could not create a stdout exporter: %w", err)
+		}
+
+		// spans are written as they end, so none are lost on exit
+		processor = sdktrace.NewSimpleSpanProcessor(exporter)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrExporter, cfg.Exporter)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL, semconv.ServiceName(ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("could not create a tracing resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(processor),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closer != nil {
+			err = errors.Join(err, closer.Close())
+		}
+
+		return err //nolint:wrapcheck
+	}, nil
+}
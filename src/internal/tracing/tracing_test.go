@@ -0,0 +1,94 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Hofsiedge/person-api/internal/config"
+	"github.com/Hofsiedge/person-api/internal/tracing"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// the tests set the global tracer provider and must not be run in parallel
+
+func TestSetup(t *testing.T) { //nolint:paralleltest
+	path := filepath.Join(t.TempDir(), "spans.json")
+
+	shutdown, err := tracing.Setup(context.Background(), config.TracingConfig{
+		Exporter:    tracing.ExporterStdout,
+		File:        path,
+		SampleRatio: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, span := otel.Tracer("test").Start(context.Background(), "test-span")
+
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+
+	if !strings.HasPrefix(carrier.Get("traceparent"), "00-"+span.SpanContext().TraceID().String()) {
+		t.Errorf("trace context was not propagated: %v", carrier)
+	}
+
+	span.End()
+
+	if err = shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(data), `"Name":"test-span"`) {
+		t.Errorf("span was not exported: %s", data)
+	}
+
+	if _, err = tracing.Setup(context.Background(), config.TracingConfig{
+		Exporter: "unknown", File: "", SampleRatio: 1,
+	}); !errors.Is(err, tracing.ErrExporter) {
+		t.Errorf("unknown exporter was not reported: %v", err)
+	}
+}
+
+func TestQueryTracer(t *testing.T) { //nolint:paralleltest
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	tracer := tracing.QueryTracer{}
+	queryErr := errors.New("query failed")
+
+	ctx := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{
+		SQL: "select * from people.create_person($1, $2)", Args: nil,
+	})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{CommandTag: pgconn.CommandTag{}, Err: nil})
+
+	ctx = tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: "begin", Args: nil})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{CommandTag: pgconn.CommandTag{}, Err: queryErr})
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+
+	if spans[0].Name() != "people.create_person" || spans[0].Status().Code == codes.Error {
+		t.Errorf("unexpected span: %s %v", spans[0].Name(), spans[0].Status())
+	}
+
+	if spans[1].Name() != "query" || spans[1].Status().Code != codes.Error {
+		t.Errorf("unexpected span: %s %v", spans[1].Name(), spans[1].Status())
+	}
+}
@@ -43,7 +43,7 @@ func HTTPLoggerMiddleware(logger *slog.Logger) func(http.Handler) http.Handler {
 			defer func() {
 				if err := recover(); err != nil {
 					writer.WriteHeader(http.StatusInternalServerError)
-					logger.Error("error processing a request",
+					logger.ErrorContext(req.Context(), "error processing a request",
 						slog.Any("message", err),
 						slog.String("trace", string(debug.Stack())),
 					)
@@ -57,7 +57,7 @@ func HTTPLoggerMiddleware(logger *slog.Logger) func(http.Handler) http.Handler {
 
 			next.ServeHTTP(&wrappedWriter, req)
 
-			logger.Info("processed a request",
+			logger.InfoContext(req.Context(), "processed a request",
 				slog.Int("status", wrappedWriter.status),
 				slog.String("method", req.Method),
 				slog.String("path", req.URL.EscapedPath()),